@@ -1,41 +1,275 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/rs/zerolog"
-	"github.com/spf13/viper"
+	"gitlab.com/cake-store-RESTFul/config"
+	"gitlab.com/cake-store-RESTFul/gql"
 	"gitlab.com/cake-store-RESTFul/handler"
+	"gitlab.com/cake-store-RESTFul/service"
 	service_manager "gitlab.com/cake-store-RESTFul/service-manager"
 )
 
-func v1(router *httprouter.Router, serviceManager service_manager.ServiceManager, log zerolog.Logger) {
+// shutdownTimeout is how long Run waits for the requests in flight once it
+// is stopped.
+const shutdownTimeout = 15 * time.Second
+
+func v1(router *specRouter, config config.API, idempotencyConfig config.Idempotency, serviceManager service_manager.ServiceManager, log zerolog.Logger) {
 
 	commonHttp := handler.NewCommonHttp()
 	cakeHanlder := handler.NewCake(serviceManager.CakeService(), commonHttp, log)
+	cakeHanlder.CacheControl = handler.CacheControl{
+		List:   config.CacheControl.List,
+		Detail: config.CacheControl.Detail,
+	}
+
+	idempotency := newIdempotency(serviceManager, commonHttp, idempotencyConfig, log)
+	// bulk operations can change the whole catalog, they are for operators
+	auth := handler.NewAdminAuth(config.Admin.Token, serviceManager.AdminUserService(), commonHttp, log)
 
-	router.POST("/api/v1/cake", cakeHanlder.Create)
-	router.POST("/api/v1/cake/json", cakeHanlder.CreateWithJSon)
+	router.POST("/api/v1/cake", idempotency.Handle(cakeHanlder.Create))
+	router.POST("/api/v1/cake/json", idempotency.Handle(cakeHanlder.CreateWithJSon))
+	router.POST("/api/v1/cake/import", idempotency.Handle(cakeHanlder.Import))
 	router.GET("/api/v1/cake", cakeHanlder.GetList)
-	router.GET("/api/v1/cake/:id", cakeHanlder.GetDetail)
+	router.PATCH("/api/v1/cake", auth.Handle(cakeHanlder.BulkUpdate))
+	router.DELETE("/api/v1/cake", auth.Handle(cakeHanlder.BulkDelete))
+	router.document(http.MethodGet, "/api/v1/cake/export")
+	router.GET("/api/v1/cake/:id", withStatic("id", "export", cakeHanlder.Export, cakeHanlder.GetDetail))
 	router.PATCH("/api/v1/cake/:id", cakeHanlder.Update)
+	router.PUT("/api/v1/cake/:id", cakeHanlder.Replace)
 	router.DELETE("/api/v1/cake/:id", cakeHanlder.Delete)
 
 }
 
-func Run(viper *viper.Viper, serviceManager service_manager.ServiceManager, log zerolog.Logger) {
+// v2 serves the same cakes as v1 with JSON bodies, RFC 7807 errors and Link
+// pagination, both versions share the cake service.
+func v2(router *specRouter, config config.API, idempotencyConfig config.Idempotency, serviceManager service_manager.ServiceManager, log zerolog.Logger) {
+
+	problemHttp := handler.NewProblemHttp()
+	cakeHandler := handler.NewCakeV2(serviceManager.CakeService(), problemHttp, log)
+	cakeHandler.CacheControl = handler.CacheControl{
+		List:   config.CacheControl.List,
+		Detail: config.CacheControl.Detail,
+	}
+
+	idempotency := newIdempotency(serviceManager, problemHttp, idempotencyConfig, log)
+
+	router.POST("/api/v2/cakes", idempotency.Handle(cakeHandler.Create))
+	router.GET("/api/v2/cakes", cakeHandler.GetList)
+	router.GET("/api/v2/cakes/:id", cakeHandler.GetDetail)
+	router.PATCH("/api/v2/cakes/:id", cakeHandler.Update)
+	router.PUT("/api/v2/cakes/:id", cakeHandler.Replace)
+	router.DELETE("/api/v2/cakes/:id", cakeHandler.Delete)
+}
+
+func newIdempotency(serviceManager service_manager.ServiceManager, serializer handler.HttpSerializer, config config.Idempotency, log zerolog.Logger) *handler.Idempotency {
+
+	idempotency := handler.NewIdempotency(serviceManager.IdempotencyService(), serializer, log)
+	if size := config.MaxBodySize; size > 0 {
+		idempotency.MaxBodySize = int64(size)
+	}
+
+	return idempotency
+}
+
+// graphQL serves the catalog schema on /graphql, the GraphiQL playground is
+// only routed in development. Both answer 404 while api.graphql.enabled is
+// off, it can be switched by a config reload.
+func graphQL(router *specRouter, store *config.Store, serviceManager service_manager.ServiceManager, log zerolog.Logger) error {
+
+	config := store.Get().API
+	enabled := func() bool {
+		return store.Get().API.GraphQL.Enabled
+	}
+
+	server, err := gql.NewServer(serviceManager.CakeService(), gql.Limits{
+		MaxDepth:      config.GraphQL.MaxDepth,
+		MaxComplexity: config.GraphQL.MaxComplexity,
+	}, log)
+	if err != nil {
+		return fmt.Errorf("graphql schema: %w", err)
+	}
+
+	graphQLHandler := handler.NewGraphQL(server, handler.NewProblemHttp(), log)
+	router.POST("/graphql", whenEnabled(enabled, graphQLHandler.Query))
+
+	if config.Development() {
+		router.Router.GET("/graphiql", whenEnabled(enabled, handler.GraphiQL))
+	}
+
+	return nil
+}
+
+// admin serves the endpoints for operators, every route requires the
+// api.admin.token bearer token or the token of an admin user.
+func admin(router *specRouter, config config.API, serviceManager service_manager.ServiceManager, log zerolog.Logger) {
+
+	commonHttp := handler.NewCommonHttp()
+	auth := handler.NewAdminAuth(config.Admin.Token, serviceManager.AdminUserService(), commonHttp, log)
+	if config.Admin.Token == "" {
+		log.Warn().Msg("api.admin.token is empty, admin endpoints only accept admin user tokens")
+	}
+
+	webhookHandler := handler.NewWebhook(serviceManager.WebhookService(), commonHttp, log)
+
+	router.POST("/api/v1/admin/webhooks", auth.Handle(webhookHandler.Create))
+	router.GET("/api/v1/admin/webhooks", auth.Handle(webhookHandler.GetList))
+	router.GET("/api/v1/admin/webhooks/:id", auth.Handle(webhookHandler.GetDetail))
+	router.PATCH("/api/v1/admin/webhooks/:id", auth.Handle(webhookHandler.Update))
+	router.DELETE("/api/v1/admin/webhooks/:id", auth.Handle(webhookHandler.Delete))
+	router.GET("/api/v1/admin/webhooks/:id/deliveries", auth.Handle(webhookHandler.GetDeliveries))
+	router.POST("/api/v1/admin/webhooks/:id/deliveries/:delivery_id/redeliver", auth.Handle(webhookHandler.Redeliver))
+}
+
+// dispatchWebhooks sends the due webhook deliveries every interval.
+func dispatchWebhooks(ctx context.Context, webhookService service.Webhook, interval time.Duration, log zerolog.Logger) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		sent, err := webhookService.Dispatch(ctx)
+		if err != nil {
+			log.Error().Msg(err.Error())
+			continue
+		}
+		if sent > 0 {
+			log.Debug().Int("sent", sent).Msg("dispatched webhook deliveries")
+		}
+	}
+}
+
+// relayOutbox publishes the pending outbox events every interval.
+func relayOutbox(ctx context.Context, outboxService service.Outbox, interval time.Duration, log zerolog.Logger) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		sent, err := outboxService.Relay(ctx)
+		if err != nil {
+			log.Error().Msg(err.Error())
+			continue
+		}
+		if sent > 0 {
+			log.Debug().Int("sent", sent).Msg("relayed outbox events")
+		}
+	}
+}
+
+// purgeOutbox removes the sent outbox events past their retention every
+// interval.
+func purgeOutbox(ctx context.Context, outboxService service.Outbox, interval time.Duration, log zerolog.Logger) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		deleted, err := outboxService.Cleanup(ctx)
+		if err != nil {
+			log.Error().Msg(err.Error())
+			continue
+		}
+		log.Debug().Int64("deleted", deleted).Msg("purged sent outbox events")
+	}
+}
+
+// purgeIdempotencyKeys removes expired Idempotency-Key responses every interval.
+func purgeIdempotencyKeys(ctx context.Context, idempotencyService service.Idempotency, interval time.Duration, log zerolog.Logger) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		deleted, err := idempotencyService.PurgeExpired(ctx)
+		if err != nil {
+			log.Error().Msg(err.Error())
+			continue
+		}
+		log.Debug().Int64("deleted", deleted).Msg("purged expired idempotency keys")
+	}
+}
+
+// withStatic routes requests whose param equals value to static, httprouter
+// does not allow a static segment next to a named parameter.
+func withStatic(param, value string, static, next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		if p.ByName(param) == value {
+			static(w, r, p)
+			return
+		}
+		next(w, r, p)
+	}
+}
+
+// whenEnabled answers 404 instead of calling next while enabled is false.
+func whenEnabled(enabled func() bool, next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		if !enabled() {
+			http.NotFound(w, r)
+			return
+		}
+		next(w, r, p)
+	}
+}
+
+// cors lets the origins of api.cors.allowed_origins read the responses of
+// next, the origins can change on a config reload.
+func cors(store *config.Store, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		origin := store.Get().API.CORS.Allow(r.Header.Get("Origin"))
+		if origin != "*" {
+			w.Header().Add("Vary", "Origin")
+		}
+		if origin != "" {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NewHandler routes the REST, GraphQL and docs endpoints to the components of
+// serviceManager, behind the OpenAPI validation and CORS. It is what Run
+// serves, and what the end-to-end tests call through httptest.
+func NewHandler(store *config.Store, serviceManager service_manager.ServiceManager, log zerolog.Logger) (http.Handler, error) {
 
-	config := viper.Sub("api")
-	port := config.GetInt("port")
-	address := fmt.Sprintf(":%d", port)
-	_ = config.Get("environtment")
+	config := store.Get().API
 
 	router := httprouter.New()
 
+	// the origin of preflight requests is allowed by cors like the others
 	router.GlobalOPTIONS = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Add("Access-Control-Allow-Origin", "*")
 		w.Header().Add("Content-Type", "application/json")
 		w.Header().Add("Access-Control-Allow-Methods", "POST, GET, OPTIONS, DELETE, PATCH, PUT")
 		w.Header().Add("Access-Control-Allow-Headers", "*")
@@ -47,7 +281,92 @@ func Run(viper *viper.Viper, serviceManager service_manager.ServiceManager, log
 		handler.NewCommonHttp().JSON(w, http.StatusInternalServerError, res)
 	}
 
-	v1(router, serviceManager, log)
-	log.Fatal().Err(http.ListenAndServe(address, router)).Msg("service stop")
+	doc, err := loadSpec()
+	if err != nil {
+		return nil, fmt.Errorf("invalid openapi.yaml: %w", err)
+	}
+
+	routes := newSpecRouter(router)
+	v1(routes, config, store.Get().Idempotency, serviceManager, log)
+	v2(routes, config, store.Get().Idempotency, serviceManager, log)
+	admin(routes, config, serviceManager, log)
+	if err = graphQL(routes, store, serviceManager, log); err != nil {
+		return nil, err
+	}
+	if err = routes.checkRoutes(doc); err != nil {
+		return nil, fmt.Errorf("openapi.yaml is out of date: %w", err)
+	}
+	docs(router)
+
+	// responses are only validated in development, buffering every response
+	// costs too much in production
+	validator, err := newOpenapiValidator(doc,
+		config.OpenAPI.ValidateRequests,
+		config.OpenAPI.ValidateResponses && config.Development(),
+		log)
+	if err != nil {
+		return nil, fmt.Errorf("openapi router: %w", err)
+	}
+
+	return cors(store, validator.Handler(router)), nil
+}
+
+// Run serves the API until ctx is done, then stops taking requests and waits
+// up to shutdownTimeout for the ones in flight. The background jobs stop with
+// ctx as well.
+func Run(ctx context.Context, store *config.Store, serviceManager service_manager.ServiceManager, log zerolog.Logger) error {
+
+	settings := store.Get()
+	address := fmt.Sprintf(":%d", settings.API.Port)
+
+	apiHandler, err := NewHandler(store, serviceManager, log)
+	if err != nil {
+		return fmt.Errorf("api: %w", err)
+	}
+
+	if interval := settings.Idempotency.CleanupInterval; interval > 0 {
+		go purgeIdempotencyKeys(ctx, serviceManager.IdempotencyService(), time.Duration(interval)*time.Second, log)
+	}
+
+	if interval := settings.Webhook.PollInterval; settings.Webhook.Enabled && interval > 0 {
+		go dispatchWebhooks(ctx, serviceManager.WebhookService(), time.Duration(interval)*time.Second, log)
+	}
+
+	if interval := settings.Outbox.PollInterval; settings.Outbox.Relayed() && interval > 0 {
+		go relayOutbox(ctx, serviceManager.OutboxService(), time.Duration(interval)*time.Second, log)
+	}
+
+	if interval := settings.Outbox.CleanupInterval; interval > 0 {
+		go purgeOutbox(ctx, serviceManager.OutboxService(), time.Duration(interval)*time.Second, log)
+	}
+
+	server := &http.Server{Addr: address, Handler: apiHandler}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+	log.Info().Str("address", address).Msg("api server started")
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Info().Msg("api server shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+
+	// ListenAndServe returns http.ErrServerClosed once Shutdown is called
+	if err := <-serveErr; err != http.ErrServerClosed {
+		return err
+	}
 
+	return nil
 }
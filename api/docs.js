@@ -0,0 +1,3 @@
+window.onload = () => {
+  window.ui = SwaggerUIBundle({ url: "/openapi.yaml", dom_id: "#swagger-ui" });
+};
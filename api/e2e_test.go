@@ -0,0 +1,520 @@
+package api_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/api"
+	"gitlab.com/cake-store-RESTFul/config"
+	"gitlab.com/cake-store-RESTFul/infra"
+	"gitlab.com/cake-store-RESTFul/migration"
+	"gitlab.com/cake-store-RESTFul/repo"
+	service_manager "gitlab.com/cake-store-RESTFul/service-manager"
+)
+
+// e2eConfig runs the app like `serve --memory` in development, so responses
+// are validated against openapi.yaml as well.
+const e2eConfig = `
+[api]
+env = "development"
+[api.openapi]
+validate_responses = true
+[api.admin]
+token = "e2e-admin"
+[grpc]
+enabled = false
+[webhook]
+enabled = false
+`
+
+const e2eImage = "https://res.cloudinary.com/demo/image/upload/cake.png"
+
+// e2eCloudinary stands in for the Cloudinary account, every upload gets the
+// same URL.
+type e2eCloudinary struct{}
+
+func (e2eCloudinary) Upload(context.Context, interface{}, uploader.UploadParams) (*uploader.UploadResult, error) {
+	return &uploader.UploadResult{URL: e2eImage}, nil
+}
+
+func (e2eCloudinary) Destroy(context.Context, uploader.DestroyParams) (*uploader.DestroyResult, error) {
+	return &uploader.DestroyResult{Result: "ok"}, nil
+}
+
+// newE2EApp wires the app with the cakes in memory and the other tables in an
+// SQLite database in memory, edit changes the settings before they are used.
+func newE2EApp(t *testing.T, edit func(settings *config.Config)) (*config.Store, service_manager.ServiceManager) {
+
+	file := filepath.Join(t.TempDir(), "app.toml")
+	if err := os.WriteFile(file, []byte(e2eConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	settings, err := config.Load(file, config.MemoryMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edit != nil {
+		edit(settings)
+	}
+
+	infrastructure := infra.NewInfra(settings)
+	infrastructure.Log = zerolog.Nop()
+	infrastructure.Cloudinary = e2eCloudinary{}
+
+	migrator, err := migration.NewMigrator(infrastructure.Database, infrastructure.Log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	serviceManager := service_manager.NewServiceManager(infrastructure,
+		service_manager.WithOverride(service_manager.CakeRepoComponent, func(service_manager.ServiceManager) interface{} {
+			return repo.NewMemoryCake(infrastructure.Log)
+		}),
+	)
+	if err = serviceManager.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := serviceManager.Stop(context.Background()); err != nil {
+			t.Error(err)
+		}
+		infrastructure.Close()
+	})
+
+	return config.NewStore(file, settings, infrastructure.Log), serviceManager
+}
+
+// newE2EServer serves the real router of newE2EApp.
+func newE2EServer(t *testing.T) *httptest.Server {
+
+	store, serviceManager := newE2EApp(t, nil)
+
+	handler, err := api.NewHandler(store, serviceManager, zerolog.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestE2E_run(t *testing.T) {
+
+	// a free port for the server, Run listens on its own
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	store, serviceManager := newE2EApp(t, func(settings *config.Config) {
+		settings.API.Port = port
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- api.Run(ctx, store, serviceManager, zerolog.Nop())
+	}()
+
+	url := fmt.Sprintf("http://127.0.0.1:%d/api/v1/cake", port)
+	for attempt := 0; ; attempt++ {
+		res, err := http.Get(url)
+		if err == nil {
+			res.Body.Close()
+			if res.StatusCode != http.StatusOK {
+				t.Fatalf("Run() GET %s = %d", url, res.StatusCode)
+			}
+			break
+		}
+		if attempt == 50 {
+			t.Fatalf("Run() is not serving: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return once its context was done")
+	}
+
+	if _, err := http.Get(url); err == nil {
+		t.Error("Run() still serves after it returned")
+	}
+}
+
+func TestE2E_docs(t *testing.T) {
+
+	server := newE2EServer(t)
+
+	for _, path := range []string{"/docs", "/docs/docs.js", "/docs/swagger-ui/swagger-ui-bundle.js", "/docs/swagger-ui/swagger-ui.css", "/openapi.yaml"} {
+		t.Run(path, func(t *testing.T) {
+			e2eRequest{
+				method:     http.MethodGet,
+				path:       path,
+				wantStatus: http.StatusOK,
+				check: func(t *testing.T, header http.Header, body []byte) {
+					// the page loads nothing from another origin
+					if strings.Contains(string(body), "unpkg.com") {
+						t.Errorf("GET %s loads assets from unpkg.com", path)
+					}
+				},
+			}.do(t, server)
+		})
+	}
+}
+
+func TestE2E_export(t *testing.T) {
+
+	server := newE2EServer(t)
+
+	// more rows than a flush, the export streams through the response
+	// validation of development
+	const cakes = 150
+	for i := 0; i < cakes; i++ {
+		createCake(fmt.Sprintf("Cake %d", i), 5, http.StatusCreated).do(t, server)
+	}
+
+	e2eRequest{
+		method:     http.MethodGet,
+		path:       "/api/v1/cake/export?format=ndjson",
+		wantStatus: http.StatusOK,
+		check: func(t *testing.T, header http.Header, body []byte) {
+			if lines := strings.Count(string(body), "\n"); lines != cakes {
+				t.Errorf("export lines = %d, want %d", lines, cakes)
+			}
+		},
+	}.do(t, server)
+}
+
+// e2eRequest is one call of a scenario, check reads the response.
+type e2eRequest struct {
+	name        string
+	method      string
+	path        string
+	contentType string
+	header      map[string]string
+	body        string
+	wantStatus  int
+	check       func(t *testing.T, header http.Header, body []byte)
+}
+
+func (r e2eRequest) do(t *testing.T, server *httptest.Server) {
+
+	req, err := http.NewRequest(r.method, server.URL+r.path, strings.NewReader(r.body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.contentType != "" {
+		req.Header.Set("Content-Type", r.contentType)
+	}
+	for key, value := range r.header {
+		req.Header.Set(key, value)
+	}
+
+	res, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.StatusCode != r.wantStatus {
+		t.Fatalf("%s %s status = %d, want %d: %s", r.method, r.path, res.StatusCode, r.wantStatus, body)
+	}
+	if r.check != nil {
+		r.check(t, res.Header, body)
+	}
+}
+
+// decode reads body into v, failing the test when it does not decode.
+func decode(t *testing.T, body []byte, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(body, v); err != nil {
+		t.Fatalf("decode %s: %v", body, err)
+	}
+}
+
+type e2eCake struct {
+	ID          int     `json:"id"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Image       string  `json:"image"`
+	Rating      float32 `json:"rating"`
+	UpdatedAt   *string `json:"updated_at"`
+}
+
+func cakeIDs(cakes []e2eCake) []int {
+	ids := []int{}
+	for _, cake := range cakes {
+		ids = append(ids, cake.ID)
+	}
+	return ids
+}
+
+// createCake is the v2 request creating a cake.
+func createCake(title string, rating float32, wantStatus int) e2eRequest {
+	return e2eRequest{
+		name:        "create " + title,
+		method:      http.MethodPost,
+		path:        "/api/v2/cakes",
+		contentType: "application/json",
+		body: fmt.Sprintf(`{"title": %q, "description": "baked for the e2e suite", "rating": %v, "image": %q}`,
+			title, rating, base64.StdEncoding.EncodeToString([]byte("png"))),
+		wantStatus: wantStatus,
+	}
+}
+
+// seed creates the cakes the list scenarios read, with IDs 1 to 4.
+func seed(t *testing.T, server *httptest.Server) {
+	for _, request := range []e2eRequest{
+		createCake("Lemon Cheesecake", 8.5, http.StatusCreated),
+		createCake("Chocolate Torte", 9, http.StatusCreated),
+		createCake("Carrot Cake", 7, http.StatusCreated),
+		createCake("Carrot Cupcake", 9, http.StatusCreated),
+	} {
+		request.do(t, server)
+	}
+}
+
+func TestE2E_list(t *testing.T) {
+
+	server := newE2EServer(t)
+	seed(t, server)
+
+	tests := []struct {
+		name      string
+		path      string
+		wantIDs   []int
+		wantTotal int
+	}{
+		{name: "v1 every cake", path: "/api/v1/cake", wantIDs: []int{1, 2, 3, 4}, wantTotal: 4},
+		{name: "v1 search", path: "/api/v1/cake?search=carrot", wantIDs: []int{3, 4}, wantTotal: 2},
+		{name: "v1 sort descending", path: "/api/v1/cake?sort=rating&sort_by=DESC", wantIDs: []int{2, 4, 1, 3}, wantTotal: 4},
+		{name: "v1 page", path: "/api/v1/cake?limit=3&page=2", wantIDs: []int{4}, wantTotal: 4},
+		{name: "v1 no match", path: "/api/v1/cake?search=pavlova", wantIDs: []int{}, wantTotal: 0},
+		{name: "v2 sort per field", path: "/api/v2/cakes?sort=-rating,title", wantIDs: []int{4, 2, 1, 3}, wantTotal: 4},
+		{name: "v2 search and page", path: "/api/v2/cakes?search=CAKE&limit=2&page=1", wantIDs: []int{1, 3}, wantTotal: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e2eRequest{
+				method:     http.MethodGet,
+				path:       tt.path,
+				wantStatus: http.StatusOK,
+				check: func(t *testing.T, header http.Header, body []byte) {
+
+					var cakes []e2eCake
+					total := 0
+					if strings.HasPrefix(tt.path, "/api/v1") {
+						res := struct {
+							Data     []e2eCake `json:"data"`
+							MetaData struct {
+								Total int `json:"total"`
+							} `json:"meta_data"`
+						}{}
+						decode(t, body, &res)
+						cakes, total = res.Data, res.MetaData.Total
+					} else {
+						decode(t, body, &cakes)
+						fmt.Sscan(header.Get("X-Total-Count"), &total)
+					}
+
+					if !reflect.DeepEqual(cakeIDs(cakes), tt.wantIDs) || total != tt.wantTotal {
+						t.Errorf("GET %s = %v of %d, want %v of %d", tt.path, cakeIDs(cakes), total, tt.wantIDs, tt.wantTotal)
+					}
+				},
+			}.do(t, server)
+		})
+	}
+}
+
+func TestE2E_cake(t *testing.T) {
+
+	server := newE2EServer(t)
+	seed(t, server)
+
+	wantCake := func(want e2eCake, updated bool) func(t *testing.T, header http.Header, body []byte) {
+		return func(t *testing.T, header http.Header, body []byte) {
+			got := e2eCake{}
+			if strings.Contains(string(body), `"data"`) {
+				res := struct {
+					Data e2eCake `json:"data"`
+				}{}
+				decode(t, body, &res)
+				got = res.Data
+			} else {
+				decode(t, body, &got)
+			}
+
+			if (got.UpdatedAt != nil) != updated {
+				t.Errorf("updated_at = %v, want set %v", got.UpdatedAt, updated)
+			}
+			got.UpdatedAt = nil
+			if got != want {
+				t.Errorf("cake = %+v, want %+v", got, want)
+			}
+		}
+	}
+
+	lemon := e2eCake{ID: 1, Title: "Lemon Cheesecake", Description: "baked for the e2e suite", Image: e2eImage, Rating: 8.5}
+	patched := lemon
+	patched.Title, patched.Rating = "Lemon Curd Cheesecake", 9.5
+
+	admin := map[string]string{"Authorization": "Bearer e2e-admin"}
+
+	replay := createCake("Pavlova", 6, http.StatusCreated)
+	replay.header = map[string]string{"Idempotency-Key": "e2e-pavlova"}
+
+	// the steps run in order against the same server
+	steps := []e2eRequest{
+		{name: "detail", method: http.MethodGet, path: "/api/v2/cakes/1", wantStatus: http.StatusOK, check: wantCake(lemon, false)},
+		{name: "v1 detail", method: http.MethodGet, path: "/api/v1/cake/1", wantStatus: http.StatusOK, check: wantCake(lemon, false)},
+		{name: "detail not found", method: http.MethodGet, path: "/api/v2/cakes/99", wantStatus: http.StatusNotFound},
+		{name: "list unknown sort column", method: http.MethodGet, path: "/api/v1/cake?sort=id,password", wantStatus: http.StatusBadRequest},
+		{name: "export invalid sort direction", method: http.MethodGet, path: "/api/v1/cake/export?sort_by=ASC%3B%20DROP%20TABLE%20cake", wantStatus: http.StatusBadRequest},
+		{
+			name:        "partial update keeps the other fields",
+			method:      http.MethodPatch,
+			path:        "/api/v2/cakes/1",
+			contentType: "application/merge-patch+json",
+			body:        `{"title": "Lemon Curd Cheesecake", "rating": 9.5}`,
+			wantStatus:  http.StatusOK,
+			check:       wantCake(patched, true),
+		},
+		{name: "detail after update", method: http.MethodGet, path: "/api/v2/cakes/1", wantStatus: http.StatusOK, check: wantCake(patched, true)},
+		{
+			name:        "update not found",
+			method:      http.MethodPatch,
+			path:        "/api/v2/cakes/99",
+			contentType: "application/merge-patch+json",
+			body:        `{"rating": 1}`,
+			wantStatus:  http.StatusNotFound,
+		},
+		{name: "delete", method: http.MethodDelete, path: "/api/v2/cakes/2", wantStatus: http.StatusNoContent},
+		{name: "detail after delete", method: http.MethodGet, path: "/api/v2/cakes/2", wantStatus: http.StatusNotFound},
+		{name: "delete not found", method: http.MethodDelete, path: "/api/v2/cakes/2", wantStatus: http.StatusNotFound},
+		{
+			name:        "bulk delete without the admin token",
+			method:      http.MethodDelete,
+			path:        "/api/v1/cake",
+			contentType: "application/json",
+			body:        `{"ids": [3]}`,
+			wantStatus:  http.StatusUnauthorized,
+		},
+		{
+			name:        "bulk delete with an empty filter",
+			method:      http.MethodDelete,
+			path:        "/api/v1/cake",
+			contentType: "application/json",
+			header:      admin,
+			body:        `{"filter": {}}`,
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "atomic bulk delete with a missing cake",
+			method:      http.MethodDelete,
+			path:        "/api/v1/cake",
+			contentType: "application/json",
+			header:      admin,
+			body:        `{"ids": [3, 2], "atomic": true}`,
+			wantStatus:  http.StatusConflict,
+		},
+		{name: "rolled back cake is kept", method: http.MethodGet, path: "/api/v2/cakes/3", wantStatus: http.StatusOK},
+		{
+			name:        "bulk update by search",
+			method:      http.MethodPatch,
+			path:        "/api/v1/cake",
+			contentType: "application/json",
+			header:      admin,
+			body:        `{"filter": {"search": "carrot"}, "changes": {"rating": 10}}`,
+			wantStatus:  http.StatusOK,
+			check: func(t *testing.T, header http.Header, body []byte) {
+				res := struct {
+					Data struct {
+						Succeeded int `json:"succeeded"`
+					} `json:"data"`
+				}{}
+				decode(t, body, &res)
+				if res.Data.Succeeded != 2 {
+					t.Errorf("bulk update = %s, want 2 cakes updated", body)
+				}
+			},
+		},
+		replay,
+		{
+			name:        "replayed create",
+			method:      replay.method,
+			path:        replay.path,
+			contentType: replay.contentType,
+			header:      replay.header,
+			body:        replay.body,
+			wantStatus:  http.StatusCreated,
+			check: func(t *testing.T, header http.Header, body []byte) {
+				if header.Get("Idempotent-Replayed") != "true" {
+					t.Errorf("Idempotent-Replayed = %q, want true", header.Get("Idempotent-Replayed"))
+				}
+			},
+		},
+		{
+			name:        "graphql reads the same cakes",
+			method:      http.MethodPost,
+			path:        "/graphql",
+			contentType: "application/json",
+			body:        `{"query": "{ cakes(search: \"carrot\") { items { id rating } pagination { total } } }"}`,
+			wantStatus:  http.StatusOK,
+			check: func(t *testing.T, header http.Header, body []byte) {
+				if want := `{"data":{"cakes":{"items":[{"id":3,"rating":10},{"id":4,"rating":10}],"pagination":{"total":2}}}}`; strings.TrimSpace(string(body)) != want {
+					t.Errorf("graphql = %s, want %s", body, want)
+				}
+			},
+		},
+	}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			step.do(t, server)
+		})
+	}
+
+	// the replay did not create a second cake
+	e2eRequest{
+		method:     http.MethodGet,
+		path:       "/api/v2/cakes",
+		wantStatus: http.StatusOK,
+		check: func(t *testing.T, header http.Header, body []byte) {
+			if total := header.Get("X-Total-Count"); total != "4" {
+				t.Errorf("X-Total-Count = %s, want 4", total)
+			}
+		},
+	}.do(t, server)
+}
@@ -0,0 +1,318 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/julienschmidt/httprouter"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/handler"
+)
+
+// openapiSpec is the single source of truth of the API, requests are
+// validated against it and it is served at /openapi.yaml.
+//
+//go:embed openapi.yaml
+var openapiSpec []byte
+
+//go:embed docs.html
+var docsPage []byte
+
+// docsAssets are the scripts and styles of the docs page, Swagger UI 5.18.2
+// (swagger-ui-dist, Apache-2.0) is vendored in swagger-ui so the page works
+// offline and under a strict Content-Security-Policy.
+//
+//go:embed docs.js swagger-ui
+var docsAssets embed.FS
+
+func init() {
+
+	// error messages are sent to the client, the schema dump is only noise
+	openapi3.SchemaErrorDetailsDisabled = true
+
+	openapi3filter.RegisterBodyDecoder("application/merge-patch+json", openapi3filter.RegisteredBodyDecoder("application/json"))
+
+	// uploaded files are checked by the handlers, the validator only needs
+	// to accept the content types of the multipart parts. zip archives are
+	// not unpacked here, the importer reports broken archives itself
+	for _, contentType := range []string{"image/png", "image/jpeg", "image/gif", "image/webp", "application/x-ndjson", "application/zip"} {
+		openapi3filter.RegisterBodyDecoder(contentType, openapi3filter.FileBodyDecoder)
+	}
+
+	// multipart fields are decoded as text/plain strings, convert them to
+	// the type of their schema like the urlencoded decoder does
+	openapi3filter.RegisterBodyDecoder("text/plain", func(body io.Reader, header http.Header, schema *openapi3.SchemaRef, encFn openapi3filter.EncodingFn) (interface{}, error) {
+		b, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		return formValue(string(b), schema), nil
+	})
+
+	// the urlencoded decoder sets every absent field to null, drop them so a
+	// partial form update is not rejected as a null value
+	urlencoded := openapi3filter.RegisteredBodyDecoder("application/x-www-form-urlencoded")
+	openapi3filter.RegisterBodyDecoder("application/x-www-form-urlencoded", func(body io.Reader, header http.Header, schema *openapi3.SchemaRef, encFn openapi3filter.EncodingFn) (interface{}, error) {
+		value, err := urlencoded(body, header, schema, encFn)
+		if obj, ok := value.(map[string]interface{}); ok {
+			for name, field := range obj {
+				if field == nil {
+					delete(obj, name)
+				}
+			}
+		}
+		return value, err
+	})
+}
+
+// formValue converts a form field to a number or a boolean when the schema
+// asks for one, a value that does not parse is kept as a string so the schema
+// validation reports it.
+func formValue(value string, schema *openapi3.SchemaRef) interface{} {
+
+	if schema == nil || schema.Value == nil {
+		return value
+	}
+
+	switch schema.Value.Type {
+	case "number", "integer":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+func loadSpec() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(openapiSpec)
+	if err != nil {
+		return nil, err
+	}
+	return doc, doc.Validate(context.Background())
+}
+
+// specRouter registers routes on the httprouter and remembers them so the
+// server can refuse to start when one is missing from the spec.
+type specRouter struct {
+	*httprouter.Router
+	routes []specRoute
+}
+
+type specRoute struct {
+	method string
+	path   string
+}
+
+func newSpecRouter(router *httprouter.Router) *specRouter {
+	return &specRouter{Router: router}
+}
+
+func (s *specRouter) Handle(method, path string, handle httprouter.Handle) {
+	s.document(method, path)
+	s.Router.Handle(method, path, handle)
+}
+
+// document records a route served by another registration, like the static
+// segments dispatched by withStatic.
+func (s *specRouter) document(method, path string) {
+	s.routes = append(s.routes, specRoute{method: method, path: path})
+}
+
+func (s *specRouter) GET(path string, handle httprouter.Handle) {
+	s.Handle(http.MethodGet, path, handle)
+}
+
+func (s *specRouter) POST(path string, handle httprouter.Handle) {
+	s.Handle(http.MethodPost, path, handle)
+}
+
+func (s *specRouter) PUT(path string, handle httprouter.Handle) {
+	s.Handle(http.MethodPut, path, handle)
+}
+
+func (s *specRouter) PATCH(path string, handle httprouter.Handle) {
+	s.Handle(http.MethodPatch, path, handle)
+}
+
+func (s *specRouter) DELETE(path string, handle httprouter.Handle) {
+	s.Handle(http.MethodDelete, path, handle)
+}
+
+// checkRoutes returns an error naming every registered route without an
+// operation in the spec.
+func (s *specRouter) checkRoutes(doc *openapi3.T) error {
+
+	missing := []string{}
+	for _, route := range s.routes {
+		segments := strings.Split(route.path, "/")
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+				segments[i] = "{" + segment[1:] + "}"
+			}
+		}
+
+		pathItem := doc.Paths.Find(strings.Join(segments, "/"))
+		if pathItem == nil || pathItem.GetOperation(route.method) == nil {
+			missing = append(missing, route.method+" "+route.path)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("routes missing from openapi.yaml: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// docs serves the spec and a Swagger UI page reading it, with its assets.
+func docs(router *httprouter.Router) {
+
+	router.GET("/openapi.yaml", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write(openapiSpec)
+	})
+
+	router.GET("/docs", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(docsPage)
+	})
+
+	router.ServeFiles("/docs/*filepath", http.FS(docsAssets))
+}
+
+// openapiValidator checks requests, and responses when enabled, against the
+// spec. Requests to paths the spec does not describe, like /docs, are passed
+// through untouched.
+type openapiValidator struct {
+	router            routers.Router
+	validateRequests  bool
+	validateResponses bool
+	log               zerolog.Logger
+}
+
+func newOpenapiValidator(doc *openapi3.T, validateRequests, validateResponses bool, log zerolog.Logger) (*openapiValidator, error) {
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &openapiValidator{
+		router:            router,
+		validateRequests:  validateRequests,
+		validateResponses: validateResponses,
+		log:               log,
+	}, nil
+}
+
+func (o *openapiValidator) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		route, pathParams, err := o.router.FindRoute(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				SkipSettingDefaults: true,
+				AuthenticationFunc:  openapi3filter.NoopAuthenticationFunc,
+			},
+		}
+
+		if o.validateRequests {
+			if err = openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+				o.rejectRequest(w, r, err)
+				return
+			}
+		}
+
+		if !o.validateResponses {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		tee := &responseTee{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(tee, r)
+		if tee.streamed {
+			return
+		}
+
+		err = openapi3filter.ValidateResponse(r.Context(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 tee.status,
+			Header:                 tee.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(tee.body.Bytes())),
+			Options:                &openapi3filter.Options{IncludeResponseStatus: true},
+		})
+		if err != nil {
+			o.log.Warn().Str("method", r.Method).Str("path", r.URL.Path).Int("status", tee.status).Msg(err.Error())
+		}
+	})
+}
+
+// rejectRequest answers 415 for an unsupported Content-Type and 400 for any
+// other mismatch, in the error format of the API version. /graphql shares
+// the problem details of v2.
+func (o *openapiValidator) rejectRequest(w http.ResponseWriter, r *http.Request, err error) {
+
+	status := http.StatusBadRequest
+	requestErr := &openapi3filter.RequestError{}
+	if errors.As(err, &requestErr) && strings.HasPrefix(requestErr.Reason, "header Content-Type has unexpected value") {
+		status = http.StatusUnsupportedMediaType
+	}
+
+	serializer := handler.NewCommonHttp()
+	if strings.HasPrefix(r.URL.Path, "/api/v2") || r.URL.Path == "/graphql" {
+		serializer = handler.NewProblemHttp()
+	}
+
+	serializer.JSON(w, status, handler.BaseResponse{Error: err.Error(), Data: nil})
+}
+
+// responseTee writes through to the client while keeping a copy of the
+// response for validation. A flushed response is streamed, like an export,
+// so it is not kept and not validated.
+type responseTee struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	streamed bool
+}
+
+func (t *responseTee) WriteHeader(status int) {
+	t.status = status
+	t.ResponseWriter.WriteHeader(status)
+}
+
+func (t *responseTee) Write(b []byte) (int, error) {
+	if !t.streamed {
+		t.body.Write(b)
+	}
+	return t.ResponseWriter.Write(b)
+}
+
+func (t *responseTee) Flush() {
+	t.streamed = true
+	t.body.Reset()
+	if flusher, ok := t.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/cobra"
+)
+
+func newConfigCommand(a *app) *cobra.Command {
+
+	config := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration",
+	}
+
+	config.AddCommand(&cobra.Command{
+		Use:   "print",
+		Short: "Print the loaded configuration with secrets masked",
+		Long: "Print the configuration the app runs with, the config file with the CAKE_ environment\n" +
+			"overrides and defaults applied. Passwords, secrets, tokens, API keys and replica DSNs are masked.",
+		Args: cobra.NoArgs,
+		RunE: a.run(func(cmd *cobra.Command, args []string) error {
+			return toml.NewEncoder(cmd.OutOrStdout()).Encode(a.config.Redacted())
+		}),
+	})
+
+	return config
+}
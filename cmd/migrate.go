@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/cake-store-RESTFul/migration"
+)
+
+func newMigrateCommand(a *app) *cobra.Command {
+
+	migrate := &cobra.Command{
+		Use:   "migrate",
+		Short: "Run the embedded migrations against the [mysql] database",
+	}
+
+	// migrator is shared by the subcommands, the lock is taken per command
+	migrator := func(ctx context.Context) (migration.Migrator, error) {
+		if err := a.setup(ctx); err != nil {
+			return nil, err
+		}
+		return migration.NewMigrator(a.infra.Database, a.infra.Log)
+	}
+
+	migrate.AddCommand(
+		&cobra.Command{
+			Use:   "up",
+			Short: "Apply every pending migration",
+			Args:  cobra.NoArgs,
+			RunE: a.run(func(cmd *cobra.Command, args []string) error {
+				m, err := migrator(cmd.Context())
+				if err != nil {
+					return err
+				}
+				return m.Up(cmd.Context())
+			}),
+		},
+		&cobra.Command{
+			Use:   "down [N]",
+			Short: "Roll back the last N migrations, 1 by default",
+			Args:  uintArgs(cobra.MaximumNArgs(1), 1),
+			RunE: a.run(func(cmd *cobra.Command, args []string) error {
+				steps := 1
+				if len(args) > 0 {
+					steps, _ = strconv.Atoi(args[0])
+				}
+
+				m, err := migrator(cmd.Context())
+				if err != nil {
+					return err
+				}
+				return m.Down(cmd.Context(), steps)
+			}),
+		},
+		&cobra.Command{
+			Use:   "goto N",
+			Short: "Migrate up or down to version N, 0 rolls back everything",
+			Args:  uintArgs(cobra.ExactArgs(1), 0),
+			RunE: a.run(func(cmd *cobra.Command, args []string) error {
+				version, _ := strconv.ParseUint(args[0], 10, 64)
+
+				m, err := migrator(cmd.Context())
+				if err != nil {
+					return err
+				}
+				return m.Goto(cmd.Context(), uint(version))
+			}),
+		},
+		&cobra.Command{
+			Use:   "status",
+			Short: "List the migrations and the version of the database",
+			Args:  cobra.NoArgs,
+			RunE: a.run(func(cmd *cobra.Command, args []string) error {
+				m, err := migrator(cmd.Context())
+				if err != nil {
+					return err
+				}
+
+				status, current, dirty, err := m.Status(cmd.Context())
+				if err != nil {
+					return err
+				}
+
+				out := cmd.OutOrStdout()
+				state := "clean"
+				if dirty {
+					state = "dirty"
+				}
+				fmt.Fprintf(out, "version %d (%s)\n", current, state)
+
+				for _, s := range status {
+					applied := "pending"
+					if s.Applied {
+						applied = "applied"
+					}
+					fmt.Fprintf(out, "%06d %-40s %s\n", s.Version, s.Name, applied)
+				}
+				return nil
+			}),
+		},
+	)
+
+	return migrate
+}
+
+// uintArgs checks the argument count with count and that every argument is a
+// number of at least min.
+func uintArgs(count cobra.PositionalArgs, min uint64) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+
+		if err := count(cmd, args); err != nil {
+			return err
+		}
+
+		for _, arg := range args {
+			if n, err := strconv.ParseUint(arg, 10, 64); err != nil || n < min {
+				return fmt.Errorf("invalid argument %q, want a number of at least %d", arg, min)
+			}
+		}
+		return nil
+	}
+}
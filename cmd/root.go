@@ -0,0 +1,182 @@
+// Package cmd is the cake-store command line, every command shares the
+// config loading and the wiring through infra and the service manager.
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/cake-store-RESTFul/config"
+	"gitlab.com/cake-store-RESTFul/infra"
+	"gitlab.com/cake-store-RESTFul/migration"
+	"gitlab.com/cake-store-RESTFul/repo"
+	service_manager "gitlab.com/cake-store-RESTFul/service-manager"
+)
+
+// Exit codes of the binary, scripts can tell a bad invocation or a migration
+// that has to wait apart from a plain failure.
+const (
+	ExitOK = iota
+	ExitError
+	ExitUsage
+	ExitConfig
+	ExitMigrationLocked
+	ExitMigrationDirty
+)
+
+const defaultConfigFile = "./config/app.toml"
+
+// exitError carries the exit code of an error returned by a command.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e exitError) Unwrap() error {
+	return e.err
+}
+
+// app is the state shared by the commands, infra is only set up by the
+// commands that need it.
+type app struct {
+	configFile     string
+	memory         bool
+	config         *config.Config
+	infra          *infra.Infra
+	serviceManager service_manager.ServiceManager
+}
+
+// loadConfig reads the config file with its environment overrides, an
+// invalid config is reported before anything is set up.
+func (a *app) loadConfig() error {
+
+	var overrides []map[string]interface{}
+	if a.memory {
+		overrides = append(overrides, config.MemoryMode)
+	}
+
+	config, err := config.Load(a.configFile, overrides...)
+	if err != nil {
+		return exitError{code: ExitConfig, err: err}
+	}
+
+	a.config = config
+	return nil
+}
+
+// setup wires infra and the service manager and starts the components, they
+// are stopped and infra is closed by teardown once the command returns.
+func (a *app) setup(ctx context.Context) error {
+	if a.infra == nil {
+		a.infra = infra.NewInfra(a.config)
+
+		var options []service_manager.Option
+		if a.config.Database.Memory {
+			a.infra.Log.Warn().Msg("database.memory is set, cakes are lost when the app exits")
+			options = append(options, service_manager.WithOverride(service_manager.CakeRepoComponent, func(service_manager.ServiceManager) interface{} {
+				return repo.NewMemoryCake(a.infra.Log)
+			}))
+		}
+		a.serviceManager = service_manager.NewServiceManager(a.infra, options...)
+	}
+	return a.serviceManager.Start(ctx)
+}
+
+func (a *app) teardown(ctx context.Context) {
+	if a.serviceManager == nil {
+		return
+	}
+	if err := a.serviceManager.Stop(ctx); err != nil {
+		a.infra.Log.Error().Msg(err.Error())
+	}
+	if err := a.infra.Close(); err != nil {
+		a.infra.Log.Error().Msg(err.Error())
+	}
+}
+
+// run adapts fn to a cobra RunE, errors are logged and given their exit code.
+// Errors cobra returns without going through run are usage errors.
+func (a *app) run(fn func(cmd *cobra.Command, args []string) error) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+
+		err := fn(cmd, args)
+		a.teardown(cmd.Context())
+		if err == nil {
+			return nil
+		}
+
+		var exit exitError
+		if errors.As(err, &exit) {
+			return err
+		}
+
+		var dirty migration.ErrDirty
+		switch {
+		case errors.Is(err, migration.ErrLocked):
+			return exitError{code: ExitMigrationLocked, err: err}
+		case errors.As(err, &dirty):
+			return exitError{code: ExitMigrationDirty, err: err}
+		}
+
+		return exitError{code: ExitError, err: err}
+	}
+}
+
+func newRootCommand() *cobra.Command {
+
+	a := &app{}
+
+	root := &cobra.Command{
+		Use:           "cake-store",
+		Short:         "Cake store REST, gRPC and GraphQL API",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return a.loadConfig()
+		},
+	}
+	root.PersistentFlags().StringVar(&a.configFile, "config", defaultConfigFile, "path of the config file")
+
+	// without a command the APIs are served, so `go run main.go` still works
+	serve := newServeCommand(a)
+	root.Args = serve.Args
+	root.RunE = serve.RunE
+	root.Flags().AddFlag(serve.Flags().Lookup("memory"))
+
+	root.AddCommand(
+		serve,
+		newMigrateCommand(a),
+		newSeedCommand(a),
+		newUserCommand(a),
+		newConfigCommand(a),
+	)
+
+	return root
+}
+
+// Execute runs the command line and returns the exit code.
+func Execute() int {
+
+	root := newRootCommand()
+	cmd, err := root.ExecuteC()
+	if err == nil {
+		return ExitOK
+	}
+
+	fmt.Fprintln(os.Stderr, "Error:", err.Error())
+
+	var exit exitError
+	if errors.As(err, &exit) {
+		return exit.code
+	}
+
+	fmt.Fprint(os.Stderr, cmd.UsageString())
+	return ExitUsage
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	cakeApi "gitlab.com/cake-store-RESTFul/service/cake"
+	seedApi "gitlab.com/cake-store-RESTFul/service/seed"
+)
+
+func newSeedCommand(a *app) *cobra.Command {
+
+	seed := &cobra.Command{
+		Use:   "seed",
+		Short: "Fill the cake table with demo data or the cakes of a file",
+	}
+
+	seed.AddCommand(newSeedDemoCommand(a), newSeedImportCommand(a))
+	return seed
+}
+
+func newSeedDemoCommand(a *app) *cobra.Command {
+
+	req := seedApi.Request{}
+
+	demo := &cobra.Command{
+		Use:   "demo",
+		Short: "Create generated demo cakes",
+		Long: "Create generated demo cakes with placeholder images. The cakes only depend on --seed,\n" +
+			"running it again only adds the cakes missing up to --count.",
+		Args: cobra.NoArgs,
+		RunE: a.run(func(cmd *cobra.Command, args []string) error {
+
+			if err := a.setup(cmd.Context()); err != nil {
+				return err
+			}
+			res, err := a.serviceManager.SeederService().Seed(cmd.Context(), req)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "seed %d: %d cakes existed, %d created\n", res.Seed, res.Existing, res.Created)
+			return nil
+		}),
+	}
+
+	demo.Flags().IntVar(&req.Count, "count", 100, fmt.Sprintf("number of demo cakes, at most %d", seedApi.MaxCount))
+	demo.Flags().Int64Var(&req.Seed, "seed", 1, "seed of the generator, each seed has its own cakes")
+
+	return demo
+}
+
+func newSeedImportCommand(a *app) *cobra.Command {
+
+	var format, images string
+	var dryRun bool
+
+	imports := &cobra.Command{
+		Use:   "import FILE",
+		Short: "Import cakes from a CSV or NDJSON file",
+		Long: "Import cakes from a CSV or NDJSON file, the same way as POST /api/v1/cake/import.\n" +
+			"Image columns hold an http(s) URL or the name of a file in the --images zip.",
+		Args: cobra.ExactArgs(1),
+		RunE: a.run(func(cmd *cobra.Command, args []string) error {
+
+			file, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			if format == "" {
+				format = cakeApi.ImportFormatCSV
+				switch strings.ToLower(filepath.Ext(args[0])) {
+				case ".ndjson", ".jsonl", ".json":
+					format = cakeApi.ImportFormatNDJSON
+				}
+			}
+
+			rows, err := cakeApi.ParseImport(strings.ToLower(format), file)
+			if err != nil {
+				return err
+			}
+
+			req := cakeApi.ImportRequest{Rows: rows, DryRun: dryRun}
+			if images != "" {
+				archive, err := zip.OpenReader(images)
+				if err != nil {
+					return err
+				}
+				defer archive.Close()
+				req.Images = &archive.Reader
+			}
+
+			if err := a.setup(cmd.Context()); err != nil {
+				return err
+			}
+			res, err := a.serviceManager.CakeService().Import(cmd.Context(), req)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			for _, row := range res.Rows {
+				if row.Error != "" {
+					fmt.Fprintf(out, "line %d %q: %s: %s\n", row.Line, row.Title, row.Status, row.Error)
+				}
+			}
+			fmt.Fprintf(out, "total %d, succeeded %d, failed %d\n", res.Total, res.Succeeded, res.Failed)
+
+			if res.Failed > 0 {
+				return fmt.Errorf("%d of %d rows failed", res.Failed, res.Total)
+			}
+			return nil
+		}),
+	}
+
+	imports.Flags().StringVar(&format, "format", "", "csv or ndjson, by default taken from the file extension")
+	imports.Flags().StringVar(&images, "images", "", "zip archive with the image files the rows refer to")
+	imports.Flags().BoolVar(&dryRun, "dry-run", false, "only validate the rows")
+
+	return imports
+}
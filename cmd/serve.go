@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/cake-store-RESTFul/api"
+	"gitlab.com/cake-store-RESTFul/config"
+	"gitlab.com/cake-store-RESTFul/infra"
+	"gitlab.com/cake-store-RESTFul/migration"
+	"gitlab.com/cake-store-RESTFul/rpc"
+	"golang.org/x/sync/errgroup"
+)
+
+func newServeCommand(a *app) *cobra.Command {
+	serve := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the REST, GraphQL and gRPC APIs",
+		Args:  cobra.NoArgs,
+		RunE: a.run(func(cmd *cobra.Command, args []string) error {
+
+			if err := a.setup(cmd.Context()); err != nil {
+				return err
+			}
+
+			// an SQLite database is local to this instance, and a new one in
+			// memory on every start, so it is always brought up to date
+			if a.config.Database.AutoMigrate || a.infra.Dialect == infra.DialectSQLite {
+				migrator, err := migration.NewMigrator(a.infra.Database, a.infra.Log)
+				if err != nil {
+					return err
+				}
+				if err = migrator.Up(cmd.Context()); err != nil {
+					return err
+				}
+			}
+
+			// SIGINT and SIGTERM drain the servers, the components are then
+			// stopped by teardown
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			// both servers stop when either fails, teardown waits for them
+			group, ctx := errgroup.WithContext(ctx)
+
+			if a.config.GRPC.Enabled {
+				group.Go(func() error {
+					return rpc.Run(ctx, a.config.GRPC, a.serviceManager, a.infra.Log)
+				})
+			}
+
+			// the live settings are reloaded when the config file changes or
+			// on SIGHUP
+			store := config.NewStore(a.configFile, a.config, a.infra.Log)
+			store.OnReload(a.infra.Reload)
+			go store.Watch(ctx)
+
+			group.Go(func() error {
+				return api.Run(ctx, store, a.serviceManager, a.infra.Log)
+			})
+
+			return group.Wait()
+		}),
+	}
+	serve.Flags().BoolVar(&a.memory, "memory", false, "keep everything in memory for development, nothing is written to disk")
+
+	return serve
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newUserCommand(a *app) *cobra.Command {
+
+	user := &cobra.Command{
+		Use:   "user",
+		Short: "Manage the admin users allowed to call the admin endpoints",
+	}
+
+	var name string
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Create an admin user and print its API token",
+		Long: "Create an admin user and print its API token. Only a hash of the token is stored,\n" +
+			"it is shown once and cannot be recovered.",
+		Args: cobra.NoArgs,
+		RunE: a.run(func(cmd *cobra.Command, args []string) error {
+
+			if err := a.setup(cmd.Context()); err != nil {
+				return err
+			}
+			token, err := a.serviceManager.AdminUserService().Create(cmd.Context(), name)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), token)
+			return nil
+		}),
+	}
+	create.Flags().StringVar(&name, "name", "", "unique name of the admin user")
+	_ = create.MarkFlagRequired("name")
+
+	user.AddCommand(create)
+	return user
+}
@@ -0,0 +1,200 @@
+// Package config loads the settings of the app from the config file and the
+// environment into a Config, and checks them before anything is started.
+package config
+
+// Config is the whole configuration, the sections match the ones of
+// app.toml. Durations are in seconds unless the key says otherwise.
+type Config struct {
+	Log         Log         `mapstructure:"log"`
+	API         API         `mapstructure:"api"`
+	GRPC        GRPC        `mapstructure:"grpc"`
+	Database    Database    `mapstructure:"database"`
+	SQLite      SQLite      `mapstructure:"sqlite"`
+	MySQL       Server      `mapstructure:"mysql"`
+	Postgres    Server      `mapstructure:"postgres"`
+	Cache       Cache       `mapstructure:"cache"`
+	Idempotency Idempotency `mapstructure:"idempotency"`
+	Webhook     Webhook     `mapstructure:"webhook"`
+	Outbox      Outbox      `mapstructure:"outbox"`
+	Cloudinary  Cloudinary  `mapstructure:"cloudinary"`
+
+	// settings are the raw values the Config was decoded from, kept for
+	// Redacted.
+	settings map[string]interface{}
+	// overrides were given to Load, a reload applies them again.
+	overrides map[string]interface{}
+}
+
+type Log struct {
+	Level string `mapstructure:"level"`
+}
+
+type API struct {
+	Env          string       `mapstructure:"env"`
+	Port         int          `mapstructure:"port"`
+	Host         string       `mapstructure:"host"`
+	CORS         CORS         `mapstructure:"cors"`
+	CacheControl CacheControl `mapstructure:"cache_control"`
+	OpenAPI      OpenAPI      `mapstructure:"openapi"`
+	GraphQL      GraphQL      `mapstructure:"graphql"`
+	Admin        Admin        `mapstructure:"admin"`
+}
+
+// Development reports whether the API runs in development, where the
+// GraphiQL playground is served and responses are validated.
+func (a API) Development() bool {
+	return a.Env == EnvDevelopment
+}
+
+// CORS are the origins browsers may call the API from, "*" allows any.
+type CORS struct {
+	AllowedOrigins []string `mapstructure:"allowed_origins"`
+}
+
+// Allow returns the Access-Control-Allow-Origin of a request from origin,
+// empty when the origin is not allowed.
+func (c CORS) Allow(origin string) string {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" {
+			return "*"
+		}
+		if origin != "" && allowed == origin {
+			return origin
+		}
+	}
+	return ""
+}
+
+// CacheControl is the Cache-Control header per route, empty omits it.
+type CacheControl struct {
+	List   string `mapstructure:"list"`
+	Detail string `mapstructure:"detail"`
+}
+
+type OpenAPI struct {
+	ValidateRequests  bool `mapstructure:"validate_requests"`
+	ValidateResponses bool `mapstructure:"validate_responses"`
+}
+
+type GraphQL struct {
+	Enabled       bool `mapstructure:"enabled"`
+	MaxDepth      int  `mapstructure:"max_depth"`
+	MaxComplexity int  `mapstructure:"max_complexity"`
+}
+
+type Admin struct {
+	Token string `mapstructure:"token"`
+}
+
+type GRPC struct {
+	Enabled      bool `mapstructure:"enabled"`
+	Port         int  `mapstructure:"port"`
+	Reflection   bool `mapstructure:"reflection"`
+	MaxImageSize int  `mapstructure:"max_image_size"`
+}
+
+type Database struct {
+	// Driver selects the section the connection is read from.
+	Driver      string `mapstructure:"driver"`
+	AutoMigrate bool   `mapstructure:"auto_migrate"`
+	// Memory keeps the cakes in process instead of the database, the other
+	// tables stay in the database.
+	Memory bool `mapstructure:"memory"`
+}
+
+// Pool are the connection pool settings of a database, the times are in
+// minutes.
+type Pool struct {
+	MaxOpenConn     int `mapstructure:"max_open_conn"`
+	MaxIdleConn     int `mapstructure:"max_idle_conn"`
+	ConnMaxIdleTime int `mapstructure:"conn_max_idle_time"`
+	ConnMaxLifeTime int `mapstructure:"conn_max_life_time"`
+}
+
+type SQLite struct {
+	// Path is the database file, ":memory:" keeps the database in memory.
+	Path string `mapstructure:"path"`
+	// BusyTimeout is in milliseconds.
+	BusyTimeout int  `mapstructure:"busy_timeout"`
+	Pool        Pool `mapstructure:",squash"`
+}
+
+// Memory reports whether the database is kept in memory.
+func (s SQLite) Memory() bool {
+	return s.Path == ":memory:"
+}
+
+// Server is the connection of a MySQL or PostgreSQL database.
+type Server struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Database string `mapstructure:"database"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// SSLMode is only used by PostgreSQL.
+	SSLMode string `mapstructure:"sslmode"`
+	Pool    Pool   `mapstructure:",squash"`
+
+	// Replicas are the DSNs of the read replicas.
+	Replicas             []string `mapstructure:"replicas"`
+	ReplicaMaxLag        int      `mapstructure:"replica_max_lag"`
+	ReplicaCheckInterval int      `mapstructure:"replica_check_interval"`
+}
+
+type Cache struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	Driver        string `mapstructure:"driver"`
+	Size          int    `mapstructure:"size"`
+	DetailTTL     int    `mapstructure:"detail_ttl"`
+	ListTTL       int    `mapstructure:"list_ttl"`
+	RedisAddress  string `mapstructure:"redis_address"`
+	RedisPassword string `mapstructure:"redis_password"`
+	RedisDB       int    `mapstructure:"redis_db"`
+}
+
+type Idempotency struct {
+	TTL             int `mapstructure:"ttl"`
+	CleanupInterval int `mapstructure:"cleanup_interval"`
+	MaxBodySize     int `mapstructure:"max_body_size"`
+}
+
+type Webhook struct {
+	Enabled      bool `mapstructure:"enabled"`
+	Timeout      int  `mapstructure:"timeout"`
+	MaxAttempts  int  `mapstructure:"max_attempts"`
+	BackoffBase  int  `mapstructure:"backoff_base"`
+	BackoffMax   int  `mapstructure:"backoff_max"`
+	PollInterval int  `mapstructure:"poll_interval"`
+	BatchSize    int  `mapstructure:"batch_size"`
+}
+
+type Outbox struct {
+	Driver          string `mapstructure:"driver"`
+	Topic           string `mapstructure:"topic"`
+	NatsURL         string `mapstructure:"nats_url"`
+	KafkaRestURL    string `mapstructure:"kafka_rest_url"`
+	Timeout         int    `mapstructure:"timeout"`
+	PollInterval    int    `mapstructure:"poll_interval"`
+	BatchSize       int    `mapstructure:"batch_size"`
+	Retention       int    `mapstructure:"retention"`
+	CleanupInterval int    `mapstructure:"cleanup_interval"`
+}
+
+// Relayed reports whether the events are published to a broker, with the none
+// and memory drivers they are not relayed.
+func (o Outbox) Relayed() bool {
+	return o.Driver == "nats" || o.Driver == "kafka"
+}
+
+// Cloudinary is the account images are uploaded to, uploads fail while it is
+// not set.
+type Cloudinary struct {
+	CloudName string `mapstructure:"cloud_name"`
+	APIKey    string `mapstructure:"api_key"`
+	Secret    string `mapstructure:"secret"`
+}
+
+// Configured reports whether the account is set.
+func (c Cloudinary) Configured() bool {
+	return c.CloudName != "" && c.APIKey != "" && c.Secret != ""
+}
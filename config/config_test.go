@@ -0,0 +1,298 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+// writeFile writes content to a file in a temporary directory and returns its
+// path.
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+
+	secret := writeFile(t, "secret", "from-file\n")
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	tests := []struct {
+		name      string
+		file      string
+		env       map[string]string
+		overrides map[string]interface{}
+		check     func(t *testing.T, config *Config)
+		wantErrs  Errors
+	}{
+		{
+			name: "defaults",
+			file: "[api]\nenv = \"development\"\n",
+			check: func(t *testing.T, config *Config) {
+				if !config.API.Development() || config.API.Port != 8081 || config.Database.Driver != "sqlite" || config.SQLite.Path != "cake-store.db" {
+					t.Errorf("Load() = %+v", config)
+				}
+				if config.Cloudinary.Configured() {
+					t.Error("Load() cloudinary is configured without settings")
+				}
+				if config.Outbox.Driver != "none" || config.Outbox.Relayed() {
+					t.Errorf("Load() outbox = %+v", config.Outbox)
+				}
+			},
+		},
+		{
+			name: "environment overrides the file",
+			file: "[api]\nport = 8081\n[mysql]\nhost = \"localhost\"\n",
+			env: map[string]string{
+				"CAKE_API_PORT":                  "8080",
+				"CAKE_DATABASE_DRIVER":           "mysql",
+				"CAKE_MYSQL_HOST":                "mysql",
+				"CAKE_MYSQL_REPLICAS":            "replica-1,replica-2",
+				"CAKE_API_CACHE_CONTROL_LIST":    "",
+				"CAKE_API_GRAPHQL_ENABLED":       "false",
+				"CAKE_MYSQL_PASSWORD_FILE":       secret,
+				"CAKE_CLOUDINARY_CLOUD_NAME":     "cloud",
+				"CAKE_CLOUDINARY_API_KEY":        "key",
+				"CAKE_CLOUDINARY_SECRET_FILE":    secret,
+				"CAKE_WEBHOOK_MAX_ATTEMPTS_FILE": "",
+			},
+			check: func(t *testing.T, config *Config) {
+				if config.API.Port != 8080 || config.MySQL.Host != "mysql" || config.API.CacheControl.List != "" || config.API.GraphQL.Enabled {
+					t.Errorf("Load() api = %+v, mysql = %+v", config.API, config.MySQL)
+				}
+				if !reflect.DeepEqual(config.MySQL.Replicas, []string{"replica-1", "replica-2"}) {
+					t.Errorf("Load() mysql.replicas = %q", config.MySQL.Replicas)
+				}
+				if config.MySQL.Password != "from-file" || config.Cloudinary.Secret != "from-file" || !config.Cloudinary.Configured() {
+					t.Errorf("Load() secrets = %q, %q", config.MySQL.Password, config.Cloudinary.Secret)
+				}
+			},
+		},
+		{
+			name:      "overrides win over the environment",
+			file:      "[api]\nenv = \"development\"\n[database]\ndriver = \"mysql\"\n",
+			env:       map[string]string{"CAKE_SQLITE_PATH": "cake-store.db"},
+			overrides: MemoryMode,
+			check: func(t *testing.T, config *Config) {
+				if !config.Database.Memory || config.Database.Driver != "sqlite" || !config.SQLite.Memory() {
+					t.Errorf("Load() database = %+v, sqlite = %+v", config.Database, config.SQLite)
+				}
+			},
+		},
+		{
+			name: "error every problem at once",
+			file: "[api]\nenv = \"staging\"\nport = 0\n[database]\ndriver = \"postgres\"\n[postgres]\nsslmode = \"maybe\"\nreplicas = [\"\"]\n[cache]\ndriver = \"redis\"\nredis_address = \"\"\n[cloudinary]\ncloud_name = \"cloud\"\n",
+			env: map[string]string{
+				"CAKE_POSTGRES_HOST":         "",
+				"CAKE_OUTBOX_DRIVER":         "kafka",
+				"CAKE_OUTBOX_KAFKA_REST_URL": "",
+				"CAKE_WEBHOOK_BACKOFF_MAX":   "10",
+			},
+			wantErrs: Errors{
+				`api.env: "staging" is not one of development, production`,
+				"api.port: 0 is not a port",
+				"postgres.host: is required",
+				"postgres.replicas[0]: is empty",
+				`postgres.sslmode: "maybe" is not one of disable, require, verify-ca, verify-full`,
+				"cache.redis_address: is required",
+				"webhook.backoff_max: is below webhook.backoff_base",
+				"outbox.kafka_rest_url: is required",
+				"cloudinary.api_key: is required with the other cloudinary settings",
+				"cloudinary.secret: is required with the other cloudinary settings",
+			},
+		},
+		{
+			name:     "error nats url",
+			file:     "[outbox]\ndriver = \"nats\"\nnats_url = \"nats://localhost:4222,localhost:4223\"\n",
+			wantErrs: Errors{`outbox.nats_url: "localhost:4223" is not a nats:// or tls:// url`},
+		},
+		{
+			name:     "error memory outbox outside development",
+			file:     "[outbox]\ndriver = \"memory\"\n",
+			wantErrs: Errors{"outbox.driver: memory is only allowed when api.env is development"},
+		},
+		{
+			name: "error unknown setting and bad value",
+			file: "[api]\nenv = \"development\"\nprot = 8080\n",
+			env:  map[string]string{"CAKE_API_PORT": "http"},
+			wantErrs: Errors{
+				`cannot parse 'api.port' as int: strconv.ParseInt: parsing "http": invalid syntax`,
+				"'api' has invalid keys: prot",
+				"api.port: 0 is not a port",
+			},
+		},
+		{
+			name: "error secret file",
+			file: "[api]\nenv = \"development\"\n",
+			env: map[string]string{
+				"CAKE_API_ADMIN_TOKEN":      "token",
+				"CAKE_API_ADMIN_TOKEN_FILE": secret,
+				"CAKE_MYSQL_PASSWORD_FILE":  missing,
+			},
+			wantErrs: Errors{
+				"api.admin.token: only one of CAKE_API_ADMIN_TOKEN and CAKE_API_ADMIN_TOKEN_FILE can be set",
+				"mysql.password: CAKE_MYSQL_PASSWORD_FILE: open " + missing + ": no such file or directory",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			config, err := Load(writeFile(t, "app.toml", tt.file), tt.overrides)
+			if tt.wantErrs != nil {
+				if errs, _ := err.(Errors); !reflect.DeepEqual(errs, tt.wantErrs) {
+					t.Errorf("Load() error = %v, want %v", err, tt.wantErrs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			tt.check(t, config)
+		})
+	}
+}
+
+func TestLoad_missingFile(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "app.toml")); err == nil {
+		t.Error("Load() error = nil for a missing file")
+	}
+}
+
+func TestConfig_Redacted(t *testing.T) {
+
+	t.Setenv("CAKE_DATABASE_DRIVER", "mysql")
+	t.Setenv("CAKE_MYSQL_PASSWORD", "root")
+	t.Setenv("CAKE_MYSQL_REPLICAS", "root:root@tcp(replica:3306)/cake-store")
+
+	config, err := Load(writeFile(t, "app.toml", "[api]\nenv = \"development\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settings := config.Redacted()
+	mysql := settings["mysql"].(map[string]interface{})
+	if mysql["password"] != redacted || mysql["replicas"] != redacted || mysql["host"] != "localhost" {
+		t.Errorf("Redacted() mysql = %v", mysql)
+	}
+	if postgres := settings["postgres"].(map[string]interface{}); postgres["password"] != "" {
+		t.Errorf("Redacted() masked the empty postgres.password: %v", postgres["password"])
+	}
+	if admin := settings["api"].(map[string]interface{})["admin"].(map[string]interface{}); admin["token"] != "" {
+		t.Errorf("Redacted() api.admin.token = %v", admin["token"])
+	}
+}
+
+func TestCORS_Allow(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		origin  string
+		want    string
+	}{
+		{name: "any", allowed: []string{"*"}, origin: "https://shop.example.com", want: "*"},
+		{name: "listed", allowed: []string{"https://admin.example.com", "https://shop.example.com"}, origin: "https://shop.example.com", want: "https://shop.example.com"},
+		{name: "not listed", allowed: []string{"https://admin.example.com"}, origin: "https://evil.example.com"},
+		{name: "no origin", allowed: []string{"https://admin.example.com"}},
+		{name: "none allowed", origin: "https://shop.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (CORS{AllowedOrigins: tt.allowed}).Allow(tt.origin); got != tt.want {
+				t.Errorf("CORS.Allow() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStore_Reload(t *testing.T) {
+
+	file := writeFile(t, "app.toml", "[log]\nlevel = \"info\"\n[api]\nenv = \"development\"\nport = 8081\n[cache]\nlist_ttl = 30\n")
+
+	loaded, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logs := &bytes.Buffer{}
+	store := NewStore(file, loaded, zerolog.New(logs))
+
+	var reloaded []*Config
+	store.OnReload(func(config *Config) {
+		reloaded = append(reloaded, config)
+	})
+
+	tests := []struct {
+		name      string
+		file      string
+		wantErr   bool
+		wantLevel string
+		wantTTL   int
+		wantLogs  []string
+	}{
+		{
+			name:      "live settings are applied, the others kept",
+			file:      "[log]\nlevel = \"debug\"\n[api]\nenv = \"development\"\nport = 9000\n[cache]\nlist_ttl = 10\n",
+			wantLevel: "debug",
+			wantTTL:   10,
+			wantLogs:  []string{"cache.list_ttl: 30 -> 10, log.level: info -> debug", `"settings":["api.port"]`},
+		},
+		{
+			name:      "no live setting changed",
+			file:      "[log]\nlevel = \"debug\"\n[api]\nenv = \"development\"\n[cache]\nlist_ttl = 10\n",
+			wantLevel: "debug",
+			wantTTL:   10,
+			wantLogs:  []string{"no live setting changed"},
+		},
+		{
+			name:      "error invalid config is rejected",
+			file:      "[log]\nlevel = \"loud\"\n[api]\nenv = \"development\"\n[cache]\nlist_ttl = 5\n",
+			wantErr:   true,
+			wantLevel: "debug",
+			wantTTL:   10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			logs.Reset()
+			if err := os.WriteFile(file, []byte(tt.file), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := store.Reload(); (err != nil) != tt.wantErr {
+				t.Fatalf("Store.Reload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			got := store.Get()
+			if got.Log.Level != tt.wantLevel || got.Cache.ListTTL != tt.wantTTL || got.API.Port != 8081 {
+				t.Errorf("Store.Get() log.level = %q, cache.list_ttl = %d, api.port = %d", got.Log.Level, got.Cache.ListTTL, got.API.Port)
+			}
+			for _, want := range tt.wantLogs {
+				if !strings.Contains(logs.String(), want) {
+					t.Errorf("Store.Reload() logged %s, want %s", logs.String(), want)
+				}
+			}
+		})
+	}
+
+	if len(reloaded) != 1 || reloaded[0] != store.Get() {
+		t.Errorf("Store.OnReload() called %d times", len(reloaded))
+	}
+	if loaded.Log.Level != "info" {
+		t.Errorf("Store.Reload() modified the previous snapshot, log.level = %q", loaded.Log.Level)
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+// Environments of api.env.
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// MemoryMode are the overrides of the --memory mode, the cakes are kept in
+// process and the other tables in an SQLite database in memory, so nothing
+// outlives the process.
+var MemoryMode = map[string]interface{}{
+	"database.memory": true,
+	"database.driver": "sqlite",
+	"sqlite.path":     ":memory:",
+}
+
+// defaults are used for the settings missing from the config file. Every
+// setting has one, so every setting can also be set from the environment.
+var defaults = map[string]interface{}{
+	"log.level": "info",
+
+	"api.env":                        EnvProduction,
+	"api.port":                       8081,
+	"api.host":                       "0.0.0.0",
+	"api.cors.allowed_origins":       []string{"*"},
+	"api.cache_control.list":         "public, max-age=30, stale-while-revalidate=30",
+	"api.cache_control.detail":       "public, max-age=60, stale-while-revalidate=60",
+	"api.openapi.validate_requests":  true,
+	"api.openapi.validate_responses": false,
+	"api.graphql.enabled":            true,
+	"api.graphql.max_depth":          6,
+	"api.graphql.max_complexity":     500,
+	"api.admin.token":                "",
+
+	"grpc.enabled":        true,
+	"grpc.port":           9090,
+	"grpc.reflection":     true,
+	"grpc.max_image_size": 10 << 20,
+
+	"database.driver":       "sqlite",
+	"database.auto_migrate": false,
+	"database.memory":       false,
+
+	"sqlite.path":               "cake-store.db",
+	"sqlite.busy_timeout":       5000,
+	"sqlite.max_open_conn":      10,
+	"sqlite.max_idle_conn":      10,
+	"sqlite.conn_max_idle_time": 0,
+	"sqlite.conn_max_life_time": 0,
+
+	"mysql.host":                   "localhost",
+	"mysql.port":                   3306,
+	"mysql.database":               "cake-store",
+	"mysql.username":               "root",
+	"mysql.password":               "",
+	"mysql.max_open_conn":          20,
+	"mysql.max_idle_conn":          10,
+	"mysql.conn_max_idle_time":     10,
+	"mysql.conn_max_life_time":     10,
+	"mysql.replicas":               []string{},
+	"mysql.replica_max_lag":        5,
+	"mysql.replica_check_interval": 5,
+
+	"postgres.host":                   "localhost",
+	"postgres.port":                   5432,
+	"postgres.database":               "cake-store",
+	"postgres.username":               "postgres",
+	"postgres.password":               "",
+	"postgres.sslmode":                "disable",
+	"postgres.max_open_conn":          20,
+	"postgres.max_idle_conn":          10,
+	"postgres.conn_max_idle_time":     10,
+	"postgres.conn_max_life_time":     10,
+	"postgres.replicas":               []string{},
+	"postgres.replica_max_lag":        5,
+	"postgres.replica_check_interval": 5,
+
+	"cache.enabled":        true,
+	"cache.driver":         "memory",
+	"cache.size":           1000,
+	"cache.detail_ttl":     60,
+	"cache.list_ttl":       30,
+	"cache.redis_address":  "localhost:6379",
+	"cache.redis_password": "",
+	"cache.redis_db":       0,
+
+	"idempotency.ttl":              86400,
+	"idempotency.cleanup_interval": 3600,
+	"idempotency.max_body_size":    10 << 20,
+
+	"webhook.enabled":       true,
+	"webhook.timeout":       10,
+	"webhook.max_attempts":  8,
+	"webhook.backoff_base":  30,
+	"webhook.backoff_max":   21600,
+	"webhook.poll_interval": 2,
+	"webhook.batch_size":    50,
+
+	"outbox.driver":           "none",
+	"outbox.topic":            "cake.events",
+	"outbox.nats_url":         "nats://localhost:4222",
+	"outbox.kafka_rest_url":   "http://localhost:8082",
+	"outbox.timeout":          5,
+	"outbox.poll_interval":    1,
+	"outbox.batch_size":       100,
+	"outbox.retention":        86400,
+	"outbox.cleanup_interval": 3600,
+
+	"cloudinary.cloud_name": "",
+	"cloudinary.api_key":    "",
+	"cloudinary.secret":     "",
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix starts the environment variables overriding a setting, e.g.
+// CAKE_MYSQL_PASSWORD for mysql.password. CAKE_MYSQL_PASSWORD_FILE reads the
+// value from a file instead, like a mounted Docker or Kubernetes secret.
+const EnvPrefix = "CAKE"
+
+const fileSuffix = "_FILE"
+
+// secretKeys are the parts of a setting name whose value is masked by
+// Redacted. The replica DSNs carry their password.
+var secretKeys = []string{"password", "secret", "token", "api_key", "replicas"}
+
+const redacted = "REDACTED"
+
+// Load reads file, applies the environment overrides and then overrides, and
+// checks the result. Every problem found is reported at once in Errors.
+func Load(file string, overrides ...map[string]interface{}) (*Config, error) {
+
+	v := viper.New()
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AllowEmptyEnv(true)
+	v.AutomaticEnv()
+
+	v.SetConfigFile(file)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("read config %s: %w", file, err)
+	}
+
+	errs := readSecretFiles(v)
+
+	config := &Config{overrides: map[string]interface{}{}}
+	for _, settings := range overrides {
+		for key, value := range settings {
+			v.Set(key, value)
+			config.overrides[key] = value
+		}
+	}
+
+	if err := v.UnmarshalExact(config); err != nil {
+		var decodeErr *mapstructure.Error
+		if !errors.As(err, &decodeErr) {
+			return nil, err
+		}
+		errs = append(errs, decodeErr.Errors...)
+	}
+	config.settings = v.AllSettings()
+
+	errs = append(errs, config.validate()...)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return config, nil
+}
+
+// readSecretFiles sets every setting whose _FILE variable is set to the
+// content of that file, without the trailing line break.
+func readSecretFiles(v *viper.Viper) (errs Errors) {
+
+	keys := v.AllKeys()
+	sort.Strings(keys)
+
+	for _, key := range keys {
+
+		env := envName(key)
+		path := os.Getenv(env + fileSuffix)
+		if path == "" {
+			continue
+		}
+
+		if _, set := os.LookupEnv(env); set {
+			errs = append(errs, fmt.Sprintf("%s: only one of %s and %s can be set", key, env, env+fileSuffix))
+			continue
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s: %v", key, env+fileSuffix, err))
+			continue
+		}
+
+		v.Set(key, strings.TrimRight(string(content), "\r\n"))
+	}
+
+	return errs
+}
+
+// envName is the environment variable overriding key.
+func envName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// Redacted returns the settings the Config was loaded from, environment
+// overrides included, with the non empty values of secret settings masked.
+func (c *Config) Redacted() map[string]interface{} {
+	return redact(c.settings)
+}
+
+func redact(settings map[string]interface{}) map[string]interface{} {
+
+	output := make(map[string]interface{}, len(settings))
+	for key, value := range settings {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			output[key] = redact(value)
+		default:
+			output[key] = value
+			if isSecret(key) && !empty(value) {
+				output[key] = redacted
+			}
+		}
+	}
+
+	return output
+}
+
+func empty(value interface{}) bool {
+	switch value := value.(type) {
+	case nil:
+		return true
+	case string:
+		return value == ""
+	case []interface{}:
+		return len(value) == 0
+	case []string:
+		return len(value) == 0
+	}
+	return false
+}
+
+func isSecret(key string) bool {
+	key = strings.ToLower(key)
+	for _, secret := range secretKeys {
+		if strings.Contains(key, secret) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
+)
+
+// liveSections have their pool settings reloaded.
+var liveSections = []string{"sqlite", "mysql", "postgres"}
+
+// liveKeys are the settings a reload applies to the running app, every other
+// setting is only read on startup and needs a restart.
+var liveKeys = func() []string {
+
+	keys := []string{
+		"log.level",
+		"api.cors.allowed_origins",
+		"api.graphql.enabled",
+		"cache.detail_ttl",
+		"cache.list_ttl",
+	}
+	for _, section := range liveSections {
+		keys = append(keys,
+			section+".max_open_conn",
+			section+".max_idle_conn",
+			section+".conn_max_idle_time",
+			section+".conn_max_life_time",
+		)
+	}
+
+	return keys
+}()
+
+// Store holds the running Config. A reload loads the config file again and
+// swaps in a new snapshot in which only the live settings changed, the
+// snapshot readers hold is never modified.
+type Store struct {
+	file string
+	Log  zerolog.Logger
+
+	current   atomic.Value
+	mu        sync.Mutex
+	listeners []func(*Config)
+}
+
+func NewStore(file string, config *Config, log zerolog.Logger) *Store {
+
+	store := &Store{
+		file: file,
+		Log:  log,
+	}
+	store.current.Store(config)
+
+	return store
+}
+
+// Get returns the running Config.
+func (s *Store) Get() *Config {
+	return s.current.Load().(*Config)
+}
+
+// OnReload registers fn to apply the live settings of every Config swapped
+// in, fn is called after the swap and one reload at a time.
+func (s *Store) OnReload(fn func(*Config)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// Reload loads the config file again. A config that does not load or is
+// invalid is rejected and the running one is kept.
+func (s *Store) Reload() error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	loaded, err := Load(s.file, s.Get().overrides)
+	if err != nil {
+		return err
+	}
+
+	running := s.Get()
+	next, err := running.withLive(loaded)
+	if err != nil {
+		return err
+	}
+
+	changed, ignored := diff(running, loaded)
+	if len(ignored) > 0 {
+		s.Log.Warn().Strs("settings", ignored).Msg("config reload kept the running value of settings only read on startup, restart to apply them")
+	}
+	if len(changed) == 0 {
+		s.Log.Info().Msg("config reloaded, no live setting changed")
+		return nil
+	}
+
+	s.current.Store(next)
+	for _, fn := range s.listeners {
+		fn(next)
+	}
+
+	s.Log.Info().Str("changed", strings.Join(changed, ", ")).Msg("config reloaded")
+	return nil
+}
+
+// Watch reloads the config when its file is written or the process gets a
+// SIGHUP, until ctx is done.
+func (s *Store) Watch(ctx context.Context) {
+
+	v := viper.New()
+	v.SetConfigFile(s.file)
+	v.OnConfigChange(func(event fsnotify.Event) {
+		s.reload()
+	})
+	v.WatchConfig()
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			s.reload()
+		}
+	}
+}
+
+func (s *Store) reload() {
+	if err := s.Reload(); err != nil {
+		s.Log.Error().Msg("config reload rejected: " + err.Error())
+	}
+}
+
+// withLive returns a copy of c with the live settings of loaded.
+func (c *Config) withLive(loaded *Config) (*Config, error) {
+
+	v := viper.New()
+	if err := v.MergeConfigMap(c.settings); err != nil {
+		return nil, err
+	}
+
+	source := viper.New()
+	if err := source.MergeConfigMap(loaded.settings); err != nil {
+		return nil, err
+	}
+	for _, key := range liveKeys {
+		if source.IsSet(key) {
+			v.Set(key, source.Get(key))
+		}
+	}
+
+	next := &Config{}
+	if err := v.UnmarshalExact(next); err != nil {
+		return nil, err
+	}
+	next.settings = v.AllSettings()
+
+	if errs := next.validate(); len(errs) > 0 {
+		return nil, errs
+	}
+
+	return next, nil
+}
+
+// diff returns the live settings loaded changes as "key: old -> new", secret
+// values left out, and the other settings it changes.
+func diff(running, loaded *Config) (changed, ignored []string) {
+
+	was := viper.New()
+	_ = was.MergeConfigMap(running.settings)
+	now := viper.New()
+	_ = now.MergeConfigMap(loaded.settings)
+
+	live := map[string]bool{}
+	for _, key := range liveKeys {
+		live[key] = true
+	}
+
+	keys := append(was.AllKeys(), now.AllKeys()...)
+	sort.Strings(keys)
+
+	for i, key := range keys {
+		if i > 0 && keys[i-1] == key {
+			continue
+		}
+
+		before, after := fmt.Sprint(was.Get(key)), fmt.Sprint(now.Get(key))
+		if before == after {
+			continue
+		}
+
+		switch {
+		case !live[key]:
+			ignored = append(ignored, key)
+		case isSecret(key):
+			changed = append(changed, key)
+		default:
+			changed = append(changed, fmt.Sprintf("%s: %s -> %s", key, before, after))
+		}
+	}
+
+	return changed, ignored
+}
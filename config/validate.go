@@ -0,0 +1,164 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Errors are the problems found in a configuration, one per setting.
+type Errors []string
+
+func (e Errors) Error() string {
+	return "invalid config:\n  " + strings.Join(e, "\n  ")
+}
+
+// check appends key: message to e unless ok.
+func (e *Errors) check(ok bool, key, message string, args ...interface{}) {
+	if !ok {
+		*e = append(*e, key+": "+fmt.Sprintf(message, args...))
+	}
+}
+
+func (e *Errors) oneOf(key, value string, values ...string) {
+	for _, v := range values {
+		if value == v {
+			return
+		}
+	}
+	e.check(false, key, "%q is not one of %s", value, strings.Join(values, ", "))
+}
+
+func (e *Errors) required(key, value string) {
+	e.check(value != "", key, "is required")
+}
+
+func (e *Errors) port(key string, value int) {
+	e.check(value > 0 && value < 1<<16, key, "%d is not a port", value)
+}
+
+func (e *Errors) notNegative(key string, value int) {
+	e.check(value >= 0, key, "%d is negative", value)
+}
+
+func (c *Config) validate() (errs Errors) {
+
+	errs.oneOf("log.level", c.Log.Level, "trace", "debug", "info", "warn", "error")
+
+	errs.oneOf("api.env", c.API.Env, EnvDevelopment, EnvProduction)
+	errs.port("api.port", c.API.Port)
+	for i, origin := range c.API.CORS.AllowedOrigins {
+		errs.check(origin != "", fmt.Sprintf("api.cors.allowed_origins[%d]", i), "is empty")
+	}
+	errs.notNegative("api.graphql.max_depth", c.API.GraphQL.MaxDepth)
+	errs.notNegative("api.graphql.max_complexity", c.API.GraphQL.MaxComplexity)
+
+	if c.GRPC.Enabled {
+		errs.port("grpc.port", c.GRPC.Port)
+		errs.check(c.GRPC.Port != c.API.Port, "grpc.port", "is also api.port")
+	}
+	errs.notNegative("grpc.max_image_size", c.GRPC.MaxImageSize)
+
+	switch c.Database.Driver {
+	case "sqlite":
+		errs.required("sqlite.path", c.SQLite.Path)
+		errs.notNegative("sqlite.busy_timeout", c.SQLite.BusyTimeout)
+		errs.pool("sqlite", c.SQLite.Pool)
+	case "mysql":
+		errs.server("mysql", c.MySQL)
+	case "postgres":
+		errs.server("postgres", c.Postgres)
+		errs.oneOf("postgres.sslmode", c.Postgres.SSLMode, "disable", "require", "verify-ca", "verify-full")
+	default:
+		errs.oneOf("database.driver", c.Database.Driver, "sqlite", "mysql", "postgres")
+	}
+
+	if c.Cache.Enabled {
+		errs.oneOf("cache.driver", c.Cache.Driver, "memory", "redis")
+		switch c.Cache.Driver {
+		case "memory":
+			errs.check(c.Cache.Size > 0, "cache.size", "must be positive")
+		case "redis":
+			errs.required("cache.redis_address", c.Cache.RedisAddress)
+			errs.notNegative("cache.redis_db", c.Cache.RedisDB)
+		}
+		errs.notNegative("cache.detail_ttl", c.Cache.DetailTTL)
+		errs.notNegative("cache.list_ttl", c.Cache.ListTTL)
+	}
+
+	errs.notNegative("idempotency.ttl", c.Idempotency.TTL)
+	errs.notNegative("idempotency.cleanup_interval", c.Idempotency.CleanupInterval)
+	errs.notNegative("idempotency.max_body_size", c.Idempotency.MaxBodySize)
+
+	if c.Webhook.Enabled {
+		errs.notNegative("webhook.timeout", c.Webhook.Timeout)
+		errs.notNegative("webhook.max_attempts", c.Webhook.MaxAttempts)
+		errs.notNegative("webhook.backoff_base", c.Webhook.BackoffBase)
+		errs.notNegative("webhook.backoff_max", c.Webhook.BackoffMax)
+		errs.notNegative("webhook.poll_interval", c.Webhook.PollInterval)
+		errs.notNegative("webhook.batch_size", c.Webhook.BatchSize)
+		errs.check(c.Webhook.BackoffMax == 0 || c.Webhook.BackoffMax >= c.Webhook.BackoffBase, "webhook.backoff_max", "is below webhook.backoff_base")
+	}
+
+	errs.oneOf("outbox.driver", c.Outbox.Driver, "none", "memory", "nats", "kafka")
+	errs.check(c.Outbox.Driver != "memory" || c.API.Development(), "outbox.driver", "memory is only allowed when api.env is %s", EnvDevelopment)
+	switch c.Outbox.Driver {
+	case "nats":
+		errs.natsURL("outbox.nats_url", c.Outbox.NatsURL)
+	case "kafka":
+		errs.required("outbox.kafka_rest_url", c.Outbox.KafkaRestURL)
+	}
+	errs.notNegative("outbox.timeout", c.Outbox.Timeout)
+	errs.notNegative("outbox.poll_interval", c.Outbox.PollInterval)
+	errs.notNegative("outbox.batch_size", c.Outbox.BatchSize)
+	errs.notNegative("outbox.retention", c.Outbox.Retention)
+	errs.notNegative("outbox.cleanup_interval", c.Outbox.CleanupInterval)
+
+	// images can not be uploaded without an account, but a partial one is a
+	// mistake
+	if cloudinary := c.Cloudinary; cloudinary != (Cloudinary{}) {
+		errs.check(cloudinary.CloudName != "", "cloudinary.cloud_name", "is required with the other cloudinary settings")
+		errs.check(cloudinary.APIKey != "", "cloudinary.api_key", "is required with the other cloudinary settings")
+		errs.check(cloudinary.Secret != "", "cloudinary.secret", "is required with the other cloudinary settings")
+	}
+
+	return errs
+}
+
+func (e *Errors) server(section string, server Server) {
+
+	e.required(section+".host", server.Host)
+	e.port(section+".port", server.Port)
+	e.required(section+".database", server.Database)
+	e.required(section+".username", server.Username)
+	e.pool(section, server.Pool)
+
+	if len(server.Replicas) > 0 {
+		e.check(server.ReplicaMaxLag > 0, section+".replica_max_lag", "must be positive")
+		e.check(server.ReplicaCheckInterval > 0, section+".replica_check_interval", "must be positive")
+	}
+	for i, dsn := range server.Replicas {
+		e.check(dsn != "", fmt.Sprintf("%s.replicas[%d]", section, i), "is empty")
+	}
+}
+
+// natsURL checks a comma separated list of nats:// or tls:// server URLs.
+func (e *Errors) natsURL(key, value string) {
+
+	if value == "" {
+		e.required(key, value)
+		return
+	}
+
+	for _, server := range strings.Split(value, ",") {
+		u, err := url.Parse(strings.TrimSpace(server))
+		e.check(err == nil && (u.Scheme == "nats" || u.Scheme == "tls") && u.Host != "", key, "%q is not a nats:// or tls:// url", server)
+	}
+}
+
+func (e *Errors) pool(section string, pool Pool) {
+	e.notNegative(section+".max_open_conn", pool.MaxOpenConn)
+	e.notNegative(section+".max_idle_conn", pool.MaxIdleConn)
+	e.notNegative(section+".conn_max_idle_time", pool.ConnMaxIdleTime)
+	e.notNegative(section+".conn_max_life_time", pool.ConnMaxLifeTime)
+}
@@ -0,0 +1,127 @@
+package gql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// defaultListSize is the number of items a list field is assumed to return
+// when its limit argument is missing or not a literal.
+const defaultListSize = 10
+
+// Limits rejects queries that would be too expensive before they run. A zero
+// value disables the matching check.
+type Limits struct {
+	// MaxDepth is the deepest level of nested fields.
+	MaxDepth int
+	// MaxComplexity is the cost of the query where every field costs 1 and
+	// the fields below a field with a limit argument are counted limit times.
+	MaxComplexity int
+}
+
+// Check measures every operation of doc. Introspection fields are ignored so
+// GraphiQL keeps working with tight limits.
+func (l Limits) Check(doc *ast.Document, variables map[string]interface{}) error {
+
+	m := measure{fragments: map[string]*ast.FragmentDefinition{}, variables: variables}
+	for _, definition := range doc.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			m.fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	for _, definition := range doc.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		depth, complexity := m.selectionSet(operation.SelectionSet, map[string]bool{})
+		if l.MaxDepth > 0 && depth > l.MaxDepth {
+			return fmt.Errorf("query depth %d exceeds the limit of %d", depth, l.MaxDepth)
+		}
+		if l.MaxComplexity > 0 && complexity > l.MaxComplexity {
+			return fmt.Errorf("query complexity %d exceeds the limit of %d", complexity, l.MaxComplexity)
+		}
+	}
+
+	return nil
+}
+
+type measure struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+// selectionSet returns the depth and the complexity of set. visiting holds
+// the fragments being expanded so a fragment cycle is only counted once, the
+// validation rejects it afterwards.
+func (m measure) selectionSet(set *ast.SelectionSet, visiting map[string]bool) (depth, complexity int) {
+
+	if set == nil {
+		return 0, 0
+	}
+
+	for _, selection := range set.Selections {
+		d, c := 0, 0
+
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name.Value, "__") {
+				continue
+			}
+			childDepth, childComplexity := m.selectionSet(selection.SelectionSet, visiting)
+			d, c = childDepth+1, 1+childComplexity*m.listSize(selection)
+
+		case *ast.InlineFragment:
+			d, c = m.selectionSet(selection.SelectionSet, visiting)
+
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			fragment, ok := m.fragments[name]
+			if !ok || visiting[name] {
+				continue
+			}
+			visiting[name] = true
+			d, c = m.selectionSet(fragment.SelectionSet, visiting)
+			delete(visiting, name)
+		}
+
+		if d > depth {
+			depth = d
+		}
+		complexity += c
+	}
+
+	return depth, complexity
+}
+
+// listSize is the limit argument of field, or 1 when the field has none.
+func (m measure) listSize(field *ast.Field) int {
+
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "limit" {
+			continue
+		}
+
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(value.Value); err == nil && n > 0 {
+				return n
+			}
+		case *ast.Variable:
+			if n, ok := m.variables[value.Name.Value].(float64); ok && n > 0 {
+				return int(n)
+			}
+		}
+		return defaultListSize
+	}
+
+	if field.Name.Value == "cakes" {
+		return defaultListSize
+	}
+	return 1
+}
@@ -0,0 +1,81 @@
+package gql
+
+import (
+	"testing"
+
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+func TestLimits_Check(t *testing.T) {
+	tests := []struct {
+		name      string
+		limits    Limits
+		query     string
+		variables map[string]interface{}
+		wantErr   string
+	}{
+		{
+			name:   "within limits",
+			limits: Limits{MaxDepth: 3, MaxComplexity: 20},
+			query:  `{ cake(id: 1) { id title } }`,
+		},
+		{
+			name:    "too deep",
+			limits:  Limits{MaxDepth: 2},
+			query:   `{ cakes { items { id } } }`,
+			wantErr: "query depth 3 exceeds the limit of 2",
+		},
+		{
+			name:    "list counted limit times",
+			limits:  Limits{MaxComplexity: 100},
+			query:   `{ cakes(limit: 50) { items { id title } } }`,
+			wantErr: "query complexity 151 exceeds the limit of 100",
+		},
+		{
+			name:      "limit from variables",
+			limits:    Limits{MaxComplexity: 10},
+			query:     `query ($n: Int) { cakes(limit: $n) { items { id } } }`,
+			variables: map[string]interface{}{"n": float64(2)},
+		},
+		{
+			name:    "list without limit uses the default page size",
+			limits:  Limits{MaxComplexity: 20},
+			query:   `{ cakes { items { id } } }`,
+			wantErr: "query complexity 21 exceeds the limit of 20",
+		},
+		{
+			name:    "fragments are expanded",
+			limits:  Limits{MaxDepth: 2},
+			query:   `{ ...page } fragment page on Query { cakes { ... on CakePage { items { id } } } }`,
+			wantErr: "query depth 3 exceeds the limit of 2",
+		},
+		{
+			name:   "fragment cycle",
+			limits: Limits{MaxDepth: 5},
+			query:  `{ cake(id: 1) { ...a } } fragment a on Cake { id ...a }`,
+		},
+		{
+			name:   "introspection is ignored",
+			limits: Limits{MaxDepth: 1},
+			query:  `{ __schema { types { name fields { name type { name } } } } }`,
+		},
+		{
+			name:   "zero limits are disabled",
+			limits: Limits{},
+			query:  `{ cakes(limit: 1000) { items { id title description image rating } } }`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: tt.query})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = tt.limits.Check(doc, tt.variables)
+			if (err != nil) != (tt.wantErr != "") || (err != nil && err.Error() != tt.wantErr) {
+				t.Errorf("Limits.Check() error = %v, wantErr %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package gql
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"gitlab.com/cake-store-RESTFul/service"
+	cakeApi "gitlab.com/cake-store-RESTFul/service/cake"
+)
+
+// cakeLoader batches the cakes read by one request into a single
+// service.Cake GetByIDs call and caches them for the rest of the request.
+//
+// graphql-go resolves every field of a level before it calls their thunks, so
+// the first thunk called reads the ids queued by the whole level. The batch
+// runs in the goroutine calling the thunk, there is no timer.
+type cakeLoader struct {
+	cakeService service.Cake
+
+	mu      sync.Mutex
+	pending []int
+	results map[int]cakeResult
+}
+
+// cakeResult is a cakeApi.CakeResponse, nil for a missing cake, or the error
+// of its batch.
+type cakeResult struct {
+	cake interface{}
+	err  error
+}
+
+func newCakeLoader(cakeService service.Cake) *cakeLoader {
+	return &cakeLoader{
+		cakeService: cakeService,
+		results:     map[int]cakeResult{},
+	}
+}
+
+// Load queues id for the next batch, the returned thunk reads the batch when
+// id is not read yet and yields a cakeApi.CakeResponse or nil.
+func (l *cakeLoader) Load(ctx context.Context, id int) func() (interface{}, error) {
+
+	l.mu.Lock()
+	l.queue(id)
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		// a Clear since Load dropped it
+		l.queue(id)
+
+		if _, ok := l.results[id]; !ok {
+			l.dispatch(ctx)
+		}
+
+		result := l.results[id]
+		return result.cake, result.err
+	}
+}
+
+// queue adds id to the pending ones unless it is read or queued already, the
+// caller holds the lock.
+func (l *cakeLoader) queue(id int) {
+
+	if _, ok := l.results[id]; ok {
+		return
+	}
+	for _, pending := range l.pending {
+		if pending == id {
+			return
+		}
+	}
+
+	l.pending = append(l.pending, id)
+}
+
+// dispatch reads the pending ids in one call, in ascending order as the
+// fields of a level are resolved in no given order. The caller holds the lock.
+func (l *cakeLoader) dispatch(ctx context.Context) {
+
+	ids := l.pending
+	l.pending = nil
+	sort.Ints(ids)
+
+	cakes, err := l.cakeService.GetByIDs(ctx, ids)
+	if err != nil {
+		for _, id := range ids {
+			l.results[id] = cakeResult{err: err}
+		}
+		return
+	}
+
+	// a missing cake resolves to null, not to an error
+	for _, id := range ids {
+		l.results[id] = cakeResult{}
+	}
+	for _, cake := range cakes {
+		l.results[cake.ID] = cakeResult{cake: cake}
+	}
+}
+
+// Prime stores cakes already read, like a list page, so later lookups of the
+// same ids do not hit the service again.
+func (l *cakeLoader) Prime(ctx context.Context, cakes cakeApi.CakesResponse) {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, cake := range cakes {
+		if _, ok := l.results[cake.ID]; !ok {
+			l.results[cake.ID] = cakeResult{cake: cake}
+		}
+	}
+}
+
+// Clear drops id after a mutation so the request does not read a stale cake.
+func (l *cakeLoader) Clear(ctx context.Context, id int) {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.results, id)
+}
+
+type loaderKey struct{}
+
+func withCakeLoader(ctx context.Context, loader *cakeLoader) context.Context {
+	return context.WithValue(ctx, loaderKey{}, loader)
+}
+
+func cakeLoaderFrom(ctx context.Context) *cakeLoader {
+	loader, _ := ctx.Value(loaderKey{}).(*cakeLoader)
+	return loader
+}
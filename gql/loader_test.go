@@ -0,0 +1,95 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	cakeApi "gitlab.com/cake-store-RESTFul/service/cake"
+	mockService "gitlab.com/cake-store-RESTFul/service/mocks"
+)
+
+func Test_cakeLoader(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		beforeFunc func(s *mockService.MockCake)
+		// load runs against the loader and returns the thunks to call in order
+		load     func(l *cakeLoader) []func() (interface{}, error)
+		want     []interface{}
+		wantErrs []error
+	}{
+		{
+			name: "queued ids are read in one batch",
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().GetByIDs(ctx, []int{1, 3}).Return(cakeApi.CakesResponse{{ID: 1}}, nil)
+			},
+			load: func(l *cakeLoader) []func() (interface{}, error) {
+				return []func() (interface{}, error){l.Load(ctx, 3), l.Load(ctx, 1), l.Load(ctx, 3)}
+			},
+			want:     []interface{}{nil, cakeApi.CakeResponse{ID: 1}, nil},
+			wantErrs: []error{nil, nil, nil},
+		},
+		{
+			name: "primed cakes are not read",
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().GetByIDs(ctx, []int{2}).Return(cakeApi.CakesResponse{{ID: 2}}, nil)
+			},
+			load: func(l *cakeLoader) []func() (interface{}, error) {
+				l.Prime(ctx, cakeApi.CakesResponse{{ID: 1, Title: "lemon"}})
+				return []func() (interface{}, error){l.Load(ctx, 1), l.Load(ctx, 2)}
+			},
+			want:     []interface{}{cakeApi.CakeResponse{ID: 1, Title: "lemon"}, cakeApi.CakeResponse{ID: 2}},
+			wantErrs: []error{nil, nil},
+		},
+		{
+			name: "cleared cake is read again",
+			beforeFunc: func(s *mockService.MockCake) {
+				gomock.InOrder(
+					s.EXPECT().GetByIDs(ctx, []int{1}).Return(cakeApi.CakesResponse{{ID: 1, Title: "lemon"}}, nil),
+					s.EXPECT().GetByIDs(ctx, []int{1}).Return(cakeApi.CakesResponse{{ID: 1, Title: "lime"}}, nil),
+				)
+			},
+			load: func(l *cakeLoader) []func() (interface{}, error) {
+				first := l.Load(ctx, 1)
+				return []func() (interface{}, error){first, func() (interface{}, error) {
+					l.Clear(ctx, 1)
+					return l.Load(ctx, 1)()
+				}}
+			},
+			want:     []interface{}{cakeApi.CakeResponse{ID: 1, Title: "lemon"}, cakeApi.CakeResponse{ID: 1, Title: "lime"}},
+			wantErrs: []error{nil, nil},
+		},
+		{
+			name: "error of the batch",
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().GetByIDs(ctx, []int{1, 2}).Return(nil, errors.New("foo"))
+			},
+			load: func(l *cakeLoader) []func() (interface{}, error) {
+				return []func() (interface{}, error){l.Load(ctx, 1), l.Load(ctx, 2)}
+			},
+			want:     []interface{}{nil, nil},
+			wantErrs: []error{errors.New("foo"), errors.New("foo")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			service := mockService.NewMockCake(ctrl)
+			tt.beforeFunc(service)
+
+			for i, thunk := range tt.load(newCakeLoader(service)) {
+				got, err := thunk()
+				if !reflect.DeepEqual(err, tt.wantErrs[i]) {
+					t.Errorf("cakeLoader thunk %d error = %v, want %v", i, err, tt.wantErrs[i])
+				}
+				if !reflect.DeepEqual(got, tt.want[i]) {
+					t.Errorf("cakeLoader thunk %d = %v, want %v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
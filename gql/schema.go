@@ -0,0 +1,285 @@
+package gql
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/service"
+	cakeApi "gitlab.com/cake-store-RESTFul/service/cake"
+	commonApi "gitlab.com/cake-store-RESTFul/service/common"
+)
+
+var errCakeNotFound = errors.New("cake not found")
+
+// resolver holds the service behind the schema, reads by id go through the
+// request loader instead.
+type resolver struct {
+	cakeService service.Cake
+	Log         zerolog.Logger
+}
+
+var cakeType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Cake",
+	Fields: graphql.Fields{
+		"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"title":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"description": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"image":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"rating":      &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+		"createdAt": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.DateTime),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(cakeApi.CakeResponse).CreatedAt, nil
+			},
+		},
+		"updatedAt": &graphql.Field{
+			Type: graphql.DateTime,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(cakeApi.CakeResponse).UpdatedAt, nil
+			},
+		},
+	},
+})
+
+var paginationType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Pagination",
+	Fields: graphql.Fields{
+		"page":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"limit": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"total": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+	},
+})
+
+// cakePage is the source of the CakePage type.
+type cakePage struct {
+	Items      cakeApi.CakesResponse        `json:"items"`
+	Pagination commonApi.PaginationResponse `json:"pagination"`
+}
+
+var cakePageType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "CakePage",
+	Fields: graphql.Fields{
+		"items":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(cakeType)))},
+		"pagination": &graphql.Field{Type: graphql.NewNonNull(paginationType)},
+	},
+})
+
+var createCakeInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "CreateCakeInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"title":       &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"description": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"rating":      &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Float)},
+		"image": &graphql.InputObjectFieldConfig{
+			Type:        graphql.NewNonNull(graphql.String),
+			Description: "base64 encoded image",
+		},
+	},
+})
+
+var updateCakeInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "UpdateCakeInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"title":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"description": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"rating":      &graphql.InputObjectFieldConfig{Type: graphql.Float},
+		"imageUrl": &graphql.InputObjectFieldConfig{
+			Type:        graphql.String,
+			Description: "stored image URL, an empty string removes the image",
+		},
+	},
+})
+
+func newSchema(r *resolver) (graphql.Schema, error) {
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"cake": &graphql.Field{
+				Type: cakeType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: r.cake,
+			},
+			"cakes": &graphql.Field{
+				Type: graphql.NewNonNull(cakePageType),
+				Args: graphql.FieldConfigArgument{
+					"search": &graphql.ArgumentConfig{Type: graphql.String},
+					"sort": &graphql.ArgumentConfig{
+						Type:        graphql.String,
+						Description: `comma separated fields, a leading "-" sorts descending, e.g. "-rating,title"`,
+					},
+					"page":  &graphql.ArgumentConfig{Type: graphql.Int},
+					"limit": &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Resolve: r.cakes,
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createCake": &graphql.Field{
+				Type: graphql.NewNonNull(cakeType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(createCakeInput)},
+				},
+				Resolve: r.createCake,
+			},
+			"updateCake": &graphql.Field{
+				Type: graphql.NewNonNull(cakeType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(updateCakeInput)},
+				},
+				Resolve: r.updateCake,
+			},
+			"deleteCake": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: r.deleteCake,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+// cake resolves through the loader, a cake that does not exist is null.
+func (r *resolver) cake(p graphql.ResolveParams) (interface{}, error) {
+	return cakeLoaderFrom(p.Context).Load(p.Context, p.Args["id"].(int)), nil
+}
+
+// cakes applies the sort syntax and pagination defaults of GET /api/v2/cakes.
+func (r *resolver) cakes(p graphql.ResolveParams) (interface{}, error) {
+
+	sort, _ := p.Args["sort"].(string)
+	search, _ := p.Args["search"].(string)
+
+	req := cakeApi.GetListRequest{Search: search}
+	if err := req.ParseSort(sort); err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	if limit, ok := p.Args["limit"].(int); ok {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if page, ok := p.Args["page"].(int); ok {
+		query.Set("page", strconv.Itoa(page))
+	}
+
+	paginateReq := commonApi.PaginationRequest{}
+	paginateReq.ParseQuery(query)
+
+	res, pagination, err := r.cakeService.GetList(p.Context, req, paginateReq)
+	if err != nil {
+		return nil, r.serviceError(err)
+	}
+
+	cakeLoaderFrom(p.Context).Prime(p.Context, res)
+
+	return cakePage{Items: res, Pagination: pagination}, nil
+}
+
+func (r *resolver) createCake(p graphql.ResolveParams) (interface{}, error) {
+
+	input := p.Args["input"].(map[string]interface{})
+
+	rating, _ := input["rating"].(float64)
+	req := cakeApi.CreateRequestJSON{
+		Title:       input["title"].(string),
+		Description: input["description"].(string),
+		Rating:      float32(rating),
+		Image:       input["image"].(string),
+	}
+
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	b, err := base64.StdEncoding.DecodeString(req.Image)
+	if err != nil {
+		return nil, errors.New("image must be base64 encoded")
+	}
+
+	res, err := r.cakeService.Create(p.Context, cakeApi.CreateRequest{
+		Title:       req.Title,
+		Rating:      req.Rating,
+		Description: req.Description,
+		Image:       bytes.NewReader(b),
+	})
+	if err != nil {
+		return nil, r.serviceError(err)
+	}
+
+	return res, nil
+}
+
+// updateCake only changes the fields present in the input.
+func (r *resolver) updateCake(p graphql.ResolveParams) (interface{}, error) {
+
+	id := p.Args["id"].(int)
+	input := p.Args["input"].(map[string]interface{})
+
+	req := cakeApi.UpdateRequest{ID: id}
+	if title, ok := input["title"].(string); ok {
+		if title == "" {
+			return nil, errors.New("title cannot by empty")
+		}
+		req.Title = &title
+	}
+	if description, ok := input["description"].(string); ok {
+		req.Description = &description
+	}
+	if value, ok := input["rating"].(float64); ok {
+		if value < 0 {
+			return nil, errors.New("rating cannot less than 0")
+		}
+		rating := float32(value)
+		req.Rating = &rating
+	}
+	if imageURL, ok := input["imageUrl"].(string); ok {
+		req.ImageURL = &imageURL
+	}
+
+	res, err := r.cakeService.Update(p.Context, req)
+	if err != nil {
+		return nil, r.serviceError(err)
+	}
+
+	cakeLoaderFrom(p.Context).Clear(p.Context, id)
+
+	return res, nil
+}
+
+func (r *resolver) deleteCake(p graphql.ResolveParams) (interface{}, error) {
+
+	id := p.Args["id"].(int)
+	if err := r.cakeService.Delete(p.Context, id); err != nil {
+		return nil, r.serviceError(err)
+	}
+
+	cakeLoaderFrom(p.Context).Clear(p.Context, id)
+
+	return true, nil
+}
+
+func (r *resolver) serviceError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return errCakeNotFound
+	}
+
+	r.Log.Error().Msg(err.Error())
+	return err
+}
@@ -0,0 +1,55 @@
+package gql
+
+import (
+	"context"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/service"
+)
+
+// Server executes GraphQL requests against the cake catalog.
+type Server struct {
+	schema      graphql.Schema
+	cakeService service.Cake
+	Limits      Limits
+}
+
+func NewServer(cakeService service.Cake, limits Limits, log zerolog.Logger) (*Server, error) {
+
+	schema, err := newSchema(&resolver{cakeService: cakeService, Log: log})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		schema:      schema,
+		cakeService: cakeService,
+		Limits:      limits,
+	}, nil
+}
+
+// Do runs one request with its own loader, queries over the limits are
+// rejected before any resolver is called.
+func (s *Server) Do(ctx context.Context, query string, variables map[string]interface{}, operationName string) *graphql.Result {
+
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(query), Name: "GraphQL request"})})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	if err = s.Limits.Check(doc, variables); err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	return graphql.Do(graphql.Params{
+		Schema:         s.schema,
+		RequestString:  query,
+		VariableValues: variables,
+		OperationName:  operationName,
+		Context:        withCakeLoader(ctx, newCakeLoader(s.cakeService)),
+	})
+}
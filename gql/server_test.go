@@ -0,0 +1,139 @@
+package gql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	cakeApi "gitlab.com/cake-store-RESTFul/service/cake"
+	commonApi "gitlab.com/cake-store-RESTFul/service/common"
+	mockService "gitlab.com/cake-store-RESTFul/service/mocks"
+)
+
+func TestServer_Do(t *testing.T) {
+	createdAt := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		query      string
+		variables  map[string]interface{}
+		beforeFunc func(s *mockService.MockCake)
+		want       string
+	}{
+		{
+			name:  "cakes by id are read in one batch",
+			query: `{ a: cake(id: 1) { id title createdAt updatedAt } b: cake(id: 2) { id } c: cake(id: 1) { rating } }`,
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().GetByIDs(gomock.Any(), []int{1, 2}).Return(cakeApi.CakesResponse{
+					{ID: 2},
+					{ID: 1, Title: "lemon", Rating: 4.5, CreatedAt: createdAt},
+				}, nil)
+			},
+			want: `{"data":{"a":{"createdAt":"2023-01-02T03:04:05Z","id":1,"title":"lemon","updatedAt":null},"b":{"id":2},"c":{"rating":4.5}}}`,
+		},
+		{
+			name:  "missing cake is null",
+			query: `{ cake(id: 9) { id } }`,
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().GetByIDs(gomock.Any(), []int{9}).Return(nil, nil)
+			},
+			want: `{"data":{"cake":null}}`,
+		},
+		{
+			name:  "list with v2 sort",
+			query: `{ cakes(search: "le", sort: "-rating", limit: 2) { items { id } pagination { page limit total } } }`,
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().GetList(gomock.Any(),
+					cakeApi.GetListRequest{Search: "le", Sort: "rating DESC"},
+					commonApi.PaginationRequest{Page: 1, Limit: 2},
+				).Return(cakeApi.CakesResponse{{ID: 3}}, commonApi.PaginationResponse{Page: 1, Limit: 2, Total: 1}, nil)
+			},
+			want: `{"data":{"cakes":{"items":[{"id":3}],"pagination":{"limit":2,"page":1,"total":1}}}}`,
+		},
+		{
+			name:       "invalid sort",
+			query:      `{ cakes(sort: "price") { items { id } } }`,
+			beforeFunc: func(s *mockService.MockCake) {},
+			want:       `{"data":null,"errors":[{"message":"cannot sort by \"price\"","locations":[{"line":1,"column":3}],"path":["cakes"]}]}`,
+		},
+		{
+			name:  "create",
+			query: `mutation ($in: CreateCakeInput!) { createCake(input: $in) { id } }`,
+			variables: map[string]interface{}{"in": map[string]interface{}{
+				"title": "lemon", "description": "sour", "rating": 4, "image": "cG5n",
+			}},
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().Create(gomock.Any(), gomock.Any()).Return(cakeApi.CakeResponse{ID: 5}, nil)
+			},
+			want: `{"data":{"createCake":{"id":5}}}`,
+		},
+		{
+			name:       "create invalid",
+			query:      `mutation { createCake(input: {title: "", description: "sour", rating: 4, image: "cG5n"}) { id } }`,
+			beforeFunc: func(s *mockService.MockCake) {},
+			want:       `{"data":null,"errors":[{"message":"title cannot by empty","locations":[{"line":1,"column":12}],"path":["createCake"]}]}`,
+		},
+		{
+			name:  "update reads the cake again",
+			query: `mutation { updateCake(id: 1, input: {rating: 5}) { rating } }`,
+			beforeFunc: func(s *mockService.MockCake) {
+				rating := float32(5)
+				s.EXPECT().Update(gomock.Any(), cakeApi.UpdateRequest{ID: 1, Rating: &rating}).Return(cakeApi.CakeResponse{ID: 1, Rating: 5}, nil)
+			},
+			want: `{"data":{"updateCake":{"rating":5}}}`,
+		},
+		{
+			name:  "delete not found",
+			query: `mutation { deleteCake(id: 7) }`,
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().Delete(gomock.Any(), 7).Return(sql.ErrNoRows)
+			},
+			want: `{"data":null,"errors":[{"message":"cake not found","locations":[{"line":1,"column":12}],"path":["deleteCake"]}]}`,
+		},
+		{
+			name:  "service error",
+			query: `{ cake(id: 1) { id } }`,
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().GetByIDs(gomock.Any(), []int{1}).Return(nil, errors.New("foo"))
+			},
+			want: `{"data":{"cake":null},"errors":[{"message":"foo","locations":[{"line":1,"column":3}],"path":["cake"]}]}`,
+		},
+		{
+			name:       "over the limits",
+			query:      `{ cakes(limit: 100) { items { id title } } }`,
+			beforeFunc: func(s *mockService.MockCake) {},
+			want:       `{"data":null,"errors":[{"message":"query complexity 301 exceeds the limit of 100","locations":[]}]}`,
+		},
+		{
+			name:       "syntax error",
+			query:      `{ cake(`,
+			beforeFunc: func(s *mockService.MockCake) {},
+			want:       `{"data":null,"errors":[{"message":"Syntax Error GraphQL request (1:8) Expected Name, found EOF\n\n1: { cake(\n          ^\n","locations":[{"line":1,"column":8}]}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			service := mockService.NewMockCake(ct)
+			tt.beforeFunc(service)
+
+			s, err := NewServer(service, Limits{MaxDepth: 5, MaxComplexity: 100}, zerolog.Logger{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := json.Marshal(s.Do(context.Background(), tt.query, tt.variables, ""))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Server.Do() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
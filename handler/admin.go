@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/service"
+)
+
+// AdminAuth only lets requests carrying "Authorization: Bearer <token>"
+// through, token is either the shared api.admin.token or the token of an
+// admin user created with "user create". An empty shared token never matches
+// so admin endpoints are not left open by a missing setting.
+type AdminAuth struct {
+	token            string
+	adminUserService service.AdminUser
+	HttpSerializer
+	Log zerolog.Logger
+}
+
+func NewAdminAuth(token string, adminUserService service.AdminUser, serializer HttpSerializer, log zerolog.Logger) *AdminAuth {
+	return &AdminAuth{
+		token:            token,
+		adminUserService: adminUserService,
+		HttpSerializer:   serializer,
+		Log:              log,
+	}
+}
+
+func (a *AdminAuth) Handle(next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+
+		const prefix = "Bearer "
+		header := r.Header.Get("Authorization")
+		token := strings.TrimPrefix(header, prefix)
+
+		if !strings.HasPrefix(header, prefix) || token == "" {
+			a.unauthorized(w)
+			return
+		}
+
+		if a.token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) == 1 {
+			next(w, r, p)
+			return
+		}
+
+		if a.adminUserService == nil {
+			a.unauthorized(w)
+			return
+		}
+
+		_, err := a.adminUserService.Authenticate(r.Context(), token)
+		if err == sql.ErrNoRows {
+			a.unauthorized(w)
+			return
+		}
+		if err != nil {
+			a.Log.Error().Msg(err.Error())
+			a.JSON(w, http.StatusInternalServerError, BaseResponse{Error: err.Error(), Data: nil})
+			return
+		}
+
+		next(w, r, p)
+	}
+}
+
+func (a *AdminAuth) unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	a.JSON(w, http.StatusUnauthorized, BaseResponse{Error: "unauthorized", Data: nil})
+}
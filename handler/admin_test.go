@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/julienschmidt/httprouter"
+	"github.com/rs/zerolog"
+	mockSvc "gitlab.com/cake-store-RESTFul/service/mocks"
+)
+
+func TestAdminAuth_Handle(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		beforeFunc    func(m *mockSvc.MockAdminUser)
+		wantStatus    int
+	}{
+		{
+			name:          "valid token",
+			token:         "secret",
+			authorization: "Bearer secret",
+			wantStatus:    http.StatusNoContent,
+		},
+		{
+			name:          "wrong token",
+			token:         "secret",
+			authorization: "Bearer other",
+			beforeFunc: func(m *mockSvc.MockAdminUser) {
+				m.EXPECT().Authenticate(gomock.Any(), "other").Return("", sql.ErrNoRows)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:          "admin user token",
+			token:         "secret",
+			authorization: "Bearer user-token",
+			beforeFunc: func(m *mockSvc.MockAdminUser) {
+				m.EXPECT().Authenticate(gomock.Any(), "user-token").Return("alice", nil)
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:          "admin user token without a shared token",
+			token:         "",
+			authorization: "Bearer user-token",
+			beforeFunc: func(m *mockSvc.MockAdminUser) {
+				m.EXPECT().Authenticate(gomock.Any(), "user-token").Return("alice", nil)
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:          "error admin users unavailable",
+			token:         "secret",
+			authorization: "Bearer user-token",
+			beforeFunc: func(m *mockSvc.MockAdminUser) {
+				m.EXPECT().Authenticate(gomock.Any(), "user-token").Return("", errors.New("foo"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:          "not a bearer token",
+			token:         "secret",
+			authorization: "secret",
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name:          "missing header",
+			token:         "secret",
+			authorization: "",
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name:          "token not configured",
+			token:         "",
+			authorization: "Bearer ",
+			wantStatus:    http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			users := mockSvc.NewMockAdminUser(ctrl)
+			if tt.beforeFunc != nil {
+				tt.beforeFunc(users)
+			}
+
+			next := func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+				w.WriteHeader(http.StatusNoContent)
+			}
+
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/webhooks", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			NewAdminAuth(tt.token, users, NewCommonHttp(), zerolog.Logger{}).Handle(next)(res, req, nil)
+
+			if res.Code != tt.wantStatus {
+				t.Errorf("AdminAuth.Handle() status = %v, want %v", res.Code, tt.wantStatus)
+			}
+		})
+	}
+}
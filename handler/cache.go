@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// CacheControl holds the Cache-Control policy sent with every cacheable route.
+// An empty value means the header is not set for that route.
+type CacheControl struct {
+	List   string
+	Detail string
+}
+
+// cacheable writes res as a cacheable 200 response with a strong ETag and,
+// unless lastModified is zero, a Last-Modified header, or an empty 304 when
+// the request preconditions show the client already has the current
+// representation.
+func (c *Cake) cacheable(w http.ResponseWriter, r *http.Request, cacheControl string, lastModified time.Time, res BaseResponse) {
+	writeCacheable(w, r, c.HttpSerializer, c.Log, cacheControl, lastModified, res)
+}
+
+func writeCacheable(w http.ResponseWriter, r *http.Request, serializer HttpSerializer, log zerolog.Logger, cacheControl string, lastModified time.Time, res BaseResponse) {
+
+	body, err := json.Marshal(res)
+	if err != nil {
+		log.Error().Msg(err.Error())
+		serializer.JSON(w, http.StatusInternalServerError, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	tag := etag(body)
+	w.Header().Set("ETag", tag)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if cacheControl != "" {
+		w.Header().Set("Cache-Control", cacheControl)
+	}
+
+	if notModified(r, tag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	serializer.JSON(w, http.StatusOK, res)
+}
+
+func etag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// notModified evaluates If-None-Match and If-Modified-Since as described in
+// RFC 7232, If-None-Match takes precedence when both are present.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || lastModified.IsZero() {
+		return false
+	}
+
+	t, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+
+	return !lastModified.Truncate(time.Second).After(t)
+}
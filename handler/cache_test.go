@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/julienschmidt/httprouter"
+	cakeAPi "gitlab.com/cake-store-RESTFul/service/cake"
+	commonRes "gitlab.com/cake-store-RESTFul/service/common"
+	mockService "gitlab.com/cake-store-RESTFul/service/mocks"
+)
+
+func Test_notModified(t *testing.T) {
+	lastModified := time.Date(2022, 11, 16, 17, 56, 27, 92, time.UTC)
+	tag := `"abc"`
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   bool
+	}{
+		{
+			name:   "no precondition",
+			header: http.Header{},
+			want:   false,
+		},
+		{
+			name:   "if-none-match match",
+			header: http.Header{"If-None-Match": []string{`"xyz", "abc"`}},
+			want:   true,
+		},
+		{
+			name:   "if-none-match weak match",
+			header: http.Header{"If-None-Match": []string{`W/"abc"`}},
+			want:   true,
+		},
+		{
+			name:   "if-none-match wildcard",
+			header: http.Header{"If-None-Match": []string{"*"}},
+			want:   true,
+		},
+		{
+			name:   "if-none-match mismatch wins over if-modified-since",
+			header: http.Header{"If-None-Match": []string{`"xyz"`}, "If-Modified-Since": []string{lastModified.Format(http.TimeFormat)}},
+			want:   false,
+		},
+		{
+			name:   "if-modified-since not modified",
+			header: http.Header{"If-Modified-Since": []string{lastModified.Format(http.TimeFormat)}},
+			want:   true,
+		},
+		{
+			name:   "if-modified-since modified",
+			header: http.Header{"If-Modified-Since": []string{lastModified.Add(-time.Hour).Format(http.TimeFormat)}},
+			want:   false,
+		},
+		{
+			name:   "if-modified-since invalid",
+			header: http.Header{"If-Modified-Since": []string{"invalid"}},
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/cake", nil)
+			r.Header = tt.header
+			if got := notModified(r, tag, lastModified); got != tt.want {
+				t.Errorf("notModified() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCake_GetDetailConditional(t *testing.T) {
+	createdAt := time.Date(2022, 11, 16, 17, 56, 27, 0, time.UTC)
+	cake := cakeAPi.CakeResponse{ID: 1, Title: "test", CreatedAt: createdAt}
+
+	ct := gomock.NewController(t)
+	service := mockService.NewMockCake(ct)
+	service.EXPECT().GetDetail(gomock.Any(), 1).Return(cake, nil).Times(2)
+
+	c := &Cake{
+		cakeService:    service,
+		HttpSerializer: new(commonHttp),
+		CacheControl:   CacheControl{Detail: "public, max-age=60"},
+	}
+	params := httprouter.Params{httprouter.Param{Key: "id", Value: "1"}}
+
+	res := httptest.NewRecorder()
+	c.GetDetail(res, httptest.NewRequest(http.MethodGet, "/api/v1/cake/1", nil), params)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("Cake.GetDetail() status = %v, want %v", res.Code, http.StatusOK)
+	}
+	if got := res.Header().Get("Cache-Control"); got != "public, max-age=60" {
+		t.Errorf("Cake.GetDetail() Cache-Control = %v", got)
+	}
+	if got := res.Header().Get("Last-Modified"); got != createdAt.Format(http.TimeFormat) {
+		t.Errorf("Cake.GetDetail() Last-Modified = %v, want %v", got, createdAt.Format(http.TimeFormat))
+	}
+
+	tag := res.Header().Get("ETag")
+	if tag == "" {
+		t.Fatal("Cake.GetDetail() missing ETag")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/cake/1", nil)
+	req.Header.Set("If-None-Match", tag)
+	res = httptest.NewRecorder()
+	c.GetDetail(res, req, params)
+
+	if res.Code != http.StatusNotModified {
+		t.Errorf("Cake.GetDetail() status = %v, want %v", res.Code, http.StatusNotModified)
+	}
+	if res.Body.Len() != 0 {
+		t.Errorf("Cake.GetDetail() 304 body = %v, want empty", res.Body.String())
+	}
+}
+
+func TestCake_GetListConditional(t *testing.T) {
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	ct := gomock.NewController(t)
+	service := mockService.NewMockCake(ct)
+	service.EXPECT().GetList(gomock.Any(), gomock.Any(), gomock.Any()).Return(cakeAPi.CakesResponse{{ID: 1, CreatedAt: createdAt}}, commonRes.PaginationResponse{}, nil).Times(2)
+
+	c := &Cake{
+		cakeService:    service,
+		HttpSerializer: new(commonHttp),
+	}
+
+	// a deleted cake does not move the newest change of a page, so
+	// If-Modified-Since is not evaluated on lists
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/cake", nil)
+	req.Header.Set("If-Modified-Since", createdAt.Add(time.Hour).Format(http.TimeFormat))
+	res := httptest.NewRecorder()
+	c.GetList(res, req, nil)
+
+	if res.Code != http.StatusOK {
+		t.Errorf("Cake.GetList() If-Modified-Since status = %v, want %v", res.Code, http.StatusOK)
+	}
+	if res.Header().Get("Last-Modified") != "" {
+		t.Errorf("Cake.GetList() Last-Modified = %v, want none", res.Header().Get("Last-Modified"))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/cake", nil)
+	req.Header.Set("If-None-Match", res.Header().Get("ETag"))
+	res = httptest.NewRecorder()
+	c.GetList(res, req, nil)
+
+	if res.Code != http.StatusNotModified {
+		t.Errorf("Cake.GetList() If-None-Match status = %v, want %v", res.Code, http.StatusNotModified)
+	}
+}
@@ -1,13 +1,20 @@
 package handler
 
 import (
+	"archive/zip"
 	"bytes"
 	"database/sql"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/rs/zerolog"
@@ -19,14 +26,15 @@ import (
 type Cake struct {
 	cakeService service.Cake
 	HttpSerializer
-	Log zerolog.Logger
+	Log          zerolog.Logger
+	CacheControl CacheControl
 }
 
 func NewCake(cakeService service.Cake, serializer HttpSerializer, log zerolog.Logger) *Cake {
 	return &Cake{
-		cakeService,
-		serializer,
-		log,
+		cakeService:    cakeService,
+		HttpSerializer: serializer,
+		Log:            log,
 	}
 }
 
@@ -49,14 +57,14 @@ func (c *Cake) Create(w http.ResponseWriter, r *http.Request, _ httprouter.Param
 		return
 	}
 
-	err = c.cakeService.Create(r.Context(), reqBody)
+	res, err := c.cakeService.Create(r.Context(), reqBody)
 	if err != nil {
 		c.Log.Error().Msg(err.Error())
 		c.JSON(w, http.StatusInternalServerError, BaseResponse{Error: err.Error(), Data: nil})
 		return
 	}
 
-	c.JSON(w, http.StatusOK, BaseResponse{Error: nil, Data: "success"})
+	c.created(w, res)
 }
 
 func (c *Cake) CreateWithJSon(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
@@ -82,7 +90,7 @@ func (c *Cake) CreateWithJSon(w http.ResponseWriter, r *http.Request, _ httprout
 	}
 	buf := bytes.NewBuffer(b)
 
-	err = c.cakeService.Create(r.Context(), cakeApi.CreateRequest{
+	res, err := c.cakeService.Create(r.Context(), cakeApi.CreateRequest{
 		Title:       reqBody.Title,
 		Rating:      reqBody.Rating,
 		Description: reqBody.Description,
@@ -95,14 +103,23 @@ func (c *Cake) CreateWithJSon(w http.ResponseWriter, r *http.Request, _ httprout
 		return
 	}
 
-	c.JSON(w, http.StatusOK, BaseResponse{Error: nil, Data: "success"})
+	c.created(w, res)
+}
+
+// created answers 201 with the new cake and its location.
+func (c *Cake) created(w http.ResponseWriter, res cakeApi.CakeResponse) {
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/cake/%d", res.ID))
+	c.JSON(w, http.StatusCreated, BaseResponse{Error: nil, Data: res})
 }
 
 func (c *Cake) GetList(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 
 	urlQuery := r.URL.Query()
 	req := cakeApi.GetListRequest{}
-	req.ParseQuery(urlQuery)
+	if err := req.ParseQuery(urlQuery); err != nil {
+		c.JSON(w, http.StatusBadRequest, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
 
 	paginateReq := commonApi.PaginationRequest{}
 	paginateReq.ParseQuery(urlQuery)
@@ -115,7 +132,84 @@ func (c *Cake) GetList(w http.ResponseWriter, r *http.Request, _ httprouter.Para
 		return
 	}
 
-	c.JSON(w, http.StatusOK, BaseResponse{Error: nil, Data: res, MetaData: pagination})
+	// a page has no Last-Modified, a delete or a page shifting does not move
+	// it forward, its ETag is the only validator
+	c.cacheable(w, r, c.CacheControl.List, time.Time{}, BaseResponse{Error: nil, Data: res, MetaData: pagination})
+}
+
+// Export streams every cake matching the list filters as a file download. Once
+// the first row is written the status can no longer change, so later errors
+// are only logged and the download is cut short.
+func (c *Cake) Export(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	urlQuery := r.URL.Query()
+	req := cakeApi.GetListRequest{}
+	if err := req.ParseQuery(urlQuery); err != nil {
+		c.JSON(w, http.StatusBadRequest, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	format := strings.ToLower(urlQuery.Get("format"))
+	if format == "" {
+		format = exportFormatCSV
+	}
+
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		c.JSON(w, http.StatusBadRequest, BaseResponse{Error: fmt.Sprintf("unsupported export format %q", format), Data: nil})
+		return
+	}
+
+	var encoder exportEncoder
+	flusher, _ := w.(http.Flusher)
+	rows := 0
+
+	start := func() (err error) {
+		filename := fmt.Sprintf("cakes-%s.%s", time.Now().UTC().Format("20060102150405"), format)
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		w.WriteHeader(http.StatusOK)
+
+		encoder, err = newExportEncoder(format, w)
+		return
+	}
+
+	err := c.cakeService.Export(r.Context(), req, func(cake cakeApi.CakeResponse) error {
+		if encoder == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		rows++
+		if flusher != nil && rows%exportFlushRows == 0 {
+			flusher.Flush()
+		}
+
+		return encoder.Encode(cake)
+	})
+
+	if err != nil && encoder == nil {
+		c.Log.Error().Msg(err.Error())
+		c.JSON(w, http.StatusInternalServerError, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	if err != nil {
+		c.Log.Error().Msg(err.Error())
+		return
+	}
+
+	if encoder == nil {
+		if err = start(); err != nil {
+			c.Log.Error().Msg(err.Error())
+			return
+		}
+	}
+
+	if err = encoder.Close(); err != nil {
+		c.Log.Error().Msg(err.Error())
+	}
 }
 
 func (c *Cake) GetDetail(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
@@ -139,9 +233,11 @@ func (c *Cake) GetDetail(w http.ResponseWriter, r *http.Request, param httproute
 		return
 	}
 
-	c.JSON(w, http.StatusOK, BaseResponse{Error: nil, Data: res})
+	c.cacheable(w, r, c.CacheControl.Detail, res.LastModified(), BaseResponse{Error: nil, Data: res})
 }
 
+// Update partially updates a cake from multipart form data, or from a JSON
+// Merge Patch document when the body is JSON.
 func (c *Cake) Update(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
 	reqBody := cakeApi.UpdateRequest{}
 
@@ -150,11 +246,20 @@ func (c *Cake) Update(w http.ResponseWriter, r *http.Request, param httprouter.P
 	if err != nil {
 		c.Log.Error().Msg(err.Error())
 		c.JSON(w, http.StatusBadRequest, BaseResponse{Error: err.Error(), Data: nil})
+		return
 	}
 
 	reqBody.ID = cakeID
+
+	if isJSON(r) {
+		c.updateWithJSON(w, r, reqBody, (*cakeApi.UpdateRequest).ParseMergePatch)
+		return
+	}
+
 	i, _, _ := r.FormFile("image")
-	reqBody.Image = i
+	if i != nil {
+		reqBody.Image = i
+	}
 
 	err = reqBody.ParseForm(r.Form)
 	if err != nil {
@@ -163,14 +268,76 @@ func (c *Cake) Update(w http.ResponseWriter, r *http.Request, param httprouter.P
 		return
 	}
 
-	err = c.cakeService.Update(r.Context(), reqBody)
+	c.update(w, r, reqBody)
+}
+
+// Replace fully replaces a cake with the JSON representation in the body.
+func (c *Cake) Replace(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	reqBody := cakeApi.UpdateRequest{}
+
+	cakeIDStr := param.ByName("id")
+	cakeID, err := strconv.Atoi(cakeIDStr)
+	if err != nil {
+		c.Log.Error().Msg(err.Error())
+		c.JSON(w, http.StatusBadRequest, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	reqBody.ID = cakeID
+
+	if !isJSON(r) {
+		c.JSON(w, http.StatusUnsupportedMediaType, BaseResponse{Error: errors.New("content type must be application/json").Error(), Data: nil})
+		return
+	}
+
+	c.updateWithJSON(w, r, reqBody, (*cakeApi.UpdateRequest).ParseReplace)
+}
+
+func (c *Cake) updateWithJSON(w http.ResponseWriter, r *http.Request, reqBody cakeApi.UpdateRequest, parse func(*cakeApi.UpdateRequest, []byte) error) {
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		c.Log.Error().Msg(err.Error())
+		c.JSON(w, http.StatusBadRequest, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	err = parse(&reqBody, body)
+	if err != nil {
+		c.JSON(w, http.StatusBadRequest, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	c.update(w, r, reqBody)
+}
+
+func (c *Cake) update(w http.ResponseWriter, r *http.Request, reqBody cakeApi.UpdateRequest) {
+
+	res, err := c.cakeService.Update(r.Context(), reqBody)
+	if err != nil && err == sql.ErrNoRows {
+		c.Log.Error().Msg(err.Error())
+		c.JSON(w, http.StatusNotFound, BaseResponse{Error: errors.New("data not found").Error(), Data: nil})
+		return
+	}
+
 	if err != nil {
 		c.Log.Error().Err(err)
 		c.JSON(w, http.StatusInternalServerError, BaseResponse{Error: err.Error(), Data: nil})
 		return
 	}
 
-	c.JSON(w, http.StatusOK, BaseResponse{Error: nil, Data: "success"})
+	c.JSON(w, http.StatusOK, BaseResponse{Error: nil, Data: res})
+}
+
+// isJSON reports whether the body is application/json or one of its +json
+// variants such as application/merge-patch+json.
+func isJSON(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
 }
 
 func (c *Cake) Delete(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
@@ -182,6 +349,10 @@ func (c *Cake) Delete(w http.ResponseWriter, r *http.Request, param httprouter.P
 	}
 
 	err = c.cakeService.Delete(r.Context(), cakeID)
+	if err == sql.ErrNoRows {
+		c.JSON(w, http.StatusNotFound, BaseResponse{Error: errors.New("data not found").Error(), Data: nil})
+		return
+	}
 	if err != nil {
 		c.Log.Error().Err(err)
 		c.JSON(w, http.StatusInternalServerError, BaseResponse{Error: err.Error(), Data: nil})
@@ -190,3 +361,131 @@ func (c *Cake) Delete(w http.ResponseWriter, r *http.Request, param httprouter.P
 
 	c.JSON(w, http.StatusOK, BaseResponse{Error: nil, Data: "success"})
 }
+
+func (c *Cake) Import(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		c.Log.Error().Msg(err.Error())
+		c.JSON(w, http.StatusBadRequest, BaseResponse{Error: errors.New("file cannot be empty").Error(), Data: nil})
+		return
+	}
+	defer file.Close()
+
+	rows, err := cakeApi.ParseImport(importFormat(r.FormValue("format"), header.Filename), file)
+	if err != nil {
+		c.Log.Error().Msg(err.Error())
+		c.JSON(w, http.StatusBadRequest, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(r.FormValue("dry_run"))
+	reqBody := cakeApi.ImportRequest{Rows: rows, DryRun: dryRun}
+
+	images, imagesHeader, err := r.FormFile("images")
+	if err != nil && err != http.ErrMissingFile {
+		c.Log.Error().Msg(err.Error())
+		c.JSON(w, http.StatusBadRequest, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	if err == nil {
+		defer images.Close()
+		reqBody.Images, err = zip.NewReader(images, imagesHeader.Size)
+		if err != nil {
+			c.Log.Error().Msg(err.Error())
+			c.JSON(w, http.StatusBadRequest, BaseResponse{Error: errors.New("images must be a zip archive").Error(), Data: nil})
+			return
+		}
+	}
+
+	res, err := c.cakeService.Import(r.Context(), reqBody)
+	if err != nil {
+		c.Log.Error().Msg(err.Error())
+		// the per row report tells which rows were not stored
+		var data interface{}
+		if len(res.Rows) > 0 {
+			data = res
+		}
+		c.JSON(w, http.StatusInternalServerError, BaseResponse{Error: err.Error(), Data: data})
+		return
+	}
+
+	c.JSON(w, http.StatusOK, BaseResponse{Error: nil, Data: res})
+}
+
+// importFormat picks the import format from the explicit format field or
+// falls back to the uploaded file extension.
+func importFormat(format, filename string) string {
+	if format != "" {
+		return strings.ToLower(format)
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".ndjson", ".jsonl", ".json":
+		return cakeApi.ImportFormatNDJSON
+	}
+
+	return cakeApi.ImportFormatCSV
+}
+
+func (c *Cake) BulkUpdate(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	reqBody := cakeApi.BulkUpdateRequest{}
+	err := json.NewDecoder(r.Body).Decode(&reqBody)
+	if err != nil {
+		c.JSON(w, http.StatusBadRequest, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	if err = reqBody.Validate(); err != nil {
+		c.JSON(w, http.StatusBadRequest, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	res, err := c.cakeService.BulkUpdate(r.Context(), reqBody)
+	if err != nil {
+		c.Log.Error().Msg(err.Error())
+		c.JSON(w, http.StatusInternalServerError, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	c.JSON(w, bulkStatus(res), BaseResponse{Error: nil, Data: res})
+}
+
+func (c *Cake) BulkDelete(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	reqBody := cakeApi.BulkRequest{}
+	err := json.NewDecoder(r.Body).Decode(&reqBody)
+	if err != nil {
+		c.JSON(w, http.StatusBadRequest, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	if err = reqBody.Validate(); err != nil {
+		c.JSON(w, http.StatusBadRequest, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	res, err := c.cakeService.BulkDelete(r.Context(), reqBody)
+	if err != nil {
+		c.Log.Error().Msg(err.Error())
+		c.JSON(w, http.StatusInternalServerError, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	c.JSON(w, bulkStatus(res), BaseResponse{Error: nil, Data: res})
+}
+
+// bulkStatus is 200 when every cake succeeded, 409 when an atomic operation
+// was rolled back and 207 when only some cakes succeeded.
+func bulkStatus(res cakeApi.BulkResponse) int {
+	switch {
+	case !res.Committed:
+		return http.StatusConflict
+	case res.Failed > 0:
+		return http.StatusMultiStatus
+	}
+
+	return http.StatusOK
+}
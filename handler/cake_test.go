@@ -1,7 +1,11 @@
 package handler
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"image"
 	"image/color"
@@ -11,6 +15,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -127,7 +132,7 @@ func TestCake_Create(t *testing.T) {
 					"title":       []string{"test"},
 					"description": []string{"test"},
 				}
-				s.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				s.EXPECT().Create(gomock.Any(), gomock.Any()).Return(cakeAPi.CakeResponse{ID: 1}, nil)
 			},
 		},
 		{
@@ -136,7 +141,7 @@ func TestCake_Create(t *testing.T) {
 			r:    req,
 			in2:  make(httprouter.Params, 0),
 			beforeFunc: func(s *mockService.MockCake) {
-				s.EXPECT().Create(gomock.Any(), gomock.Any()).Return(errors.New("foo"))
+				s.EXPECT().Create(gomock.Any(), gomock.Any()).Return(cakeAPi.CakeResponse{}, errors.New("foo"))
 			},
 		},
 	}
@@ -156,6 +161,61 @@ func TestCake_Create(t *testing.T) {
 	}
 }
 
+func TestCake_CreateWithJSon(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		beforeFunc   func(s *mockService.MockCake)
+		wantStatus   int
+		wantLocation string
+	}{
+		{
+			name: "success",
+			body: `{"title":"test","description":"test","rating":4,"image":"cG5n"}`,
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().Create(gomock.Any(), gomock.Any()).Return(cakeAPi.CakeResponse{ID: 7, Title: "test"}, nil)
+			},
+			wantStatus:   http.StatusCreated,
+			wantLocation: "/api/v1/cake/7",
+		},
+		{
+			name:       "error 400",
+			body:       `{"title":"test"}`,
+			beforeFunc: func(s *mockService.MockCake) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "error 500",
+			body: `{"title":"test","description":"test","rating":4,"image":"cG5n"}`,
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().Create(gomock.Any(), gomock.Any()).Return(cakeAPi.CakeResponse{}, errors.New("foo"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			service := mockService.NewMockCake(ct)
+			tt.beforeFunc(service)
+			c := NewCake(service, NewCommonHttp(), zerolog.Logger{})
+
+			res := httptest.NewRecorder()
+			c.CreateWithJSon(res, httptest.NewRequest(http.MethodPost, "/api/v1/cake/json", strings.NewReader(tt.body)), nil)
+
+			if res.Code != tt.wantStatus {
+				t.Errorf("Cake.CreateWithJSon() status = %v, want %v", res.Code, tt.wantStatus)
+			}
+			if res.Header().Get("Location") != tt.wantLocation {
+				t.Errorf("Cake.CreateWithJSon() Location = %v, want %v", res.Header().Get("Location"), tt.wantLocation)
+			}
+			if tt.wantStatus == http.StatusCreated && !strings.Contains(res.Body.String(), `"id":7`) {
+				t.Errorf("Cake.CreateWithJSon() body = %s", res.Body.String())
+			}
+		})
+	}
+}
+
 func TestCake_CreateFailedParseFloat(t *testing.T) {
 
 	pr, pw := io.Pipe()
@@ -181,7 +241,6 @@ func TestCake_CreateFailedParseFloat(t *testing.T) {
 
 	ct := gomock.NewController(t)
 	service := mockService.NewMockCake(ct)
-	service.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
 	c := &Cake{
 		cakeService:    service,
 		HttpSerializer: new(commonHttp),
@@ -214,7 +273,6 @@ func TestCake_CreateFailedParseImg(t *testing.T) {
 
 	ct := gomock.NewController(t)
 	service := mockService.NewMockCake(ct)
-	service.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
 	c := &Cake{
 		cakeService:    service,
 		HttpSerializer: new(commonHttp),
@@ -327,11 +385,11 @@ func TestCake_Update(t *testing.T) {
 			r:    req,
 			in2:  httprouter.Params{httprouter.Param{Key: "id", Value: "1"}},
 			beforeFunc: func(s *mockService.MockCake) {
-				s.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+				s.EXPECT().Update(gomock.Any(), gomock.Any()).Return(cakeAPi.CakeResponse{ID: 1}, nil)
 			},
 		},
 		{
-			name: "error 500",
+			name: "error 400 (invalid rating)",
 			w:    resF,
 			r:    reqF,
 			in2:  httprouter.Params{httprouter.Param{Key: "id", Value: "1"}},
@@ -339,17 +397,65 @@ func TestCake_Update(t *testing.T) {
 				reqF.Form = url.Values{
 					"rating": []string{"invalid"},
 				}
-				s.EXPECT().Update(gomock.Any(), cakeAPi.UpdateRequest{ID: 1}).Return(errors.New("foo"))
 			},
 		},
 		{
-			name: "error 400 (invalid param)",
-			w:    res,
-			r:    req,
-			in2:  httprouter.Params{httprouter.Param{Key: "id", Value: "invalid"}},
+			name:       "error 400 (invalid param)",
+			w:          res,
+			r:          req,
+			in2:        httprouter.Params{httprouter.Param{Key: "id", Value: "invalid"}},
+			beforeFunc: func(s *mockService.MockCake) {},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			service := mockService.NewMockCake(ct)
+			tt.beforeFunc(service)
+			c := NewCake(service, NewCommonHttp(), zerolog.Logger{})
+			c.Update(tt.w, tt.r, tt.in2)
+		})
+	}
+}
+
+func TestCake_UpdateMergePatch(t *testing.T) {
+	rating := float32(4)
+
+	tests := []struct {
+		name       string
+		body       string
+		beforeFunc func(s *mockService.MockCake)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			body: `{"rating":4}`,
 			beforeFunc: func(s *mockService.MockCake) {
-				s.EXPECT().Update(gomock.Any(), cakeAPi.UpdateRequest{ID: 0}).Return(errors.New("foo"))
+				s.EXPECT().Update(gomock.Any(), cakeAPi.UpdateRequest{ID: 1, Rating: &rating}).Return(cakeAPi.CakeResponse{ID: 1, Rating: rating}, nil)
 			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "error 404",
+			body: `{"rating":4}`,
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().Update(gomock.Any(), gomock.Any()).Return(cakeAPi.CakeResponse{}, sql.ErrNoRows)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "error 400 (invalid body)",
+			body:       `{"rating":`,
+			beforeFunc: func(s *mockService.MockCake) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "error 500",
+			body: `{"rating":4}`,
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().Update(gomock.Any(), gomock.Any()).Return(cakeAPi.CakeResponse{}, errors.New("foo"))
+			},
+			wantStatus: http.StatusInternalServerError,
 		},
 	}
 	for _, tt := range tests {
@@ -358,7 +464,66 @@ func TestCake_Update(t *testing.T) {
 			service := mockService.NewMockCake(ct)
 			tt.beforeFunc(service)
 			c := NewCake(service, NewCommonHttp(), zerolog.Logger{})
-			c.Update(tt.w, tt.r, tt.in2)
+
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPatch, "/api/v1/cake/1", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+			c.Update(res, req, httprouter.Params{httprouter.Param{Key: "id", Value: "1"}})
+
+			if res.Code != tt.wantStatus {
+				t.Errorf("Cake.Update() status = %v, want %v", res.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCake_Replace(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		beforeFunc  func(s *mockService.MockCake)
+		wantStatus  int
+	}{
+		{
+			name:        "success",
+			contentType: "application/json",
+			body:        `{"title":"test","description":"test","rating":4}`,
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().Update(gomock.Any(), gomock.Any()).Return(cakeAPi.CakeResponse{ID: 1}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "error 400 (missing field)",
+			contentType: "application/json",
+			body:        `{"title":"test"}`,
+			beforeFunc:  func(s *mockService.MockCake) {},
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "error 415",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "title=test",
+			beforeFunc:  func(s *mockService.MockCake) {},
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			service := mockService.NewMockCake(ct)
+			tt.beforeFunc(service)
+			c := NewCake(service, NewCommonHttp(), zerolog.Logger{})
+
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/cake/1", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			c.Replace(res, req, httprouter.Params{httprouter.Param{Key: "id", Value: "1"}})
+
+			if res.Code != tt.wantStatus {
+				t.Errorf("Cake.Replace() status = %v, want %v", res.Code, tt.wantStatus)
+			}
 		})
 	}
 }
@@ -392,6 +557,15 @@ func TestCake_Delete(t *testing.T) {
 				s.EXPECT().Delete(gomock.Any(), 0).Return(errors.New("foo"))
 			},
 		},
+		{
+			name: "error 404",
+			w:    res,
+			r:    req,
+			in2:  httprouter.Params{httprouter.Param{Key: "id", Value: "1"}},
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().Delete(gomock.Any(), 1).Return(sql.ErrNoRows)
+			},
+		},
 		{
 			name: "error 500",
 			w:    res,
@@ -412,3 +586,247 @@ func TestCake_Delete(t *testing.T) {
 		})
 	}
 }
+
+func newImportRequest(t *testing.T, filename, body string, images []byte) *http.Request {
+	buf := new(bytes.Buffer)
+	writer := multipart.NewWriter(buf)
+
+	if filename != "" {
+		part, err := writer.CreateFormFile("file", filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = part.Write([]byte(body))
+	}
+
+	if images != nil {
+		part, err := writer.CreateFormFile("images", "images.zip")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = part.Write(images)
+	}
+
+	_ = writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/cake/import?dry_run=true", buf)
+	req.Header.Add("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestCake_Import(t *testing.T) {
+	csv := "title,description,rating,image\nChess Cake,Yummy,4.5,https://example.com/chess.png\n"
+
+	zipBuf := new(bytes.Buffer)
+	zw := zip.NewWriter(zipBuf)
+	_ = zw.Close()
+
+	tests := []struct {
+		name       string
+		r          *http.Request
+		beforeFunc func(s *mockService.MockCake)
+		wantStatus int
+		wantRows   int
+	}{
+		{
+			name: "success",
+			r:    newImportRequest(t, "cakes.csv", csv, zipBuf.Bytes()),
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().Import(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req cakeAPi.ImportRequest) (cakeAPi.ImportResponse, error) {
+					if !req.DryRun || len(req.Rows) != 1 || req.Images == nil {
+						t.Errorf("Cake.Import() unexpected request %+v", req)
+					}
+					return cakeAPi.ImportResponse{}, nil
+				})
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "error 400 (missing file)",
+			r:          newImportRequest(t, "", "", nil),
+			beforeFunc: func(s *mockService.MockCake) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "error 400 (invalid file)",
+			r:          newImportRequest(t, "cakes.ndjson", "{invalid", nil),
+			beforeFunc: func(s *mockService.MockCake) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "error 400 (invalid images archive)",
+			r:          newImportRequest(t, "cakes.csv", csv, []byte("invalid")),
+			beforeFunc: func(s *mockService.MockCake) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "error 500",
+			r:    newImportRequest(t, "cakes.csv", csv, nil),
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().Import(gomock.Any(), gomock.Any()).Return(cakeAPi.ImportResponse{}, errors.New("foo"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "error 500 with the report",
+			r:    newImportRequest(t, "cakes.csv", csv, nil),
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().Import(gomock.Any(), gomock.Any()).Return(cakeAPi.ImportResponse{
+					Total:  1,
+					Failed: 1,
+					Rows:   []cakeAPi.ImportRowResult{{Line: 2, Status: cakeAPi.ImportStatusFailed, Error: "foo"}},
+				}, errors.New("foo"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantRows:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			service := mockService.NewMockCake(ct)
+			tt.beforeFunc(service)
+			c := NewCake(service, NewCommonHttp(), zerolog.Logger{})
+
+			res := httptest.NewRecorder()
+			c.Import(res, tt.r, nil)
+			if res.Code != tt.wantStatus {
+				t.Errorf("Cake.Import() status = %v, want %v", res.Code, tt.wantStatus)
+			}
+
+			body := struct {
+				Data *cakeAPi.ImportResponse `json:"data"`
+			}{}
+			if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			gotRows := 0
+			if body.Data != nil {
+				gotRows = len(body.Data.Rows)
+			}
+			if gotRows != tt.wantRows {
+				t.Errorf("Cake.Import() rows = %v, want %v", gotRows, tt.wantRows)
+			}
+		})
+	}
+}
+
+func TestCake_BulkUpdate(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		beforeFunc func(s *mockService.MockCake)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			body: `{"ids":[1,2],"changes":{"rating":4}}`,
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().BulkUpdate(gomock.Any(), gomock.Any()).Return(cakeAPi.BulkResponse{Committed: true, Total: 2, Succeeded: 2}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "partial success",
+			body: `{"ids":[1,2],"changes":{"rating":4}}`,
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().BulkUpdate(gomock.Any(), gomock.Any()).Return(cakeAPi.BulkResponse{Committed: true, Total: 2, Succeeded: 1, Failed: 1}, nil)
+			},
+			wantStatus: http.StatusMultiStatus,
+		},
+		{
+			name: "atomic rolled back",
+			body: `{"ids":[1,2],"changes":{"rating":4},"atomic":true}`,
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().BulkUpdate(gomock.Any(), gomock.Any()).Return(cakeAPi.BulkResponse{Atomic: true, Total: 2, Failed: 2}, nil)
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "error 400 (invalid body)",
+			body:       `{invalid`,
+			beforeFunc: func(s *mockService.MockCake) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "error 400 (empty changes)",
+			body:       `{"ids":[1]}`,
+			beforeFunc: func(s *mockService.MockCake) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "error 500",
+			body: `{"filter":{"search":"chess"},"changes":{"title":"test"}}`,
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().BulkUpdate(gomock.Any(), gomock.Any()).Return(cakeAPi.BulkResponse{}, errors.New("foo"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			service := mockService.NewMockCake(ct)
+			tt.beforeFunc(service)
+			c := NewCake(service, NewCommonHttp(), zerolog.Logger{})
+
+			res := httptest.NewRecorder()
+			c.BulkUpdate(res, httptest.NewRequest(http.MethodPatch, "/api/v1/cake", strings.NewReader(tt.body)), nil)
+			if res.Code != tt.wantStatus {
+				t.Errorf("Cake.BulkUpdate() status = %v, want %v", res.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCake_BulkDelete(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		beforeFunc func(s *mockService.MockCake)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			body: `{"ids":[1,2]}`,
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().BulkDelete(gomock.Any(), cakeAPi.BulkRequest{IDs: []int{1, 2}}).Return(cakeAPi.BulkResponse{Committed: true, Total: 2, Succeeded: 2}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "error 400 (no selection)",
+			body:       `{}`,
+			beforeFunc: func(s *mockService.MockCake) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "error 400 (invalid body)",
+			body:       `[]`,
+			beforeFunc: func(s *mockService.MockCake) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "error 500",
+			body: `{"ids":[1]}`,
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().BulkDelete(gomock.Any(), gomock.Any()).Return(cakeAPi.BulkResponse{}, errors.New("foo"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			service := mockService.NewMockCake(ct)
+			tt.beforeFunc(service)
+			c := NewCake(service, NewCommonHttp(), zerolog.Logger{})
+
+			res := httptest.NewRecorder()
+			c.BulkDelete(res, httptest.NewRequest(http.MethodDelete, "/api/v1/cake", strings.NewReader(tt.body)), nil)
+			if res.Code != tt.wantStatus {
+				t.Errorf("Cake.BulkDelete() status = %v, want %v", res.Code, tt.wantStatus)
+			}
+		})
+	}
+}
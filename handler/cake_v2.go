@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/service"
+	cakeApi "gitlab.com/cake-store-RESTFul/service/cake"
+	commonApi "gitlab.com/cake-store-RESTFul/service/common"
+)
+
+const cakeV2Path = "/api/v2/cakes"
+
+// CakeV2 serves the v2 cake resource. Bodies are always JSON, resources are
+// sent without an envelope and errors are RFC 7807 problem details.
+type CakeV2 struct {
+	cakeService service.Cake
+	HttpSerializer
+	Log          zerolog.Logger
+	CacheControl CacheControl
+}
+
+// CakeV2Response is the v2 representation of a cake.
+type CakeV2Response struct {
+	cakeApi.CakeResponse
+	Links CakeV2Links `json:"links"`
+}
+
+type CakeV2Links struct {
+	Self string `json:"self"`
+}
+
+func NewCakeV2(cakeService service.Cake, serializer HttpSerializer, log zerolog.Logger) *CakeV2 {
+	return &CakeV2{
+		cakeService:    cakeService,
+		HttpSerializer: serializer,
+		Log:            log,
+	}
+}
+
+func newCakeV2Response(cake cakeApi.CakeResponse) CakeV2Response {
+	return CakeV2Response{
+		CakeResponse: cake,
+		Links:        CakeV2Links{Self: fmt.Sprintf("%s/%d", cakeV2Path, cake.ID)},
+	}
+}
+
+func (c *CakeV2) Create(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	if !isJSON(r) {
+		c.JSON(w, http.StatusUnsupportedMediaType, BaseResponse{Error: errors.New("content type must be application/json").Error(), Data: nil})
+		return
+	}
+
+	reqBody := cakeApi.CreateRequestJSON{}
+	err := json.NewDecoder(r.Body).Decode(&reqBody)
+	if err != nil {
+		c.JSON(w, http.StatusBadRequest, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	if err = reqBody.Validate(); err != nil {
+		c.JSON(w, http.StatusBadRequest, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	b, err := base64.StdEncoding.DecodeString(reqBody.Image)
+	if err != nil {
+		c.JSON(w, http.StatusBadRequest, BaseResponse{Error: errors.New("image must be base64 encoded").Error(), Data: nil})
+		return
+	}
+
+	res, err := c.cakeService.Create(r.Context(), cakeApi.CreateRequest{
+		Title:       reqBody.Title,
+		Rating:      reqBody.Rating,
+		Description: reqBody.Description,
+		Image:       bytes.NewReader(b),
+	})
+	if err != nil {
+		c.Log.Error().Msg(err.Error())
+		c.JSON(w, http.StatusInternalServerError, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	cake := newCakeV2Response(res)
+	w.Header().Set("Location", cake.Links.Self)
+	c.JSON(w, http.StatusCreated, BaseResponse{Error: nil, Data: cake})
+}
+
+// GetList answers the page as a plain array, the pagination is sent in the
+// Link and X-Total-Count headers.
+func (c *CakeV2) GetList(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	urlQuery := r.URL.Query()
+	req := cakeApi.GetListRequest{Search: urlQuery.Get("search")}
+	if err := req.ParseSort(urlQuery.Get("sort")); err != nil {
+		c.JSON(w, http.StatusBadRequest, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	paginateReq := commonApi.PaginationRequest{}
+	paginateReq.ParseQuery(urlQuery)
+
+	res, pagination, err := c.cakeService.GetList(r.Context(), req, paginateReq)
+	if err != nil {
+		c.Log.Error().Msg(err.Error())
+		c.JSON(w, http.StatusInternalServerError, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	cakes := make([]CakeV2Response, 0, len(res))
+	for _, cake := range res {
+		cakes = append(cakes, newCakeV2Response(cake))
+	}
+
+	if link := paginationLink(r.URL, pagination); link != "" {
+		w.Header().Set("Link", link)
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(pagination.Total))
+
+	// like v1, a page is only validated by its ETag
+	writeCacheable(w, r, c.HttpSerializer, c.Log, c.CacheControl.List, time.Time{}, BaseResponse{Error: nil, Data: cakes})
+}
+
+// paginationLink builds an RFC 8288 Link header with the first, prev, next and
+// last pages, keeping every other query parameter of the request.
+func paginationLink(u *url.URL, pagination commonApi.PaginationResponse) string {
+
+	last := 1
+	if pagination.Limit > 0 && pagination.Total > 0 {
+		last = int(math.Ceil(float64(pagination.Total) / float64(pagination.Limit)))
+	}
+
+	page := func(n int) string {
+		query := u.Query()
+		query.Set("page", strconv.Itoa(n))
+		query.Set("limit", strconv.Itoa(pagination.Limit))
+		return (&url.URL{Path: u.Path, RawQuery: query.Encode()}).String()
+	}
+
+	links := []string{fmt.Sprintf(`<%s>; rel="first"`, page(1))}
+	if pagination.Page > 1 {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, page(pagination.Page-1)))
+	}
+	if pagination.Page < last {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, page(pagination.Page+1)))
+	}
+	links = append(links, fmt.Sprintf(`<%s>; rel="last"`, page(last)))
+
+	return strings.Join(links, ", ")
+}
+
+func (c *CakeV2) GetDetail(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+
+	cakeID, ok := c.cakeID(w, param)
+	if !ok {
+		return
+	}
+
+	res, err := c.cakeService.GetDetail(r.Context(), cakeID)
+	if err != nil {
+		c.serviceError(w, err)
+		return
+	}
+
+	writeCacheable(w, r, c.HttpSerializer, c.Log, c.CacheControl.Detail, res.LastModified(), BaseResponse{Error: nil, Data: newCakeV2Response(res)})
+}
+
+// Update applies a JSON Merge Patch document.
+func (c *CakeV2) Update(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	c.updateWithJSON(w, r, param, (*cakeApi.UpdateRequest).ParseMergePatch)
+}
+
+// Replace replaces every field of the cake.
+func (c *CakeV2) Replace(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	c.updateWithJSON(w, r, param, (*cakeApi.UpdateRequest).ParseReplace)
+}
+
+func (c *CakeV2) updateWithJSON(w http.ResponseWriter, r *http.Request, param httprouter.Params, parse func(*cakeApi.UpdateRequest, []byte) error) {
+
+	cakeID, ok := c.cakeID(w, param)
+	if !ok {
+		return
+	}
+
+	if !isJSON(r) {
+		c.JSON(w, http.StatusUnsupportedMediaType, BaseResponse{Error: errors.New("content type must be application/json").Error(), Data: nil})
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		c.JSON(w, http.StatusBadRequest, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	reqBody := cakeApi.UpdateRequest{ID: cakeID}
+	if err = parse(&reqBody, body); err != nil {
+		c.JSON(w, http.StatusBadRequest, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	res, err := c.cakeService.Update(r.Context(), reqBody)
+	if err != nil {
+		c.serviceError(w, err)
+		return
+	}
+
+	c.JSON(w, http.StatusOK, BaseResponse{Error: nil, Data: newCakeV2Response(res)})
+}
+
+func (c *CakeV2) Delete(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+
+	cakeID, ok := c.cakeID(w, param)
+	if !ok {
+		return
+	}
+
+	if err := c.cakeService.Delete(r.Context(), cakeID); err != nil {
+		c.serviceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *CakeV2) cakeID(w http.ResponseWriter, param httprouter.Params) (int, bool) {
+	cakeID, err := strconv.Atoi(param.ByName("id"))
+	if err != nil || cakeID <= 0 {
+		c.JSON(w, http.StatusBadRequest, BaseResponse{Error: fmt.Sprintf("invalid cake id %q", param.ByName("id")), Data: nil})
+		return 0, false
+	}
+	return cakeID, true
+}
+
+func (c *CakeV2) serviceError(w http.ResponseWriter, err error) {
+	if err == sql.ErrNoRows {
+		c.JSON(w, http.StatusNotFound, BaseResponse{Error: errors.New("cake not found").Error(), Data: nil})
+		return
+	}
+
+	c.Log.Error().Msg(err.Error())
+	c.JSON(w, http.StatusInternalServerError, BaseResponse{Error: err.Error(), Data: nil})
+}
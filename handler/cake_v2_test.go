@@ -0,0 +1,282 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/julienschmidt/httprouter"
+	"github.com/rs/zerolog"
+	cakeAPi "gitlab.com/cake-store-RESTFul/service/cake"
+	commonApi "gitlab.com/cake-store-RESTFul/service/common"
+	mockService "gitlab.com/cake-store-RESTFul/service/mocks"
+)
+
+func TestCakeV2_Create(t *testing.T) {
+	tests := []struct {
+		name         string
+		contentType  string
+		body         string
+		beforeFunc   func(s *mockService.MockCake)
+		wantStatus   int
+		wantLocation string
+	}{
+		{
+			name:        "success",
+			contentType: "application/json",
+			body:        `{"title":"test","description":"test","rating":4,"image":"cG5n"}`,
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().Create(gomock.Any(), gomock.Any()).Return(cakeAPi.CakeResponse{ID: 3}, nil)
+			},
+			wantStatus:   http.StatusCreated,
+			wantLocation: "/api/v2/cakes/3",
+		},
+		{
+			name:        "error 400",
+			contentType: "application/json",
+			body:        `{"title":""}`,
+			beforeFunc:  func(s *mockService.MockCake) {},
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "error 415",
+			contentType: "multipart/form-data; boundary=x",
+			body:        "",
+			beforeFunc:  func(s *mockService.MockCake) {},
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "error 500",
+			contentType: "application/json",
+			body:        `{"title":"test","description":"test","rating":4,"image":"cG5n"}`,
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().Create(gomock.Any(), gomock.Any()).Return(cakeAPi.CakeResponse{}, errors.New("foo"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			service := mockService.NewMockCake(ct)
+			tt.beforeFunc(service)
+			c := NewCakeV2(service, NewProblemHttp(), zerolog.Logger{})
+
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/v2/cakes", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			c.Create(res, req, nil)
+
+			if res.Code != tt.wantStatus {
+				t.Fatalf("CakeV2.Create() status = %v, want %v", res.Code, tt.wantStatus)
+			}
+			if res.Header().Get("Location") != tt.wantLocation {
+				t.Errorf("CakeV2.Create() Location = %v, want %v", res.Header().Get("Location"), tt.wantLocation)
+			}
+			if tt.wantStatus >= http.StatusBadRequest {
+				checkProblem(t, res, tt.wantStatus)
+				return
+			}
+
+			got := CakeV2Response{}
+			if err := json.Unmarshal(res.Body.Bytes(), &got); err != nil || got.ID != 3 || got.Links.Self != "/api/v2/cakes/3" {
+				t.Errorf("CakeV2.Create() body = %s", res.Body.String())
+			}
+		})
+	}
+}
+
+func TestCakeV2_GetList(t *testing.T) {
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name       string
+		query      string
+		beforeFunc func(s *mockService.MockCake)
+		wantStatus int
+		wantLink   string
+	}{
+		{
+			name:  "success",
+			query: "?sort=-rating,title&page=2&limit=1",
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().GetList(gomock.Any(), cakeAPi.GetListRequest{Sort: "rating DESC, title ASC"}, commonApi.PaginationRequest{Limit: 1, Page: 2}).
+					Return(cakeAPi.CakesResponse{{ID: 2, CreatedAt: now}}, commonApi.PaginationResponse{Page: 2, Limit: 1, Total: 3}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantLink: `</api/v2/cakes?limit=1&page=1&sort=-rating%2Ctitle>; rel="first", ` +
+				`</api/v2/cakes?limit=1&page=1&sort=-rating%2Ctitle>; rel="prev", ` +
+				`</api/v2/cakes?limit=1&page=3&sort=-rating%2Ctitle>; rel="next", ` +
+				`</api/v2/cakes?limit=1&page=3&sort=-rating%2Ctitle>; rel="last"`,
+		},
+		{
+			name:       "error 400 (invalid sort)",
+			query:      "?sort=image",
+			beforeFunc: func(s *mockService.MockCake) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "error 500",
+			query: "",
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().GetList(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, commonApi.PaginationResponse{}, errors.New("foo"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			service := mockService.NewMockCake(ct)
+			tt.beforeFunc(service)
+			c := NewCakeV2(service, NewProblemHttp(), zerolog.Logger{})
+
+			res := httptest.NewRecorder()
+			c.GetList(res, httptest.NewRequest(http.MethodGet, "/api/v2/cakes"+tt.query, nil), nil)
+
+			if res.Code != tt.wantStatus {
+				t.Fatalf("CakeV2.GetList() status = %v, want %v", res.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				checkProblem(t, res, tt.wantStatus)
+				return
+			}
+			if res.Header().Get("Link") != tt.wantLink {
+				t.Errorf("CakeV2.GetList() Link = %v, want %v", res.Header().Get("Link"), tt.wantLink)
+			}
+			if res.Header().Get("X-Total-Count") != "3" {
+				t.Errorf("CakeV2.GetList() X-Total-Count = %v", res.Header().Get("X-Total-Count"))
+			}
+			if !strings.HasPrefix(res.Body.String(), `[{"id":2`) {
+				t.Errorf("CakeV2.GetList() body = %s", res.Body.String())
+			}
+		})
+	}
+}
+
+func TestCakeV2_GetDetail(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         string
+		beforeFunc func(s *mockService.MockCake)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			id:   "1",
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().GetDetail(gomock.Any(), 1).Return(cakeAPi.CakeResponse{ID: 1}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "error 404",
+			id:   "1",
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().GetDetail(gomock.Any(), 1).Return(cakeAPi.CakeResponse{}, sql.ErrNoRows)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "error 400 (invalid id)",
+			id:         "abc",
+			beforeFunc: func(s *mockService.MockCake) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			service := mockService.NewMockCake(ct)
+			tt.beforeFunc(service)
+			c := NewCakeV2(service, NewProblemHttp(), zerolog.Logger{})
+
+			res := httptest.NewRecorder()
+			c.GetDetail(res, httptest.NewRequest(http.MethodGet, "/api/v2/cakes/"+tt.id, nil), httprouter.Params{{Key: "id", Value: tt.id}})
+
+			if res.Code != tt.wantStatus {
+				t.Fatalf("CakeV2.GetDetail() status = %v, want %v", res.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				checkProblem(t, res, tt.wantStatus)
+				return
+			}
+			if !strings.HasPrefix(res.Body.String(), `{"id":1`) || res.Header().Get("ETag") == "" {
+				t.Errorf("CakeV2.GetDetail() body = %s", res.Body.String())
+			}
+		})
+	}
+}
+
+func TestCakeV2_Update(t *testing.T) {
+	rating := float32(4)
+
+	ct := gomock.NewController(t)
+	service := mockService.NewMockCake(ct)
+	service.EXPECT().Update(gomock.Any(), cakeAPi.UpdateRequest{ID: 1, Rating: &rating}).Return(cakeAPi.CakeResponse{ID: 1, Rating: 4}, nil)
+	c := NewCakeV2(service, NewProblemHttp(), zerolog.Logger{})
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPatch, "/api/v2/cakes/1", strings.NewReader(`{"rating":4}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	c.Update(res, req, httprouter.Params{{Key: "id", Value: "1"}})
+
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"rating":4`) {
+		t.Errorf("CakeV2.Update() = %v %s", res.Code, res.Body.String())
+	}
+}
+
+func TestCakeV2_Delete(t *testing.T) {
+	ct := gomock.NewController(t)
+	service := mockService.NewMockCake(ct)
+	service.EXPECT().Delete(gomock.Any(), 1).Return(nil)
+	c := NewCakeV2(service, NewProblemHttp(), zerolog.Logger{})
+
+	res := httptest.NewRecorder()
+	c.Delete(res, httptest.NewRequest(http.MethodDelete, "/api/v2/cakes/1", nil), httprouter.Params{{Key: "id", Value: "1"}})
+
+	if res.Code != http.StatusNoContent || res.Body.Len() != 0 {
+		t.Errorf("CakeV2.Delete() = %v %s", res.Code, res.Body.String())
+	}
+}
+
+func TestCakeV2_Delete_notFound(t *testing.T) {
+	ct := gomock.NewController(t)
+	service := mockService.NewMockCake(ct)
+	service.EXPECT().Delete(gomock.Any(), 9).Return(sql.ErrNoRows)
+	c := NewCakeV2(service, NewProblemHttp(), zerolog.Logger{})
+
+	res := httptest.NewRecorder()
+	c.Delete(res, httptest.NewRequest(http.MethodDelete, "/api/v2/cakes/9", nil), httprouter.Params{{Key: "id", Value: "9"}})
+
+	checkProblem(t, res, http.StatusNotFound)
+}
+
+func Test_paginationLink(t *testing.T) {
+	u, _ := url.Parse("/api/v2/cakes?search=chess")
+	got := paginationLink(u, commonApi.PaginationResponse{Page: 1, Limit: 10, Total: 0})
+	want := `</api/v2/cakes?limit=10&page=1&search=chess>; rel="first", </api/v2/cakes?limit=10&page=1&search=chess>; rel="last"`
+	if got != want {
+		t.Errorf("paginationLink() = %v, want %v", got, want)
+	}
+}
+
+func checkProblem(t *testing.T, res *httptest.ResponseRecorder, status int) {
+	t.Helper()
+
+	if res.Header().Get("Content-Type") != "application/problem+json" {
+		t.Errorf("Content-Type = %v, want application/problem+json", res.Header().Get("Content-Type"))
+	}
+
+	problem := Problem{}
+	if err := json.Unmarshal(res.Body.Bytes(), &problem); err != nil || problem.Status != status || problem.Title != http.StatusText(status) {
+		t.Errorf("problem = %s", res.Body.String())
+	}
+}
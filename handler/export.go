@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	cakeApi "gitlab.com/cake-store-RESTFul/service/cake"
+)
+
+const (
+	exportFormatCSV    = "csv"
+	exportFormatNDJSON = "ndjson"
+	exportFormatXLSX   = "xlsx"
+
+	// flush the response every exportFlushRows rows so the download progresses
+	exportFlushRows = 100
+)
+
+var exportColumns = []string{"id", "title", "description", "image", "rating", "created_at", "updated_at"}
+
+var exportContentTypes = map[string]string{
+	exportFormatCSV:    "text/csv; charset=utf-8",
+	exportFormatNDJSON: "application/x-ndjson",
+	exportFormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+type exportEncoder interface {
+	Encode(cake cakeApi.CakeResponse) error
+	Close() error
+}
+
+func newExportEncoder(format string, w io.Writer) (exportEncoder, error) {
+	switch format {
+	case exportFormatCSV:
+		return newCSVExport(w)
+	case exportFormatNDJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		return &ndjsonExport{encoder: encoder}, nil
+	case exportFormatXLSX:
+		return newXLSXExport(w)
+	}
+
+	return nil, fmt.Errorf("unsupported export format %q", format)
+}
+
+func exportRecord(cake cakeApi.CakeResponse) []string {
+	updatedAt := ""
+	if cake.UpdatedAt != nil {
+		updatedAt = cake.UpdatedAt.Format(time.RFC3339)
+	}
+
+	return []string{
+		strconv.Itoa(cake.ID),
+		cake.Title,
+		cake.Description,
+		cake.Image,
+		strconv.FormatFloat(float64(cake.Rating), 'f', -1, 32),
+		cake.CreatedAt.Format(time.RFC3339),
+		updatedAt,
+	}
+}
+
+type csvExport struct {
+	writer *csv.Writer
+}
+
+func newCSVExport(w io.Writer) (exportEncoder, error) {
+	writer := csv.NewWriter(w)
+	return &csvExport{writer: writer}, writer.Write(exportColumns)
+}
+
+func (c *csvExport) Encode(cake cakeApi.CakeResponse) error {
+	return c.writer.Write(exportRecord(cake))
+}
+
+func (c *csvExport) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+type ndjsonExport struct {
+	encoder *json.Encoder
+}
+
+func (n *ndjsonExport) Encode(cake cakeApi.CakeResponse) error {
+	return n.encoder.Encode(cake)
+}
+
+func (n *ndjsonExport) Close() error {
+	return nil
+}
+
+// xlsxExport writes a minimal single sheet workbook. The sheet is the last
+// entry of the zip archive so rows can be streamed straight into it.
+type xlsxExport struct {
+	archive *zip.Writer
+	sheet   io.Writer
+}
+
+var xlsxParts = []struct {
+	name, body string
+}{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="cakes" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+}
+
+func newXLSXExport(w io.Writer) (exportEncoder, error) {
+	archive := zip.NewWriter(w)
+
+	for _, part := range xlsxParts {
+		f, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = io.WriteString(f, part.body); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	x := &xlsxExport{archive: archive, sheet: sheet}
+	if _, err = io.WriteString(sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`); err != nil {
+		return nil, err
+	}
+
+	return x, x.row(exportColumns, nil)
+}
+
+func (x *xlsxExport) Encode(cake cakeApi.CakeResponse) error {
+	// id and rating are numeric cells, everything else is an inline string
+	return x.row(exportRecord(cake), map[int]bool{0: true, 4: true})
+}
+
+func (x *xlsxExport) row(values []string, numeric map[int]bool) error {
+	if _, err := io.WriteString(x.sheet, "<row>"); err != nil {
+		return err
+	}
+
+	for i, v := range values {
+		if numeric[i] {
+			if _, err := fmt.Fprintf(x.sheet, "<c><v>%s</v></c>", v); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, err := io.WriteString(x.sheet, `<c t="inlineStr"><is><t xml:space="preserve">`); err != nil {
+			return err
+		}
+		if err := xml.EscapeText(x.sheet, []byte(v)); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(x.sheet, "</t></is></c>"); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(x.sheet, "</row>")
+	return err
+}
+
+func (x *xlsxExport) Close() error {
+	if _, err := io.WriteString(x.sheet, "</sheetData></worksheet>"); err != nil {
+		return err
+	}
+
+	return x.archive.Close()
+}
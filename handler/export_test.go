@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	cakeAPi "gitlab.com/cake-store-RESTFul/service/cake"
+	mockService "gitlab.com/cake-store-RESTFul/service/mocks"
+)
+
+func TestCake_Export(t *testing.T) {
+	createdAt := time.Date(2022, 11, 16, 17, 56, 27, 0, time.UTC)
+	cake := cakeAPi.CakeResponse{ID: 1, Title: "Chess <Cake>", Description: "test, with comma", Rating: 4.5, CreatedAt: createdAt}
+
+	export := func(_ context.Context, _ cakeAPi.GetListRequest, fn func(cakeAPi.CakeResponse) error) error {
+		return fn(cake)
+	}
+
+	tests := []struct {
+		name            string
+		query           string
+		beforeFunc      func(s *mockService.MockCake)
+		wantStatus      int
+		wantContentType string
+		checkBody       func(t *testing.T, body []byte)
+	}{
+		{
+			name:  "success csv",
+			query: "",
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().Export(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(export)
+			},
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/csv; charset=utf-8",
+			checkBody: func(t *testing.T, body []byte) {
+				want := "id,title,description,image,rating,created_at,updated_at\n1,Chess <Cake>,\"test, with comma\",,4.5,2022-11-16T17:56:27Z,\n"
+				if string(body) != want {
+					t.Errorf("Cake.Export() body = %q, want %q", body, want)
+				}
+			},
+		},
+		{
+			name:  "success ndjson",
+			query: "?format=ndjson",
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().Export(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(export)
+			},
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/x-ndjson",
+			checkBody: func(t *testing.T, body []byte) {
+				if !strings.HasPrefix(string(body), `{"id":1,"title":"Chess <Cake>"`) || !strings.HasSuffix(string(body), "}\n") {
+					t.Errorf("Cake.Export() body = %s", body)
+				}
+			},
+		},
+		{
+			name:  "success xlsx",
+			query: "?format=xlsx",
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().Export(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(export)
+			},
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			checkBody: func(t *testing.T, body []byte) {
+				zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+				if err != nil {
+					t.Fatalf("Cake.Export() invalid xlsx: %v", err)
+				}
+
+				for _, f := range zr.File {
+					if f.Name != "xl/worksheets/sheet1.xml" {
+						continue
+					}
+					rc, _ := f.Open()
+					sheet, _ := io.ReadAll(rc)
+					rc.Close()
+					if !strings.Contains(string(sheet), "<c><v>4.5</v></c>") || !strings.Contains(string(sheet), "Chess &lt;Cake&gt;") {
+						t.Errorf("Cake.Export() sheet = %s", sheet)
+					}
+					return
+				}
+				t.Errorf("Cake.Export() xlsx has no sheet")
+			},
+		},
+		{
+			name:  "success empty export still has header",
+			query: "",
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().Export(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/csv; charset=utf-8",
+			checkBody: func(t *testing.T, body []byte) {
+				if string(body) != "id,title,description,image,rating,created_at,updated_at\n" {
+					t.Errorf("Cake.Export() body = %q", body)
+				}
+			},
+		},
+		{
+			name:       "error 400 (invalid format)",
+			query:      "?format=pdf",
+			beforeFunc: func(s *mockService.MockCake) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "error 500",
+			query: "",
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().Export(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("foo"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			service := mockService.NewMockCake(ct)
+			tt.beforeFunc(service)
+			c := NewCake(service, NewCommonHttp(), zerolog.Logger{})
+
+			res := httptest.NewRecorder()
+			c.Export(res, httptest.NewRequest(http.MethodGet, "/api/v1/cake/export"+tt.query, nil), nil)
+
+			if res.Code != tt.wantStatus {
+				t.Fatalf("Cake.Export() status = %v, want %v", res.Code, tt.wantStatus)
+			}
+			if tt.wantContentType != "" && res.Header().Get("Content-Type") != tt.wantContentType {
+				t.Errorf("Cake.Export() Content-Type = %v, want %v", res.Header().Get("Content-Type"), tt.wantContentType)
+			}
+			if tt.wantStatus == http.StatusOK && !strings.HasPrefix(res.Header().Get("Content-Disposition"), "attachment; filename=") {
+				t.Errorf("Cake.Export() Content-Disposition = %v", res.Header().Get("Content-Disposition"))
+			}
+			if tt.checkBody != nil {
+				tt.checkBody(t, res.Body.Bytes())
+			}
+		})
+	}
+}
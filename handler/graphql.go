@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/gql"
+)
+
+// GraphQL serves POST /graphql. Results are always answered with 200 as
+// GraphQL reports errors in the body, only unreadable requests get a 400.
+type GraphQL struct {
+	server *gql.Server
+	HttpSerializer
+	Log zerolog.Logger
+}
+
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+func NewGraphQL(server *gql.Server, serializer HttpSerializer, log zerolog.Logger) *GraphQL {
+	return &GraphQL{
+		server:         server,
+		HttpSerializer: serializer,
+		Log:            log,
+	}
+}
+
+func (g *GraphQL) Query(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	if !isJSON(r) {
+		g.JSON(w, http.StatusUnsupportedMediaType, BaseResponse{Error: errors.New("content type must be application/json").Error(), Data: nil})
+		return
+	}
+
+	req := GraphQLRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		g.JSON(w, http.StatusBadRequest, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	if req.Query == "" {
+		g.JSON(w, http.StatusBadRequest, BaseResponse{Error: errors.New("query cannot be empty").Error(), Data: nil})
+		return
+	}
+
+	res := g.server.Do(r.Context(), req.Query, req.Variables, req.OperationName)
+	g.JSON(w, http.StatusOK, BaseResponse{Error: nil, Data: res})
+}
+
+// GraphiQL serves the playground, it is only routed in development.
+func GraphiQL(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(graphiqlPage))
+}
+
+const graphiqlPage = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>Cake Store GraphiQL</title>
+  <link rel="stylesheet" href="https://unpkg.com/graphiql@3.0.6/graphiql.min.css">
+</head>
+<body style="margin: 0">
+  <div id="graphiql" style="height: 100vh"></div>
+  <script crossorigin src="https://unpkg.com/react@18.2.0/umd/react.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/react-dom@18.2.0/umd/react-dom.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/graphiql@3.0.6/graphiql.min.js"></script>
+  <script>
+    const fetcher = GraphiQL.createFetcher({ url: "/graphql" });
+    ReactDOM.createRoot(document.getElementById("graphiql")).render(React.createElement(GraphiQL, { fetcher }));
+  </script>
+</body>
+</html>
+`
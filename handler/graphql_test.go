@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/gql"
+	cakeAPi "gitlab.com/cake-store-RESTFul/service/cake"
+	mockService "gitlab.com/cake-store-RESTFul/service/mocks"
+)
+
+func TestGraphQL_Query(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		beforeFunc  func(s *mockService.MockCake)
+		wantStatus  int
+		wantBody    string
+	}{
+		{
+			name:        "success",
+			contentType: "application/json",
+			body:        `{"query":"query ($id: Int!) { cake(id: $id) { title } }","variables":{"id":1}}`,
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().GetByIDs(gomock.Any(), []int{1}).Return(cakeAPi.CakesResponse{{ID: 1, Title: "lemon"}}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"data":{"cake":{"title":"lemon"}}}`,
+		},
+		{
+			name:        "graphql errors are answered with 200",
+			contentType: "application/json",
+			body:        `{"query":"{ pies { id } }"}`,
+			beforeFunc:  func(s *mockService.MockCake) {},
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "error 400 empty query",
+			contentType: "application/json",
+			body:        `{"query":""}`,
+			beforeFunc:  func(s *mockService.MockCake) {},
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "error 400 invalid body",
+			contentType: "application/json",
+			body:        `{"query":`,
+			beforeFunc:  func(s *mockService.MockCake) {},
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "error 415",
+			contentType: "application/graphql",
+			body:        `{ cake(id: 1) { id } }`,
+			beforeFunc:  func(s *mockService.MockCake) {},
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			service := mockService.NewMockCake(ct)
+			tt.beforeFunc(service)
+
+			server, err := gql.NewServer(service, gql.Limits{}, zerolog.Logger{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			g := NewGraphQL(server, NewProblemHttp(), zerolog.Logger{})
+
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			g.Query(res, req, nil)
+
+			if res.Code != tt.wantStatus {
+				t.Fatalf("GraphQL.Query() status = %v, want %v", res.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && res.Body.String() != tt.wantBody {
+				t.Errorf("GraphQL.Query() body = %v, want %v", res.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
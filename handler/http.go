@@ -25,6 +25,7 @@ func (c *commonHttp) JSON(w http.ResponseWriter, status int, res BaseResponse) {
 		panic(err)
 	}
 
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	_, err = w.Write(jsonByte)
 	if err != nil {
@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/service"
+	idempotencyApi "gitlab.com/cake-store-RESTFul/service/idempotency"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	idempotencyKeyMaxLength   = 255
+
+	defaultIdempotencyMaxBodySize = 10 << 20
+)
+
+// Idempotency makes POST handlers safe to retry. The first request with an
+// Idempotency-Key is processed and its response recorded, identical retries
+// get the recorded response back without running the handler again. Keys are
+// scoped to the sender, the method and the path.
+type Idempotency struct {
+	idempotencyService service.Idempotency
+	HttpSerializer
+	Log zerolog.Logger
+	// MaxBodySize is the largest body read to fingerprint a request sent
+	// with an Idempotency-Key, larger ones are answered with 413.
+	MaxBodySize int64
+}
+
+func NewIdempotency(idempotencyService service.Idempotency, serializer HttpSerializer, log zerolog.Logger) *Idempotency {
+	return &Idempotency{
+		idempotencyService: idempotencyService,
+		HttpSerializer:     serializer,
+		Log:                log,
+		MaxBodySize:        defaultIdempotencyMaxBodySize,
+	}
+}
+
+// Handle wraps next, requests without an Idempotency-Key are passed through.
+func (i *Idempotency) Handle(next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next(w, r, param)
+			return
+		}
+
+		if len(key) > idempotencyKeyMaxLength {
+			i.JSON(w, http.StatusBadRequest, BaseResponse{Error: errors.New("idempotency key is too long").Error(), Data: nil})
+			return
+		}
+
+		if r.ContentLength > i.MaxBodySize {
+			i.tooLarge(w)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, i.MaxBodySize))
+		// MaxBytesReader fails once the body went past the limit
+		if err != nil && int64(len(body)) == i.MaxBodySize {
+			i.tooLarge(w)
+			return
+		}
+		if err != nil {
+			i.Log.Error().Msg(err.Error())
+			i.JSON(w, http.StatusBadRequest, BaseResponse{Error: err.Error(), Data: nil})
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		fingerprint, err := requestFingerprint(r, body)
+		if err != nil {
+			i.JSON(w, http.StatusBadRequest, BaseResponse{Error: err.Error(), Data: nil})
+			return
+		}
+
+		key = idempotencyApi.Key(idempotencyPrincipal(r), r.Method, r.URL.Path, key)
+
+		recorded, err := i.idempotencyService.Begin(r.Context(), key, fingerprint)
+		switch {
+		case err == service.ErrIdempotencyInProgress:
+			i.JSON(w, http.StatusConflict, BaseResponse{Error: err.Error(), Data: nil})
+			return
+		case err == service.ErrIdempotencyMismatch:
+			i.JSON(w, http.StatusUnprocessableEntity, BaseResponse{Error: err.Error(), Data: nil})
+			return
+		case err != nil:
+			i.Log.Error().Msg(err.Error())
+			i.JSON(w, http.StatusInternalServerError, BaseResponse{Error: err.Error(), Data: nil})
+			return
+		case recorded != nil:
+			replay(w, *recorded)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		completed := false
+		defer func() {
+			// the handler panicked or failed, let the client retry
+			if !completed {
+				if err := i.idempotencyService.Release(r.Context(), key); err != nil {
+					i.Log.Error().Msg(err.Error())
+				}
+			}
+		}()
+
+		next(rec, r, param)
+
+		if rec.status >= http.StatusInternalServerError {
+			return
+		}
+
+		err = i.idempotencyService.Complete(r.Context(), key, idempotencyApi.Response{
+			StatusCode: rec.status,
+			Header:     w.Header().Clone(),
+			Body:       rec.body.Bytes(),
+		})
+		if err != nil {
+			i.Log.Error().Msg(err.Error())
+			return
+		}
+
+		completed = true
+	}
+}
+
+// idempotencyPrincipal identifies who sent r: the credentials of the
+// Authorization header when there are some, the client address otherwise.
+// Only a hash of it is stored.
+func idempotencyPrincipal(r *http.Request) string {
+
+	if authorization := r.Header.Get("Authorization"); authorization != "" {
+		return "authorization:" + authorization
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "client:" + host
+}
+
+func (i *Idempotency) tooLarge(w http.ResponseWriter) {
+	i.JSON(w, http.StatusRequestEntityTooLarge, BaseResponse{Error: fmt.Sprintf("body is larger than %d bytes", i.MaxBodySize), Data: nil})
+}
+
+func replay(w http.ResponseWriter, res idempotencyApi.Response) {
+	for k, v := range res.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Set(idempotencyReplayedHeader, "true")
+	w.WriteHeader(res.StatusCode)
+	_, _ = w.Write(res.Body)
+}
+
+// responseRecorder keeps a copy of the response while writing it through.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// requestFingerprint hashes the method, path and the content of the body.
+// Form bodies are hashed by content rather than by their raw bytes, a retried
+// multipart request usually has a new boundary and url encoded fields may come
+// in a different order.
+func requestFingerprint(r *http.Request, body []byte) (string, error) {
+
+	h := sha256.New()
+	writeField(h, r.Method)
+	writeField(h, r.URL.Path)
+
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/"):
+		mr := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", errors.New("body is not a valid multipart form")
+			}
+
+			content, err := io.ReadAll(part)
+			if err != nil {
+				return "", errors.New("body is not a valid multipart form")
+			}
+
+			writeField(h, part.FormName())
+			writeField(h, part.FileName())
+			writeField(h, string(content))
+		}
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return "", errors.New("body is not a valid form")
+		}
+		// Encode sorts by key
+		writeField(h, values.Encode())
+	default:
+		writeField(h, string(body))
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeField writes a length prefixed value so adjacent fields cannot be
+// shifted into each other.
+func writeField(h hash.Hash, v string) {
+	_ = binary.Write(h, binary.BigEndian, uint64(len(v)))
+	_, _ = io.WriteString(h, v)
+}
@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/julienschmidt/httprouter"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/service"
+	idempotencyApi "gitlab.com/cake-store-RESTFul/service/idempotency"
+	mockService "gitlab.com/cake-store-RESTFul/service/mocks"
+)
+
+func TestIdempotency_Handle(t *testing.T) {
+	// httptest requests come from 192.0.2.1
+	scopedKey := idempotencyApi.Key("client:192.0.2.1", http.MethodPost, "/api/v1/cake/json", "abc")
+
+	tests := []struct {
+		name        string
+		key         string
+		nextStatus  int
+		maxBodySize int64
+		// unknownLength sends the body without a Content-Length
+		unknownLength bool
+		beforeFunc    func(s *mockService.MockIdempotency)
+		wantStatus    int
+		wantCalls     int
+		wantReplayed  bool
+	}{
+		{
+			name:       "without key",
+			nextStatus: http.StatusCreated,
+			beforeFunc: func(s *mockService.MockIdempotency) {},
+			wantStatus: http.StatusCreated,
+			wantCalls:  1,
+		},
+		{
+			name:       "first request is recorded",
+			key:        "abc",
+			nextStatus: http.StatusCreated,
+			beforeFunc: func(s *mockService.MockIdempotency) {
+				s.EXPECT().Begin(gomock.Any(), scopedKey, gomock.Any()).Return(nil, nil)
+				s.EXPECT().Complete(gomock.Any(), scopedKey, gomock.Any()).DoAndReturn(func(_ interface{}, _ string, res idempotencyApi.Response) error {
+					if res.StatusCode != http.StatusCreated || res.Header.Get("Location") != "/api/v1/cake/1" || string(res.Body) != `{"id":1}` {
+						t.Errorf("Idempotency.Handle() recorded = %+v", res)
+					}
+					return nil
+				})
+			},
+			wantStatus: http.StatusCreated,
+			wantCalls:  1,
+		},
+		{
+			name: "retry is replayed",
+			key:  "abc",
+			beforeFunc: func(s *mockService.MockIdempotency) {
+				s.EXPECT().Begin(gomock.Any(), scopedKey, gomock.Any()).Return(&idempotencyApi.Response{
+					StatusCode: http.StatusCreated,
+					Header:     http.Header{"Location": []string{"/api/v1/cake/1"}},
+					Body:       []byte(`{"id":1}`),
+				}, nil)
+			},
+			wantStatus:   http.StatusCreated,
+			wantCalls:    0,
+			wantReplayed: true,
+		},
+		{
+			name:       "server error releases the key",
+			key:        "abc",
+			nextStatus: http.StatusInternalServerError,
+			beforeFunc: func(s *mockService.MockIdempotency) {
+				s.EXPECT().Begin(gomock.Any(), scopedKey, gomock.Any()).Return(nil, nil)
+				s.EXPECT().Release(gomock.Any(), scopedKey).Return(nil)
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantCalls:  1,
+		},
+		{
+			name: "error 409 (in progress)",
+			key:  "abc",
+			beforeFunc: func(s *mockService.MockIdempotency) {
+				s.EXPECT().Begin(gomock.Any(), scopedKey, gomock.Any()).Return(nil, service.ErrIdempotencyInProgress)
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name: "error 422 (different body)",
+			key:  "abc",
+			beforeFunc: func(s *mockService.MockIdempotency) {
+				s.EXPECT().Begin(gomock.Any(), scopedKey, gomock.Any()).Return(nil, service.ErrIdempotencyMismatch)
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "error 400 (key too long)",
+			key:        strings.Repeat("a", 256),
+			beforeFunc: func(s *mockService.MockIdempotency) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "error 413 (body too large)",
+			key:         "abc",
+			maxBodySize: 8,
+			beforeFunc:  func(s *mockService.MockIdempotency) {},
+			wantStatus:  http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:          "error 413 (body too large without length)",
+			key:           "abc",
+			maxBodySize:   8,
+			unknownLength: true,
+			beforeFunc:    func(s *mockService.MockIdempotency) {},
+			wantStatus:    http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "error 500",
+			key:  "abc",
+			beforeFunc: func(s *mockService.MockIdempotency) {
+				s.EXPECT().Begin(gomock.Any(), scopedKey, gomock.Any()).Return(nil, errors.New("foo"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			svc := mockService.NewMockIdempotency(ct)
+			tt.beforeFunc(svc)
+			i := NewIdempotency(svc, NewCommonHttp(), zerolog.Logger{})
+			if tt.maxBodySize > 0 {
+				i.MaxBodySize = tt.maxBodySize
+			}
+
+			calls := 0
+			next := func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+				calls++
+				w.Header().Set("Location", "/api/v1/cake/1")
+				w.WriteHeader(tt.nextStatus)
+				_, _ = w.Write([]byte(`{"id":1}`))
+			}
+
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/cake/json", strings.NewReader(`{"title":"test"}`))
+			if tt.key != "" {
+				req.Header.Set("Idempotency-Key", tt.key)
+			}
+			if tt.unknownLength {
+				req.ContentLength = -1
+			}
+			i.Handle(next)(res, req, nil)
+
+			if res.Code != tt.wantStatus {
+				t.Errorf("Idempotency.Handle() status = %v, want %v", res.Code, tt.wantStatus)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Idempotency.Handle() handler calls = %v, want %v", calls, tt.wantCalls)
+			}
+			if replayed := res.Header().Get("Idempotent-Replayed") == "true"; replayed != tt.wantReplayed {
+				t.Errorf("Idempotency.Handle() replayed = %v, want %v", replayed, tt.wantReplayed)
+			}
+		})
+	}
+}
+
+func Test_idempotencyPrincipal(t *testing.T) {
+	request := func(remoteAddr, authorization string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/cake/json", nil)
+		req.RemoteAddr = remoteAddr
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name string
+		r    *http.Request
+		want string
+	}{
+		{
+			name: "client address",
+			r:    request("192.0.2.1:1234", ""),
+			want: "client:192.0.2.1",
+		},
+		{
+			name: "client address without port",
+			r:    request("192.0.2.1", ""),
+			want: "client:192.0.2.1",
+		},
+		{
+			name: "credentials win over the address",
+			r:    request("192.0.2.1:1234", "Bearer secret"),
+			want: "authorization:Bearer secret",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := idempotencyPrincipal(tt.r); got != tt.want {
+				t.Errorf("idempotencyPrincipal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// the same key from another client or on another path is another key
+	key := idempotencyApi.Key("client:192.0.2.1", http.MethodPost, "/api/v1/cake/json", "abc")
+	if other := idempotencyApi.Key("client:192.0.2.2", http.MethodPost, "/api/v1/cake/json", "abc"); other == key {
+		t.Errorf("idempotencyApi.Key() is equal for another client")
+	}
+	if other := idempotencyApi.Key("client:192.0.2.1", http.MethodPost, "/api/v1/cake/import", "abc"); other == key {
+		t.Errorf("idempotencyApi.Key() is equal for another path")
+	}
+}
+
+func Test_requestFingerprint(t *testing.T) {
+	multipartRequest := func(boundary, title string) *http.Request {
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		_ = writer.SetBoundary(boundary)
+		_ = writer.WriteField("title", title)
+		part, _ := writer.CreateFormFile("image", "cake.png")
+		_, _ = part.Write([]byte("png"))
+		_ = writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/cake", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		return req
+	}
+
+	fingerprint := func(r *http.Request) string {
+		body := new(bytes.Buffer)
+		_, _ = body.ReadFrom(r.Body)
+		f, err := requestFingerprint(r, body.Bytes())
+		if err != nil {
+			t.Fatalf("requestFingerprint() error = %v", err)
+		}
+		return f
+	}
+
+	first := fingerprint(multipartRequest("boundary1", "test"))
+	if retry := fingerprint(multipartRequest("boundary2", "test")); retry != first {
+		t.Errorf("requestFingerprint() differs for a retry with a new boundary")
+	}
+	if other := fingerprint(multipartRequest("boundary1", "other")); other == first {
+		t.Errorf("requestFingerprint() is equal for a different body")
+	}
+}
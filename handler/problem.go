@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Problem is an RFC 7807 problem details body.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type problemHttp struct{}
+
+// NewProblemHttp returns the serializer of the v2 API. Successful responses
+// are written without the BaseResponse envelope, only Data is sent, and
+// errors are written as application/problem+json.
+func NewProblemHttp() HttpSerializer {
+	return new(problemHttp)
+}
+
+func (p *problemHttp) JSON(w http.ResponseWriter, status int, res BaseResponse) {
+
+	var body interface{} = res.Data
+	contentType := "application/json"
+
+	if res.Error != nil {
+		body = Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(status),
+			Status: status,
+			Detail: fmt.Sprint(res.Error),
+		}
+		contentType = "application/problem+json"
+	}
+
+	jsonByte, err := json.Marshal(body)
+	if err != nil {
+		log.Err(err)
+		panic(err)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_, err = w.Write(jsonByte)
+	if err != nil {
+		log.Err(err)
+		panic(err)
+	}
+}
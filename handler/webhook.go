@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/service"
+	commonApi "gitlab.com/cake-store-RESTFul/service/common"
+	webhookApi "gitlab.com/cake-store-RESTFul/service/webhook"
+)
+
+const webhookPath = "/api/v1/admin/webhooks"
+
+var errWebhookNotFound = errors.New("webhook not found")
+
+// Webhook serves the admin endpoints managing webhook subscriptions and their
+// delivery log.
+type Webhook struct {
+	webhookService service.Webhook
+	HttpSerializer
+	Log zerolog.Logger
+}
+
+func NewWebhook(webhookService service.Webhook, serializer HttpSerializer, log zerolog.Logger) *Webhook {
+	return &Webhook{
+		webhookService: webhookService,
+		HttpSerializer: serializer,
+		Log:            log,
+	}
+}
+
+func (h *Webhook) Create(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	if !isJSON(r) {
+		h.JSON(w, http.StatusUnsupportedMediaType, BaseResponse{Error: errors.New("content type must be application/json").Error(), Data: nil})
+		return
+	}
+
+	reqBody := webhookApi.CreateRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		h.JSON(w, http.StatusBadRequest, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	if err := reqBody.Validate(); err != nil {
+		h.JSON(w, http.StatusBadRequest, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	res, err := h.webhookService.Create(r.Context(), reqBody)
+	if err != nil {
+		h.Log.Error().Msg(err.Error())
+		h.JSON(w, http.StatusInternalServerError, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("%s/%d", webhookPath, res.ID))
+	h.JSON(w, http.StatusCreated, BaseResponse{Error: nil, Data: res})
+}
+
+func (h *Webhook) GetList(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	res, err := h.webhookService.GetList(r.Context())
+	if err != nil {
+		h.Log.Error().Msg(err.Error())
+		h.JSON(w, http.StatusInternalServerError, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	h.JSON(w, http.StatusOK, BaseResponse{Error: nil, Data: res})
+}
+
+func (h *Webhook) GetDetail(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+
+	id, ok := h.id(w, param, "id")
+	if !ok {
+		return
+	}
+
+	res, err := h.webhookService.GetDetail(r.Context(), id)
+	if err != nil {
+		h.serviceError(w, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, BaseResponse{Error: nil, Data: res})
+}
+
+// Update changes the fields present in the JSON body.
+func (h *Webhook) Update(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+
+	id, ok := h.id(w, param, "id")
+	if !ok {
+		return
+	}
+
+	if !isJSON(r) {
+		h.JSON(w, http.StatusUnsupportedMediaType, BaseResponse{Error: errors.New("content type must be application/json").Error(), Data: nil})
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.JSON(w, http.StatusBadRequest, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+
+	reqBody := webhookApi.UpdateRequest{}
+	if err = reqBody.ParseJSON(body); err != nil {
+		h.JSON(w, http.StatusBadRequest, BaseResponse{Error: err.Error(), Data: nil})
+		return
+	}
+	reqBody.ID = id
+
+	res, err := h.webhookService.Update(r.Context(), reqBody)
+	if err != nil {
+		h.serviceError(w, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, BaseResponse{Error: nil, Data: res})
+}
+
+func (h *Webhook) Delete(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+
+	id, ok := h.id(w, param, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.Delete(r.Context(), id); err != nil {
+		h.serviceError(w, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, BaseResponse{Error: nil, Data: "success"})
+}
+
+// GetDeliveries answers the delivery log of a webhook, newest first.
+func (h *Webhook) GetDeliveries(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+
+	id, ok := h.id(w, param, "id")
+	if !ok {
+		return
+	}
+
+	paginateReq := commonApi.PaginationRequest{}
+	paginateReq.ParseQuery(r.URL.Query())
+
+	res, pagination, err := h.webhookService.GetDeliveries(r.Context(), id, paginateReq)
+	if err != nil {
+		h.serviceError(w, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, BaseResponse{Error: nil, Data: res, MetaData: pagination})
+}
+
+// Redeliver queues the event of a delivery again and answers 202 with the new
+// delivery, it is sent by the dispatcher like any other.
+func (h *Webhook) Redeliver(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+
+	id, ok := h.id(w, param, "id")
+	if !ok {
+		return
+	}
+
+	deliveryID, ok := h.id(w, param, "delivery_id")
+	if !ok {
+		return
+	}
+
+	res, err := h.webhookService.Redeliver(r.Context(), id, deliveryID)
+	if err != nil {
+		h.serviceError(w, err)
+		return
+	}
+
+	h.JSON(w, http.StatusAccepted, BaseResponse{Error: nil, Data: res})
+}
+
+func (h *Webhook) id(w http.ResponseWriter, param httprouter.Params, name string) (int, bool) {
+
+	id, err := strconv.Atoi(param.ByName(name))
+	if err != nil {
+		h.JSON(w, http.StatusBadRequest, BaseResponse{Error: err.Error(), Data: nil})
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (h *Webhook) serviceError(w http.ResponseWriter, err error) {
+
+	if errors.Is(err, sql.ErrNoRows) {
+		h.JSON(w, http.StatusNotFound, BaseResponse{Error: errWebhookNotFound.Error(), Data: nil})
+		return
+	}
+
+	h.Log.Error().Msg(err.Error())
+	h.JSON(w, http.StatusInternalServerError, BaseResponse{Error: err.Error(), Data: nil})
+}
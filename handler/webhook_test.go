@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/julienschmidt/httprouter"
+	"github.com/rs/zerolog"
+	commonApi "gitlab.com/cake-store-RESTFul/service/common"
+	mockService "gitlab.com/cake-store-RESTFul/service/mocks"
+	webhookApi "gitlab.com/cake-store-RESTFul/service/webhook"
+)
+
+func TestWebhook_Create(t *testing.T) {
+	tests := []struct {
+		name         string
+		contentType  string
+		body         string
+		beforeFunc   func(s *mockService.MockWebhook)
+		wantStatus   int
+		wantLocation string
+	}{
+		{
+			name:        "success",
+			contentType: "application/json",
+			body:        `{"url":"https://example.com/hook","events":["cake.created"]}`,
+			beforeFunc: func(s *mockService.MockWebhook) {
+				s.EXPECT().Create(gomock.Any(), gomock.Any()).Return(webhookApi.WebhookResponse{ID: 2}, nil)
+			},
+			wantStatus:   http.StatusCreated,
+			wantLocation: "/api/v1/admin/webhooks/2",
+		},
+		{
+			name:        "error 400",
+			contentType: "application/json",
+			body:        `{"url":"https://example.com/hook","events":["cake.eaten"]}`,
+			beforeFunc:  func(s *mockService.MockWebhook) {},
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "error 415",
+			contentType: "text/plain",
+			body:        `{}`,
+			beforeFunc:  func(s *mockService.MockWebhook) {},
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "error 500",
+			contentType: "application/json",
+			body:        `{"url":"https://example.com/hook","events":["cake.created"]}`,
+			beforeFunc: func(s *mockService.MockWebhook) {
+				s.EXPECT().Create(gomock.Any(), gomock.Any()).Return(webhookApi.WebhookResponse{}, errors.New("foo"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			service := mockService.NewMockWebhook(ct)
+			tt.beforeFunc(service)
+			h := NewWebhook(service, NewCommonHttp(), zerolog.Logger{})
+
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/webhooks", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			h.Create(res, req, nil)
+
+			if res.Code != tt.wantStatus {
+				t.Fatalf("Webhook.Create() status = %v, want %v", res.Code, tt.wantStatus)
+			}
+			if res.Header().Get("Location") != tt.wantLocation {
+				t.Errorf("Webhook.Create() Location = %v, want %v", res.Header().Get("Location"), tt.wantLocation)
+			}
+		})
+	}
+}
+
+func TestWebhook_Update(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         string
+		body       string
+		beforeFunc func(s *mockService.MockWebhook)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			id:   "1",
+			body: `{"active":false}`,
+			beforeFunc: func(s *mockService.MockWebhook) {
+				active := false
+				s.EXPECT().Update(gomock.Any(), webhookApi.UpdateRequest{ID: 1, Active: &active}).Return(webhookApi.WebhookResponse{ID: 1}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "error 400 id",
+			id:         "x",
+			body:       `{}`,
+			beforeFunc: func(s *mockService.MockWebhook) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "error 400 body",
+			id:         "1",
+			body:       `{"events":[]}`,
+			beforeFunc: func(s *mockService.MockWebhook) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "error 404",
+			id:   "1",
+			body: `{}`,
+			beforeFunc: func(s *mockService.MockWebhook) {
+				s.EXPECT().Update(gomock.Any(), gomock.Any()).Return(webhookApi.WebhookResponse{}, sql.ErrNoRows)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			service := mockService.NewMockWebhook(ct)
+			tt.beforeFunc(service)
+			h := NewWebhook(service, NewCommonHttp(), zerolog.Logger{})
+
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPatch, "/api/v1/admin/webhooks/"+tt.id, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			h.Update(res, req, httprouter.Params{{Key: "id", Value: tt.id}})
+
+			if res.Code != tt.wantStatus {
+				t.Errorf("Webhook.Update() status = %v, want %v", res.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestWebhook_GetDeliveries(t *testing.T) {
+	tests := []struct {
+		name       string
+		beforeFunc func(s *mockService.MockWebhook)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			beforeFunc: func(s *mockService.MockWebhook) {
+				s.EXPECT().GetDeliveries(gomock.Any(), 1, commonApi.PaginationRequest{Page: 2, Limit: 5}).
+					Return(webhookApi.DeliveriesResponse{{ID: 9}}, commonApi.PaginationResponse{Page: 2, Limit: 5, Total: 6}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "error 404",
+			beforeFunc: func(s *mockService.MockWebhook) {
+				s.EXPECT().GetDeliveries(gomock.Any(), 1, gomock.Any()).Return(nil, commonApi.PaginationResponse{}, sql.ErrNoRows)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			service := mockService.NewMockWebhook(ct)
+			tt.beforeFunc(service)
+			h := NewWebhook(service, NewCommonHttp(), zerolog.Logger{})
+
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/webhooks/1/deliveries?page=2&limit=5", nil)
+			h.GetDeliveries(res, req, httprouter.Params{{Key: "id", Value: "1"}})
+
+			if res.Code != tt.wantStatus {
+				t.Errorf("Webhook.GetDeliveries() status = %v, want %v", res.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestWebhook_Redeliver(t *testing.T) {
+	tests := []struct {
+		name       string
+		beforeFunc func(s *mockService.MockWebhook)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			beforeFunc: func(s *mockService.MockWebhook) {
+				s.EXPECT().Redeliver(gomock.Any(), 1, 7).Return(webhookApi.DeliveryResponse{ID: 8}, nil)
+			},
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name: "error 404",
+			beforeFunc: func(s *mockService.MockWebhook) {
+				s.EXPECT().Redeliver(gomock.Any(), 1, 7).Return(webhookApi.DeliveryResponse{}, sql.ErrNoRows)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "error 500",
+			beforeFunc: func(s *mockService.MockWebhook) {
+				s.EXPECT().Redeliver(gomock.Any(), 1, 7).Return(webhookApi.DeliveryResponse{}, errors.New("foo"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			service := mockService.NewMockWebhook(ct)
+			tt.beforeFunc(service)
+			h := NewWebhook(service, NewCommonHttp(), zerolog.Logger{})
+
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/webhooks/1/deliveries/7/redeliver", nil)
+			h.Redeliver(res, req, httprouter.Params{{Key: "id", Value: "1"}, {Key: "delivery_id", Value: "7"}})
+
+			if res.Code != tt.wantStatus {
+				t.Errorf("Webhook.Redeliver() status = %v, want %v", res.Code, tt.wantStatus)
+			}
+		})
+	}
+}
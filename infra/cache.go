@@ -0,0 +1,66 @@
+package infra
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gitlab.com/cake-store-RESTFul/config"
+)
+
+type CacheStore interface {
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set stores value under key, a zero ttl means the key never expires.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+type Cache struct {
+	Store CacheStore
+	// DetailTTL and ListTTL change on a config reload, they are read with TTL
+	// once the cache is in use.
+	DetailTTL time.Duration
+	ListTTL   time.Duration
+	// WriteSettleTime is how long after a write cache misses are read from
+	// the primary, twice the replica max lag. It is zero without replicas.
+	WriteSettleTime time.Duration
+
+	mu sync.RWMutex
+}
+
+// TTL returns how long cake details and list pages are cached.
+func (c *Cache) TTL() (detail, list time.Duration) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.DetailTTL, c.ListTTL
+}
+
+func (c *Cache) SetTTL(detail, list time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.DetailTTL, c.ListTTL = detail, list
+}
+
+// newCache returns nil when caching is disabled.
+func newCache(config config.Cache) *Cache {
+
+	if !config.Enabled {
+		return nil
+	}
+
+	var store CacheStore
+	switch driver := config.Driver; driver {
+	case "", "memory":
+		store = NewMemoryCache(config.Size)
+	case "redis":
+		store = newRedisCache(config)
+	default:
+		panic("unknown cache driver: " + driver)
+	}
+
+	return &Cache{
+		Store:     store,
+		DetailTTL: time.Duration(config.DetailTTL) * time.Second,
+		ListTTL:   time.Duration(config.ListTTL) * time.Second,
+	}
+}
@@ -0,0 +1,96 @@
+package infra
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+const defaultMemoryCacheSize = 1000
+
+type memoryCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type memoryCacheEntry struct {
+	key       string
+	value     []byte
+	expiredAt time.Time
+}
+
+// NewMemoryCache returns an in-process LRU store holding at most size keys.
+func NewMemoryCache(size int) CacheStore {
+	if size <= 0 {
+		size = defaultMemoryCacheSize
+	}
+
+	return &memoryCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+func (m *memoryCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := el.Value.(*memoryCacheEntry)
+	if !entry.expiredAt.IsZero() && time.Now().After(entry.expiredAt) {
+		m.remove(el)
+		return nil, false, nil
+	}
+
+	m.lru.MoveToFront(el)
+	return entry.value, true, nil
+}
+
+func (m *memoryCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := &memoryCacheEntry{key: key, value: value}
+	if ttl > 0 {
+		entry.expiredAt = time.Now().Add(ttl)
+	}
+
+	if el, ok := m.entries[key]; ok {
+		el.Value = entry
+		m.lru.MoveToFront(el)
+		return nil
+	}
+
+	m.entries[key] = m.lru.PushFront(entry)
+	for m.lru.Len() > m.size {
+		m.remove(m.lru.Back())
+	}
+
+	return nil
+}
+
+func (m *memoryCache) Delete(_ context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := m.entries[key]; ok {
+			m.remove(el)
+		}
+	}
+
+	return nil
+}
+
+func (m *memoryCache) remove(el *list.Element) {
+	m.lru.Remove(el)
+	delete(m.entries, el.Value.(*memoryCacheEntry).key)
+}
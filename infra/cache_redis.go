@@ -0,0 +1,86 @@
+package infra
+
+import (
+	"context"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"gitlab.com/cake-store-RESTFul/config"
+)
+
+type redisCache struct {
+	pool *redis.Pool
+}
+
+func newRedisCache(config config.Cache) CacheStore {
+	address := config.RedisAddress
+	password := config.RedisPassword
+	database := config.RedisDB
+
+	return &redisCache{
+		pool: &redis.Pool{
+			MaxIdle:     10,
+			IdleTimeout: 5 * time.Minute,
+			Dial: func() (redis.Conn, error) {
+				return redis.Dial("tcp", address,
+					redis.DialPassword(password),
+					redis.DialDatabase(database),
+				)
+			},
+		},
+	}
+}
+
+func (r *redisCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	conn, err := r.pool.GetContext(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	defer conn.Close()
+
+	value, err := redis.Bytes(conn.Do("GET", key))
+	if err == redis.ErrNil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return value, true, nil
+}
+
+func (r *redisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	conn, err := r.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if ttl > 0 {
+		_, err = conn.Do("SET", key, value, "PX", ttl.Milliseconds())
+		return err
+	}
+
+	_, err = conn.Do("SET", key, value)
+	return err
+}
+
+func (r *redisCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	conn, err := r.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	args := make([]interface{}, len(keys))
+	for i, key := range keys {
+		args[i] = key
+	}
+
+	_, err = conn.Do("DEL", args...)
+	return err
+}
@@ -5,27 +5,27 @@ import (
 
 	"github.com/cloudinary/cloudinary-go/v2"
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
-	"github.com/spf13/viper"
+	"gitlab.com/cake-store-RESTFul/config"
 )
 
 func (c *cld) Upload(ctx context.Context, file interface{}, uploadParams uploader.UploadParams) (*uploader.UploadResult, error) {
 	return c.cloudinary.Upload.Upload(ctx, file, uploadParams)
 }
 
+func (c *cld) Destroy(ctx context.Context, params uploader.DestroyParams) (*uploader.DestroyResult, error) {
+	return c.cloudinary.Upload.Destroy(ctx, params)
+}
+
 type cld struct {
 	cloudinary *cloudinary.Cloudinary
 }
 type Cloudinary interface {
 	Upload(ctx context.Context, file interface{}, uploadParams uploader.UploadParams) (*uploader.UploadResult, error)
+	Destroy(ctx context.Context, params uploader.DestroyParams) (*uploader.DestroyResult, error)
 }
 
-func newCloudinary(viper *viper.Viper) Cloudinary {
-	config := viper.Sub("cloudinary")
-	cloudName := config.GetString("cloud_name")
-	apiKey := config.GetString("api_key")
-	secretKey := config.GetString("secret")
-
-	cl, err := cloudinary.NewFromParams(cloudName, apiKey, secretKey)
+func newCloudinary(config config.Cloudinary) Cloudinary {
+	cl, err := cloudinary.NewFromParams(config.CloudName, config.APIKey, config.Secret)
 	if err != nil {
 		panic(err)
 	}
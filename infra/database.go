@@ -0,0 +1,167 @@
+package infra
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/config"
+	_ "modernc.org/sqlite"
+)
+
+// Database is the SQL database of the app. database.driver selects the
+// engine, its connection is read from the config section of the same name.
+// Replicas is nil unless the section lists replica DSNs.
+type Database struct {
+	DB       *sql.DB
+	Dialect  Dialect
+	Replicas *Replicas
+}
+
+func newDatabase(config *config.Config, log zerolog.Logger) *Database {
+
+	database := &Database{Dialect: Dialect(config.Database.Driver)}
+
+	switch database.Dialect {
+	case DialectMySQL:
+		database.DB = openPool(database.Dialect, mysqlDSN(config.MySQL), config.MySQL.Pool)
+		database.Replicas = newReplicas(database.Dialect, config.MySQL, log)
+	case DialectPostgres:
+		database.DB = openPool(database.Dialect, postgresDSN(config.Postgres), config.Postgres.Pool)
+		database.Replicas = newReplicas(database.Dialect, config.Postgres, log)
+	case DialectSQLite:
+		database.DB = openPool(database.Dialect, sqliteDSN(config.SQLite), sqlitePool(config.SQLite))
+	default:
+		panic(fmt.Sprintf("unknown database.driver %s", database.Dialect))
+	}
+
+	return database
+}
+
+// pool returns the pool settings of the database of config.
+func (d *Database) pool(config *config.Config) config.Pool {
+	switch d.Dialect {
+	case DialectMySQL:
+		return config.MySQL.Pool
+	case DialectPostgres:
+		return config.Postgres.Pool
+	default:
+		return sqlitePool(config.SQLite)
+	}
+}
+
+// Close closes the connection pools of the primary and the replicas.
+func (d *Database) Close() error {
+	d.Replicas.Close()
+	return d.DB.Close()
+}
+
+// SetPool resizes the connection pools of the primary and the replicas.
+func (d *Database) SetPool(pool config.Pool) {
+	setPool(d.DB, pool)
+	d.Replicas.setPool(pool)
+}
+
+// newReplicas opens the replicas of server and watches their health, it
+// returns nil when there is none.
+func newReplicas(dialect Dialect, server config.Server, log zerolog.Logger) *Replicas {
+
+	if len(server.Replicas) == 0 {
+		return nil
+	}
+
+	dbs := make([]*sql.DB, 0, len(server.Replicas))
+	for _, dsn := range server.Replicas {
+		dbs = append(dbs, openPool(dialect, dsn, server.Pool))
+	}
+
+	maxLag := time.Duration(server.ReplicaMaxLag) * time.Second
+	if maxLag <= 0 {
+		maxLag = defaultReplicaMaxLag
+	}
+	interval := time.Duration(server.ReplicaCheckInterval) * time.Second
+	if interval <= 0 {
+		interval = defaultReplicaCheckInterval
+	}
+
+	replicas := NewReplicas(dialect, dbs, maxLag, log)
+	replicas.watch(interval)
+
+	return replicas
+}
+
+// openPool opens the connection pool of dsn with the settings of pool.
+func openPool(dialect Dialect, dsn string, pool config.Pool) *sql.DB {
+
+	db, err := sql.Open(string(dialect), dsn)
+	if err != nil {
+		panic(err)
+	}
+
+	setPool(db, pool)
+	return db
+}
+
+func setPool(db *sql.DB, pool config.Pool) {
+	db.SetConnMaxIdleTime(time.Duration(pool.ConnMaxIdleTime) * time.Minute)
+	db.SetConnMaxLifetime(time.Duration(pool.ConnMaxLifeTime) * time.Minute)
+	db.SetMaxIdleConns(pool.MaxIdleConn)
+	db.SetMaxOpenConns(pool.MaxOpenConn)
+}
+
+func mysqlDSN(server config.Server) string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true",
+		server.Username, server.Password, server.Host, server.Port, server.Database)
+}
+
+func postgresDSN(server config.Server) string {
+
+	sslmode := server.SSLMode
+	if sslmode == "" {
+		sslmode = "disable"
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(server.Username, server.Password),
+		Host:     server.Host + ":" + strconv.Itoa(server.Port),
+		Path:     "/" + server.Database,
+		RawQuery: url.Values{"sslmode": []string{sslmode}}.Encode(),
+	}
+
+	return dsn.String()
+}
+
+// sqliteDSN opens the file at path, or a database in memory shared by the
+// connections of the pool when path is ":memory:". Writers wait busy_timeout
+// for each other instead of failing right away.
+func sqliteDSN(sqlite config.SQLite) string {
+
+	pragmas := fmt.Sprintf("_pragma=busy_timeout(%d)&_pragma=foreign_keys(1)", sqlite.BusyTimeout)
+
+	if sqlite.Memory() {
+		return "file:cake-store?mode=memory&cache=shared&" + pragmas
+	}
+
+	return "file:" + sqlite.Path + "?" + pragmas + "&_pragma=journal_mode(WAL)"
+}
+
+// sqlitePool is the pool of sqlite, an in-memory database is dropped with its
+// last connection so one is always kept.
+func sqlitePool(sqlite config.SQLite) config.Pool {
+
+	pool := sqlite.Pool
+	if sqlite.Memory() {
+		pool.ConnMaxIdleTime, pool.ConnMaxLifeTime = 0, 0
+		if pool.MaxIdleConn < 1 {
+			pool.MaxIdleConn = 1
+		}
+	}
+
+	return pool
+}
@@ -0,0 +1,245 @@
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Dialect is the SQL engine behind a Database. Queries are written for MySQL
+// with ? placeholders, the dialect covers what differs on the other engines.
+type Dialect string
+
+const (
+	DialectMySQL    Dialect = "mysql"
+	DialectPostgres Dialect = "postgres"
+	DialectSQLite   Dialect = "sqlite"
+)
+
+// lockPollInterval is how often a PostgreSQL advisory lock or an SQLite
+// process lock is tried again while waiting for it.
+const lockPollInterval = 100 * time.Millisecond
+
+// processLocks are the named locks of SQLite, which has none of its own. A
+// SQLite database is only served by a single process, so the locks only need
+// to hold between the goroutines of this one.
+var processLocks = struct {
+	sync.Mutex
+	held map[string]bool
+}{held: map[string]bool{}}
+
+// Rebind rewrites the ? placeholders of query into the numbered placeholders
+// of PostgreSQL, question marks inside quotes are kept.
+func (d Dialect) Rebind(query string) string {
+
+	if d != DialectPostgres || !strings.Contains(query, "?") {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+
+	n := 0
+	var quote rune
+	for _, r := range query {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '?':
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// ILike is the case insensitive LIKE operator. MySQL already compares case
+// insensitively with its default collation and SQLite does for ASCII.
+func (d Dialect) ILike() string {
+	if d == DialectPostgres {
+		return "ILIKE"
+	}
+	return "LIKE"
+}
+
+// Quote quotes an identifier that is a reserved word.
+func (d Dialect) Quote(name string) string {
+	if d == DialectMySQL {
+		return "`" + name + "`"
+	}
+	return `"` + name + `"`
+}
+
+// Returning reports whether generated IDs are read with a RETURNING clause,
+// the driver has no LastInsertId.
+func (d Dialect) Returning() bool {
+	return d == DialectPostgres
+}
+
+// ForUpdate is the clause locking the rows read by a SELECT until the end of
+// the transaction. SQLite has none, a write transaction already holds the
+// whole database.
+func (d Dialect) ForUpdate() string {
+	if d == DialectSQLite {
+		return ""
+	}
+	return " FOR UPDATE"
+}
+
+// InsertIgnore turns an "INSERT INTO" statement into one that skips the rows
+// conflicting with a unique key.
+func (d Dialect) InsertIgnore(insert string) string {
+	if d == DialectMySQL {
+		return strings.Replace(insert, "INSERT INTO", "INSERT IGNORE INTO", 1)
+	}
+	return insert + " ON CONFLICT DO NOTHING"
+}
+
+// Lock takes the named lock for the session of conn, waiting up to wait for
+// another session to release it. It returns false when the lock is still
+// held by someone else.
+func (d Dialect) Lock(ctx context.Context, conn *sql.Conn, name string, wait time.Duration) (bool, error) {
+
+	if d == DialectMySQL {
+		var acquired sql.NullInt64
+		err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", name, int(wait/time.Second)).Scan(&acquired)
+		return acquired.Int64 == 1, err
+	}
+
+	// advisory and process locks cannot wait for a bounded time, so they
+	// are polled
+	deadline := time.Now().Add(wait)
+	for {
+		acquired, err := d.tryLock(ctx, conn, name)
+		if err != nil {
+			return false, err
+		}
+		if acquired || !time.Now().Before(deadline) {
+			return acquired, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+func (d Dialect) tryLock(ctx context.Context, conn *sql.Conn, name string) (acquired bool, err error) {
+
+	if d == DialectSQLite {
+		processLocks.Lock()
+		defer processLocks.Unlock()
+
+		if processLocks.held[name] {
+			return false, nil
+		}
+		processLocks.held[name] = true
+		return true, nil
+	}
+
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lockKey(name)).Scan(&acquired)
+	return
+}
+
+// Unlock releases a lock taken with Lock on the same conn.
+func (d Dialect) Unlock(ctx context.Context, conn *sql.Conn, name string) error {
+
+	switch d {
+	case DialectMySQL:
+		_, err := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", name)
+		return err
+	case DialectSQLite:
+		processLocks.Lock()
+		delete(processLocks.held, name)
+		processLocks.Unlock()
+		return nil
+	}
+
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", lockKey(name))
+	return err
+}
+
+// ReplicaLag returns how far the replica db is behind its primary. It fails
+// when replication is stopped.
+func (d Dialect) ReplicaLag(ctx context.Context, db *sql.DB) (time.Duration, error) {
+
+	switch d {
+	case DialectMySQL:
+		return mysqlReplicaLag(ctx, db)
+	case DialectPostgres:
+		// a replica that replayed everything it received is caught up, even
+		// when the primary had nothing to send for a while
+		var seconds float64
+		err := db.QueryRowContext(ctx, `SELECT CASE
+			WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+		END`).Scan(&seconds)
+		return time.Duration(seconds * float64(time.Second)), err
+	}
+
+	return 0, fmt.Errorf("%s has no replicas", d)
+}
+
+// mysqlReplicaLag reads Seconds_Behind_Source out of the replica status,
+// MariaDB still names it Seconds_Behind_Master. A server that is not a
+// replica has no status and no lag.
+func mysqlReplicaLag(ctx context.Context, db *sql.DB) (time.Duration, error) {
+
+	rows, err := db.QueryContext(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	if !rows.Next() {
+		return 0, rows.Err()
+	}
+
+	values := make([]sql.RawBytes, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err = rows.Scan(dest...); err != nil {
+		return 0, err
+	}
+
+	for i, column := range columns {
+		if column != "Seconds_Behind_Master" && column != "Seconds_Behind_Source" {
+			continue
+		}
+		if values[i] == nil {
+			return 0, errors.New("replication is not running")
+		}
+		seconds, err := strconv.Atoi(string(values[i]))
+		return time.Duration(seconds) * time.Second, err
+	}
+
+	return 0, errors.New("replica status has no Seconds_Behind_Source")
+}
+
+// lockKey maps a lock name on the 64 bit key of an advisory lock.
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,24 @@
+package infra
+
+import (
+	"time"
+
+	"gitlab.com/cake-store-RESTFul/config"
+)
+
+const defaultIdempotencyTTL = 24 * time.Hour
+
+// Idempotency holds how long Idempotency-Key responses are kept.
+type Idempotency struct {
+	TTL time.Duration
+}
+
+func newIdempotency(config config.Idempotency) Idempotency {
+
+	ttl := time.Duration(config.TTL) * time.Second
+	if ttl <= 0 {
+		ttl = defaultIdempotencyTTL
+	}
+
+	return Idempotency{TTL: ttl}
+}
@@ -1,20 +1,68 @@
 package infra
 
 import (
+	"time"
+
 	"github.com/rs/zerolog"
-	"github.com/spf13/viper"
+	"gitlab.com/cake-store-RESTFul/config"
 )
 
 type Infra struct {
-	*Mysql
-	Log        zerolog.Logger
-	Cloudinary Cloudinary
+	*Database
+	Log         zerolog.Logger
+	Cloudinary  Cloudinary
+	Cache       *Cache
+	Idempotency Idempotency
+	Webhook     Webhook
+	Outbox      Outbox
 }
 
-func NewInfra(viper *viper.Viper) *Infra {
+func NewInfra(config *config.Config) *Infra {
+
+	setLogLevel(config.Log.Level)
+	log := newLogger()
+	if !config.Cloudinary.Configured() {
+		log.Warn().Msg("cloudinary is not set, image uploads fail")
+	}
+
+	database := newDatabase(config, log)
+	cache := newCache(config.Cache)
+	if cache != nil && database.Replicas != nil {
+		cache.WriteSettleTime = 2 * database.Replicas.MaxLag
+	}
+
 	return &Infra{
-		Mysql:      newMysql(viper),
-		Log:        newLogger(),
-		Cloudinary: newCloudinary(viper),
+		Database:    database,
+		Log:         log,
+		Cloudinary:  newCloudinary(config.Cloudinary),
+		Cache:       cache,
+		Idempotency: newIdempotency(config.Idempotency),
+		Webhook:     newWebhook(config.Webhook),
+		Outbox:      newOutbox(config.Outbox),
+	}
+}
+
+// Close releases what NewInfra opened, once every ServiceManager built on it
+// is stopped.
+func (i *Infra) Close() error {
+
+	if i.Outbox.Publisher != nil {
+		if err := i.Outbox.Publisher.Close(); err != nil {
+			i.Log.Error().Msg(err.Error())
+		}
+	}
+
+	return i.Database.Close()
+}
+
+// Reload applies the live settings of a reloaded config: the log level, the
+// connection pool sizes and the cache TTLs.
+func (i *Infra) Reload(config *config.Config) {
+
+	setLogLevel(config.Log.Level)
+	i.Database.SetPool(i.Database.pool(config))
+
+	if i.Cache != nil {
+		i.Cache.SetTTL(time.Duration(config.Cache.DetailTTL)*time.Second, time.Duration(config.Cache.ListTTL)*time.Second)
 	}
 }
@@ -21,3 +21,11 @@ func newLogger() zerolog.Logger {
 		Logger()
 
 }
+
+// setLogLevel sets the level of every logger, log.level is validated so an
+// unknown level is never set.
+func setLogLevel(level string) {
+	if parsed, err := zerolog.ParseLevel(level); err == nil {
+		zerolog.SetGlobalLevel(parsed)
+	}
+}
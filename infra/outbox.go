@@ -0,0 +1,62 @@
+package infra
+
+import (
+	"time"
+
+	"gitlab.com/cake-store-RESTFul/config"
+)
+
+const (
+	defaultOutboxTopic     = "cake.events"
+	defaultOutboxBatchSize = 100
+	defaultOutboxRetention = 24 * time.Hour
+	defaultOutboxTimeout   = 5 * time.Second
+)
+
+// Outbox holds where the outbox events are relayed to and how long sent
+// events are kept. Publisher is nil for the none driver, the events then stay
+// in the outbox table.
+type Outbox struct {
+	Publisher Publisher
+	Topic     string
+	BatchSize int
+	Retention time.Duration
+}
+
+func newOutbox(outbox config.Outbox) Outbox {
+
+	timeout := time.Duration(outbox.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultOutboxTimeout
+	}
+
+	config := Outbox{
+		Topic:     outbox.Topic,
+		BatchSize: outbox.BatchSize,
+		Retention: time.Duration(outbox.Retention) * time.Second,
+	}
+
+	switch driver := outbox.Driver; driver {
+	case "", "none":
+	case "memory":
+		config.Publisher = NewMemoryPublisher()
+	case "nats":
+		config.Publisher = newNatsPublisher(outbox.NatsURL, timeout)
+	case "kafka":
+		config.Publisher = newKafkaPublisher(outbox.KafkaRestURL, timeout)
+	default:
+		panic("unknown outbox driver: " + driver)
+	}
+
+	if config.Topic == "" {
+		config.Topic = defaultOutboxTopic
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultOutboxBatchSize
+	}
+	if config.Retention <= 0 {
+		config.Retention = defaultOutboxRetention
+	}
+
+	return config
+}
@@ -0,0 +1,19 @@
+package infra
+
+import "context"
+
+// Message is an event sent to a broker. Key groups the messages that must
+// stay in order, e.g. Kafka uses it to pick the partition.
+type Message struct {
+	Topic  string
+	Key    string
+	Value  []byte
+	Header map[string]string
+}
+
+// Publisher sends messages to a broker. Publish returns once the broker
+// accepted the message, so a nil error means it will not be lost.
+type Publisher interface {
+	Publish(ctx context.Context, message Message) error
+	Close() error
+}
@@ -0,0 +1,101 @@
+package infra
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// kafkaPublisher produces to Kafka through a Confluent compatible REST proxy
+// (API v2). The message key is sent as the record key so the events of a key
+// land on the same partition and keep their order. The REST API has no record
+// headers, they are dropped.
+type kafkaPublisher struct {
+	url    string
+	client *http.Client
+}
+
+type kafkaRecord struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+type kafkaProduceRequest struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		Partition int     `json:"partition"`
+		Offset    int64   `json:"offset"`
+		ErrorCode *int    `json:"error_code"`
+		Error     *string `json:"error"`
+	} `json:"offsets"`
+}
+
+func newKafkaPublisher(restURL string, timeout time.Duration) Publisher {
+	return &kafkaPublisher{
+		url:    strings.TrimRight(restURL, "/"),
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (k *kafkaPublisher) Publish(ctx context.Context, message Message) error {
+
+	// binary records are base64 encoded, which is what encoding/json does
+	// with []byte
+	body, err := json.Marshal(kafkaProduceRequest{Records: []kafkaRecord{{Key: []byte(message.Key), Value: message.Value}}})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.url+"/topics/"+url.PathEscape(message.Topic), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.binary.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	res, err := k.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, 64*1024))
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("kafka rest proxy answered %d: %s", res.StatusCode, data)
+	}
+
+	produced := kafkaProduceResponse{}
+	if err = json.Unmarshal(data, &produced); err != nil {
+		return err
+	}
+
+	for _, offset := range produced.Offsets {
+		if offset.ErrorCode != nil {
+			msg := ""
+			if offset.Error != nil {
+				msg = *offset.Error
+			}
+			return fmt.Errorf("kafka error %d: %s", *offset.ErrorCode, msg)
+		}
+	}
+
+	return nil
+}
+
+func (k *kafkaPublisher) Close() error {
+	k.client.CloseIdleConnections()
+	return nil
+}
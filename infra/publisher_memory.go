@@ -0,0 +1,52 @@
+package infra
+
+import (
+	"context"
+	"sync"
+)
+
+const defaultMemoryPublisherLimit = 1000
+
+// MemoryPublisher keeps the published messages in process, it is meant for
+// development and tests. Only the last Limit messages are kept.
+type MemoryPublisher struct {
+	mu       sync.Mutex
+	messages []Message
+	Limit    int
+	// Fail, when set, is called for every message and its error is returned
+	// by Publish instead of keeping the message.
+	Fail func(message Message) error
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{Limit: defaultMemoryPublisherLimit}
+}
+
+func (m *MemoryPublisher) Publish(_ context.Context, message Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.Fail != nil {
+		if err := m.Fail(message); err != nil {
+			return err
+		}
+	}
+
+	m.messages = append(m.messages, message)
+	if m.Limit > 0 && len(m.messages) > m.Limit {
+		m.messages = append([]Message(nil), m.messages[len(m.messages)-m.Limit:]...)
+	}
+	return nil
+}
+
+// Messages returns the published messages in the order they were published.
+func (m *MemoryPublisher) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Message(nil), m.messages...)
+}
+
+func (m *MemoryPublisher) Close() error {
+	return nil
+}
@@ -0,0 +1,78 @@
+package infra
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// natsPublisher publishes with the NATS client. Every message is followed by
+// a flush, its answer confirms the server processed it. The connection is
+// opened on first use, so the app starts while the server is down, and the
+// client reconnects on its own afterwards.
+type natsPublisher struct {
+	mu      sync.Mutex
+	url     string
+	timeout time.Duration
+	conn    *nats.Conn
+}
+
+func newNatsPublisher(url string, timeout time.Duration) Publisher {
+	return &natsPublisher{url: url, timeout: timeout}
+}
+
+func (n *natsPublisher) Publish(ctx context.Context, message Message) error {
+
+	conn, err := n.connect()
+	if err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(message.Topic)
+	msg.Data = message.Value
+	// servers before 2.2 do not support headers, they are dropped
+	if conn.HeadersSupported() {
+		for key, value := range message.Header {
+			msg.Header.Set(key, value)
+		}
+	}
+
+	if err := conn.PublishMsg(msg); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+
+	return conn.FlushWithContext(ctx)
+}
+
+func (n *natsPublisher) connect() (*nats.Conn, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn != nil {
+		return n.conn, nil
+	}
+
+	conn, err := nats.Connect(n.url, nats.Name("cake-store"), nats.Timeout(n.timeout), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+	n.conn = conn
+
+	return conn, nil
+}
+
+func (n *natsPublisher) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn != nil {
+		n.conn.Close()
+		n.conn = nil
+	}
+	return nil
+}
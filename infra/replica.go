@@ -0,0 +1,174 @@
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/config"
+)
+
+const (
+	defaultReplicaMaxLag        = 5 * time.Second
+	defaultReplicaCheckInterval = 5 * time.Second
+	// replicaCheckTimeout bounds the ping and lag query of a single replica.
+	replicaCheckTimeout = 2 * time.Second
+)
+
+// Replicas are the read replicas of a Database. A replica is only read from
+// while it answers its health check and lags MaxLag or less behind the
+// primary.
+type Replicas struct {
+	Dialect Dialect
+	MaxLag  time.Duration
+	Log     zerolog.Logger
+
+	replicas []*replica
+	next     uint32
+
+	// stopWatch ends the Watch started by watch, Close calls it.
+	stopWatch context.CancelFunc
+	watching  chan struct{}
+}
+
+type replica struct {
+	index   int
+	db      *sql.DB
+	healthy int32
+}
+
+// NewReplicas returns the replicas of dbs, none of them is used before the
+// first Check.
+func NewReplicas(dialect Dialect, dbs []*sql.DB, maxLag time.Duration, log zerolog.Logger) *Replicas {
+
+	replicas := &Replicas{
+		Dialect: dialect,
+		MaxLag:  maxLag,
+		Log:     log,
+	}
+	for i, db := range dbs {
+		replicas.replicas = append(replicas.replicas, &replica{index: i, db: db})
+	}
+
+	return replicas
+}
+
+// Pick returns the next healthy replica round-robin, nil when there is none
+// and the primary has to be read instead.
+func (r *Replicas) Pick() *sql.DB {
+
+	if r == nil || len(r.replicas) == 0 {
+		return nil
+	}
+
+	start := atomic.AddUint32(&r.next, 1)
+	for i := range r.replicas {
+		replica := r.replicas[(int(start)+i)%len(r.replicas)]
+		if atomic.LoadInt32(&replica.healthy) == 1 {
+			return replica.db
+		}
+	}
+
+	return nil
+}
+
+// Check pings every replica and reads its lag, replicas that fail or lag too
+// far behind are skipped by Pick until a later Check finds them caught up.
+func (r *Replicas) Check(ctx context.Context) {
+
+	for _, replica := range r.replicas {
+
+		healthy, reason := r.check(ctx, replica)
+
+		var state int32
+		if healthy {
+			state = 1
+		}
+		if atomic.SwapInt32(&replica.healthy, state) == state {
+			continue
+		}
+
+		if healthy {
+			r.Log.Info().Int("replica", replica.index).Msg("replica is healthy, reads are routed to it")
+		} else {
+			r.Log.Warn().Int("replica", replica.index).Str("reason", reason).Msg("replica is unhealthy, reads fall back to the other replicas or the primary")
+		}
+	}
+}
+
+func (r *Replicas) check(ctx context.Context, replica *replica) (bool, string) {
+
+	ctx, cancel := context.WithTimeout(ctx, replicaCheckTimeout)
+	defer cancel()
+
+	if err := replica.db.PingContext(ctx); err != nil {
+		return false, err.Error()
+	}
+
+	lag, err := r.Dialect.ReplicaLag(ctx, replica.db)
+	if err != nil {
+		return false, err.Error()
+	}
+	if lag > r.MaxLag {
+		return false, "lagging " + lag.String() + " behind the primary"
+	}
+
+	return true, ""
+}
+
+// watch runs Watch in the background until Close.
+func (r *Replicas) watch(interval time.Duration) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.stopWatch = cancel
+	r.watching = make(chan struct{})
+
+	go func() {
+		defer close(r.watching)
+		r.Watch(ctx, interval)
+	}()
+}
+
+// Watch runs Check every interval until ctx is done.
+func (r *Replicas) Watch(ctx context.Context, interval time.Duration) {
+
+	r.Check(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Check(ctx)
+		}
+	}
+}
+
+func (r *Replicas) setPool(pool config.Pool) {
+	if r == nil {
+		return
+	}
+	for _, replica := range r.replicas {
+		setPool(replica.db, pool)
+	}
+}
+
+// Close stops the health checks and closes the connection pools of every
+// replica.
+func (r *Replicas) Close() {
+	if r == nil {
+		return
+	}
+	if r.stopWatch != nil {
+		r.stopWatch()
+		<-r.watching
+	}
+	for _, replica := range r.replicas {
+		replica.db.Close()
+	}
+}
@@ -0,0 +1,58 @@
+package infra
+
+import (
+	"time"
+
+	"gitlab.com/cake-store-RESTFul/config"
+)
+
+const (
+	defaultWebhookTimeout     = 10 * time.Second
+	defaultWebhookMaxAttempts = 8
+	defaultWebhookBackoffBase = 30 * time.Second
+	defaultWebhookBackoffMax  = 6 * time.Hour
+	defaultWebhookBatchSize   = 50
+)
+
+// Webhook holds how webhook deliveries are sent and retried.
+type Webhook struct {
+	Enabled bool
+	// Timeout bounds a single delivery attempt.
+	Timeout     time.Duration
+	MaxAttempts int
+	// BackoffBase is the delay after the first failed attempt, it doubles
+	// after every further failure up to BackoffMax.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	BatchSize   int
+}
+
+func newWebhook(webhook config.Webhook) Webhook {
+
+	config := Webhook{
+		Enabled:     webhook.Enabled,
+		Timeout:     time.Duration(webhook.Timeout) * time.Second,
+		MaxAttempts: webhook.MaxAttempts,
+		BackoffBase: time.Duration(webhook.BackoffBase) * time.Second,
+		BackoffMax:  time.Duration(webhook.BackoffMax) * time.Second,
+		BatchSize:   webhook.BatchSize,
+	}
+
+	if config.Timeout <= 0 {
+		config.Timeout = defaultWebhookTimeout
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaultWebhookMaxAttempts
+	}
+	if config.BackoffBase <= 0 {
+		config.BackoffBase = defaultWebhookBackoffBase
+	}
+	if config.BackoffMax <= 0 {
+		config.BackoffMax = defaultWebhookBackoffMax
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultWebhookBatchSize
+	}
+
+	return config
+}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `idempotency_key`;
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS `idempotency_key` (
+	`key` VARCHAR(255) NOT NULL,
+	`fingerprint` CHAR(64) NOT NULL,
+	`status_code` INT NOT NULL DEFAULT 0,
+	`header` TEXT,
+	`body` MEDIUMBLOB,
+	`created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP(),
+	`expires_at` TIMESTAMP NOT NULL,
+	PRIMARY KEY(`key`),
+	INDEX `idx_idempotency_key_expires_at` (`expires_at`)
+);
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS `webhook` (
+	`id` INT NOT NULL AUTO_INCREMENT,
+	`url` VARCHAR(2048) NOT NULL,
+	`secret` VARCHAR(255) NOT NULL,
+	`events` VARCHAR(255) NOT NULL,
+	`active` BOOLEAN NOT NULL DEFAULT TRUE,
+	`created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP(),
+	`updated_at` TIMESTAMP NULL,
+	PRIMARY KEY(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `webhook_delivery` (
+	`id` INT NOT NULL AUTO_INCREMENT,
+	`webhook_id` INT NOT NULL,
+	`event_id` CHAR(36) NOT NULL,
+	`event` VARCHAR(64) NOT NULL,
+	`payload` MEDIUMTEXT NOT NULL,
+	`status` VARCHAR(16) NOT NULL DEFAULT 'pending',
+	`attempts` INT NOT NULL DEFAULT 0,
+	`next_attempt_at` TIMESTAMP NULL,
+	`response_code` INT,
+	`response_body` TEXT,
+	`last_error` TEXT,
+	`created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP(),
+	`updated_at` TIMESTAMP NULL,
+	PRIMARY KEY(`id`),
+	INDEX `idx_webhook_delivery_webhook_id` (`webhook_id`, `id`),
+	INDEX `idx_webhook_delivery_due` (`status`, `next_attempt_at`),
+	CONSTRAINT `fk_webhook_delivery_webhook` FOREIGN KEY (`webhook_id`) REFERENCES `webhook` (`id`) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `outbox`;
\ No newline at end of file
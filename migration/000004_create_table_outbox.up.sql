@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS `outbox` (
+	`id` BIGINT NOT NULL AUTO_INCREMENT,
+	`event_id` CHAR(36) NOT NULL,
+	`aggregate_id` INT NOT NULL,
+	`event` VARCHAR(64) NOT NULL,
+	`payload` MEDIUMTEXT NOT NULL,
+	`attempts` INT NOT NULL DEFAULT 0,
+	`last_error` TEXT,
+	`created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP(),
+	`sent_at` TIMESTAMP NULL,
+	PRIMARY KEY(`id`),
+	INDEX `idx_outbox_pending` (`sent_at`, `id`)
+);
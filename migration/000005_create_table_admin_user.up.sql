@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS `admin_user` (
+	`id` INT NOT NULL AUTO_INCREMENT,
+	`name` VARCHAR(64) NOT NULL,
+	`token_hash` CHAR(64) NOT NULL,
+	`created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP(),
+	PRIMARY KEY(`id`),
+	UNIQUE INDEX `idx_admin_user_name` (`name`),
+	UNIQUE INDEX `idx_admin_user_token_hash` (`token_hash`)
+);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS `demo_seed` (
+	`seed` BIGINT NOT NULL,
+	`count` INT NOT NULL,
+	`updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP(),
+	PRIMARY KEY(`seed`)
+);
@@ -0,0 +1,414 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/infra"
+)
+
+// files holds the MySQL migrations, and in postgres/ and sqlite/ the same
+// migrations for PostgreSQL and SQLite. Every change needs a file in each.
+//
+//go:embed *.sql postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+const (
+	// lockName is the named lock held while migrating, so replicas
+	// starting together do not run the same migration twice.
+	lockName = "cake_store_migrate"
+	// lockTimeout is how long to wait for another instance to finish.
+	lockTimeout = 60 * time.Second
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// ErrLocked is returned when another instance held the migration lock for
+// longer than the lock timeout.
+var ErrLocked = errors.New("migration lock is held by another instance")
+
+// ErrDirty is returned when an earlier migration failed halfway. The schema
+// must be fixed by hand and schema_migrations set to the version it matches.
+type ErrDirty struct {
+	Version uint
+}
+
+func (e ErrDirty) Error() string {
+	return fmt.Sprintf("database is dirty at version %d, fix the schema and the schema_migrations table by hand", e.Version)
+}
+
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version uint
+	Name    string
+	Applied bool
+}
+
+// Migrator applies the migrations embedded from this directory. The applied
+// version is kept in schema_migrations, the same table the migrate CLI uses,
+// so a database migrated with the CLI can be taken over.
+type Migrator interface {
+	// Up applies every pending migration.
+	Up(ctx context.Context) error
+	// Down rolls back the last steps migrations.
+	Down(ctx context.Context, steps int) error
+	// Goto migrates up or down to version, 0 rolls back everything.
+	Goto(ctx context.Context, version uint) error
+	// Status returns every migration with whether it is applied, with the
+	// current version and whether it is dirty.
+	Status(ctx context.Context) ([]Status, uint, bool, error)
+}
+
+type migrator struct {
+	Log        zerolog.Logger
+	DB         *infra.Database
+	migrations []Migration
+}
+
+// NewMigrator returns the migrator of the migrations written for the dialect
+// of db.
+func NewMigrator(db *infra.Database, log zerolog.Logger) (Migrator, error) {
+
+	var fsys fs.FS = files
+	if db.Dialect != infra.DialectMySQL {
+		sub, err := fs.Sub(files, string(db.Dialect))
+		if err != nil {
+			return nil, err
+		}
+		fsys = sub
+	}
+
+	return newMigrator(db, log, fsys)
+}
+
+func newMigrator(db *infra.Database, log zerolog.Logger, fsys fs.FS) (Migrator, error) {
+
+	migrations, err := load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	if len(migrations) == 0 {
+		return nil, fmt.Errorf("no %s migrations", db.Dialect)
+	}
+
+	return &migrator{
+		Log:        log,
+		DB:         db,
+		migrations: migrations,
+	}, nil
+}
+
+// load reads the up and down file of every version, sorted by version.
+func load(fsys fs.FS) ([]Migration, error) {
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[uint]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(body)
+		} else {
+			migration.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d needs both an up and a down file", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+func (m *migrator) Up(ctx context.Context) error {
+	if len(m.migrations) == 0 {
+		return nil
+	}
+	return m.Goto(ctx, m.migrations[len(m.migrations)-1].Version)
+}
+
+func (m *migrator) Down(ctx context.Context, steps int) error {
+
+	if steps <= 0 {
+		return fmt.Errorf("steps must be positive, got %d", steps)
+	}
+
+	return m.locked(ctx, func(conn *sql.Conn) error {
+
+		current, err := m.current(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		index := m.index(current)
+		target := uint(0)
+		if index-steps >= 0 {
+			target = m.migrations[index-steps].Version
+		}
+
+		return m.migrate(ctx, conn, current, target)
+	})
+}
+
+func (m *migrator) Goto(ctx context.Context, target uint) error {
+
+	if target != 0 && m.index(target) < 0 {
+		return fmt.Errorf("no migration has version %d", target)
+	}
+
+	return m.locked(ctx, func(conn *sql.Conn) error {
+
+		current, err := m.current(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		return m.migrate(ctx, conn, current, target)
+	})
+}
+
+func (m *migrator) Status(ctx context.Context) (status []Status, current uint, dirty bool, err error) {
+
+	err = m.locked(ctx, func(conn *sql.Conn) error {
+		current, dirty, err = m.version(ctx, conn)
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	for _, migration := range m.migrations {
+		status = append(status, Status{
+			Version: migration.Version,
+			Name:    migration.Name,
+			Applied: migration.Version <= current,
+		})
+	}
+
+	return
+}
+
+// migrate runs the up files after current up to target, or the down files
+// from current down to the one after target. Like the migrate CLI the version
+// is marked dirty while a file runs, MySQL cannot roll back schema changes.
+func (m *migrator) migrate(ctx context.Context, conn *sql.Conn, current, target uint) error {
+
+	for _, migration := range m.migrations {
+		if migration.Version <= current || migration.Version > target {
+			continue
+		}
+
+		if err := m.run(ctx, conn, migration.Version, migration.Up); err != nil {
+			return fmt.Errorf("migration %d %s up: %w", migration.Version, migration.Name, err)
+		}
+		m.Log.Info().Uint("version", migration.Version).Msg("applied migration " + migration.Name)
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version > current || migration.Version <= target {
+			continue
+		}
+
+		previous := uint(0)
+		if i > 0 {
+			previous = m.migrations[i-1].Version
+		}
+
+		if err := m.run(ctx, conn, previous, migration.Down); err != nil {
+			return fmt.Errorf("migration %d %s down: %w", migration.Version, migration.Name, err)
+		}
+		m.Log.Info().Uint("version", previous).Msg("rolled back migration " + migration.Name)
+	}
+
+	return nil
+}
+
+// run executes body and records version once it succeeded.
+func (m *migrator) run(ctx context.Context, conn *sql.Conn, version uint, body string) error {
+
+	if err := m.setVersion(ctx, conn, version, true); err != nil {
+		return err
+	}
+
+	for _, statement := range statements(body) {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	return m.setVersion(ctx, conn, version, false)
+}
+
+// locked runs fn on a connection holding the migration lock.
+func (m *migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+
+	conn, err := m.DB.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	acquired, err := m.DB.Dialect.Lock(ctx, conn, lockName, lockTimeout)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return ErrLocked
+	}
+
+	defer func() {
+		if err := m.DB.Dialect.Unlock(context.Background(), conn, lockName); err != nil {
+			m.Log.Error().Msg(err.Error())
+		}
+	}()
+
+	_, err = conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)")
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// current returns the applied version, it fails when the version is dirty or
+// unknown to this binary.
+func (m *migrator) current(ctx context.Context, conn *sql.Conn) (uint, error) {
+
+	current, dirty, err := m.version(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, ErrDirty{Version: current}
+	}
+	if current != 0 && m.index(current) < 0 {
+		return 0, fmt.Errorf("database is at version %d which this build does not know", current)
+	}
+
+	return current, nil
+}
+
+// index returns the position of version in the migrations, -1 when there is
+// none.
+func (m *migrator) index(version uint) int {
+	for i, migration := range m.migrations {
+		if migration.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// nilVersion is stored, as by the migrate CLI, when rolling back the first
+// migration failed and there is no version to mark dirty.
+const nilVersion = -1
+
+// version returns the applied version, 0 when nothing is applied.
+func (m *migrator) version(ctx context.Context, conn *sql.Conn) (uint, bool, error) {
+
+	var version int64
+	var dirty bool
+
+	err := conn.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	if version == nilVersion {
+		return 0, dirty, nil
+	}
+
+	return uint(version), dirty, nil
+}
+
+func (m *migrator) setVersion(ctx context.Context, conn *sql.Conn, version uint, dirty bool) (err error) {
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return
+	}
+
+	if version > 0 || dirty {
+		stored := int64(version)
+		if version == 0 {
+			stored = nilVersion
+		}
+		query := m.DB.Dialect.Rebind("INSERT INTO schema_migrations (version, dirty) VALUES (?, ?)")
+		if _, err = tx.ExecContext(ctx, query, stored, dirty); err != nil {
+			return
+		}
+	}
+
+	return tx.Commit()
+}
+
+// statements splits a migration file on semicolons, the MySQL driver runs a
+// single statement per call. A semicolon inside a string literal is not
+// supported.
+func statements(body string) (output []string) {
+	for _, statement := range strings.Split(body, ";") {
+		if statement = strings.TrimSpace(statement); statement != "" {
+			output = append(output, statement)
+		}
+	}
+	return
+}
@@ -0,0 +1,294 @@
+package migration
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/infra"
+)
+
+var testFiles = fstest.MapFS{
+	"000001_create_a.up.sql":   {Data: []byte("CREATE TABLE a (id INT);")},
+	"000001_create_a.down.sql": {Data: []byte("DROP TABLE a;")},
+	"000002_create_b.up.sql":   {Data: []byte("CREATE TABLE b (id INT);\nCREATE TABLE c (id INT);")},
+	"000002_create_b.down.sql": {Data: []byte("DROP TABLE c;\nDROP TABLE b;")},
+	"README.md":                {Data: []byte("not a migration")},
+}
+
+func expectLock(mock sqlmock.Sqlmock, version int64, dirty bool) {
+	mock.ExpectQuery("SELECT GET_LOCK\\(\\?, \\?\\)").WithArgs(lockName, int(lockTimeout/time.Second)).WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	rows := sqlmock.NewRows([]string{"version", "dirty"})
+	if version != 0 {
+		rows.AddRow(version, dirty)
+	}
+	mock.ExpectQuery("SELECT version, dirty FROM schema_migrations LIMIT 1").WillReturnRows(rows)
+}
+
+func expectVersion(mock sqlmock.Sqlmock, version int64, dirty bool) {
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM schema_migrations").WillReturnResult(sqlmock.NewResult(0, 1))
+	if version != 0 {
+		mock.ExpectExec("INSERT INTO schema_migrations \\(version, dirty\\) VALUES \\(\\?, \\?\\)").WithArgs(version, dirty).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+}
+
+func expectUnlock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec("SELECT RELEASE_LOCK\\(\\?\\)").WithArgs(lockName).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func Test_load(t *testing.T) {
+
+	got, err := load(testFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Migration{
+		{Version: 1, Name: "create_a", Up: "CREATE TABLE a (id INT);", Down: "DROP TABLE a;"},
+		{Version: 2, Name: "create_b", Up: "CREATE TABLE b (id INT);\nCREATE TABLE c (id INT);", Down: "DROP TABLE c;\nDROP TABLE b;"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("load() = %v, want %v", got, want)
+	}
+
+	if _, err := load(fstest.MapFS{"000001_a.up.sql": {Data: []byte("SELECT 1")}}); err == nil {
+		t.Error("load() without a down file error = nil")
+	}
+}
+
+func Test_load_embedded(t *testing.T) {
+	migrations, err := load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 || migrations[0].Version != 1 {
+		t.Errorf("load() = %v", migrations)
+	}
+}
+
+func Test_NewMigrator(t *testing.T) {
+	db, _, _ := sqlmock.New()
+	defer db.Close()
+
+	versions := map[infra.Dialect][]uint{}
+	for _, dialect := range []infra.Dialect{infra.DialectMySQL, infra.DialectPostgres, infra.DialectSQLite} {
+		m, err := NewMigrator(&infra.Database{DB: db, Dialect: dialect}, zerolog.Logger{})
+		if err != nil {
+			t.Fatalf("NewMigrator(%s) error = %v", dialect, err)
+		}
+		for _, migration := range m.(*migrator).migrations {
+			versions[dialect] = append(versions[dialect], migration.Version)
+		}
+	}
+
+	for _, dialect := range []infra.Dialect{infra.DialectPostgres, infra.DialectSQLite} {
+		if !reflect.DeepEqual(versions[dialect], versions[infra.DialectMySQL]) {
+			t.Errorf("%s versions = %v, want the mysql versions %v", dialect, versions[dialect], versions[infra.DialectMySQL])
+		}
+	}
+}
+
+func Test_migrator_Goto_postgres(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	m, err := newMigrator(&infra.Database{DB: db, Dialect: infra.DialectPostgres}, zerolog.Logger{}, testFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery("SELECT pg_try_advisory_lock\\(\\$1\\)").WithArgs(lockKeyArg{}).WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(true))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}))
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM schema_migrations").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO schema_migrations \\(version, dirty\\) VALUES \\(\\$1, \\$2\\)").WithArgs(int64(1), true).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("CREATE TABLE a \\(id INT\\)").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM schema_migrations").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO schema_migrations \\(version, dirty\\) VALUES \\(\\$1, \\$2\\)").WithArgs(int64(1), false).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("SELECT pg_advisory_unlock\\(\\$1\\)").WithArgs(lockKeyArg{}).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := m.Goto(context.Background(), 1); err != nil {
+		t.Fatalf("Goto() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// lockKeyArg matches the advisory lock key, which is derived from lockName.
+type lockKeyArg struct{}
+
+func (lockKeyArg) Match(v driver.Value) bool {
+	_, ok := v.(int64)
+	return ok
+}
+
+func Test_migrator_Goto(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		target     uint
+		beforeFunc func(mock sqlmock.Sqlmock)
+		wantErr    bool
+	}{
+		{
+			name:   "up from an empty database",
+			target: 2,
+			beforeFunc: func(mock sqlmock.Sqlmock) {
+				expectLock(mock, 0, false)
+				expectVersion(mock, 1, true)
+				mock.ExpectExec("CREATE TABLE a \\(id INT\\)").WillReturnResult(sqlmock.NewResult(0, 0))
+				expectVersion(mock, 1, false)
+				expectVersion(mock, 2, true)
+				mock.ExpectExec("CREATE TABLE b \\(id INT\\)").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("CREATE TABLE c \\(id INT\\)").WillReturnResult(sqlmock.NewResult(0, 0))
+				expectVersion(mock, 2, false)
+				expectUnlock(mock)
+			},
+		},
+		{
+			name:   "down to nothing",
+			target: 0,
+			beforeFunc: func(mock sqlmock.Sqlmock) {
+				expectLock(mock, 2, false)
+				expectVersion(mock, 1, true)
+				mock.ExpectExec("DROP TABLE c").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("DROP TABLE b").WillReturnResult(sqlmock.NewResult(0, 0))
+				expectVersion(mock, 1, false)
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM schema_migrations").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO schema_migrations").WithArgs(nilVersion, true).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectExec("DROP TABLE a").WillReturnResult(sqlmock.NewResult(0, 0))
+				expectVersion(mock, 0, false)
+				expectUnlock(mock)
+			},
+		},
+		{
+			name:   "failed statement leaves the version dirty",
+			target: 2,
+			beforeFunc: func(mock sqlmock.Sqlmock) {
+				expectLock(mock, 1, false)
+				expectVersion(mock, 2, true)
+				mock.ExpectExec("CREATE TABLE b \\(id INT\\)").WillReturnError(errors.New("foo"))
+				expectUnlock(mock)
+			},
+			wantErr: true,
+		},
+		{
+			name:   "dirty database",
+			target: 2,
+			beforeFunc: func(mock sqlmock.Sqlmock) {
+				expectLock(mock, 1, true)
+				expectUnlock(mock)
+			},
+			wantErr: true,
+		},
+		{
+			name:   "unknown database version",
+			target: 1,
+			beforeFunc: func(mock sqlmock.Sqlmock) {
+				expectLock(mock, 9, false)
+				expectUnlock(mock)
+			},
+			wantErr: true,
+		},
+		{
+			name:       "unknown target",
+			target:     3,
+			beforeFunc: func(mock sqlmock.Sqlmock) {},
+			wantErr:    true,
+		},
+		{
+			name:   "lock held by another instance",
+			target: 2,
+			beforeFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT GET_LOCK").WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(0))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+			m, err := newMigrator(&infra.Database{DB: db, Dialect: infra.DialectMySQL}, zerolog.Logger{}, testFiles)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tt.beforeFunc(mock)
+			if err := m.Goto(ctx, tt.target); (err != nil) != tt.wantErr {
+				t.Errorf("migrator.Goto() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func Test_migrator_Down(t *testing.T) {
+
+	ctx := context.Background()
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	m, err := newMigrator(&infra.Database{DB: db, Dialect: infra.DialectMySQL}, zerolog.Logger{}, testFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectLock(mock, 2, false)
+	expectVersion(mock, 1, true)
+	mock.ExpectExec("DROP TABLE c").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DROP TABLE b").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectVersion(mock, 1, false)
+	expectUnlock(mock)
+
+	if err := m.Down(ctx, 1); err != nil {
+		t.Errorf("migrator.Down() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_migrator_Status(t *testing.T) {
+
+	ctx := context.Background()
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	m, err := newMigrator(&infra.Database{DB: db, Dialect: infra.DialectMySQL}, zerolog.Logger{}, testFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectLock(mock, 1, false)
+	expectUnlock(mock)
+
+	status, current, dirty, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Status{{Version: 1, Name: "create_a", Applied: true}, {Version: 2, Name: "create_b", Applied: false}}
+	if !reflect.DeepEqual(status, want) || current != 1 || dirty {
+		t.Errorf("migrator.Status() = %v, %v, %v", status, current, dirty)
+	}
+}
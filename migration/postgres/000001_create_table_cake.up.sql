@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS cake (
+	id SERIAL PRIMARY KEY,
+	title VARCHAR(100) NOT NULL,
+	description TEXT NOT NULL,
+	image VARCHAR(255) NOT NULL DEFAULT '',
+	rating REAL NOT NULL DEFAULT 0,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP
+);
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS webhook (
+	id SERIAL PRIMARY KEY,
+	url VARCHAR(2048) NOT NULL,
+	secret VARCHAR(255) NOT NULL,
+	events VARCHAR(255) NOT NULL,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NULL
+);
+
+CREATE TABLE IF NOT EXISTS webhook_delivery (
+	id SERIAL PRIMARY KEY,
+	webhook_id INT NOT NULL REFERENCES webhook (id) ON DELETE CASCADE,
+	event_id CHAR(36) NOT NULL,
+	event VARCHAR(64) NOT NULL,
+	payload TEXT NOT NULL,
+	status VARCHAR(16) NOT NULL DEFAULT 'pending',
+	attempts INT NOT NULL DEFAULT 0,
+	next_attempt_at TIMESTAMP NULL,
+	response_code INT,
+	response_body TEXT,
+	last_error TEXT,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_webhook_id ON webhook_delivery (webhook_id, id);
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_due ON webhook_delivery (status, next_attempt_at);
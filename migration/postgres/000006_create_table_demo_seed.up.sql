@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS demo_seed (
+	seed BIGINT PRIMARY KEY,
+	count INT NOT NULL,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS idempotency_key (
+	key VARCHAR(255) PRIMARY KEY,
+	fingerprint CHAR(64) NOT NULL,
+	status_code INT NOT NULL DEFAULT 0,
+	header TEXT,
+	body BLOB,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_key_expires_at ON idempotency_key (expires_at);
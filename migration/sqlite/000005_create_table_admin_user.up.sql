@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS admin_user (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name VARCHAR(64) NOT NULL UNIQUE,
+	token_hash CHAR(64) NOT NULL UNIQUE,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,67 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/infra"
+)
+
+// AdminUserModel is an operator allowed to call the admin endpoints,
+// TokenHash is the hex encoded SHA-256 of its API token.
+type AdminUserModel struct {
+	ID        int       `db:"id"`
+	Name      string    `db:"name"`
+	TokenHash string    `db:"token_hash"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type AdminUser interface {
+	Create(ctx context.Context, input AdminUserModel) (bool, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (AdminUserModel, error)
+}
+
+type adminUser struct {
+	Log zerolog.Logger
+	DB  *infra.Database
+}
+
+func NewAdminUser(db *infra.Database, log zerolog.Logger) AdminUser {
+	return &adminUser{
+		DB:  db,
+		Log: log,
+	}
+}
+
+// Create stores a new admin user, it returns false when the name is taken.
+func (a *adminUser) Create(ctx context.Context, input AdminUserModel) (created bool, err error) {
+
+	query := a.DB.Dialect.InsertIgnore("INSERT INTO admin_user (name, token_hash, created_at) VALUES (?, ?, ?)")
+
+	res, err := querier(ctx, a.DB).ExecContext(ctx, query, input.Name, input.TokenHash, input.CreatedAt)
+	if err != nil {
+		a.Log.Error().Msg(err.Error())
+		return
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		a.Log.Error().Msg(err.Error())
+		return
+	}
+
+	return affected > 0, nil
+}
+
+func (a *adminUser) GetByTokenHash(ctx context.Context, tokenHash string) (output AdminUserModel, err error) {
+
+	row := querier(ctx, a.DB).QueryRowContext(ctx, "SELECT id, name, token_hash, created_at FROM admin_user WHERE token_hash = ?", tokenHash)
+	err = row.Scan(&output.ID, &output.Name, &output.TokenHash, &output.CreatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		a.Log.Error().Msg(err.Error())
+	}
+
+	return
+}
@@ -0,0 +1,114 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+)
+
+func Test_adminUser_Create(t *testing.T) {
+
+	ctx := context.Background()
+	now := time.Now()
+	query := "INSERT IGNORE INTO admin_user \\(name, token_hash, created_at\\) VALUES \\(\\?, \\?, \\?\\)"
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	repo := NewAdminUser(mysqlDatabase(db), zerolog.Logger{})
+
+	input := AdminUserModel{Name: "alice", TokenHash: "hash", CreatedAt: now}
+
+	tests := []struct {
+		name       string
+		beforeFunc func()
+		want       bool
+		wantErr    bool
+	}{
+		{
+			name: "success",
+			beforeFunc: func() {
+				mock.ExpectExec(query).WithArgs("alice", "hash", now).WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			want: true,
+		},
+		{
+			name: "name taken",
+			beforeFunc: func() {
+				mock.ExpectExec(query).WithArgs("alice", "hash", now).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			want: false,
+		},
+		{
+			name: "error",
+			beforeFunc: func() {
+				mock.ExpectExec(query).WillReturnError(errors.New("foo"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.beforeFunc()
+			got, err := repo.Create(ctx, input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("adminUser.Create() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("adminUser.Create() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_adminUser_GetByTokenHash(t *testing.T) {
+
+	ctx := context.Background()
+	now := time.Now()
+	query := "SELECT id, name, token_hash, created_at FROM admin_user WHERE token_hash = \\?"
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	repo := NewAdminUser(mysqlDatabase(db), zerolog.Logger{})
+
+	columns := []string{"id", "name", "token_hash", "created_at"}
+
+	tests := []struct {
+		name       string
+		beforeFunc func()
+		want       AdminUserModel
+		wantErr    error
+	}{
+		{
+			name: "success",
+			beforeFunc: func() {
+				mock.ExpectQuery(query).WithArgs("hash").WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "alice", "hash", now))
+			},
+			want: AdminUserModel{ID: 1, Name: "alice", TokenHash: "hash", CreatedAt: now},
+		},
+		{
+			name: "not found",
+			beforeFunc: func() {
+				mock.ExpectQuery(query).WithArgs("hash").WillReturnRows(sqlmock.NewRows(columns))
+			},
+			wantErr: sql.ErrNoRows,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.beforeFunc()
+			got, err := repo.GetByTokenHash(ctx, "hash")
+			if err != tt.wantErr {
+				t.Errorf("adminUser.GetByTokenHash() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("adminUser.GetByTokenHash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/infra"
 )
 
 type CakeBaseModel struct {
@@ -20,60 +21,127 @@ type CakeBaseModel struct {
 	UpdatedAt   sql.NullTime `db:"updated_at"`
 }
 
+// CakeUpdateModel holds the columns to change, nil fields are left untouched.
+type CakeUpdateModel struct {
+	ID          int
+	Title       *string
+	Description *string
+	Image       *string
+	Rating      *float32
+	UpdatedAt   sql.NullTime
+}
+
 type Cake interface {
-	Create(ctx context.Context, input CakeBaseModel) error
+	Create(ctx context.Context, input CakeBaseModel) (int, error)
+	CreateBatch(ctx context.Context, input []CakeBaseModel) ([]int, error)
 	GetList(ctx context.Context, limit, offset int, search, sort, sortBy string) ([]CakeBaseModel, error)
+	Stream(ctx context.Context, search, sort, sortBy string, fn func(CakeBaseModel) error) error
 	GetDetail(ctx context.Context, id int) (CakeBaseModel, error)
-	Update(ctx context.Context, input CakeBaseModel) error
+	GetByIDs(ctx context.Context, ids []int) ([]CakeBaseModel, error)
+	Update(ctx context.Context, input CakeUpdateModel) error
 	CountCake(ctx context.Context, search string) (count int, err error)
 	Delete(ctx context.Context, id int) error
-	Close()
+	BulkUpdate(ctx context.Context, filter BulkFilter, input CakeUpdateModel, atomic bool) ([]BulkResult, error)
+	BulkDelete(ctx context.Context, filter BulkFilter, atomic bool) ([]BulkResult, error)
 }
 
 type cake struct {
-	Log   zerolog.Logger
-	MySQL *sql.DB
+	Log zerolog.Logger
+	DB  *infra.Database
 }
 
-func NewCake(mysql *sql.DB, log zerolog.Logger) Cake {
+func NewCake(db *infra.Database, log zerolog.Logger) Cake {
 	return &cake{
-		MySQL: mysql,
-		Log:   log,
+		DB:  db,
+		Log: log,
 	}
 }
 
-func (c *cake) Create(ctx context.Context, input CakeBaseModel) (err error) {
+// Create inserts the cake and returns its generated ID, the cake.created
+// event is written to the outbox in the same transaction.
+func (c *cake) Create(ctx context.Context, input CakeBaseModel) (id int, err error) {
+
+	err = c.withTx(ctx, func(ctx context.Context, tx DBTX) error {
 
-	query := "INSERT INTO cake (title, description, image, rating, created_at) VALUES (?, ?, ?, ?, ?)"
+		query := "INSERT INTO cake (title, description, image, rating, created_at) VALUES (?, ?, ?, ?, ?)"
+
+		lastID, err := insertID(ctx, tx, c.DB.Dialect, query, input.Title, input.Description, input.Image, input.Rating, input.CreatedAt)
+		if err != nil {
+			return err
+		}
 
-	stmt, err := c.MySQL.PrepareContext(ctx, query)
+		id = int(lastID)
+		input.ID = id
+		return writeOutbox(ctx, tx, OutboxCakeCreated, input.ID, outboxCake(input), input.CreatedAt)
+	})
 	if err != nil {
-		c.Log.Error().Msg(err.Error())
-		return
+		return 0, err
 	}
-	defer stmt.Close()
 
-	_, err = stmt.ExecContext(ctx, input.Title, input.Description, input.Image, input.Rating, input.CreatedAt)
+	return id, nil
+}
+
+// withTx runs fn through withinTx, so the cake write joins the transaction of
+// ctx when there is one.
+func (c *cake) withTx(ctx context.Context, fn func(ctx context.Context, tx DBTX) error) (err error) {
+
+	err = withinTx(ctx, c.DB, c.Log, func(ctx context.Context) error {
+		return fn(ctx, querier(ctx, c.DB))
+	})
 	if err != nil {
 		c.Log.Error().Msg(err.Error())
-		return
 	}
 
-	return nil
+	return
 }
 
-func (c *cake) GetList(ctx context.Context, limit, offset int, search, sort, sortBy string) (output []CakeBaseModel, err error) {
-
-	query := "SELECT id, title, description, image, rating, created_at, updated_at FROM cake"
+// CreateBatch inserts all cakes inside a single transaction and returns their
+// IDs in the order of input, either every cake is stored or none is. A
+// cake.created event is written to the outbox for every cake.
+//
+// The cakes are inserted one by one: the IDs of a multi-row INSERT are not
+// consecutive under concurrent inserts, e.g. with the interleaved
+// auto-increment lock mode of MySQL 8.
+func (c *cake) CreateBatch(ctx context.Context, input []CakeBaseModel) (ids []int, err error) {
+
+	err = c.withTx(ctx, func(ctx context.Context, tx DBTX) error {
+
+		ids = make([]int, 0, len(input))
+		for _, cake := range input {
+			id, err := insertID(ctx, tx, c.DB.Dialect, "INSERT INTO cake (title, description, image, rating, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+				cake.Title, cake.Description, cake.Image, cake.Rating, cake.CreatedAt, cake.UpdatedAt)
+			if err != nil {
+				return err
+			}
+
+			cake.ID = int(id)
+			if err = writeOutbox(ctx, tx, OutboxCakeCreated, cake.ID, outboxCake(cake), cake.CreatedAt); err != nil {
+				return err
+			}
+			ids = append(ids, cake.ID)
+		}
 
-	if search != "" {
-		query += fmt.Sprintf(" WHERE title LIKE '%s%s%s' ", "%", search, "%")
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	query += fmt.Sprintf(" ORDER BY %s %s ", sort, sortBy)
+	return ids, nil
+}
+
+// GetList, CountCake and GetDetail read from a replica when there is a
+// healthy one, see reader.
+func (c *cake) GetList(ctx context.Context, limit, offset int, search, sort, sortBy string) (output []CakeBaseModel, err error) {
+
+	query, args, err := c.listQuery(search, sort, sortBy)
+	if err != nil {
+		c.Log.Error().Msg(err.Error())
+		return
+	}
 	query += fmt.Sprintf(" LIMIT %d OFFSET %d ", limit, offset)
 
-	rows, err := c.MySQL.QueryContext(ctx, query)
+	rows, err := reader(ctx, c.DB).QueryContext(ctx, query, args...)
 	if err != nil {
 		c.Log.Error().Msg(err.Error())
 		return
@@ -94,36 +162,39 @@ func (c *cake) GetList(ctx context.Context, limit, offset int, search, sort, sor
 
 }
 
-func (c *cake) CountCake(ctx context.Context, search string) (count int, err error) {
-
-	query := "SELECT count(id) FROM cake"
+// Stream calls fn for every cake matching the filter while reading them from
+// a cursor, so the whole table is never held in memory.
+func (c *cake) Stream(ctx context.Context, search, sort, sortBy string, fn func(CakeBaseModel) error) (err error) {
 
-	if search != "" {
-		query += fmt.Sprintf(" WHERE title LIKE '%s%s%s' ", "%", search, "%")
-	}
-
-	stmt, err := c.MySQL.Prepare(query)
+	query, args, err := c.listQuery(search, sort, sortBy)
 	if err != nil {
 		c.Log.Error().Msg(err.Error())
 		return
 	}
 
-	row := stmt.QueryRowContext(ctx)
-	err = row.Scan(&count)
+	rows, err := querier(ctx, c.DB).QueryContext(ctx, query, args...)
 	if err != nil {
 		c.Log.Error().Msg(err.Error())
 		return
 	}
 
-	return
-}
+	defer rows.Close()
 
-func (c *cake) GetDetail(ctx context.Context, id int) (output CakeBaseModel, err error) {
+	for rows.Next() {
+		cake := CakeBaseModel{}
+		err = rows.Scan(&cake.ID, &cake.Title, &cake.Description, &cake.Image, &cake.Rating, &cake.CreatedAt, &cake.UpdatedAt)
+		if err != nil {
+			c.Log.Error().Msg(err.Error())
+			return
+		}
 
-	query := "SELECT id, title, description, image, rating, created_at, updated_at FROM cake WHERE id = ?"
+		err = fn(cake)
+		if err != nil {
+			return
+		}
+	}
 
-	row := c.MySQL.QueryRowContext(ctx, query, id)
-	err = row.Scan(&output.ID, &output.Title, &output.Description, &output.Image, &output.Rating, &output.CreatedAt, &output.UpdatedAt)
+	err = rows.Err()
 	if err != nil {
 		c.Log.Error().Msg(err.Error())
 		return
@@ -132,46 +203,111 @@ func (c *cake) GetDetail(ctx context.Context, id int) (output CakeBaseModel, err
 	return
 }
 
-func (c *cake) Update(ctx context.Context, input CakeBaseModel) (err error) {
-
-	fields := []string{}
-	values := []interface{}{}
+// listQuery returns the query of the cakes matching search ordered by the
+// ORDER BY clause "sort sortBy". The clause is parsed and written back, so
+// only known columns and directions reach the query.
+func (c *cake) listQuery(search, sort, sortBy string) (string, []interface{}, error) {
 
-	if input.Title != "" {
-		fields = append(fields, "title = ?")
-		values = append(values, input.Title)
+	orders, err := parseOrderBy(sort + " " + sortBy)
+	if err != nil {
+		return "", nil, err
 	}
 
-	if input.Description != "" {
-		fields = append(fields, "description = ?")
-		values = append(values, input.Description)
+	terms := make([]string, len(orders))
+	for i, order := range orders {
+		terms[i] = order.String()
 	}
 
-	if input.Image != "" {
-		fields = append(fields, "image = ?")
-		values = append(values, input.Image)
+	query := "SELECT id, title, description, image, rating, created_at, updated_at FROM cake"
+	where, args := c.searchFilter(search)
+
+	query += where + " ORDER BY " + strings.Join(terms, ", ")
+	return query, args, nil
+}
+
+// cakeSortColumns maps the names a list can be sorted by to their column,
+// only these columns ever reach an ORDER BY clause.
+var cakeSortColumns = map[string]string{
+	"id":          "id",
+	"title":       "title",
+	"description": "description",
+	"image":       "image",
+	"rating":      "rating",
+	"created_at":  "created_at",
+	"updated_at":  "updated_at",
+}
+
+// cakeOrder is a term of an ORDER BY clause.
+type cakeOrder struct {
+	column string
+	desc   bool
+}
+
+func (o cakeOrder) String() string {
+	if o.desc {
+		return o.column + " DESC"
 	}
+	return o.column + " ASC"
+}
 
-	if input.Rating != 0 {
-		fields = append(fields, "rating = ?")
-		values = append(values, input.Rating)
+// parseOrderBy reads an ORDER BY clause such as "rating DESC, title", a term
+// without a direction is ascending. Names are resolved by cakeSortColumns.
+func parseOrderBy(orderBy string) (orders []cakeOrder, err error) {
+
+	for _, term := range strings.Split(orderBy, ",") {
+
+		fields := strings.Fields(term)
+		if len(fields) == 0 || len(fields) > 2 {
+			return nil, fmt.Errorf("invalid order %q", term)
+		}
+
+		column, ok := cakeSortColumns[strings.ToLower(fields[0])]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q", fields[0])
+		}
+		order := cakeOrder{column: column}
+
+		if len(fields) == 2 {
+			switch strings.ToUpper(fields[1]) {
+			case "ASC":
+			case "DESC":
+				order.desc = true
+			default:
+				return nil, fmt.Errorf("invalid order %q", term)
+			}
+		}
+
+		orders = append(orders, order)
 	}
 
-	if input.UpdatedAt.Valid {
-		fields = append(fields, "updated_at = ?")
-		values = append(values, input.UpdatedAt)
+	return orders, nil
+}
+
+// searchFilter returns the WHERE clause matching search anywhere in the
+// title regardless of case, empty when there is no search.
+func (c *cake) searchFilter(search string) (string, []interface{}) {
+
+	if search == "" {
+		return "", nil
 	}
 
-	query := fmt.Sprintf("UPDATE cake SET %s WHERE id = %d", strings.Join(fields, ", "), input.ID)
+	return fmt.Sprintf(" WHERE title %s ?", c.DB.Dialect.ILike()), []interface{}{"%" + search + "%"}
+}
+
+func (c *cake) CountCake(ctx context.Context, search string) (count int, err error) {
+
+	where, args := c.searchFilter(search)
+	query := "SELECT count(id) FROM cake" + where
 
-	stmt, err := c.MySQL.PrepareContext(ctx, query)
+	stmt, err := reader(ctx, c.DB).PrepareContext(ctx, query)
 	if err != nil {
 		c.Log.Error().Msg(err.Error())
 		return
 	}
 	defer stmt.Close()
 
-	_, err = stmt.ExecContext(ctx, values...)
+	row := stmt.QueryRowContext(ctx, args...)
+	err = row.Scan(&count)
 	if err != nil {
 		c.Log.Error().Msg(err.Error())
 		return
@@ -180,26 +316,164 @@ func (c *cake) Update(ctx context.Context, input CakeBaseModel) (err error) {
 	return
 }
 
-func (c *cake) Delete(ctx context.Context, id int) (err error) {
+func (c *cake) GetDetail(ctx context.Context, id int) (output CakeBaseModel, err error) {
 
-	query := "DELETE FROM cake WHERE id = ?"
+	query := "SELECT id, title, description, image, rating, created_at, updated_at FROM cake WHERE id = ?"
 
-	stmt, err := c.MySQL.PrepareContext(ctx, query)
+	row := reader(ctx, c.DB).QueryRowContext(ctx, query, id)
+	err = row.Scan(&output.ID, &output.Title, &output.Description, &output.Image, &output.Rating, &output.CreatedAt, &output.UpdatedAt)
 	if err != nil {
 		c.Log.Error().Msg(err.Error())
 		return
 	}
-	defer stmt.Close()
 
-	_, err = stmt.ExecContext(ctx, id)
+	return
+}
+
+// GetByIDs reads every cake of ids in a single query, ids without a cake are
+// left out of the output.
+func (c *cake) GetByIDs(ctx context.Context, ids []int) (output []CakeBaseModel, err error) {
+
+	if len(ids) == 0 {
+		return
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	query := fmt.Sprintf("SELECT id, title, description, image, rating, created_at, updated_at FROM cake WHERE id IN (%s)", strings.Join(placeholders, ", "))
+
+	rows, err := querier(ctx, c.DB).QueryContext(ctx, query, args...)
 	if err != nil {
 		c.Log.Error().Msg(err.Error())
 		return
 	}
 
+	defer rows.Close()
+
+	for rows.Next() {
+		cake := CakeBaseModel{}
+		err = rows.Scan(&cake.ID, &cake.Title, &cake.Description, &cake.Image, &cake.Rating, &cake.CreatedAt, &cake.UpdatedAt)
+		if err != nil {
+			c.Log.Error().Msg(err.Error())
+			return
+		}
+		output = append(output, cake)
+	}
+
+	return output, rows.Err()
+}
+
+// Update changes the cake and writes the cake.updated event with the stored
+// cake to the outbox in the same transaction, nothing is written when no cake
+// has the ID.
+func (c *cake) Update(ctx context.Context, input CakeUpdateModel) (err error) {
+
+	fields, values := updateFields(input)
+
+	return c.withTx(ctx, func(ctx context.Context, tx DBTX) error {
+
+		query := fmt.Sprintf("UPDATE cake SET %s WHERE id = %d", strings.Join(fields, ", "), input.ID)
+
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		_, err = stmt.ExecContext(ctx, values...)
+		if err != nil {
+			return err
+		}
+
+		return writeUpdatedOutbox(ctx, tx, input)
+	})
+}
+
+// writeUpdatedOutbox writes the cake.updated event with the cake stored for
+// input.ID, nothing is written when there is none.
+func writeUpdatedOutbox(ctx context.Context, tx DBTX, input CakeUpdateModel) error {
+
+	cake := CakeBaseModel{}
+	query := "SELECT id, title, description, image, rating, created_at, updated_at FROM cake WHERE id = ?"
+	err := tx.QueryRowContext(ctx, query, input.ID).Scan(&cake.ID, &cake.Title, &cake.Description, &cake.Image, &cake.Rating, &cake.CreatedAt, &cake.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	createdAt := time.Now()
+	if input.UpdatedAt.Valid {
+		createdAt = input.UpdatedAt.Time
+	}
+
+	return writeOutbox(ctx, tx, OutboxCakeUpdated, cake.ID, outboxCake(cake), createdAt)
+}
+
+// updateFields returns the SET expressions and values for every non nil field.
+func updateFields(input CakeUpdateModel) (fields []string, values []interface{}) {
+
+	if input.Title != nil {
+		fields = append(fields, "title = ?")
+		values = append(values, *input.Title)
+	}
+
+	if input.Description != nil {
+		fields = append(fields, "description = ?")
+		values = append(values, *input.Description)
+	}
+
+	if input.Image != nil {
+		fields = append(fields, "image = ?")
+		values = append(values, *input.Image)
+	}
+
+	if input.Rating != nil {
+		fields = append(fields, "rating = ?")
+		values = append(values, *input.Rating)
+	}
+
+	if input.UpdatedAt.Valid {
+		fields = append(fields, "updated_at = ?")
+		values = append(values, input.UpdatedAt)
+	}
+
 	return
 }
 
-func (r *cake) Close() {
-	r.MySQL.Close()
+// Delete removes the cake and writes the cake.deleted event to the outbox in
+// the same transaction, it is sql.ErrNoRows when no cake has the ID.
+func (c *cake) Delete(ctx context.Context, id int) (err error) {
+
+	return c.withTx(ctx, func(ctx context.Context, tx DBTX) error {
+
+		query := "DELETE FROM cake WHERE id = ?"
+
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		res, err := stmt.ExecContext(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return sql.ErrNoRows
+		}
+
+		return writeOutbox(ctx, tx, OutboxCakeDeleted, id, OutboxCakeKey{ID: id}, time.Now())
+	})
 }
@@ -0,0 +1,150 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrBulkRolledBack is returned with the per cake results when an atomic bulk
+// operation had at least one failure and nothing was committed.
+var ErrBulkRolledBack = errors.New("bulk operation rolled back")
+
+// BulkFilter selects the cakes of a bulk operation, either by IDs or, when no
+// IDs are given, by the same title search as GetList.
+type BulkFilter struct {
+	IDs    []int
+	Search string
+}
+
+// BulkResult is the outcome of a bulk operation for a single cake, Found is
+// false when no cake has the ID.
+type BulkResult struct {
+	ID    int
+	Found bool
+	Err   error
+}
+
+func (c *cake) BulkUpdate(ctx context.Context, filter BulkFilter, input CakeUpdateModel, atomic bool) ([]BulkResult, error) {
+
+	fields, values := updateFields(input)
+	if len(fields) == 0 {
+		return nil, errors.New("nothing to update")
+	}
+
+	query := fmt.Sprintf("UPDATE cake SET %s WHERE id = ?", strings.Join(fields, ", "))
+	return c.bulkExec(ctx, filter, atomic, query, values, func(ctx context.Context, tx DBTX, id int) error {
+		input.ID = id
+		return writeUpdatedOutbox(ctx, tx, input)
+	})
+}
+
+func (c *cake) BulkDelete(ctx context.Context, filter BulkFilter, atomic bool) ([]BulkResult, error) {
+	return c.bulkExec(ctx, filter, atomic, "DELETE FROM cake WHERE id = ?", nil, func(ctx context.Context, tx DBTX, id int) error {
+		return writeOutbox(ctx, tx, OutboxCakeDeleted, id, OutboxCakeKey{ID: id}, time.Now())
+	})
+}
+
+// bulkExec runs query once per selected cake inside a single transaction,
+// each in its own savepoint so a failed cake does not abort the statements
+// of the next ones. The event of a found cake is written to the outbox by
+// outbox in the same savepoint. In atomic mode every cake is still attempted
+// so the results are complete, but the transaction is rolled back when any of
+// them failed or was not found.
+func (c *cake) bulkExec(ctx context.Context, filter BulkFilter, atomic bool, query string, values []interface{}, outbox func(ctx context.Context, tx DBTX, id int) error) (results []BulkResult, err error) {
+
+	err = withinTx(ctx, c.DB, c.Log, func(ctx context.Context) error {
+
+		// a retried transaction starts over
+		results = nil
+		tx := querier(ctx, c.DB)
+
+		var err error
+		ids := filter.IDs
+		if len(ids) == 0 {
+			ids, err = c.bulkIDs(ctx, tx, filter.Search)
+			if err != nil {
+				return err
+			}
+		}
+
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		failed := false
+		for _, id := range ids {
+			result := BulkResult{ID: id}
+
+			execErr := withinTx(ctx, c.DB, c.Log, func(ctx context.Context) error {
+				args := append(append([]interface{}{}, values...), id)
+				res, err := stmt.ExecContext(ctx, args...)
+				if err != nil {
+					return err
+				}
+
+				affected, err := res.RowsAffected()
+				if err != nil || affected == 0 {
+					return err
+				}
+
+				result.Found = true
+				return outbox(ctx, querier(ctx, c.DB), id)
+			})
+
+			// a deadlock or lock timeout runs the whole transaction again
+			if isRetryable(execErr) {
+				return execErr
+			}
+
+			if execErr != nil {
+				c.Log.Error().Msg(execErr.Error())
+			}
+
+			result.Err = execErr
+			failed = failed || execErr != nil || !result.Found
+			results = append(results, result)
+		}
+
+		if atomic && failed {
+			return ErrBulkRolledBack
+		}
+
+		return nil
+	})
+
+	if err == ErrBulkRolledBack {
+		return results, err
+	}
+	if err != nil {
+		c.Log.Error().Msg(err.Error())
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (c *cake) bulkIDs(ctx context.Context, tx DBTX, search string) (ids []int, err error) {
+
+	where, args := c.searchFilter(search)
+
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM cake"+where+" ORDER BY id"+c.DB.Dialect.ForUpdate(), args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
@@ -0,0 +1,244 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func Test_cake_BulkUpdate(t *testing.T) {
+
+	ctx := context.Background()
+	query := "UPDATE cake SET title = \\?, rating = \\? WHERE id = \\?"
+	outboxQuery := "INSERT INTO outbox \\(event_id, aggregate_id, event, payload, created_at\\) VALUES \\(\\?, \\?, \\?, \\?, \\?\\)"
+	cake, mock := NewMockCake()
+
+	title, rating := "test", float32(2)
+	input := CakeUpdateModel{Title: &title, Rating: &rating}
+
+	// expectEvent expects the stored cake to be read for its cake.updated event
+	expectEvent := func(id int) {
+		mock.ExpectQuery("SELECT id, title, description, image, rating, created_at, updated_at FROM cake WHERE id = \\?").WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description", "image", "rating", "created_at", "updated_at"}).
+				AddRow(id, title, "", "", rating, time.Now(), time.Now()))
+		mock.ExpectExec(outboxQuery).WithArgs(sqlmock.AnyArg(), id, OutboxCakeUpdated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+
+	type args struct {
+		filter BulkFilter
+		input  CakeUpdateModel
+		atomic bool
+	}
+	tests := []struct {
+		name        string
+		args        args
+		beforeFunc  func()
+		wantResults []BulkResult
+		wantErr     error
+	}{
+		{
+			name: "success by ids",
+			args: args{filter: BulkFilter{IDs: []int{1, 2}}, input: input},
+			beforeFunc: func() {
+				mock.ExpectBegin()
+				prep := mock.ExpectPrepare(query)
+				mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				prep.ExpectExec().WithArgs("test", float32(2), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectEvent(1)
+				mock.ExpectExec("RELEASE SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				prep.ExpectExec().WithArgs("test", float32(2), 2).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("RELEASE SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			wantResults: []BulkResult{{ID: 1, Found: true}, {ID: 2, Found: false}},
+		},
+		{
+			name: "success by filter",
+			args: args{filter: BulkFilter{Search: "chess"}, input: input},
+			beforeFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT id FROM cake WHERE title LIKE \\? ORDER BY id FOR UPDATE").
+					WithArgs("%chess%").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+				prep := mock.ExpectPrepare(query)
+				mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				prep.ExpectExec().WithArgs("test", float32(2), 3).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectEvent(3)
+				mock.ExpectExec("RELEASE SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			wantResults: []BulkResult{{ID: 3, Found: true}},
+		},
+		{
+			name: "atomic rolls back on not found",
+			args: args{filter: BulkFilter{IDs: []int{1, 2}}, input: input, atomic: true},
+			beforeFunc: func() {
+				mock.ExpectBegin()
+				prep := mock.ExpectPrepare(query)
+				mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				prep.ExpectExec().WithArgs("test", float32(2), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectEvent(1)
+				mock.ExpectExec("RELEASE SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				prep.ExpectExec().WithArgs("test", float32(2), 2).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("RELEASE SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantResults: []BulkResult{{ID: 1, Found: true}, {ID: 2, Found: false}},
+			wantErr:     ErrBulkRolledBack,
+		},
+		{
+			name: "error begin transaction",
+			args: args{filter: BulkFilter{IDs: []int{1}}, input: input},
+			beforeFunc: func() {
+				mock.ExpectBegin().WillReturnError(errors.New("foo"))
+			},
+			wantErr: errors.New("foo"),
+		},
+		{
+			name: "error filter query",
+			args: args{filter: BulkFilter{}, input: input},
+			beforeFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT id FROM cake ORDER BY id FOR UPDATE").WillReturnError(errors.New("foo"))
+				mock.ExpectRollback()
+			},
+			wantErr: errors.New("foo"),
+		},
+		{
+			name: "error prepare statement",
+			args: args{filter: BulkFilter{IDs: []int{1}}, input: input},
+			beforeFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(query).WillReturnError(errors.New("foo"))
+				mock.ExpectRollback()
+			},
+			wantErr: errors.New("foo"),
+		},
+		{
+			name: "error commit",
+			args: args{filter: BulkFilter{IDs: []int{1}}, input: input},
+			beforeFunc: func() {
+				mock.ExpectBegin()
+				prep := mock.ExpectPrepare(query)
+				mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				prep.ExpectExec().WithArgs("test", float32(2), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectEvent(1)
+				mock.ExpectExec("RELEASE SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit().WillReturnError(errors.New("foo"))
+			},
+			wantErr: errors.New("foo"),
+		},
+		{
+			name:       "error nothing to update",
+			args:       args{filter: BulkFilter{IDs: []int{1}}},
+			beforeFunc: func() {},
+			wantErr:    errors.New("nothing to update"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.beforeFunc()
+			gotResults, err := cake.BulkUpdate(ctx, tt.args.filter, tt.args.input, tt.args.atomic)
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("cake.BulkUpdate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotResults, tt.wantResults) {
+				t.Errorf("cake.BulkUpdate() = %v, want %v", gotResults, tt.wantResults)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("cake.BulkUpdate() unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func Test_cake_BulkDelete(t *testing.T) {
+
+	ctx := context.Background()
+	query := "DELETE FROM cake WHERE id = \\?"
+	outboxQuery := "INSERT INTO outbox \\(event_id, aggregate_id, event, payload, created_at\\) VALUES \\(\\?, \\?, \\?, \\?, \\?\\)"
+	cake, mock := NewMockCake()
+
+	tests := []struct {
+		name        string
+		atomic      bool
+		beforeFunc  func()
+		wantResults []BulkResult
+		wantErr     error
+	}{
+		{
+			name: "success keeps going after a failure",
+			beforeFunc: func() {
+				mock.ExpectBegin()
+				prep := mock.ExpectPrepare(query)
+				mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				prep.ExpectExec().WithArgs(1).WillReturnError(errors.New("foo"))
+				mock.ExpectExec("ROLLBACK TO SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				prep.ExpectExec().WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(outboxQuery).WithArgs(sqlmock.AnyArg(), 2, OutboxCakeDeleted, `{"id":2}`, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("RELEASE SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			wantResults: []BulkResult{{ID: 1, Err: errors.New("foo")}, {ID: 2, Found: true}},
+		},
+		{
+			name: "failed event keeps the cake",
+			beforeFunc: func() {
+				mock.ExpectBegin()
+				prep := mock.ExpectPrepare(query)
+				mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				prep.ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(outboxQuery).WillReturnError(errors.New("foo"))
+				mock.ExpectExec("ROLLBACK TO SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				prep.ExpectExec().WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("RELEASE SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			wantResults: []BulkResult{{ID: 1, Found: true, Err: errors.New("foo")}, {ID: 2}},
+		},
+		{
+			name:   "atomic rolls back on failure",
+			atomic: true,
+			beforeFunc: func() {
+				mock.ExpectBegin()
+				prep := mock.ExpectPrepare(query)
+				mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				prep.ExpectExec().WithArgs(1).WillReturnError(errors.New("foo"))
+				mock.ExpectExec("ROLLBACK TO SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				prep.ExpectExec().WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(outboxQuery).WithArgs(sqlmock.AnyArg(), 2, OutboxCakeDeleted, `{"id":2}`, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("RELEASE SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantResults: []BulkResult{{ID: 1, Err: errors.New("foo")}, {ID: 2, Found: true}},
+			wantErr:     ErrBulkRolledBack,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.beforeFunc()
+			gotResults, err := cake.BulkDelete(ctx, BulkFilter{IDs: []int{1, 2}}, tt.atomic)
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("cake.BulkDelete() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotResults, tt.wantResults) {
+				t.Errorf("cake.BulkDelete() = %v, want %v", gotResults, tt.wantResults)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("cake.BulkDelete() unmet expectations: %v", err)
+			}
+		})
+	}
+}
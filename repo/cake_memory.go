@@ -0,0 +1,440 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/rs/zerolog"
+)
+
+// memoryCake keeps the cakes in process, it is meant for development and
+// tests. It answers like the SQL repository: the search matches like ILIKE,
+// a missing cake is sql.ErrNoRows on read and delete and ignored on update,
+// and IDs are never reused. No outbox event is written and transactions are not joined.
+type memoryCake struct {
+	Log zerolog.Logger
+
+	mu     sync.RWMutex
+	cakes  map[int]CakeBaseModel
+	lastID int
+}
+
+func NewMemoryCake(log zerolog.Logger) Cake {
+	return &memoryCake{
+		Log:   log,
+		cakes: map[int]CakeBaseModel{},
+	}
+}
+
+func (m *memoryCake) Create(ctx context.Context, input CakeBaseModel) (int, error) {
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// like the INSERT, updated_at is left NULL
+	input.UpdatedAt = sql.NullTime{}
+	return m.insert(input), nil
+}
+
+func (m *memoryCake) insert(cake CakeBaseModel) int {
+	m.lastID++
+	cake.ID = m.lastID
+	m.cakes[cake.ID] = cake
+	return cake.ID
+}
+
+func (m *memoryCake) CreateBatch(ctx context.Context, input []CakeBaseModel) ([]int, error) {
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := make([]int, 0, len(input))
+	for _, cake := range input {
+		ids = append(ids, m.insert(cake))
+	}
+
+	return ids, nil
+}
+
+func (m *memoryCake) GetList(ctx context.Context, limit, offset int, search, sort, sortBy string) (output []CakeBaseModel, err error) {
+
+	cakes, err := m.list(ctx, search, sort, sortBy)
+	if err != nil {
+		m.Log.Error().Msg(err.Error())
+		return
+	}
+
+	if offset < 0 {
+		offset = 0
+	}
+	for i := offset; i < len(cakes) && i-offset < limit; i++ {
+		output = append(output, cakes[i])
+	}
+
+	return
+}
+
+// Stream calls fn outside the lock on the cakes matching when it was called,
+// so fn may use the repository.
+func (m *memoryCake) Stream(ctx context.Context, search, sort, sortBy string, fn func(CakeBaseModel) error) (err error) {
+
+	cakes, err := m.list(ctx, search, sort, sortBy)
+	if err != nil {
+		m.Log.Error().Msg(err.Error())
+		return
+	}
+
+	for _, cake := range cakes {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		if err = fn(cake); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// list returns the cakes matching search ordered by sort and sortBy, read
+// as the ORDER BY clause "sort sortBy" of the SQL repository.
+func (m *memoryCake) list(ctx context.Context, search, sort, sortBy string) ([]CakeBaseModel, error) {
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	orders, err := parseOrderBy(sort + " " + sortBy)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	cakes := m.matching(search)
+	m.mu.RUnlock()
+
+	sortCakes(cakes, orders)
+
+	return cakes, nil
+}
+
+// matching returns the cakes whose title matches search ordered by ID, the
+// caller holds the lock.
+func (m *memoryCake) matching(search string) []CakeBaseModel {
+
+	cakes := []CakeBaseModel{}
+	for _, cake := range m.cakes {
+		if search == "" || like(cake.Title, "%"+search+"%") {
+			cakes = append(cakes, cake)
+		}
+	}
+	sortCakes(cakes, nil)
+
+	return cakes
+}
+
+func (m *memoryCake) CountCake(ctx context.Context, search string) (count int, err error) {
+
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.matching(search)), nil
+}
+
+func (m *memoryCake) GetDetail(ctx context.Context, id int) (output CakeBaseModel, err error) {
+
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	output, ok := m.cakes[id]
+	if !ok {
+		return output, sql.ErrNoRows
+	}
+
+	return output, nil
+}
+
+func (m *memoryCake) GetByIDs(ctx context.Context, ids []int) (output []CakeBaseModel, err error) {
+
+	if err = ctx.Err(); err != nil || len(ids) == 0 {
+		return
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	seen := map[int]bool{}
+	for _, id := range ids {
+		cake, ok := m.cakes[id]
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		output = append(output, cake)
+	}
+	sortCakes(output, nil)
+
+	return output, nil
+}
+
+func (m *memoryCake) Update(ctx context.Context, input CakeUpdateModel) error {
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if fields, _ := updateFields(input); len(fields) == 0 {
+		return errors.New("nothing to update")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.update(input.ID, input)
+	return nil
+}
+
+// update applies the non nil fields of input to the cake of id and reports
+// whether there is one.
+func (m *memoryCake) update(id int, input CakeUpdateModel) bool {
+
+	cake, ok := m.cakes[id]
+	if !ok {
+		return false
+	}
+
+	if input.Title != nil {
+		cake.Title = *input.Title
+	}
+	if input.Description != nil {
+		cake.Description = *input.Description
+	}
+	if input.Image != nil {
+		cake.Image = *input.Image
+	}
+	if input.Rating != nil {
+		cake.Rating = *input.Rating
+	}
+	if input.UpdatedAt.Valid {
+		cake.UpdatedAt = input.UpdatedAt
+	}
+
+	m.cakes[id] = cake
+	return true
+}
+
+func (m *memoryCake) Delete(ctx context.Context, id int) error {
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.cakes[id]; !ok {
+		return sql.ErrNoRows
+	}
+
+	delete(m.cakes, id)
+	return nil
+}
+
+func (m *memoryCake) BulkUpdate(ctx context.Context, filter BulkFilter, input CakeUpdateModel, atomic bool) ([]BulkResult, error) {
+
+	if fields, _ := updateFields(input); len(fields) == 0 {
+		return nil, errors.New("nothing to update")
+	}
+
+	return m.bulk(ctx, filter, atomic, func(id int) bool {
+		return m.update(id, input)
+	})
+}
+
+func (m *memoryCake) BulkDelete(ctx context.Context, filter BulkFilter, atomic bool) ([]BulkResult, error) {
+
+	return m.bulk(ctx, filter, atomic, func(id int) bool {
+		_, ok := m.cakes[id]
+		delete(m.cakes, id)
+		return ok
+	})
+}
+
+// bulk runs apply for every selected cake under one lock, as the SQL
+// repository runs one statement per cake. In atomic mode the cakes are put
+// back when one was not found.
+func (m *memoryCake) bulk(ctx context.Context, filter BulkFilter, atomic bool, apply func(id int) bool) (results []BulkResult, err error) {
+
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := filter.IDs
+	if len(ids) == 0 {
+		for _, cake := range m.matching(filter.Search) {
+			ids = append(ids, cake.ID)
+		}
+	}
+
+	var saved map[int]CakeBaseModel
+	if atomic {
+		saved = make(map[int]CakeBaseModel, len(m.cakes))
+		for id, cake := range m.cakes {
+			saved[id] = cake
+		}
+	}
+
+	failed := false
+	for _, id := range ids {
+		found := apply(id)
+		failed = failed || !found
+		results = append(results, BulkResult{ID: id, Found: found})
+	}
+
+	if atomic && failed {
+		m.cakes = saved
+		return results, ErrBulkRolledBack
+	}
+
+	return results, nil
+}
+
+// compareCakes compares two cakes by a column of cakeSortColumns, below zero
+// when a sorts first. A NULL updated_at sorts first as in MySQL and SQLite.
+func compareCakes(column string, a, b CakeBaseModel) int {
+
+	switch column {
+	case "id":
+		return a.ID - b.ID
+	case "title":
+		return strings.Compare(a.Title, b.Title)
+	case "description":
+		return strings.Compare(a.Description, b.Description)
+	case "image":
+		return strings.Compare(a.Image, b.Image)
+	case "rating":
+		switch {
+		case a.Rating < b.Rating:
+			return -1
+		case a.Rating > b.Rating:
+			return 1
+		}
+		return 0
+	case "created_at":
+		return compareTime(a.CreatedAt.UnixNano(), b.CreatedAt.UnixNano())
+	case "updated_at":
+		switch {
+		case !a.UpdatedAt.Valid && !b.UpdatedAt.Valid:
+			return 0
+		case !a.UpdatedAt.Valid:
+			return -1
+		case !b.UpdatedAt.Valid:
+			return 1
+		}
+		return compareTime(a.UpdatedAt.Time.UnixNano(), b.UpdatedAt.Time.UnixNano())
+	}
+
+	panic("no comparison for cake column " + column)
+}
+
+func compareTime(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// sortCakes orders cakes by orders, ties keep the ID order.
+func sortCakes(cakes []CakeBaseModel, orders []cakeOrder) {
+
+	orders = append(orders, cakeOrder{column: "id"})
+
+	sort.SliceStable(cakes, func(i, j int) bool {
+		for _, order := range orders {
+			compare := compareCakes(order.column, cakes[i], cakes[j])
+			if order.desc {
+				compare = -compare
+			}
+			if compare != 0 {
+				return compare < 0
+			}
+		}
+		return false
+	})
+}
+
+// like reports whether value matches the LIKE pattern regardless of case, %
+// matches any run of characters, _ a single one and \ escapes the next one.
+func like(value, pattern string) bool {
+
+	v, p := []rune(value), []rune(pattern)
+
+	// star is the pattern position after the last %, retry the value position
+	// it matches up to so far
+	star, retry := -1, 0
+	i, j := 0, 0
+	for i < len(v) {
+
+		if j < len(p) && p[j] == '%' {
+			star, retry = j+1, i
+			j++
+			continue
+		}
+
+		if j < len(p) {
+			next, r := j+1, p[j]
+			if r == '\\' && next < len(p) {
+				next, r = next+1, p[next]
+			} else if r == '_' {
+				i, j = i+1, next
+				continue
+			}
+			if equalFold(v[i], r) {
+				i, j = i+1, next
+				continue
+			}
+		}
+
+		if star < 0 {
+			return false
+		}
+		retry++
+		i, j = retry, star
+	}
+
+	for j < len(p) && p[j] == '%' {
+		j++
+	}
+	return j == len(p)
+}
+
+func equalFold(a, b rune) bool {
+	return a == b || unicode.ToLower(a) == unicode.ToLower(b)
+}
@@ -0,0 +1,379 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/infra"
+	"gitlab.com/cake-store-RESTFul/migration"
+)
+
+// memoryCakes are the cakes both repositories of the tests start with.
+func memoryCakes(now time.Time) []CakeBaseModel {
+	return []CakeBaseModel{
+		{Title: "Lemon Cheesecake", Description: "tangy", Rating: 8.5, CreatedAt: now},
+		{Title: "Chocolate Torte", Description: "rich", Rating: 9, CreatedAt: now.Add(time.Hour)},
+		{Title: "Carrot Cake", Description: "spiced", Rating: 7, CreatedAt: now.Add(-time.Hour)},
+		{Title: "carrot cupcake", Description: "small", Rating: 9, CreatedAt: now},
+		{Title: "100% Cocoa_Cake", Description: "bitter", Rating: 6, CreatedAt: now},
+	}
+}
+
+func newMemoryCakes(t *testing.T, now time.Time) Cake {
+	cakes := NewMemoryCake(zerolog.Nop())
+	if _, err := cakes.CreateBatch(context.Background(), memoryCakes(now)); err != nil {
+		t.Fatal(err)
+	}
+	return cakes
+}
+
+func ids(cakes []CakeBaseModel) []int {
+	ids := []int{}
+	for _, cake := range cakes {
+		ids = append(ids, cake.ID)
+	}
+	return ids
+}
+
+func Test_memoryCake_GetList(t *testing.T) {
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name    string
+		limit   int
+		offset  int
+		search  string
+		sort    string
+		sortBy  string
+		wantIDs []int
+		wantErr bool
+	}{
+		{name: "every cake", limit: 10, sort: "id,title", sortBy: "ASC", wantIDs: []int{1, 2, 3, 4, 5}},
+		{name: "search ignores case", limit: 10, search: "CARROT", sort: "id", sortBy: "ASC", wantIDs: []int{3, 4}},
+		{name: "search with wildcards", limit: 10, search: "c_ke", sort: "id", sortBy: "ASC", wantIDs: []int{1, 3, 4, 5}},
+		{name: "escaped wildcard", limit: 10, search: `0\%`, sort: "id", sortBy: "ASC", wantIDs: []int{5}},
+		{name: "direction of the last term", limit: 10, sort: "rating,id", sortBy: "DESC", wantIDs: []int{5, 3, 1, 4, 2}},
+		{name: "direction per term", limit: 10, sort: "rating DESC, title ASC", wantIDs: []int{2, 4, 1, 3, 5}},
+		{name: "sort by time", limit: 10, sort: "created_at", sortBy: "desc", wantIDs: []int{2, 1, 4, 5, 3}},
+		{name: "limit and offset", limit: 2, offset: 1, sort: "id", sortBy: "ASC", wantIDs: []int{2, 3}},
+		{name: "offset past the end", limit: 2, offset: 9, sort: "id", sortBy: "ASC", wantIDs: []int{}},
+		{name: "unknown column", limit: 10, sort: "flavour", sortBy: "ASC", wantErr: true},
+		{name: "unknown direction", limit: 10, sort: "id", sortBy: "UP", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cakes := newMemoryCakes(t, now)
+
+			got, err := cakes.GetList(context.Background(), tt.limit, tt.offset, tt.search, tt.sort, tt.sortBy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("memoryCake.GetList() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(ids(got), tt.wantIDs) {
+				t.Errorf("memoryCake.GetList() = %v, want %v", ids(got), tt.wantIDs)
+			}
+		})
+	}
+}
+
+func Test_memoryCake_CountCake(t *testing.T) {
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name   string
+		search string
+		want   int
+	}{
+		{name: "every cake", want: 5},
+		{name: "search", search: "cake", want: 4},
+		{name: "no match", search: "pie", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newMemoryCakes(t, now).CountCake(context.Background(), tt.search)
+			if err != nil || got != tt.want {
+				t.Errorf("memoryCake.CountCake() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func Test_memoryCake_write(t *testing.T) {
+	now := time.Now().UTC()
+	ctx := context.Background()
+	title := "Lemon Curd Cheesecake"
+	updatedAt := sql.NullTime{Time: now, Valid: true}
+
+	tests := []struct {
+		name    string
+		write   func(cakes Cake) error
+		id      int
+		want    CakeBaseModel
+		wantErr error
+	}{
+		{
+			name: "create gets the next ID",
+			write: func(cakes Cake) error {
+				id, err := cakes.Create(ctx, CakeBaseModel{ID: 1, Title: "Pavlova", CreatedAt: now, UpdatedAt: updatedAt})
+				if id != 6 {
+					t.Errorf("memoryCake.Create() = %v, want 6", id)
+				}
+				return err
+			},
+			id:   6,
+			want: CakeBaseModel{ID: 6, Title: "Pavlova", CreatedAt: now},
+		},
+		{
+			name: "update only changes the given fields",
+			write: func(cakes Cake) error {
+				return cakes.Update(ctx, CakeUpdateModel{ID: 1, Title: &title, UpdatedAt: updatedAt})
+			},
+			id:   1,
+			want: CakeBaseModel{ID: 1, Title: title, Description: "tangy", Rating: 8.5, CreatedAt: now, UpdatedAt: updatedAt},
+		},
+		{
+			name: "update of a missing cake",
+			write: func(cakes Cake) error {
+				return cakes.Update(ctx, CakeUpdateModel{ID: 9, Title: &title})
+			},
+			id:      9,
+			wantErr: sql.ErrNoRows,
+		},
+		{
+			name: "delete",
+			write: func(cakes Cake) error {
+				return cakes.Delete(ctx, 1)
+			},
+			id:      1,
+			wantErr: sql.ErrNoRows,
+		},
+		{
+			name: "delete of a missing cake",
+			write: func(cakes Cake) error {
+				if err := cakes.Delete(ctx, 9); err != sql.ErrNoRows {
+					t.Errorf("memoryCake.Delete() error = %v, want %v", err, sql.ErrNoRows)
+				}
+				return nil
+			},
+			id:      9,
+			wantErr: sql.ErrNoRows,
+		},
+		{
+			name: "deleted IDs are not reused",
+			write: func(cakes Cake) error {
+				if err := cakes.Delete(ctx, 5); err != nil {
+					return err
+				}
+				_, err := cakes.Create(ctx, CakeBaseModel{Title: "Pavlova", CreatedAt: now})
+				return err
+			},
+			id:   6,
+			want: CakeBaseModel{ID: 6, Title: "Pavlova", CreatedAt: now},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cakes := newMemoryCakes(t, now)
+
+			if err := tt.write(cakes); err != nil {
+				t.Fatalf("write error = %v", err)
+			}
+
+			got, err := cakes.GetDetail(ctx, tt.id)
+			if err != tt.wantErr {
+				t.Fatalf("memoryCake.GetDetail() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("memoryCake.GetDetail() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_memoryCake_GetByIDs(t *testing.T) {
+	got, err := newMemoryCakes(t, time.Now()).GetByIDs(context.Background(), []int{4, 9, 2, 4})
+	if err != nil || !reflect.DeepEqual(ids(got), []int{2, 4}) {
+		t.Errorf("memoryCake.GetByIDs() = %v, %v, want [2 4]", ids(got), err)
+	}
+}
+
+func Test_memoryCake_Bulk(t *testing.T) {
+	ctx := context.Background()
+	rating := float32(10)
+
+	tests := []struct {
+		name        string
+		bulk        func(cakes Cake) ([]BulkResult, error)
+		wantResults []BulkResult
+		wantErr     error
+		wantCount   int
+		wantRating  float32
+	}{
+		{
+			name: "delete by search",
+			bulk: func(cakes Cake) ([]BulkResult, error) {
+				return cakes.BulkDelete(ctx, BulkFilter{Search: "carrot"}, true)
+			},
+			wantResults: []BulkResult{{ID: 3, Found: true}, {ID: 4, Found: true}},
+			wantCount:   3,
+			wantRating:  8.5,
+		},
+		{
+			name: "missing cake skipped",
+			bulk: func(cakes Cake) ([]BulkResult, error) {
+				return cakes.BulkUpdate(ctx, BulkFilter{IDs: []int{1, 9}}, CakeUpdateModel{Rating: &rating}, false)
+			},
+			wantResults: []BulkResult{{ID: 1, Found: true}, {ID: 9}},
+			wantCount:   5,
+			wantRating:  10,
+		},
+		{
+			name: "missing cake rolls back",
+			bulk: func(cakes Cake) ([]BulkResult, error) {
+				return cakes.BulkUpdate(ctx, BulkFilter{IDs: []int{1, 9}}, CakeUpdateModel{Rating: &rating}, true)
+			},
+			wantResults: []BulkResult{{ID: 1, Found: true}, {ID: 9}},
+			wantErr:     ErrBulkRolledBack,
+			wantCount:   5,
+			wantRating:  8.5,
+		},
+		{
+			name: "cake deleted twice rolls back",
+			bulk: func(cakes Cake) ([]BulkResult, error) {
+				return cakes.BulkDelete(ctx, BulkFilter{IDs: []int{1, 1}}, true)
+			},
+			wantResults: []BulkResult{{ID: 1, Found: true}, {ID: 1}},
+			wantErr:     ErrBulkRolledBack,
+			wantCount:   5,
+			wantRating:  8.5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cakes := newMemoryCakes(t, time.Now())
+
+			got, err := tt.bulk(cakes)
+			if err != tt.wantErr {
+				t.Fatalf("bulk error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.wantResults) {
+				t.Errorf("bulk = %+v, want %+v", got, tt.wantResults)
+			}
+
+			count, _ := cakes.CountCake(ctx, "")
+			cake, _ := cakes.GetDetail(ctx, 1)
+			if count != tt.wantCount || cake.Rating != tt.wantRating {
+				t.Errorf("after bulk count = %v, rating = %v, want %v, %v", count, cake.Rating, tt.wantCount, tt.wantRating)
+			}
+		})
+	}
+}
+
+// Test_memoryCake_sqlite runs the same reads against the memory and the SQLite
+// repository, they must answer alike.
+func Test_memoryCake_sqlite(t *testing.T) {
+	ctx := context.Background()
+	log := zerolog.Nop()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "cake-store.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	database := &infra.Database{DB: db, Dialect: infra.DialectSQLite}
+	migrator, err := migration.NewMigrator(database, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	sqlite := NewCake(database, log)
+	if _, err := sqlite.CreateBatch(ctx, memoryCakes(now)); err != nil {
+		t.Fatal(err)
+	}
+	memory := newMemoryCakes(t, now)
+
+	tests := []struct {
+		name   string
+		limit  int
+		offset int
+		search string
+		sort   string
+		sortBy string
+	}{
+		{name: "default", limit: 10, sort: "id,title", sortBy: "ASC"},
+		{name: "search", limit: 10, search: "cAkE", sort: "id", sortBy: "ASC"},
+		{name: "wildcard", limit: 10, search: "c_ke", sort: "title", sortBy: "DESC"},
+		{name: "last term direction", limit: 10, sort: "rating,id", sortBy: "DESC"},
+		{name: "per term direction", limit: 10, sort: "rating DESC, title ASC"},
+		{name: "page", limit: 2, offset: 2, sort: "created_at", sortBy: "ASC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := sqlite.GetList(ctx, tt.limit, tt.offset, tt.search, tt.sort, tt.sortBy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := memory.GetList(ctx, tt.limit, tt.offset, tt.search, tt.sort, tt.sortBy)
+			if err != nil || !reflect.DeepEqual(ids(got), ids(want)) {
+				t.Errorf("memoryCake.GetList() = %v, %v, want %v", ids(got), err, ids(want))
+			}
+
+			wantCount, _ := sqlite.CountCake(ctx, tt.search)
+			if got, _ := memory.CountCake(ctx, tt.search); got != wantCount {
+				t.Errorf("memoryCake.CountCake() = %v, want %v", got, wantCount)
+			}
+		})
+	}
+}
+
+func Test_compareCakes(t *testing.T) {
+
+	now := time.Now()
+	a := CakeBaseModel{ID: 1, Title: "a", Description: "a", Image: "a", Rating: 1, CreatedAt: now}
+	b := CakeBaseModel{ID: 2, Title: "b", Description: "b", Image: "b", Rating: 2, CreatedAt: now.Add(time.Second), UpdatedAt: sql.NullTime{Time: now, Valid: true}}
+
+	// every column the SQL repository sorts by is sorted in memory as well
+	for name, column := range cakeSortColumns {
+		if compare := compareCakes(column, a, b); compare >= 0 {
+			t.Errorf("compareCakes(%q) = %d, want a before b", name, compare)
+		}
+	}
+}
+
+func Test_memoryCake_concurrent(t *testing.T) {
+	ctx := context.Background()
+	cakes := NewMemoryCake(zerolog.Nop())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				id, _ := cakes.Create(ctx, CakeBaseModel{Title: "Sponge"})
+				rating := float32(j)
+				_ = cakes.Update(ctx, CakeUpdateModel{ID: id, Rating: &rating})
+				_, _ = cakes.GetList(ctx, 10, 0, "sponge", "rating", "DESC")
+				_, _ = cakes.BulkDelete(ctx, BulkFilter{IDs: []int{id}}, true)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if count, _ := cakes.CountCake(ctx, ""); count != 0 {
+		t.Errorf("memoryCake.CountCake() = %v, want 0", count)
+	}
+	if id, _ := cakes.Create(ctx, CakeBaseModel{}); id != 401 {
+		t.Errorf("memoryCake.Create() = %v, want 401", id)
+	}
+}
@@ -11,23 +11,33 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/infra"
 )
 
 func NewMockCake() (Cake, sqlmock.Sqlmock) {
+	return newMockCake(infra.DialectMySQL)
+}
+
+func newMockCake(dialect infra.Dialect) (Cake, sqlmock.Sqlmock) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
 	}
-	cake := NewCake(db, *zerolog.Ctx(context.Background()))
+	cake := NewCake(&infra.Database{DB: db, Dialect: dialect}, *zerolog.Ctx(context.Background()))
 	return cake, mock
 }
 
+// mysqlDatabase wraps the stub connection of sqlmock in a MySQL database.
+func mysqlDatabase(db *sql.DB) *infra.Database {
+	return &infra.Database{DB: db, Dialect: infra.DialectMySQL}
+}
+
 func Test_cake_Create(t *testing.T) {
 	now := time.Now()
 	ctx := context.Background()
 	query := "INSERT INTO cake \\(title, description, image, rating, created_at\\) VALUES \\(\\?, \\?, \\?, \\?, \\?\\)"
+	outboxQuery := "INSERT INTO outbox \\(event_id, aggregate_id, event, payload, created_at\\) VALUES \\(\\?, \\?, \\?, \\?, \\?\\)"
 	cake, mock := NewMockCake()
-	defer cake.Close()
 
 	input := CakeBaseModel{
 		Title:       "test",
@@ -43,6 +53,7 @@ func Test_cake_Create(t *testing.T) {
 	tests := []struct {
 		name       string
 		args       args
+		wantID     int
 		wantErr    bool
 		beforeFunc func()
 	}{
@@ -53,21 +64,26 @@ func Test_cake_Create(t *testing.T) {
 				input: input,
 			},
 			beforeFunc: func() {
-				mock.ExpectPrepare(query).
-					ExpectExec().
+				mock.ExpectBegin()
+				mock.ExpectExec(query).
 					WithArgs(input.Title, input.Description, input.Image, input.Rating, now).
-					WillReturnResult(sqlmock.NewResult(0, 1))
+					WillReturnResult(sqlmock.NewResult(7, 1))
+				mock.ExpectExec(outboxQuery).
+					WithArgs(sqlmock.AnyArg(), 7, OutboxCakeCreated, sqlmock.AnyArg(), now).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
 			},
+			wantID:  7,
 			wantErr: false,
 		},
 		{
-			name: "error prepare statement",
+			name: "error begin",
 			args: args{
 				ctx:   ctx,
 				input: input,
 			},
 			beforeFunc: func() {
-				mock.ExpectPrepare(query).WillReturnError(errors.New("foo"))
+				mock.ExpectBegin().WillReturnError(errors.New("foo"))
 			},
 			wantErr: true,
 		},
@@ -78,10 +94,27 @@ func Test_cake_Create(t *testing.T) {
 				input: input,
 			},
 			beforeFunc: func() {
-				mock.ExpectPrepare(query).
-					ExpectExec().
-					WithArgs(input.Title, input.Description, input.Rating, now).
+				mock.ExpectBegin()
+				mock.ExpectExec(query).
+					WithArgs(input.Title, input.Description, input.Image, input.Rating, now).
 					WillReturnError(errors.New("foo"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+		{
+			name: "error outbox rolls back the cake",
+			args: args{
+				ctx:   ctx,
+				input: input,
+			},
+			beforeFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(query).
+					WithArgs(input.Title, input.Description, input.Image, input.Rating, now).
+					WillReturnResult(sqlmock.NewResult(7, 1))
+				mock.ExpectExec(outboxQuery).WillReturnError(errors.New("foo"))
+				mock.ExpectRollback()
 			},
 			wantErr: true,
 		},
@@ -89,8 +122,16 @@ func Test_cake_Create(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.beforeFunc()
-			if err := cake.Create(tt.args.ctx, tt.args.input); (err != nil) != tt.wantErr {
+			gotID, err := cake.Create(tt.args.ctx, tt.args.input)
+			if (err != nil) != tt.wantErr {
 				t.Errorf("cake.Create() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotID != tt.wantID {
+				t.Errorf("cake.Create() = %v, want %v", gotID, tt.wantID)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
 			}
 		})
 	}
@@ -101,9 +142,8 @@ func Test_cake_GetList(t *testing.T) {
 	ctx := context.Background()
 	now := time.Now()
 	cake, mock := NewMockCake()
-	defer cake.Close()
 
-	query := "SELECT id, title, description, image, rating, created_at, updated_at FROM cake ORDER BY id asc LIMIT 10 OFFSET 0"
+	query := "SELECT id, title, description, image, rating, created_at, updated_at FROM cake ORDER BY id ASC LIMIT 10 OFFSET 0"
 
 	output := CakeBaseModel{
 		ID:          1,
@@ -197,11 +237,63 @@ func Test_cake_GetList(t *testing.T) {
 			},
 			wantOutput: nil,
 			beforeFunc: func() {
-				mock.ExpectQuery(query).WillReturnError(errors.New("foo"))
+				mock.ExpectQuery("SELECT id, title, description, image, rating, created_at, updated_at FROM cake WHERE title LIKE \\? ORDER BY id ASC").
+					WithArgs("%chesscake%").
+					WillReturnError(errors.New("foo"))
 
 			},
 			wantErr: true,
 		},
+		{
+			name: "success sort by columns",
+			args: args{
+				ctx:    ctx,
+				limit:  10,
+				offset: 0,
+				search: "",
+				sort:   "rating desc, Title",
+				sortBy: "desc",
+			},
+			wantOutput: []CakeBaseModel{
+				output,
+			},
+			beforeFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "description", "image", "rating", "created_at", "updated_at"}).
+					AddRow(output.ID, output.Title, output.Description, output.Image, output.Rating, output.CreatedAt, output.UpdatedAt)
+				mock.ExpectQuery("SELECT id, title, description, image, rating, created_at, updated_at FROM cake ORDER BY rating DESC, title DESC LIMIT 10 OFFSET 0").
+					WillReturnRows(rows)
+
+			},
+			wantErr: false,
+		},
+		{
+			name: "error unknown sort column",
+			args: args{
+				ctx:    ctx,
+				limit:  10,
+				offset: 0,
+				search: "",
+				sort:   "(SELECT 1)",
+				sortBy: "asc",
+			},
+			wantOutput: nil,
+			beforeFunc: func() {},
+			wantErr:    true,
+		},
+		{
+			name: "error invalid sort direction",
+			args: args{
+				ctx:    ctx,
+				limit:  10,
+				offset: 0,
+				search: "",
+				sort:   "id",
+				sortBy: "asc; DROP TABLE cake",
+			},
+			wantOutput: nil,
+			beforeFunc: func() {},
+			wantErr:    true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -223,7 +315,6 @@ func Test_cake_GetDetail(t *testing.T) {
 	ctx := context.Background()
 	now := time.Now()
 	cake, mock := NewMockCake()
-	defer cake.Close()
 
 	query := "SELECT id, title, description, image, rating, created_at, updated_at FROM cake WHERE id = ?"
 	output := CakeBaseModel{
@@ -292,26 +383,88 @@ func Test_cake_GetDetail(t *testing.T) {
 	}
 }
 
+func Test_cake_GetByIDs(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	cake, mock := NewMockCake()
+
+	query := "SELECT id, title, description, image, rating, created_at, updated_at FROM cake WHERE id IN \\(\\?, \\?\\)"
+	output := []CakeBaseModel{{ID: 1, Title: "test", Description: "test", Rating: 1, CreatedAt: now}}
+
+	tests := []struct {
+		name       string
+		ids        []int
+		wantOutput []CakeBaseModel
+		beforeFunc func()
+		wantErr    bool
+	}{
+		{
+			name:       "success",
+			ids:        []int{1, 2},
+			wantOutput: output,
+			beforeFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "description", "image", "rating", "created_at", "updated_at"}).
+					AddRow(output[0].ID, output[0].Title, output[0].Description, output[0].Image, output[0].Rating, output[0].CreatedAt, output[0].UpdatedAt)
+				mock.ExpectQuery(query).WithArgs(1, 2).WillReturnRows(rows)
+			},
+			wantErr: false,
+		},
+		{
+			name:       "success (no ids)",
+			ids:        nil,
+			wantOutput: nil,
+			beforeFunc: func() {},
+			wantErr:    false,
+		},
+		{
+			name:       "error",
+			ids:        []int{1, 2},
+			wantOutput: nil,
+			beforeFunc: func() {
+				mock.ExpectQuery(query).WithArgs(1, 2).WillReturnError(errors.New("foo"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.beforeFunc()
+			gotOutput, err := cake.GetByIDs(ctx, tt.ids)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("cake.GetByIDs() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotOutput, tt.wantOutput) {
+				t.Errorf("cake.GetByIDs() = %v, want %v", gotOutput, tt.wantOutput)
+			}
+		})
+	}
+}
+
 func Test_cake_Update(t *testing.T) {
 
 	now := sql.NullTime{Time: time.Now(), Valid: true}
 	ctx := context.Background()
 	query := "UPDATE cake SET title = \\?, description = \\?, image = \\?, rating = \\?, updated_at = \\? WHERE id = 1"
+	selectQuery := "SELECT id, title, description, image, rating, created_at, updated_at FROM cake WHERE id = \\?"
+	outboxQuery := "INSERT INTO outbox \\(event_id, aggregate_id, event, payload, created_at\\) VALUES \\(\\?, \\?, \\?, \\?, \\?\\)"
 	cake, mock := NewMockCake()
-	defer cake.Close()
 
-	input := CakeBaseModel{
-		Title:       "test",
-		Description: "test",
-		Rating:      10,
-		Image:       "test",
+	title, description, image, rating := "test", "test", "test", float32(10)
+	input := CakeUpdateModel{
+		Title:       &title,
+		Description: &description,
+		Rating:      &rating,
+		Image:       &image,
 		UpdatedAt:   now,
 		ID:          1,
 	}
+	columns := []string{"id", "title", "description", "image", "rating", "created_at", "updated_at"}
 
 	type args struct {
 		ctx   context.Context
-		input CakeBaseModel
+		input CakeUpdateModel
 	}
 	tests := []struct {
 		name       string
@@ -326,10 +479,34 @@ func Test_cake_Update(t *testing.T) {
 				input: input,
 			},
 			beforeFunc: func() {
+				mock.ExpectBegin()
 				mock.ExpectPrepare(query).
 					ExpectExec().
-					WithArgs(input.Title, input.Description, input.Image, input.Rating, now).
+					WithArgs(title, description, image, rating, now).
 					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(selectQuery).WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(columns).AddRow(1, title, description, image, rating, now.Time, now.Time))
+				mock.ExpectExec(outboxQuery).
+					WithArgs(sqlmock.AnyArg(), 1, OutboxCakeUpdated, sqlmock.AnyArg(), now.Time).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: false,
+		},
+		{
+			name: "not found writes no event",
+			args: args{
+				ctx:   ctx,
+				input: input,
+			},
+			beforeFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(query).
+					ExpectExec().
+					WithArgs(title, description, image, rating, now).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(selectQuery).WithArgs(1).WillReturnRows(sqlmock.NewRows(columns))
+				mock.ExpectCommit()
 			},
 			wantErr: false,
 		},
@@ -340,10 +517,12 @@ func Test_cake_Update(t *testing.T) {
 				input: input,
 			},
 			beforeFunc: func() {
+				mock.ExpectBegin()
 				mock.ExpectPrepare(query).
 					ExpectExec().
-					WithArgs(input.Title, input.Description, input.Image, input.Rating, now).
+					WithArgs(title, description, image, rating, now).
 					WillReturnResult(sqlmock.NewResult(0, 1)).WillReturnError(errors.New("foo"))
+				mock.ExpectRollback()
 			},
 			wantErr: true,
 		},
@@ -354,8 +533,10 @@ func Test_cake_Update(t *testing.T) {
 				input: input,
 			},
 			beforeFunc: func() {
+				mock.ExpectBegin()
 				mock.ExpectPrepare(query).
 					WillReturnError(errors.New("foo"))
+				mock.ExpectRollback()
 			},
 			wantErr: true,
 		},
@@ -366,6 +547,9 @@ func Test_cake_Update(t *testing.T) {
 			if err := cake.Update(tt.args.ctx, tt.args.input); (err != nil) != tt.wantErr {
 				t.Errorf("cake.Update() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
 		})
 	}
 }
@@ -375,8 +559,8 @@ func Test_cake_Delete(t *testing.T) {
 	ctx := context.Background()
 	id := 1
 	query := "DELETE FROM cake WHERE id = \\?"
+	outboxQuery := "INSERT INTO outbox \\(event_id, aggregate_id, event, payload, created_at\\) VALUES \\(\\?, \\?, \\?, \\?, \\?\\)"
 	cake, mock := NewMockCake()
-	defer cake.Close()
 
 	type args struct {
 		ctx context.Context
@@ -395,13 +579,34 @@ func Test_cake_Delete(t *testing.T) {
 				id:  id,
 			},
 			beforeFunc: func() {
+				mock.ExpectBegin()
 				mock.ExpectPrepare(query).
 					ExpectExec().
 					WithArgs(id).
 					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(outboxQuery).
+					WithArgs(sqlmock.AnyArg(), id, OutboxCakeDeleted, `{"id":1}`, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
 			},
 			wantErr: false,
 		},
+		{
+			name: "not found writes no event",
+			args: args{
+				ctx: ctx,
+				id:  id,
+			},
+			beforeFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(query).
+					ExpectExec().
+					WithArgs(id).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
 		{
 			name: "error",
 			args: args{
@@ -409,10 +614,12 @@ func Test_cake_Delete(t *testing.T) {
 				id:  id,
 			},
 			beforeFunc: func() {
+				mock.ExpectBegin()
 				mock.ExpectPrepare(query).
 					ExpectExec().
 					WithArgs(id).
 					WillReturnError(errors.New("foo"))
+				mock.ExpectRollback()
 			},
 			wantErr: true,
 		},
@@ -423,8 +630,10 @@ func Test_cake_Delete(t *testing.T) {
 				id:  id,
 			},
 			beforeFunc: func() {
+				mock.ExpectBegin()
 				mock.ExpectPrepare(query).
 					WillReturnError(errors.New("foo"))
+				mock.ExpectRollback()
 			},
 			wantErr: true,
 		},
@@ -435,6 +644,9 @@ func Test_cake_Delete(t *testing.T) {
 			if err := cake.Delete(tt.args.ctx, tt.args.id); (err != nil) != tt.wantErr {
 				t.Errorf("cake.Delete() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
 		})
 	}
 }
@@ -444,7 +656,6 @@ func Test_cake_CountCake(t *testing.T) {
 	ctx := context.Background()
 	query := "SELECT count\\(id\\) FROM cake"
 	cake, mock := NewMockCake()
-	defer cake.Close()
 
 	type args struct {
 		ctx    context.Context
@@ -482,8 +693,9 @@ func Test_cake_CountCake(t *testing.T) {
 			},
 			wantCount: 0,
 			beforeFunc: func() {
-				mock.ExpectPrepare(query).
+				mock.ExpectPrepare("SELECT count\\(id\\) FROM cake WHERE title LIKE \\?").
 					ExpectQuery().
+					WithArgs("%chesscake%").
 					WillReturnError(errors.New("foo"))
 			},
 			wantErr: true,
@@ -508,8 +720,9 @@ func Test_cake_CountCake(t *testing.T) {
 			},
 			wantCount: 0,
 			beforeFunc: func() {
-				mock.ExpectPrepare(query).
+				mock.ExpectPrepare("SELECT count\\(id\\) FROM cake WHERE title LIKE \\?").
 					ExpectQuery().
+					WithArgs("%chesscake%").
 					WillReturnError(errors.New("foo"))
 			},
 			wantErr: true,
@@ -530,3 +743,368 @@ func Test_cake_CountCake(t *testing.T) {
 		})
 	}
 }
+
+func Test_cake_CreateBatch(t *testing.T) {
+	now := time.Now()
+	ctx := context.Background()
+	query := "INSERT INTO cake \\(title, description, image, rating, created_at, updated_at\\) VALUES \\(\\?, \\?, \\?, \\?, \\?, \\?\\)"
+	outboxQuery := "INSERT INTO outbox \\(event_id, aggregate_id, event, payload, created_at\\) VALUES \\(\\?, \\?, \\?, \\?, \\?\\)"
+	cake, mock := NewMockCake()
+
+	updatedAt := sql.NullTime{Time: now, Valid: true}
+	input := []CakeBaseModel{
+		{Title: "test", Description: "test", Image: "test", Rating: 1, CreatedAt: now},
+		{Title: "test 2", Description: "test 2", Image: "test 2", Rating: 2, CreatedAt: now, UpdatedAt: updatedAt},
+	}
+
+	type args struct {
+		ctx   context.Context
+		input []CakeBaseModel
+	}
+	tests := []struct {
+		name       string
+		args       args
+		beforeFunc func()
+		want       []int
+		wantErr    bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:   ctx,
+				input: input,
+			},
+			beforeFunc: func() {
+				mock.ExpectBegin()
+				// a concurrent insert took the IDs in between, every cake
+				// and its event get the ID of its own insert
+				mock.ExpectExec(query).
+					WithArgs("test", "test", "test", float32(1), now, sql.NullTime{}).
+					WillReturnResult(sqlmock.NewResult(4, 1))
+				mock.ExpectExec(outboxQuery).WithArgs(sqlmock.AnyArg(), 4, OutboxCakeCreated, sqlmock.AnyArg(), now).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(query).
+					WithArgs("test 2", "test 2", "test 2", float32(2), now, updatedAt).
+					WillReturnResult(sqlmock.NewResult(9, 1))
+				mock.ExpectExec(outboxQuery).WithArgs(sqlmock.AnyArg(), 9, OutboxCakeCreated, sqlmock.AnyArg(), now).
+					WillReturnResult(sqlmock.NewResult(2, 1))
+				mock.ExpectCommit()
+			},
+			want:    []int{4, 9},
+			wantErr: false,
+		},
+		{
+			name: "error begin transaction",
+			args: args{
+				ctx:   ctx,
+				input: input,
+			},
+			beforeFunc: func() {
+				mock.ExpectBegin().WillReturnError(errors.New("foo"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "error rollback",
+			args: args{
+				ctx:   ctx,
+				input: input,
+			},
+			beforeFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(outboxQuery).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(query).WillReturnError(errors.New("foo"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+		{
+			name: "error outbox rolls back the batch",
+			args: args{
+				ctx:   ctx,
+				input: input,
+			},
+			beforeFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(outboxQuery).WillReturnError(errors.New("foo"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+		{
+			name: "error commit",
+			args: args{
+				ctx:   ctx,
+				input: input,
+			},
+			beforeFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(outboxQuery).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(2, 1))
+				mock.ExpectExec(outboxQuery).WillReturnResult(sqlmock.NewResult(2, 1))
+				mock.ExpectCommit().WillReturnError(errors.New("foo"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.beforeFunc()
+			got, err := cake.CreateBatch(tt.args.ctx, tt.args.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("cake.CreateBatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cake.CreateBatch() = %v, want %v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("cake.CreateBatch() unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func Test_cake_Stream(t *testing.T) {
+
+	ctx := context.Background()
+	now := time.Now()
+	cake, mock := NewMockCake()
+
+	query := "SELECT id, title, description, image, rating, created_at, updated_at FROM cake ORDER BY id ASC"
+
+	output := CakeBaseModel{
+		ID:          1,
+		Title:       "test",
+		Description: "test",
+		Rating:      1,
+		CreatedAt:   now,
+	}
+
+	tests := []struct {
+		name       string
+		fn         func(CakeBaseModel) error
+		wantOutput []CakeBaseModel
+		beforeFunc func()
+		wantErr    bool
+	}{
+		{
+			name:       "success",
+			wantOutput: []CakeBaseModel{output, output},
+			beforeFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "description", "image", "rating", "created_at", "updated_at"}).
+					AddRow(output.ID, output.Title, output.Description, output.Image, output.Rating, output.CreatedAt, output.UpdatedAt).
+					AddRow(output.ID, output.Title, output.Description, output.Image, output.Rating, output.CreatedAt, output.UpdatedAt)
+				mock.ExpectQuery(query).WillReturnRows(rows)
+			},
+			wantErr: false,
+		},
+		{
+			name:       "error callback stops the stream",
+			fn:         func(CakeBaseModel) error { return errors.New("foo") },
+			wantOutput: []CakeBaseModel{output},
+			beforeFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "description", "image", "rating", "created_at", "updated_at"}).
+					AddRow(output.ID, output.Title, output.Description, output.Image, output.Rating, output.CreatedAt, output.UpdatedAt).
+					AddRow(output.ID, output.Title, output.Description, output.Image, output.Rating, output.CreatedAt, output.UpdatedAt)
+				mock.ExpectQuery(query).WillReturnRows(rows)
+			},
+			wantErr: true,
+		},
+		{
+			name: "error scan result",
+			beforeFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "description", "image", "rating", "created_at", "updated_at"}).
+					AddRow(output.ID, output.Description, output.Image, output.Rating, output.CreatedAt, output.UpdatedAt)
+				mock.ExpectQuery(query).WillReturnRows(rows)
+			},
+			wantErr: true,
+		},
+		{
+			name: "error rows",
+			beforeFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "description", "image", "rating", "created_at", "updated_at"}).
+					AddRow(output.ID, output.Title, output.Description, output.Image, output.Rating, output.CreatedAt, output.UpdatedAt).
+					RowError(0, errors.New("foo"))
+				mock.ExpectQuery(query).WillReturnRows(rows)
+			},
+			wantErr: true,
+		},
+		{
+			name: "error",
+			beforeFunc: func() {
+				mock.ExpectQuery(query).WillReturnError(errors.New("foo"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.beforeFunc()
+
+			var gotOutput []CakeBaseModel
+			err := cake.Stream(ctx, "", "id", "asc", func(c CakeBaseModel) error {
+				gotOutput = append(gotOutput, c)
+				if tt.fn != nil {
+					return tt.fn(c)
+				}
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("cake.Stream() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotOutput, tt.wantOutput) {
+				t.Errorf("cake.Stream() = %v, want %v", gotOutput, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func Test_cake_postgres(t *testing.T) {
+
+	ctx := context.Background()
+	now := time.Now()
+	cake, mock := newMockCake(infra.DialectPostgres)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO cake \\(title, description, image, rating, created_at\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5\\) RETURNING id").
+		WithArgs("test", "test", "", float32(10), now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectExec("INSERT INTO outbox \\(event_id, aggregate_id, event, payload, created_at\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5\\)").
+		WithArgs(sqlmock.AnyArg(), 7, OutboxCakeCreated, sqlmock.AnyArg(), now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	id, err := cake.Create(ctx, CakeBaseModel{Title: "test", Description: "test", Rating: 10, CreatedAt: now})
+	if err != nil || id != 7 {
+		t.Errorf("cake.Create() = %v, %v, want 7", id, err)
+	}
+
+	mock.ExpectQuery("SELECT id, title, description, image, rating, created_at, updated_at FROM cake WHERE title ILIKE \\$1 ORDER BY id ASC LIMIT 10 OFFSET 0").
+		WithArgs("%chess%").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description", "image", "rating", "created_at", "updated_at"}).
+			AddRow(7, "Chesscake", "test", "", 10, now, nil))
+
+	list, err := cake.GetList(ctx, 10, 0, "chess", "id", "asc")
+	if err != nil || len(list) != 1 || list[0].ID != 7 {
+		t.Errorf("cake.GetList() = %v, %v", list, err)
+	}
+
+	mock.ExpectPrepare("SELECT count\\(id\\) FROM cake WHERE title ILIKE \\$1").
+		ExpectQuery().
+		WithArgs("%chess%").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	count, err := cake.CountCake(ctx, "chess")
+	if err != nil || count != 1 {
+		t.Errorf("cake.CountCake() = %v, %v, want 1", count, err)
+	}
+
+	mock.ExpectBegin()
+	for _, id := range []int{8, 12} {
+		mock.ExpectQuery("INSERT INTO cake \\(title, description, image, rating, created_at, updated_at\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5, \\$6\\) RETURNING id").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+		mock.ExpectExec("INSERT INTO outbox").
+			WithArgs(sqlmock.AnyArg(), id, OutboxCakeCreated, sqlmock.AnyArg(), now).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+
+	if ids, err := cake.CreateBatch(ctx, []CakeBaseModel{{Title: "a", CreatedAt: now}, {Title: "b", CreatedAt: now}}); err != nil || !reflect.DeepEqual(ids, []int{8, 12}) {
+		t.Errorf("cake.CreateBatch() = %v, %v, want [8 12]", ids, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_cake_replicas(t *testing.T) {
+
+	ctx := context.Background()
+	now := time.Now()
+	detail := "SELECT id, title, description, image, rating, created_at, updated_at FROM cake WHERE id = \\?"
+	columns := []string{"id", "title", "description", "image", "rating", "created_at", "updated_at"}
+
+	primary, primaryMock, _ := sqlmock.New()
+	defer primary.Close()
+	caughtUp, caughtUpMock, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	defer caughtUp.Close()
+	lagging, laggingMock, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	defer lagging.Close()
+
+	replicas := infra.NewReplicas(infra.DialectMySQL, []*sql.DB{caughtUp, lagging}, 5*time.Second, zerolog.Logger{})
+	cake := NewCake(&infra.Database{DB: primary, Dialect: infra.DialectMySQL, Replicas: replicas}, zerolog.Logger{})
+	tm := NewTxManager(&infra.Database{DB: primary, Dialect: infra.DialectMySQL}, zerolog.Logger{})
+
+	status := func(lag interface{}) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"Replica_IO_State", "Seconds_Behind_Source"}).AddRow("Waiting for source to send event", lag)
+	}
+
+	// before the first check every read stays on the primary
+	primaryMock.ExpectQuery(detail).WithArgs(1).WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "primary", "", "", 9, now, nil))
+	if got, err := cake.GetDetail(ctx, 1); err != nil || got.Title != "primary" {
+		t.Errorf("cake.GetDetail() before the check = %v, %v, want the primary", got.Title, err)
+	}
+
+	caughtUpMock.ExpectPing()
+	caughtUpMock.ExpectQuery("SHOW REPLICA STATUS").WillReturnRows(status(0))
+	laggingMock.ExpectPing()
+	laggingMock.ExpectQuery("SHOW REPLICA STATUS").WillReturnRows(status(60))
+	replicas.Check(ctx)
+
+	// the lagging replica is skipped, round-robin always lands on the other
+	for i := 0; i < 2; i++ {
+		caughtUpMock.ExpectQuery(detail).WithArgs(1).WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "replica", "", "", 9, now, nil))
+		if got, err := cake.GetDetail(ctx, 1); err != nil || got.Title != "replica" {
+			t.Errorf("cake.GetDetail() = %v, %v, want the caught up replica", got.Title, err)
+		}
+	}
+
+	caughtUpMock.ExpectPrepare("SELECT count\\(id\\) FROM cake").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	if count, err := cake.CountCake(ctx, ""); err != nil || count != 3 {
+		t.Errorf("cake.CountCake() = %v, %v, want 3 from the replica", count, err)
+	}
+
+	// reads asked for the primary or inside a transaction do not use replicas
+	primaryMock.ExpectQuery(detail).WithArgs(1).WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "primary", "", "", 9, now, nil))
+	if got, err := cake.GetDetail(WithPrimary(ctx), 1); err != nil || got.Title != "primary" {
+		t.Errorf("cake.GetDetail(WithPrimary) = %v, %v, want the primary", got.Title, err)
+	}
+
+	primaryMock.ExpectBegin()
+	primaryMock.ExpectQuery(detail).WithArgs(1).WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "primary", "", "", 9, now, nil))
+	primaryMock.ExpectCommit()
+	err := tm.WithinTx(ctx, func(ctx context.Context) error {
+		got, err := cake.GetDetail(ctx, 1)
+		if got.Title != "primary" {
+			t.Errorf("cake.GetDetail() in a transaction = %v, want the primary", got.Title)
+		}
+		return err
+	})
+	if err != nil {
+		t.Errorf("txManager.WithinTx() error = %v", err)
+	}
+
+	// once no replica is healthy the primary serves the reads again
+	caughtUpMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	laggingMock.ExpectPing()
+	laggingMock.ExpectQuery("SHOW REPLICA STATUS").WillReturnRows(status(nil))
+	replicas.Check(ctx)
+
+	primaryMock.ExpectQuery("SELECT id, title, description, image, rating, created_at, updated_at FROM cake ORDER BY id ASC LIMIT 10 OFFSET 0").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "primary", "", "", 9, now, nil))
+	if list, err := cake.GetList(ctx, 10, 0, "", "id", "asc"); err != nil || len(list) != 1 {
+		t.Errorf("cake.GetList() = %v, %v, want the primary", list, err)
+	}
+
+	for name, mock := range map[string]sqlmock.Sqlmock{"primary": primaryMock, "caught up": caughtUpMock, "lagging": laggingMock} {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
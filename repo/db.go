@@ -0,0 +1,98 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+
+	"gitlab.com/cake-store-RESTFul/infra"
+)
+
+type primaryKey struct{}
+
+// WithPrimary returns a ctx whose reads go to the primary even where they are
+// usually served by a replica, so a read right after a write sees it.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// ReadsPrimary reports whether ctx was made by WithPrimary.
+func ReadsPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
+
+// querier returns the transaction carried by ctx, or db outside of one. The
+// placeholders of its queries are rewritten for the dialect of db.
+func querier(ctx context.Context, db *infra.Database) DBTX {
+
+	var q DBTX = db.DB
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		q = state.tx
+	}
+
+	return bind(q, db.Dialect)
+}
+
+// reader is the querier of reads that tolerate replication lag. They go to a
+// healthy replica unless ctx carries a transaction or asks for the primary.
+func reader(ctx context.Context, db *infra.Database) DBTX {
+
+	if _, inTx := ctx.Value(txKey{}).(*txState); inTx || ReadsPrimary(ctx) {
+		return querier(ctx, db)
+	}
+
+	replica := db.Replicas.Pick()
+	if replica == nil {
+		return querier(ctx, db)
+	}
+
+	return bind(replica, db.Dialect)
+}
+
+func bind(q DBTX, dialect infra.Dialect) DBTX {
+	if dialect != infra.DialectPostgres {
+		return q
+	}
+	return rebound{DBTX: q, dialect: dialect}
+}
+
+// rebound passes every query through Dialect.Rebind, so the repos write
+// their queries with ? placeholders only.
+type rebound struct {
+	DBTX
+	dialect infra.Dialect
+}
+
+func (r rebound) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return r.DBTX.ExecContext(ctx, r.dialect.Rebind(query), args...)
+}
+
+func (r rebound) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return r.DBTX.QueryContext(ctx, r.dialect.Rebind(query), args...)
+}
+
+func (r rebound) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return r.DBTX.QueryRowContext(ctx, r.dialect.Rebind(query), args...)
+}
+
+func (r rebound) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return r.DBTX.PrepareContext(ctx, r.dialect.Rebind(query))
+}
+
+// insertID runs an INSERT into a table with an id column and returns the
+// generated id, read back with RETURNING where there is no LastInsertId.
+func insertID(ctx context.Context, q DBTX, dialect infra.Dialect, query string, args ...interface{}) (int64, error) {
+
+	if dialect.Returning() {
+		var id int64
+		err := q.QueryRowContext(ctx, query+" RETURNING id", args...).Scan(&id)
+		return id, err
+	}
+
+	res, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.LastInsertId()
+}
@@ -0,0 +1,60 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/infra"
+)
+
+// DemoSeed records how many demo cakes of each seed were stored, so seeding
+// again only adds the missing ones.
+type DemoSeed interface {
+	// GetCount returns the stored count of seed, 0 when it was never used.
+	// Inside a transaction the row is locked until it ends.
+	GetCount(ctx context.Context, seed int64) (int, error)
+	SetCount(ctx context.Context, seed int64, count int, updatedAt time.Time) error
+}
+
+type demoSeed struct {
+	Log zerolog.Logger
+	DB  *infra.Database
+}
+
+func NewDemoSeed(db *infra.Database, log zerolog.Logger) DemoSeed {
+	return &demoSeed{
+		DB:  db,
+		Log: log,
+	}
+}
+
+func (d *demoSeed) GetCount(ctx context.Context, seed int64) (count int, err error) {
+
+	err = querier(ctx, d.DB).QueryRowContext(ctx, "SELECT count FROM demo_seed WHERE seed = ?"+d.DB.Dialect.ForUpdate(), seed).Scan(&count)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		d.Log.Error().Msg(err.Error())
+		return
+	}
+
+	return
+}
+
+func (d *demoSeed) SetCount(ctx context.Context, seed int64, count int, updatedAt time.Time) error {
+
+	query := "INSERT INTO demo_seed (seed, count, updated_at) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE count = VALUES(count), updated_at = VALUES(updated_at)"
+	if d.DB.Dialect != infra.DialectMySQL {
+		query = "INSERT INTO demo_seed (seed, count, updated_at) VALUES (?, ?, ?) ON CONFLICT (seed) DO UPDATE SET count = EXCLUDED.count, updated_at = EXCLUDED.updated_at"
+	}
+
+	if _, err := querier(ctx, d.DB).ExecContext(ctx, query, seed, count, updatedAt); err != nil {
+		d.Log.Error().Msg(err.Error())
+		return err
+	}
+
+	return nil
+}
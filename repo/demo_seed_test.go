@@ -0,0 +1,101 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/infra"
+)
+
+func Test_demoSeed_GetCount(t *testing.T) {
+
+	ctx := context.Background()
+	query := "SELECT count FROM demo_seed WHERE seed = \\? FOR UPDATE"
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	repo := NewDemoSeed(mysqlDatabase(db), zerolog.Logger{})
+
+	tests := []struct {
+		name       string
+		beforeFunc func()
+		want       int
+		wantErr    bool
+	}{
+		{
+			name: "success",
+			beforeFunc: func() {
+				mock.ExpectQuery(query).WithArgs(int64(7)).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(30))
+			},
+			want: 30,
+		},
+		{
+			name: "never seeded",
+			beforeFunc: func() {
+				mock.ExpectQuery(query).WithArgs(int64(7)).WillReturnRows(sqlmock.NewRows([]string{"count"}))
+			},
+			want: 0,
+		},
+		{
+			name: "error",
+			beforeFunc: func() {
+				mock.ExpectQuery(query).WillReturnError(errors.New("foo"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.beforeFunc()
+			got, err := repo.GetCount(ctx, 7)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("demoSeed.GetCount() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("demoSeed.GetCount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_demoSeed_SetCount(t *testing.T) {
+
+	ctx := context.Background()
+	now := time.Now()
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	repo := NewDemoSeed(mysqlDatabase(db), zerolog.Logger{})
+
+	mock.ExpectExec("INSERT INTO demo_seed \\(seed, count, updated_at\\) VALUES \\(\\?, \\?, \\?\\) ON DUPLICATE KEY UPDATE").
+		WithArgs(int64(7), 30, now).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := repo.SetCount(ctx, 7, 30, now); err != nil {
+		t.Errorf("demoSeed.SetCount() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_demoSeed_SetCount_postgres(t *testing.T) {
+
+	ctx := context.Background()
+	now := time.Now()
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	repo := NewDemoSeed(&infra.Database{DB: db, Dialect: infra.DialectPostgres}, zerolog.Logger{})
+
+	mock.ExpectExec("INSERT INTO demo_seed \\(seed, count, updated_at\\) VALUES \\(\\$1, \\$2, \\$3\\) ON CONFLICT \\(seed\\) DO UPDATE").
+		WithArgs(int64(7), 30, now).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := repo.SetCount(ctx, 7, 30, now); err != nil {
+		t.Errorf("demoSeed.SetCount() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
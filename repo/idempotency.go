@@ -0,0 +1,119 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/infra"
+)
+
+// IdempotencyModel is a stored Idempotency-Key. StatusCode is 0 while the
+// original request is still being processed.
+type IdempotencyModel struct {
+	Key         string         `db:"key"`
+	Fingerprint string         `db:"fingerprint"`
+	StatusCode  int            `db:"status_code"`
+	Header      sql.NullString `db:"header"`
+	Body        []byte         `db:"body"`
+	CreatedAt   time.Time      `db:"created_at"`
+	ExpiresAt   time.Time      `db:"expires_at"`
+}
+
+type Idempotency interface {
+	Reserve(ctx context.Context, input IdempotencyModel) (bool, error)
+	Get(ctx context.Context, key string) (IdempotencyModel, error)
+	Complete(ctx context.Context, key string, statusCode int, header string, body []byte) error
+	Delete(ctx context.Context, key string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type idempotency struct {
+	Log zerolog.Logger
+	DB  *infra.Database
+}
+
+func NewIdempotency(db *infra.Database, log zerolog.Logger) Idempotency {
+	return &idempotency{
+		DB:  db,
+		Log: log,
+	}
+}
+
+// Reserve stores a new in progress key, it returns false when the key already
+// exists.
+func (i *idempotency) Reserve(ctx context.Context, input IdempotencyModel) (reserved bool, err error) {
+
+	query := i.DB.Dialect.InsertIgnore("INSERT INTO idempotency_key (" + i.key() + ", fingerprint, created_at, expires_at) VALUES (?, ?, ?, ?)")
+
+	res, err := querier(ctx, i.DB).ExecContext(ctx, query, input.Key, input.Fingerprint, input.CreatedAt, input.ExpiresAt)
+	if err != nil {
+		i.Log.Error().Msg(err.Error())
+		return
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		i.Log.Error().Msg(err.Error())
+		return
+	}
+
+	return affected > 0, nil
+}
+
+func (i *idempotency) Get(ctx context.Context, key string) (output IdempotencyModel, err error) {
+
+	query := "SELECT " + i.key() + ", fingerprint, status_code, header, body, created_at, expires_at FROM idempotency_key WHERE " + i.key() + " = ?"
+
+	row := querier(ctx, i.DB).QueryRowContext(ctx, query, key)
+	err = row.Scan(&output.Key, &output.Fingerprint, &output.StatusCode, &output.Header, &output.Body, &output.CreatedAt, &output.ExpiresAt)
+	if err != nil {
+		i.Log.Error().Msg(err.Error())
+		return
+	}
+
+	return
+}
+
+// Complete records the response of the original request.
+func (i *idempotency) Complete(ctx context.Context, key string, statusCode int, header string, body []byte) (err error) {
+
+	query := "UPDATE idempotency_key SET status_code = ?, header = ?, body = ? WHERE " + i.key() + " = ?"
+
+	_, err = querier(ctx, i.DB).ExecContext(ctx, query, statusCode, header, body, key)
+	if err != nil {
+		i.Log.Error().Msg(err.Error())
+		return
+	}
+
+	return nil
+}
+
+func (i *idempotency) Delete(ctx context.Context, key string) (err error) {
+
+	_, err = querier(ctx, i.DB).ExecContext(ctx, "DELETE FROM idempotency_key WHERE "+i.key()+" = ?", key)
+	if err != nil {
+		i.Log.Error().Msg(err.Error())
+		return
+	}
+
+	return nil
+}
+
+// DeleteExpired removes every key that expired before now.
+func (i *idempotency) DeleteExpired(ctx context.Context, now time.Time) (deleted int64, err error) {
+
+	res, err := querier(ctx, i.DB).ExecContext(ctx, "DELETE FROM idempotency_key WHERE expires_at < ?", now)
+	if err != nil {
+		i.Log.Error().Msg(err.Error())
+		return
+	}
+
+	return res.RowsAffected()
+}
+
+// key is the quoted key column, KEY is a reserved word in MySQL.
+func (i *idempotency) key() string {
+	return i.DB.Dialect.Quote("key")
+}
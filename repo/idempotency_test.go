@@ -0,0 +1,177 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/infra"
+)
+
+func Test_idempotency_Reserve(t *testing.T) {
+
+	ctx := context.Background()
+	now := time.Now()
+	query := "INSERT IGNORE INTO idempotency_key \\(`key`, fingerprint, created_at, expires_at\\) VALUES \\(\\?, \\?, \\?, \\?\\)"
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	repo := NewIdempotency(mysqlDatabase(db), zerolog.Logger{})
+
+	input := IdempotencyModel{Key: "abc", Fingerprint: "f", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+
+	tests := []struct {
+		name       string
+		beforeFunc func()
+		want       bool
+		wantErr    bool
+	}{
+		{
+			name: "reserved",
+			beforeFunc: func() {
+				mock.ExpectExec(query).WithArgs("abc", "f", now, now.Add(time.Hour)).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: true,
+		},
+		{
+			name: "already exists",
+			beforeFunc: func() {
+				mock.ExpectExec(query).WithArgs("abc", "f", now, now.Add(time.Hour)).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			want: false,
+		},
+		{
+			name: "error",
+			beforeFunc: func() {
+				mock.ExpectExec(query).WillReturnError(errors.New("foo"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.beforeFunc()
+			got, err := repo.Reserve(ctx, input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("idempotency.Reserve() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("idempotency.Reserve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_idempotency_Get(t *testing.T) {
+
+	ctx := context.Background()
+	now := time.Now()
+	query := "SELECT `key`, fingerprint, status_code, header, body, created_at, expires_at FROM idempotency_key WHERE `key` = \\?"
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	repo := NewIdempotency(mysqlDatabase(db), zerolog.Logger{})
+
+	columns := []string{"key", "fingerprint", "status_code", "header", "body", "created_at", "expires_at"}
+
+	tests := []struct {
+		name       string
+		beforeFunc func()
+		want       IdempotencyModel
+		wantErr    bool
+	}{
+		{
+			name: "success",
+			beforeFunc: func() {
+				mock.ExpectQuery(query).WithArgs("abc").
+					WillReturnRows(sqlmock.NewRows(columns).AddRow("abc", "f", 201, `{"Location":["/api/v1/cake/1"]}`, []byte("{}"), now, now))
+			},
+			want: IdempotencyModel{
+				Key: "abc", Fingerprint: "f", StatusCode: 201,
+				Header:    sql.NullString{String: `{"Location":["/api/v1/cake/1"]}`, Valid: true},
+				Body:      []byte("{}"),
+				CreatedAt: now, ExpiresAt: now,
+			},
+		},
+		{
+			name: "error not found",
+			beforeFunc: func() {
+				mock.ExpectQuery(query).WithArgs("abc").WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.beforeFunc()
+			got, err := repo.Get(ctx, "abc")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("idempotency.Get() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("idempotency.Get() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_idempotency_Complete(t *testing.T) {
+
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	repo := NewIdempotency(mysqlDatabase(db), zerolog.Logger{})
+
+	mock.ExpectExec("UPDATE idempotency_key SET status_code = \\?, header = \\?, body = \\? WHERE `key` = \\?").
+		WithArgs(201, "{}", []byte("body"), "abc").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := repo.Complete(context.Background(), "abc", 201, "{}", []byte("body")); err != nil {
+		t.Errorf("idempotency.Complete() error = %v", err)
+	}
+}
+
+func Test_idempotency_DeleteExpired(t *testing.T) {
+
+	now := time.Now()
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	repo := NewIdempotency(mysqlDatabase(db), zerolog.Logger{})
+
+	mock.ExpectExec("DELETE FROM idempotency_key WHERE expires_at < \\?").
+		WithArgs(now).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	got, err := repo.DeleteExpired(context.Background(), now)
+	if err != nil || got != 3 {
+		t.Errorf("idempotency.DeleteExpired() = %v, %v, want 3", got, err)
+	}
+}
+
+func Test_idempotency_postgres(t *testing.T) {
+
+	ctx := context.Background()
+	now := time.Now()
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	repo := NewIdempotency(&infra.Database{DB: db, Dialect: infra.DialectPostgres}, zerolog.Logger{})
+
+	mock.ExpectExec("INSERT INTO idempotency_key \\(\"key\", fingerprint, created_at, expires_at\\) VALUES \\(\\$1, \\$2, \\$3, \\$4\\) ON CONFLICT DO NOTHING").
+		WithArgs("abc", "f", now, now.Add(time.Hour)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM idempotency_key WHERE \"key\" = \\$1").WithArgs("abc").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	reserved, err := repo.Reserve(ctx, IdempotencyModel{Key: "abc", Fingerprint: "f", CreatedAt: now, ExpiresAt: now.Add(time.Hour)})
+	if err != nil || !reserved {
+		t.Errorf("idempotency.Reserve() = %v, %v, want true", reserved, err)
+	}
+	if err := repo.Delete(ctx, "abc"); err != nil {
+		t.Errorf("idempotency.Delete() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,190 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/infra"
+	"gitlab.com/cake-store-RESTFul/migration"
+)
+
+// integrationDatabases are the engines the integration suite runs against.
+// MySQL and PostgreSQL are skipped unless their DSN is set, the MySQL DSN
+// needs parseTime=true. SQLite runs on a temporary file by default.
+var integrationDatabases = []struct {
+	dialect infra.Dialect
+	driver  string
+	env     string
+}{
+	{dialect: infra.DialectMySQL, driver: "mysql", env: "CAKE_TEST_MYSQL_DSN"},
+	{dialect: infra.DialectPostgres, driver: "postgres", env: "CAKE_TEST_POSTGRES_DSN"},
+	{dialect: infra.DialectSQLite, driver: "sqlite", env: "CAKE_TEST_SQLITE_DSN"},
+}
+
+func Test_integration(t *testing.T) {
+
+	for _, database := range integrationDatabases {
+		database := database
+		t.Run(string(database.dialect), func(t *testing.T) {
+
+			dsn := os.Getenv(database.env)
+			if dsn == "" && database.dialect == infra.DialectSQLite {
+				dsn = "file:" + filepath.Join(t.TempDir(), "cake-store.db") + "?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)"
+			}
+			if dsn == "" {
+				t.Skipf("%s is not set", database.env)
+			}
+
+			db, err := sql.Open(database.driver, dsn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			runIntegration(t, &infra.Database{DB: db, Dialect: database.dialect})
+		})
+	}
+}
+
+// runIntegration migrates the database from scratch and runs the repositories
+// against it.
+func runIntegration(t *testing.T, db *infra.Database) {
+
+	ctx := context.Background()
+	log := zerolog.Logger{}
+	now := time.Now().UTC().Truncate(time.Second)
+
+	migrator, err := migration.NewMigrator(db, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Goto(ctx, 0); err != nil {
+		t.Fatalf("migrate down: %v", err)
+	}
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+
+	cakes := NewCake(db, log)
+	id, err := cakes.Create(ctx, CakeBaseModel{Title: "Lemon Cheesecake", Description: "tangy", Rating: 8.5, CreatedAt: now})
+	if err != nil {
+		t.Fatalf("cake.Create() error = %v", err)
+	}
+	ids, err := cakes.CreateBatch(ctx, []CakeBaseModel{
+		{Title: "Chocolate Torte", Description: "rich", Rating: 9, CreatedAt: now},
+		{Title: "Carrot Cake", Description: "spiced", Rating: 7, CreatedAt: now},
+	})
+	if err != nil {
+		t.Fatalf("cake.CreateBatch() error = %v", err)
+	}
+	if batch, _ := cakes.GetByIDs(ctx, ids); len(batch) != 2 || batch[0].Title != "Chocolate Torte" || batch[1].Title != "Carrot Cake" {
+		t.Errorf("cake.GetByIDs() of the batch IDs %v = %+v", ids, batch)
+	}
+
+	got, err := cakes.GetDetail(ctx, id)
+	if err != nil || got.Title != "Lemon Cheesecake" || !got.CreatedAt.Equal(now) {
+		t.Errorf("cake.GetDetail() = %+v, %v", got, err)
+	}
+
+	list, err := cakes.GetList(ctx, 10, 0, "cheese", "id", "asc")
+	if err != nil || len(list) != 1 || list[0].ID != id {
+		t.Errorf("cake.GetList() = %+v, %v, want the cheesecake", list, err)
+	}
+
+	count, err := cakes.CountCake(ctx, "CAKE")
+	if err != nil || count != 2 {
+		t.Errorf("cake.CountCake() = %v, %v, want 2", count, err)
+	}
+
+	title := "Lemon Curd Cheesecake"
+	if err := cakes.Update(ctx, CakeUpdateModel{ID: id, Title: &title, UpdatedAt: sql.NullTime{Time: now, Valid: true}}); err != nil {
+		t.Errorf("cake.Update() error = %v", err)
+	}
+	if got, _ := cakes.GetDetail(ctx, id); got.Title != title {
+		t.Errorf("cake.GetDetail() after Update title = %q, want %q", got.Title, title)
+	}
+
+	if err := cakes.Delete(ctx, id); err != nil {
+		t.Errorf("cake.Delete() error = %v", err)
+	}
+	if _, err := cakes.GetDetail(ctx, id); err != sql.ErrNoRows {
+		t.Errorf("cake.GetDetail() after Delete error = %v, want sql.ErrNoRows", err)
+	}
+
+	results, err := cakes.BulkDelete(ctx, BulkFilter{Search: "torte"}, true)
+	if err != nil || len(results) != 1 || !results[0].Found {
+		t.Errorf("cake.BulkDelete() = %+v, %v, want the torte", results, err)
+	}
+
+	// every write, batch and bulk ones included, wrote an event per cake
+	outbox := NewOutbox(db, log)
+	pending, err := outbox.GetPending(ctx, 10)
+	events := []string{}
+	for _, event := range pending {
+		events = append(events, fmt.Sprintf("%s %d", event.Event, event.AggregateID))
+	}
+	wantEvents := []string{
+		"cake.created 1", "cake.created 2", "cake.created 3",
+		"cake.updated 1", "cake.deleted 1", "cake.deleted 2",
+	}
+	if err != nil || !reflect.DeepEqual(events, wantEvents) {
+		t.Errorf("outbox.GetPending() = %v, %v, want %v", events, err, wantEvents)
+	}
+
+	release, locked, err := outbox.Lock(ctx)
+	if err != nil || !locked {
+		t.Fatalf("outbox.Lock() = %v, %v, want the lock", locked, err)
+	}
+	if _, again, _ := outbox.Lock(ctx); again {
+		t.Error("outbox.Lock() while held = true")
+	}
+	release()
+
+	idempotency := NewIdempotency(db, log)
+	key := IdempotencyModel{Key: "integration", Fingerprint: "f", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	for i, want := range []bool{true, false} {
+		reserved, err := idempotency.Reserve(ctx, key)
+		if err != nil || reserved != want {
+			t.Errorf("idempotency.Reserve() #%d = %v, %v, want %v", i, reserved, err, want)
+		}
+	}
+
+	webhooks := NewWebhook(db, log)
+	if _, err := webhooks.Create(ctx, WebhookModel{URL: "https://example.com", Secret: "s", Events: "cake.created,cake.deleted", Active: true, CreatedAt: now}); err != nil {
+		t.Fatalf("webhook.Create() error = %v", err)
+	}
+	hooks, err := webhooks.GetByEvent(ctx, "cake.deleted")
+	if err != nil || len(hooks) != 1 {
+		t.Errorf("webhook.GetByEvent() = %+v, %v, want one webhook", hooks, err)
+	}
+
+	created, err := NewAdminUser(db, log).Create(ctx, AdminUserModel{Name: "ops", TokenHash: "h", CreatedAt: now})
+	if err != nil || !created {
+		t.Errorf("adminUser.Create() = %v, %v, want true", created, err)
+	}
+
+	err = NewTxManager(db, log).WithinTx(ctx, func(ctx context.Context) error {
+		seeds := NewDemoSeed(db, log)
+		if err := seeds.SetCount(ctx, 1, 10, now); err != nil {
+			return err
+		}
+		if err := seeds.SetCount(ctx, 1, 20, now); err != nil {
+			return err
+		}
+		count, err := seeds.GetCount(ctx, 1)
+		if count != 20 {
+			t.Errorf("demoSeed.GetCount() = %v, want 20", count)
+		}
+		return err
+	})
+	if err != nil {
+		t.Errorf("txManager.WithinTx() error = %v", err)
+	}
+}
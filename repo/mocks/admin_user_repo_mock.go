@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./repo/admin_user.go
+
+// Package mock_repo is a generated GoMock package.
+package mock_repo
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	repo "gitlab.com/cake-store-RESTFul/repo"
+)
+
+// MockAdminUser is a mock of AdminUser interface.
+type MockAdminUser struct {
+	ctrl     *gomock.Controller
+	recorder *MockAdminUserMockRecorder
+}
+
+// MockAdminUserMockRecorder is the mock recorder for MockAdminUser.
+type MockAdminUserMockRecorder struct {
+	mock *MockAdminUser
+}
+
+// NewMockAdminUser creates a new mock instance.
+func NewMockAdminUser(ctrl *gomock.Controller) *MockAdminUser {
+	mock := &MockAdminUser{ctrl: ctrl}
+	mock.recorder = &MockAdminUserMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAdminUser) EXPECT() *MockAdminUserMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockAdminUser) Create(ctx context.Context, input repo.AdminUserModel) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, input)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockAdminUserMockRecorder) Create(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockAdminUser)(nil).Create), ctx, input)
+}
+
+// GetByTokenHash mocks base method.
+func (m *MockAdminUser) GetByTokenHash(ctx context.Context, tokenHash string) (repo.AdminUserModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByTokenHash", ctx, tokenHash)
+	ret0, _ := ret[0].(repo.AdminUserModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByTokenHash indicates an expected call of GetByTokenHash.
+func (mr *MockAdminUserMockRecorder) GetByTokenHash(ctx, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByTokenHash", reflect.TypeOf((*MockAdminUser)(nil).GetByTokenHash), ctx, tokenHash)
+}
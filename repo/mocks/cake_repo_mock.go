@@ -35,16 +35,34 @@ func (m *MockCake) EXPECT() *MockCakeMockRecorder {
 	return m.recorder
 }
 
-// Close mocks base method.
-func (m *MockCake) Close() {
+// BulkDelete mocks base method.
+func (m *MockCake) BulkDelete(ctx context.Context, filter repo.BulkFilter, atomic bool) ([]repo.BulkResult, error) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "Close")
+	ret := m.ctrl.Call(m, "BulkDelete", ctx, filter, atomic)
+	ret0, _ := ret[0].([]repo.BulkResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BulkDelete indicates an expected call of BulkDelete.
+func (mr *MockCakeMockRecorder) BulkDelete(ctx, filter, atomic interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkDelete", reflect.TypeOf((*MockCake)(nil).BulkDelete), ctx, filter, atomic)
+}
+
+// BulkUpdate mocks base method.
+func (m *MockCake) BulkUpdate(ctx context.Context, filter repo.BulkFilter, input repo.CakeUpdateModel, atomic bool) ([]repo.BulkResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BulkUpdate", ctx, filter, input, atomic)
+	ret0, _ := ret[0].([]repo.BulkResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// Close indicates an expected call of Close.
-func (mr *MockCakeMockRecorder) Close() *gomock.Call {
+// BulkUpdate indicates an expected call of BulkUpdate.
+func (mr *MockCakeMockRecorder) BulkUpdate(ctx, filter, input, atomic interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockCake)(nil).Close))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkUpdate", reflect.TypeOf((*MockCake)(nil).BulkUpdate), ctx, filter, input, atomic)
 }
 
 // CountCake mocks base method.
@@ -63,11 +81,12 @@ func (mr *MockCakeMockRecorder) CountCake(ctx, search interface{}) *gomock.Call
 }
 
 // Create mocks base method.
-func (m *MockCake) Create(ctx context.Context, input repo.CakeBaseModel) error {
+func (m *MockCake) Create(ctx context.Context, input repo.CakeBaseModel) (int, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Create", ctx, input)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
@@ -76,6 +95,21 @@ func (mr *MockCakeMockRecorder) Create(ctx, input interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockCake)(nil).Create), ctx, input)
 }
 
+// CreateBatch mocks base method.
+func (m *MockCake) CreateBatch(ctx context.Context, input []repo.CakeBaseModel) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBatch", ctx, input)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateBatch indicates an expected call of CreateBatch.
+func (mr *MockCakeMockRecorder) CreateBatch(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBatch", reflect.TypeOf((*MockCake)(nil).CreateBatch), ctx, input)
+}
+
 // Delete mocks base method.
 func (m *MockCake) Delete(ctx context.Context, id int) error {
 	m.ctrl.T.Helper()
@@ -90,6 +124,21 @@ func (mr *MockCakeMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCake)(nil).Delete), ctx, id)
 }
 
+// GetByIDs mocks base method.
+func (m *MockCake) GetByIDs(ctx context.Context, ids []int) ([]repo.CakeBaseModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDs", ctx, ids)
+	ret0, _ := ret[0].([]repo.CakeBaseModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDs indicates an expected call of GetByIDs.
+func (mr *MockCakeMockRecorder) GetByIDs(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDs", reflect.TypeOf((*MockCake)(nil).GetByIDs), ctx, ids)
+}
+
 // GetDetail mocks base method.
 func (m *MockCake) GetDetail(ctx context.Context, id int) (repo.CakeBaseModel, error) {
 	m.ctrl.T.Helper()
@@ -120,8 +169,22 @@ func (mr *MockCakeMockRecorder) GetList(ctx, limit, offset, search, sort, sortBy
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetList", reflect.TypeOf((*MockCake)(nil).GetList), ctx, limit, offset, search, sort, sortBy)
 }
 
+// Stream mocks base method.
+func (m *MockCake) Stream(ctx context.Context, search, sort, sortBy string, fn func(repo.CakeBaseModel) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stream", ctx, search, sort, sortBy, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Stream indicates an expected call of Stream.
+func (mr *MockCakeMockRecorder) Stream(ctx, search, sort, sortBy, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stream", reflect.TypeOf((*MockCake)(nil).Stream), ctx, search, sort, sortBy, fn)
+}
+
 // Update mocks base method.
-func (m *MockCake) Update(ctx context.Context, input repo.CakeBaseModel) error {
+func (m *MockCake) Update(ctx context.Context, input repo.CakeUpdateModel) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Update", ctx, input)
 	ret0, _ := ret[0].(error)
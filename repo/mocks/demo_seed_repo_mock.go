@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./repo/demo_seed.go
+
+// Package mock_repo is a generated GoMock package.
+package mock_repo
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockDemoSeed is a mock of DemoSeed interface.
+type MockDemoSeed struct {
+	ctrl     *gomock.Controller
+	recorder *MockDemoSeedMockRecorder
+}
+
+// MockDemoSeedMockRecorder is the mock recorder for MockDemoSeed.
+type MockDemoSeedMockRecorder struct {
+	mock *MockDemoSeed
+}
+
+// NewMockDemoSeed creates a new mock instance.
+func NewMockDemoSeed(ctrl *gomock.Controller) *MockDemoSeed {
+	mock := &MockDemoSeed{ctrl: ctrl}
+	mock.recorder = &MockDemoSeedMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDemoSeed) EXPECT() *MockDemoSeedMockRecorder {
+	return m.recorder
+}
+
+// GetCount mocks base method.
+func (m *MockDemoSeed) GetCount(ctx context.Context, seed int64) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCount", ctx, seed)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCount indicates an expected call of GetCount.
+func (mr *MockDemoSeedMockRecorder) GetCount(ctx, seed interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCount", reflect.TypeOf((*MockDemoSeed)(nil).GetCount), ctx, seed)
+}
+
+// SetCount mocks base method.
+func (m *MockDemoSeed) SetCount(ctx context.Context, seed int64, count int, updatedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCount", ctx, seed, count, updatedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetCount indicates an expected call of SetCount.
+func (mr *MockDemoSeedMockRecorder) SetCount(ctx, seed, count, updatedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCount", reflect.TypeOf((*MockDemoSeed)(nil).SetCount), ctx, seed, count, updatedAt)
+}
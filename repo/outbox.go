@@ -0,0 +1,203 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/infra"
+)
+
+// Cake events written to the outbox.
+const (
+	OutboxCakeCreated = "cake.created"
+	OutboxCakeUpdated = "cake.updated"
+	OutboxCakeDeleted = "cake.deleted"
+)
+
+// outboxLock is the named lock held by the instance relaying the outbox,
+// a single relay keeps the events of a cake in order.
+const outboxLock = "cake_store_outbox_relay"
+
+// OutboxModel is an event waiting to be published, it is written in the same
+// transaction as the cake change it describes. SentAt is set once the event
+// was published.
+type OutboxModel struct {
+	ID          int64          `db:"id"`
+	EventID     string         `db:"event_id"`
+	AggregateID int            `db:"aggregate_id"`
+	Event       string         `db:"event"`
+	Payload     []byte         `db:"payload"`
+	Attempts    int            `db:"attempts"`
+	LastError   sql.NullString `db:"last_error"`
+	CreatedAt   time.Time      `db:"created_at"`
+	SentAt      sql.NullTime   `db:"sent_at"`
+}
+
+// OutboxCake is the payload of the cake.created and cake.updated events.
+// Every field is always sent, a zero rating or an empty description is a value
+// the consumers must see.
+type OutboxCake struct {
+	ID          int        `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Image       string     `json:"image"`
+	Rating      float32    `json:"rating"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+}
+
+// OutboxCakeKey is the payload of the cake.deleted event.
+type OutboxCakeKey struct {
+	ID int `json:"id"`
+}
+
+const outboxColumns = "id, event_id, aggregate_id, event, payload, attempts, last_error, created_at, sent_at"
+
+type Outbox interface {
+	// Lock takes the relay lock without waiting, ok is false when another
+	// instance holds it. release must be called when ok is true.
+	Lock(ctx context.Context) (release func(), ok bool, err error)
+	GetPending(ctx context.Context, limit int) ([]OutboxModel, error)
+	MarkSent(ctx context.Context, id int64, sentAt time.Time) error
+	MarkFailed(ctx context.Context, id int64, lastError string) error
+	DeleteSent(ctx context.Context, before time.Time) (int64, error)
+}
+
+type outbox struct {
+	Log zerolog.Logger
+	DB  *infra.Database
+}
+
+func NewOutbox(db *infra.Database, log zerolog.Logger) Outbox {
+	return &outbox{
+		DB:  db,
+		Log: log,
+	}
+}
+
+func (o *outbox) Lock(ctx context.Context) (release func(), ok bool, err error) {
+
+	// named locks belong to a connection, so the same one must release it
+	conn, err := o.DB.DB.Conn(ctx)
+	if err != nil {
+		o.Log.Error().Msg(err.Error())
+		return
+	}
+
+	acquired, err := o.DB.Dialect.Lock(ctx, conn, outboxLock, 0)
+	if err != nil {
+		o.Log.Error().Msg(err.Error())
+		conn.Close()
+		return
+	}
+
+	if !acquired {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	release = func() {
+		if err := o.DB.Dialect.Unlock(context.Background(), conn, outboxLock); err != nil {
+			o.Log.Error().Msg(err.Error())
+		}
+		conn.Close()
+	}
+
+	return release, true, nil
+}
+
+// GetPending returns the unsent events in the order they were written.
+func (o *outbox) GetPending(ctx context.Context, limit int) (output []OutboxModel, err error) {
+
+	query := fmt.Sprintf("SELECT %s FROM outbox WHERE sent_at IS NULL ORDER BY id LIMIT %d", outboxColumns, limit)
+
+	rows, err := querier(ctx, o.DB).QueryContext(ctx, query)
+	if err != nil {
+		o.Log.Error().Msg(err.Error())
+		return
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		event := OutboxModel{}
+		err = rows.Scan(&event.ID, &event.EventID, &event.AggregateID, &event.Event, &event.Payload, &event.Attempts, &event.LastError, &event.CreatedAt, &event.SentAt)
+		if err != nil {
+			o.Log.Error().Msg(err.Error())
+			return
+		}
+		output = append(output, event)
+	}
+
+	return output, rows.Err()
+}
+
+func (o *outbox) MarkSent(ctx context.Context, id int64, sentAt time.Time) (err error) {
+
+	_, err = querier(ctx, o.DB).ExecContext(ctx, "UPDATE outbox SET sent_at = ?, attempts = attempts + 1, last_error = NULL WHERE id = ?", sentAt, id)
+	if err != nil {
+		o.Log.Error().Msg(err.Error())
+	}
+
+	return
+}
+
+func (o *outbox) MarkFailed(ctx context.Context, id int64, lastError string) (err error) {
+
+	_, err = querier(ctx, o.DB).ExecContext(ctx, "UPDATE outbox SET attempts = attempts + 1, last_error = ? WHERE id = ?", lastError, id)
+	if err != nil {
+		o.Log.Error().Msg(err.Error())
+	}
+
+	return
+}
+
+// DeleteSent removes the events sent before the given time.
+func (o *outbox) DeleteSent(ctx context.Context, before time.Time) (deleted int64, err error) {
+
+	res, err := querier(ctx, o.DB).ExecContext(ctx, "DELETE FROM outbox WHERE sent_at IS NOT NULL AND sent_at < ?", before)
+	if err != nil {
+		o.Log.Error().Msg(err.Error())
+		return
+	}
+
+	return res.RowsAffected()
+}
+
+// writeOutbox records the event of the cake id inside tx, it is only relayed
+// when tx commits.
+func writeOutbox(ctx context.Context, tx DBTX, event string, id int, payload interface{}, createdAt time.Time) error {
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	query := "INSERT INTO outbox (event_id, aggregate_id, event, payload, created_at) VALUES (?, ?, ?, ?, ?)"
+	// a string, PostgreSQL drivers send []byte as bytea
+	_, err = tx.ExecContext(ctx, query, uuid.NewString(), id, event, string(data), createdAt)
+	return err
+}
+
+func outboxCake(model CakeBaseModel) OutboxCake {
+
+	payload := OutboxCake{
+		ID:          model.ID,
+		Title:       model.Title,
+		Description: model.Description,
+		Image:       model.Image,
+		Rating:      model.Rating,
+		CreatedAt:   model.CreatedAt,
+	}
+
+	if model.UpdatedAt.Valid {
+		payload.UpdatedAt = &model.UpdatedAt.Time
+	}
+
+	return payload
+}
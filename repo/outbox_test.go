@@ -0,0 +1,203 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+)
+
+func Test_outbox_Lock(t *testing.T) {
+
+	ctx := context.Background()
+	query := "SELECT GET_LOCK\\(\\?, \\?\\)"
+
+	tests := []struct {
+		name       string
+		beforeFunc func(mock sqlmock.Sqlmock)
+		want       bool
+		wantErr    bool
+	}{
+		{
+			name: "acquired",
+			beforeFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).WithArgs(outboxLock, 0).WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+				mock.ExpectExec("SELECT RELEASE_LOCK\\(\\?\\)").WithArgs(outboxLock).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			want: true,
+		},
+		{
+			name: "held by another instance",
+			beforeFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).WithArgs(outboxLock, 0).WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(0))
+			},
+			want: false,
+		},
+		{
+			name: "error",
+			beforeFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).WillReturnError(errors.New("foo"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+			repo := NewOutbox(mysqlDatabase(db), zerolog.Logger{})
+
+			tt.beforeFunc(mock)
+			release, got, err := repo.Lock(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("outbox.Lock() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("outbox.Lock() = %v, want %v", got, tt.want)
+			}
+			if got {
+				release()
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func Test_outbox_GetPending(t *testing.T) {
+
+	ctx := context.Background()
+	now := time.Now()
+	query := "SELECT id, event_id, aggregate_id, event, payload, attempts, last_error, created_at, sent_at FROM outbox WHERE sent_at IS NULL ORDER BY id LIMIT 10"
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	repo := NewOutbox(mysqlDatabase(db), zerolog.Logger{})
+
+	columns := []string{"id", "event_id", "aggregate_id", "event", "payload", "attempts", "last_error", "created_at", "sent_at"}
+
+	tests := []struct {
+		name       string
+		beforeFunc func()
+		want       []OutboxModel
+		wantErr    bool
+	}{
+		{
+			name: "success",
+			beforeFunc: func() {
+				mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows(columns).
+					AddRow(1, "e1", 3, "cake.created", []byte(`{"id":3}`), 0, nil, now, nil).
+					AddRow(2, "e2", 3, "cake.deleted", []byte(`{"id":3}`), 1, "broker down", now, nil))
+			},
+			want: []OutboxModel{
+				{ID: 1, EventID: "e1", AggregateID: 3, Event: "cake.created", Payload: []byte(`{"id":3}`), CreatedAt: now},
+				{ID: 2, EventID: "e2", AggregateID: 3, Event: "cake.deleted", Payload: []byte(`{"id":3}`), Attempts: 1, LastError: sql.NullString{String: "broker down", Valid: true}, CreatedAt: now},
+			},
+		},
+		{
+			name: "error",
+			beforeFunc: func() {
+				mock.ExpectQuery(query).WillReturnError(errors.New("foo"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.beforeFunc()
+			got, err := repo.GetPending(ctx, 10)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("outbox.GetPending() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("outbox.GetPending() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_outbox_DeleteSent(t *testing.T) {
+
+	ctx := context.Background()
+	before := time.Now()
+	query := "DELETE FROM outbox WHERE sent_at IS NOT NULL AND sent_at < \\?"
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	repo := NewOutbox(mysqlDatabase(db), zerolog.Logger{})
+
+	tests := []struct {
+		name       string
+		beforeFunc func()
+		want       int64
+		wantErr    bool
+	}{
+		{
+			name: "success",
+			beforeFunc: func() {
+				mock.ExpectExec(query).WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 4))
+			},
+			want: 4,
+		},
+		{
+			name: "error",
+			beforeFunc: func() {
+				mock.ExpectExec(query).WillReturnError(errors.New("foo"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.beforeFunc()
+			got, err := repo.DeleteSent(ctx, before)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("outbox.DeleteSent() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("outbox.DeleteSent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_outboxCake(t *testing.T) {
+
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		model CakeBaseModel
+		want  string
+	}{
+		{
+			name:  "zero and empty values are sent",
+			model: CakeBaseModel{ID: 3, Title: "Cheese Cake", CreatedAt: createdAt},
+			want:  `{"id":3,"title":"Cheese Cake","description":"","image":"","rating":0,"created_at":"2024-01-02T03:04:05Z","updated_at":null}`,
+		},
+		{
+			name:  "updated",
+			model: CakeBaseModel{ID: 3, Title: "Cheese Cake", Rating: 4.5, CreatedAt: createdAt, UpdatedAt: sql.NullTime{Time: createdAt, Valid: true}},
+			want:  `{"id":3,"title":"Cheese Cake","description":"","image":"","rating":4.5,"created_at":"2024-01-02T03:04:05Z","updated_at":"2024-01-02T03:04:05Z"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(outboxCake(tt.model))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("outboxCake() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
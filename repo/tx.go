@@ -0,0 +1,167 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/infra"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+const (
+	// txMaxAttempts bounds how often a transaction failing on a deadlock or a
+	// lock wait timeout is run.
+	txMaxAttempts = 3
+	txRetryDelay  = 20 * time.Millisecond
+
+	mysqlErrLockWaitTimeout = 1205
+	mysqlErrDeadlock        = 1213
+
+	postgresErrSerialization = "40001"
+	postgresErrDeadlock      = "40P01"
+	postgresErrLockTimeout   = "55P03"
+)
+
+// DBTX is implemented by both *sql.DB and *sql.Tx.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+type TxManager interface {
+	// WithinTx runs fn in a transaction carried by the context fn gets, every
+	// repo method called with that context joins the transaction. It is
+	// committed when fn returns nil and rolled back otherwise.
+	//
+	// Inside another WithinTx fn runs in a savepoint, only its own writes are
+	// rolled back when it fails. A transaction failing on a deadlock or a lock
+	// wait timeout is run again, so fn must not have effects outside the
+	// database.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txKey struct{}
+
+type txState struct {
+	tx    *sql.Tx
+	depth int
+}
+
+type txManager struct {
+	Log zerolog.Logger
+	DB  *infra.Database
+}
+
+func NewTxManager(db *infra.Database, log zerolog.Logger) TxManager {
+	return &txManager{
+		DB:  db,
+		Log: log,
+	}
+}
+
+func (t *txManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withinTx(ctx, t.DB, t.Log, fn)
+}
+
+func withinTx(ctx context.Context, db *infra.Database, log zerolog.Logger, fn func(ctx context.Context) error) (err error) {
+
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return savepoint(ctx, state, log, fn)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = runTx(ctx, db, fn)
+		if err == nil || !isRetryable(err) || attempt == txMaxAttempts {
+			return err
+		}
+
+		log.Warn().Int("attempt", attempt).Msg(err.Error())
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * txRetryDelay):
+		}
+	}
+}
+
+func runTx(ctx context.Context, db *infra.Database, fn func(ctx context.Context) error) (err error) {
+
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	err = fn(context.WithValue(ctx, txKey{}, &txState{tx: tx}))
+	if err != nil {
+		_ = tx.Rollback()
+		return
+	}
+
+	return tx.Commit()
+}
+
+func savepoint(ctx context.Context, state *txState, log zerolog.Logger, fn func(ctx context.Context) error) (err error) {
+
+	nested := &txState{tx: state.tx, depth: state.depth + 1}
+	name := fmt.Sprintf("sp_%d", nested.depth)
+
+	_, err = state.tx.ExecContext(ctx, "SAVEPOINT "+name)
+	if err != nil {
+		return
+	}
+
+	err = fn(context.WithValue(ctx, txKey{}, nested))
+	if err != nil {
+		// a deadlock already rolled back the whole transaction and its
+		// savepoints, the outermost WithinTx runs it again
+		if !isMySQLError(err, mysqlErrDeadlock) {
+			if _, rollbackErr := state.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rollbackErr != nil {
+				log.Error().Msg(rollbackErr.Error())
+			}
+		}
+		return
+	}
+
+	_, err = state.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	return
+}
+
+func isRetryable(err error) bool {
+	return isMySQLError(err, mysqlErrDeadlock) || isMySQLError(err, mysqlErrLockWaitTimeout) ||
+		isPostgresError(err, postgresErrSerialization) || isPostgresError(err, postgresErrDeadlock) || isPostgresError(err, postgresErrLockTimeout) ||
+		isSQLiteError(err, sqlite3.SQLITE_BUSY) || isSQLiteError(err, sqlite3.SQLITE_LOCKED)
+}
+
+func isMySQLError(err error, number uint16) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == number
+}
+
+func isPostgresError(err error, code pq.ErrorCode) bool {
+	var postgresErr *pq.Error
+	return errors.As(err, &postgresErr) && postgresErr.Code == code
+}
+
+// isSQLiteError compares the primary result code, the extended codes keep it
+// in their lowest byte.
+func isSQLiteError(err error, code int) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code()&0xff == code
+}
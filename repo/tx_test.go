@@ -0,0 +1,177 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/rs/zerolog"
+)
+
+func Test_txManager_WithinTx(t *testing.T) {
+
+	ctx := context.Background()
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+	deleteQuery := "DELETE FROM webhook WHERE id = \\?"
+
+	tests := []struct {
+		name       string
+		beforeFunc func(mock sqlmock.Sqlmock)
+		fn         func(ctx context.Context, hooks Webhook, tm TxManager) error
+		wantErr    bool
+		wantCalls  int
+	}{
+		{
+			name: "repo calls join the transaction",
+			beforeFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(deleteQuery).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(deleteQuery).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			fn: func(ctx context.Context, hooks Webhook, _ TxManager) error {
+				if err := hooks.Delete(ctx, 1); err != nil {
+					return err
+				}
+				return hooks.Delete(ctx, 2)
+			},
+			wantCalls: 1,
+		},
+		{
+			name: "error rolls back",
+			beforeFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(deleteQuery).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(deleteQuery).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			fn: func(ctx context.Context, hooks Webhook, _ TxManager) error {
+				if err := hooks.Delete(ctx, 1); err != nil {
+					return err
+				}
+				return hooks.Delete(ctx, 2)
+			},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name: "nested calls use savepoints",
+			beforeFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(deleteQuery).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("RELEASE SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(deleteQuery).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("ROLLBACK TO SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			fn: func(ctx context.Context, hooks Webhook, tm TxManager) error {
+				err := tm.WithinTx(ctx, func(ctx context.Context) error {
+					return hooks.Delete(ctx, 1)
+				})
+				if err != nil {
+					return err
+				}
+
+				// the failed savepoint does not fail the outer transaction
+				_ = tm.WithinTx(ctx, func(ctx context.Context) error {
+					return hooks.Delete(ctx, 2)
+				})
+				return nil
+			},
+			wantCalls: 1,
+		},
+		{
+			name: "deadlock is retried",
+			beforeFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(deleteQuery).WithArgs(1).WillReturnError(deadlock)
+				mock.ExpectRollback()
+				mock.ExpectBegin()
+				mock.ExpectExec(deleteQuery).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			fn: func(ctx context.Context, hooks Webhook, _ TxManager) error {
+				return hooks.Delete(ctx, 1)
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "deadlock gives up after the last attempt",
+			beforeFunc: func(mock sqlmock.Sqlmock) {
+				for i := 0; i < txMaxAttempts; i++ {
+					mock.ExpectBegin()
+					mock.ExpectExec(deleteQuery).WithArgs(1).WillReturnError(deadlock)
+					mock.ExpectRollback()
+				}
+			},
+			fn: func(ctx context.Context, hooks Webhook, _ TxManager) error {
+				return hooks.Delete(ctx, 1)
+			},
+			wantErr:   true,
+			wantCalls: txMaxAttempts,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+			database := mysqlDatabase(db)
+			hooks := NewWebhook(database, zerolog.Logger{})
+			tm := NewTxManager(database, zerolog.Logger{})
+
+			tt.beforeFunc(mock)
+			calls := 0
+			err := tm.WithinTx(ctx, func(ctx context.Context) error {
+				calls++
+				return tt.fn(ctx, hooks, tm)
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("txManager.WithinTx() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("txManager.WithinTx() ran fn %d times, want %d", calls, tt.wantCalls)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func Test_txManager_WithinTx_cakeWrite(t *testing.T) {
+
+	ctx := context.Background()
+	now := time.Now()
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	database := mysqlDatabase(db)
+	cake := NewCake(database, zerolog.Logger{})
+	tm := NewTxManager(database, zerolog.Logger{})
+
+	// the cake write and its outbox event run in a savepoint of the outer
+	// transaction instead of a transaction of their own
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO cake").WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectExec("INSERT INTO outbox").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("RELEASE SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := tm.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := cake.Create(ctx, CakeBaseModel{Title: "test", CreatedAt: now}); err != nil {
+			return err
+		}
+		return errors.New("foo")
+	})
+	if err == nil {
+		t.Error("txManager.WithinTx() error = nil, want foo")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
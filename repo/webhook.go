@@ -0,0 +1,319 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/infra"
+)
+
+// WebhookModel is a subscription, Events is a comma separated list of event
+// types.
+type WebhookModel struct {
+	ID        int          `db:"id"`
+	URL       string       `db:"url"`
+	Secret    string       `db:"secret"`
+	Events    string       `db:"events"`
+	Active    bool         `db:"active"`
+	CreatedAt time.Time    `db:"created_at"`
+	UpdatedAt sql.NullTime `db:"updated_at"`
+}
+
+// WebhookDeliveryModel is one attempt to deliver an event to a webhook, it is
+// retried until Status is no longer pending.
+type WebhookDeliveryModel struct {
+	ID            int            `db:"id"`
+	WebhookID     int            `db:"webhook_id"`
+	EventID       string         `db:"event_id"`
+	Event         string         `db:"event"`
+	Payload       []byte         `db:"payload"`
+	Status        string         `db:"status"`
+	Attempts      int            `db:"attempts"`
+	NextAttemptAt sql.NullTime   `db:"next_attempt_at"`
+	ResponseCode  sql.NullInt64  `db:"response_code"`
+	ResponseBody  sql.NullString `db:"response_body"`
+	LastError     sql.NullString `db:"last_error"`
+	CreatedAt     time.Time      `db:"created_at"`
+	UpdatedAt     sql.NullTime   `db:"updated_at"`
+}
+
+const webhookColumns = "id, url, secret, events, active, created_at, updated_at"
+
+const webhookDeliveryColumns = "id, webhook_id, event_id, event, payload, status, attempts, next_attempt_at, response_code, response_body, last_error, created_at, updated_at"
+
+type Webhook interface {
+	Create(ctx context.Context, input WebhookModel) (int, error)
+	GetList(ctx context.Context) ([]WebhookModel, error)
+	GetDetail(ctx context.Context, id int) (WebhookModel, error)
+	GetByEvent(ctx context.Context, event string) ([]WebhookModel, error)
+	Update(ctx context.Context, input WebhookModel) error
+	Delete(ctx context.Context, id int) error
+	CreateDelivery(ctx context.Context, input WebhookDeliveryModel) (int, error)
+	CreateDeliveries(ctx context.Context, input []WebhookDeliveryModel) error
+	GetDeliveries(ctx context.Context, webhookID, limit, offset int) ([]WebhookDeliveryModel, error)
+	CountDeliveries(ctx context.Context, webhookID int) (int, error)
+	GetDelivery(ctx context.Context, id int) (WebhookDeliveryModel, error)
+	GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]WebhookDeliveryModel, error)
+	ClaimDelivery(ctx context.Context, id int, now, leaseUntil time.Time) (bool, error)
+	UpdateDelivery(ctx context.Context, input WebhookDeliveryModel) error
+}
+
+type webhook struct {
+	Log zerolog.Logger
+	DB  *infra.Database
+}
+
+func NewWebhook(db *infra.Database, log zerolog.Logger) Webhook {
+	return &webhook{
+		DB:  db,
+		Log: log,
+	}
+}
+
+func (w *webhook) Create(ctx context.Context, input WebhookModel) (id int, err error) {
+
+	query := "INSERT INTO webhook (url, secret, events, active, created_at) VALUES (?, ?, ?, ?, ?)"
+
+	lastID, err := insertID(ctx, querier(ctx, w.DB), w.DB.Dialect, query, input.URL, input.Secret, input.Events, input.Active, input.CreatedAt)
+	if err != nil {
+		w.Log.Error().Msg(err.Error())
+		return
+	}
+
+	return int(lastID), nil
+}
+
+func (w *webhook) GetList(ctx context.Context) (output []WebhookModel, err error) {
+	return w.query(ctx, "SELECT "+webhookColumns+" FROM webhook ORDER BY id")
+}
+
+func (w *webhook) GetDetail(ctx context.Context, id int) (output WebhookModel, err error) {
+
+	row := querier(ctx, w.DB).QueryRowContext(ctx, "SELECT "+webhookColumns+" FROM webhook WHERE id = ?", id)
+	err = row.Scan(&output.ID, &output.URL, &output.Secret, &output.Events, &output.Active, &output.CreatedAt, &output.UpdatedAt)
+	if err != nil {
+		w.Log.Error().Msg(err.Error())
+		return
+	}
+
+	return
+}
+
+// GetByEvent returns the active webhooks subscribed to event.
+func (w *webhook) GetByEvent(ctx context.Context, event string) (output []WebhookModel, err error) {
+
+	var subscribed string
+	switch w.DB.Dialect {
+	case infra.DialectMySQL:
+		subscribed = "FIND_IN_SET(?, events) > 0"
+	case infra.DialectSQLite:
+		subscribed = "instr(',' || events || ',', ',' || ? || ',') > 0"
+	default:
+		subscribed = "? = ANY(string_to_array(events, ','))"
+	}
+
+	return w.query(ctx, "SELECT "+webhookColumns+" FROM webhook WHERE active = TRUE AND "+subscribed+" ORDER BY id", event)
+}
+
+func (w *webhook) query(ctx context.Context, query string, args ...interface{}) (output []WebhookModel, err error) {
+
+	rows, err := querier(ctx, w.DB).QueryContext(ctx, query, args...)
+	if err != nil {
+		w.Log.Error().Msg(err.Error())
+		return
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		hook := WebhookModel{}
+		err = rows.Scan(&hook.ID, &hook.URL, &hook.Secret, &hook.Events, &hook.Active, &hook.CreatedAt, &hook.UpdatedAt)
+		if err != nil {
+			w.Log.Error().Msg(err.Error())
+			return
+		}
+		output = append(output, hook)
+	}
+
+	return output, rows.Err()
+}
+
+// Update writes every column of the webhook, a missing webhook is reported as
+// sql.ErrNoRows.
+func (w *webhook) Update(ctx context.Context, input WebhookModel) (err error) {
+
+	query := "UPDATE webhook SET url = ?, secret = ?, events = ?, active = ?, updated_at = ? WHERE id = ?"
+
+	res, err := querier(ctx, w.DB).ExecContext(ctx, query, input.URL, input.Secret, input.Events, input.Active, input.UpdatedAt, input.ID)
+	if err != nil {
+		w.Log.Error().Msg(err.Error())
+		return
+	}
+
+	return requireAffected(res)
+}
+
+// Delete removes the webhook and, through the foreign key, its deliveries.
+func (w *webhook) Delete(ctx context.Context, id int) (err error) {
+
+	res, err := querier(ctx, w.DB).ExecContext(ctx, "DELETE FROM webhook WHERE id = ?", id)
+	if err != nil {
+		w.Log.Error().Msg(err.Error())
+		return
+	}
+
+	return requireAffected(res)
+}
+
+func requireAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (w *webhook) CreateDelivery(ctx context.Context, input WebhookDeliveryModel) (id int, err error) {
+
+	query := "INSERT INTO webhook_delivery (webhook_id, event_id, event, payload, status, next_attempt_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)"
+
+	lastID, err := insertID(ctx, querier(ctx, w.DB), w.DB.Dialect, query, input.WebhookID, input.EventID, input.Event, string(input.Payload), input.Status, input.NextAttemptAt, input.CreatedAt)
+	if err != nil {
+		w.Log.Error().Msg(err.Error())
+		return
+	}
+
+	return int(lastID), nil
+}
+
+// CreateDeliveries queues the deliveries of one event in a single insert.
+func (w *webhook) CreateDeliveries(ctx context.Context, input []WebhookDeliveryModel) (err error) {
+
+	if len(input) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, 0, len(input))
+	args := make([]interface{}, 0, len(input)*7)
+	for _, delivery := range input {
+		placeholders = append(placeholders, "(?, ?, ?, ?, ?, ?, ?)")
+		args = append(args, delivery.WebhookID, delivery.EventID, delivery.Event, string(delivery.Payload), delivery.Status, delivery.NextAttemptAt, delivery.CreatedAt)
+	}
+
+	query := "INSERT INTO webhook_delivery (webhook_id, event_id, event, payload, status, next_attempt_at, created_at) VALUES " + strings.Join(placeholders, ", ")
+
+	_, err = querier(ctx, w.DB).ExecContext(ctx, query, args...)
+	if err != nil {
+		w.Log.Error().Msg(err.Error())
+		return
+	}
+
+	return nil
+}
+
+// GetDeliveries returns the delivery log of a webhook, newest first.
+func (w *webhook) GetDeliveries(ctx context.Context, webhookID, limit, offset int) (output []WebhookDeliveryModel, err error) {
+	query := fmt.Sprintf("SELECT %s FROM webhook_delivery WHERE webhook_id = ? ORDER BY id DESC LIMIT %d OFFSET %d", webhookDeliveryColumns, limit, offset)
+	return w.queryDeliveries(ctx, query, webhookID)
+}
+
+func (w *webhook) CountDeliveries(ctx context.Context, webhookID int) (total int, err error) {
+
+	row := querier(ctx, w.DB).QueryRowContext(ctx, "SELECT COUNT(*) FROM webhook_delivery WHERE webhook_id = ?", webhookID)
+	if err = row.Scan(&total); err != nil {
+		w.Log.Error().Msg(err.Error())
+		return
+	}
+
+	return
+}
+
+func (w *webhook) GetDelivery(ctx context.Context, id int) (output WebhookDeliveryModel, err error) {
+
+	row := querier(ctx, w.DB).QueryRowContext(ctx, "SELECT "+webhookDeliveryColumns+" FROM webhook_delivery WHERE id = ?", id)
+	err = scanDelivery(row, &output)
+	if err != nil {
+		w.Log.Error().Msg(err.Error())
+		return
+	}
+
+	return
+}
+
+// GetDueDeliveries returns pending deliveries whose next attempt is at or
+// before now, oldest first.
+func (w *webhook) GetDueDeliveries(ctx context.Context, now time.Time, limit int) (output []WebhookDeliveryModel, err error) {
+	query := fmt.Sprintf("SELECT %s FROM webhook_delivery WHERE status = 'pending' AND next_attempt_at <= ? ORDER BY next_attempt_at, id LIMIT %d", webhookDeliveryColumns, limit)
+	return w.queryDeliveries(ctx, query, now)
+}
+
+func (w *webhook) queryDeliveries(ctx context.Context, query string, args ...interface{}) (output []WebhookDeliveryModel, err error) {
+
+	rows, err := querier(ctx, w.DB).QueryContext(ctx, query, args...)
+	if err != nil {
+		w.Log.Error().Msg(err.Error())
+		return
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		delivery := WebhookDeliveryModel{}
+		if err = scanDelivery(rows, &delivery); err != nil {
+			w.Log.Error().Msg(err.Error())
+			return
+		}
+		output = append(output, delivery)
+	}
+
+	return output, rows.Err()
+}
+
+func scanDelivery(row interface{ Scan(...interface{}) error }, d *WebhookDeliveryModel) error {
+	return row.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.Event, &d.Payload, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &d.ResponseCode, &d.ResponseBody, &d.LastError, &d.CreatedAt, &d.UpdatedAt)
+}
+
+// ClaimDelivery moves the next attempt of a due delivery to leaseUntil so no
+// other instance sends it meanwhile, it returns false when another instance
+// claimed it first.
+func (w *webhook) ClaimDelivery(ctx context.Context, id int, now, leaseUntil time.Time) (claimed bool, err error) {
+
+	query := "UPDATE webhook_delivery SET next_attempt_at = ? WHERE id = ? AND status = 'pending' AND next_attempt_at <= ?"
+
+	res, err := querier(ctx, w.DB).ExecContext(ctx, query, leaseUntil, id, now)
+	if err != nil {
+		w.Log.Error().Msg(err.Error())
+		return
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		w.Log.Error().Msg(err.Error())
+		return
+	}
+
+	return affected > 0, nil
+}
+
+// UpdateDelivery records the outcome of an attempt.
+func (w *webhook) UpdateDelivery(ctx context.Context, input WebhookDeliveryModel) (err error) {
+
+	query := "UPDATE webhook_delivery SET status = ?, attempts = ?, next_attempt_at = ?, response_code = ?, response_body = ?, last_error = ?, updated_at = ? WHERE id = ?"
+
+	_, err = querier(ctx, w.DB).ExecContext(ctx, query, input.Status, input.Attempts, input.NextAttemptAt, input.ResponseCode, input.ResponseBody, input.LastError, input.UpdatedAt, input.ID)
+	if err != nil {
+		w.Log.Error().Msg(err.Error())
+		return
+	}
+
+	return nil
+}
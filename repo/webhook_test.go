@@ -0,0 +1,340 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/infra"
+)
+
+func Test_webhook_Create(t *testing.T) {
+
+	ctx := context.Background()
+	now := time.Now()
+	query := "INSERT INTO webhook \\(url, secret, events, active, created_at\\) VALUES \\(\\?, \\?, \\?, \\?, \\?\\)"
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	repo := NewWebhook(mysqlDatabase(db), zerolog.Logger{})
+
+	input := WebhookModel{URL: "https://example.com", Secret: "s", Events: "cake.created,cake.deleted", Active: true, CreatedAt: now}
+
+	tests := []struct {
+		name       string
+		beforeFunc func()
+		want       int
+		wantErr    bool
+	}{
+		{
+			name: "success",
+			beforeFunc: func() {
+				mock.ExpectExec(query).WithArgs("https://example.com", "s", "cake.created,cake.deleted", true, now).WillReturnResult(sqlmock.NewResult(4, 1))
+			},
+			want: 4,
+		},
+		{
+			name: "error",
+			beforeFunc: func() {
+				mock.ExpectExec(query).WillReturnError(errors.New("foo"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.beforeFunc()
+			got, err := repo.Create(ctx, input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("webhook.Create() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("webhook.Create() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_webhook_GetByEvent(t *testing.T) {
+
+	ctx := context.Background()
+	now := time.Now()
+	query := "SELECT id, url, secret, events, active, created_at, updated_at FROM webhook WHERE active = TRUE AND FIND_IN_SET\\(\\?, events\\) > 0 ORDER BY id"
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	repo := NewWebhook(mysqlDatabase(db), zerolog.Logger{})
+
+	columns := []string{"id", "url", "secret", "events", "active", "created_at", "updated_at"}
+
+	tests := []struct {
+		name       string
+		beforeFunc func()
+		want       []WebhookModel
+		wantErr    bool
+	}{
+		{
+			name: "success",
+			beforeFunc: func() {
+				mock.ExpectQuery(query).WithArgs("cake.created").WillReturnRows(sqlmock.NewRows(columns).
+					AddRow(1, "https://a.example", "s1", "cake.created", true, now, nil).
+					AddRow(2, "https://b.example", "s2", "cake.created,cake.updated", true, now, now))
+			},
+			want: []WebhookModel{
+				{ID: 1, URL: "https://a.example", Secret: "s1", Events: "cake.created", Active: true, CreatedAt: now},
+				{ID: 2, URL: "https://b.example", Secret: "s2", Events: "cake.created,cake.updated", Active: true, CreatedAt: now, UpdatedAt: sql.NullTime{Time: now, Valid: true}},
+			},
+		},
+		{
+			name: "error",
+			beforeFunc: func() {
+				mock.ExpectQuery(query).WillReturnError(errors.New("foo"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.beforeFunc()
+			got, err := repo.GetByEvent(ctx, "cake.created")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("webhook.GetByEvent() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("webhook.GetByEvent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_webhook_Delete(t *testing.T) {
+
+	ctx := context.Background()
+	query := "DELETE FROM webhook WHERE id = \\?"
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	repo := NewWebhook(mysqlDatabase(db), zerolog.Logger{})
+
+	tests := []struct {
+		name       string
+		beforeFunc func()
+		wantErr    error
+	}{
+		{
+			name: "success",
+			beforeFunc: func() {
+				mock.ExpectExec(query).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "not found",
+			beforeFunc: func() {
+				mock.ExpectExec(query).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantErr: sql.ErrNoRows,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.beforeFunc()
+			if err := repo.Delete(ctx, 1); err != tt.wantErr {
+				t.Errorf("webhook.Delete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_webhook_CreateDeliveries(t *testing.T) {
+
+	ctx := context.Background()
+	now := time.Now()
+	next := sql.NullTime{Time: now, Valid: true}
+	query := "INSERT INTO webhook_delivery \\(webhook_id, event_id, event, payload, status, next_attempt_at, created_at\\) VALUES \\(\\?, \\?, \\?, \\?, \\?, \\?, \\?\\), \\(\\?, \\?, \\?, \\?, \\?, \\?, \\?\\)"
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	repo := NewWebhook(mysqlDatabase(db), zerolog.Logger{})
+
+	input := []WebhookDeliveryModel{
+		{WebhookID: 1, EventID: "e", Event: "cake.created", Payload: []byte("{}"), Status: "pending", NextAttemptAt: next, CreatedAt: now},
+		{WebhookID: 2, EventID: "e", Event: "cake.created", Payload: []byte("{}"), Status: "pending", NextAttemptAt: next, CreatedAt: now},
+	}
+
+	tests := []struct {
+		name       string
+		input      []WebhookDeliveryModel
+		beforeFunc func()
+		wantErr    bool
+	}{
+		{
+			name:  "success",
+			input: input,
+			beforeFunc: func() {
+				mock.ExpectExec(query).WithArgs(
+					1, "e", "cake.created", "{}", "pending", next, now,
+					2, "e", "cake.created", "{}", "pending", next, now,
+				).WillReturnResult(sqlmock.NewResult(2, 2))
+			},
+		},
+		{
+			name:       "nothing to insert",
+			input:      nil,
+			beforeFunc: func() {},
+		},
+		{
+			name:  "error",
+			input: input,
+			beforeFunc: func() {
+				mock.ExpectExec(query).WillReturnError(errors.New("foo"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.beforeFunc()
+			if err := repo.CreateDeliveries(ctx, tt.input); (err != nil) != tt.wantErr {
+				t.Errorf("webhook.CreateDeliveries() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func Test_webhook_GetDueDeliveries(t *testing.T) {
+
+	ctx := context.Background()
+	now := time.Now()
+	query := "SELECT id, webhook_id, event_id, event, payload, status, attempts, next_attempt_at, response_code, response_body, last_error, created_at, updated_at FROM webhook_delivery WHERE status = 'pending' AND next_attempt_at <= \\? ORDER BY next_attempt_at, id LIMIT 10"
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	repo := NewWebhook(mysqlDatabase(db), zerolog.Logger{})
+
+	columns := []string{"id", "webhook_id", "event_id", "event", "payload", "status", "attempts", "next_attempt_at", "response_code", "response_body", "last_error", "created_at", "updated_at"}
+
+	tests := []struct {
+		name       string
+		beforeFunc func()
+		want       []WebhookDeliveryModel
+		wantErr    bool
+	}{
+		{
+			name: "success",
+			beforeFunc: func() {
+				mock.ExpectQuery(query).WithArgs(now).WillReturnRows(sqlmock.NewRows(columns).
+					AddRow(3, 1, "e", "cake.created", []byte("{}"), "pending", 1, now, 500, "oops", "webhook answered 500", now, now))
+			},
+			want: []WebhookDeliveryModel{{
+				ID: 3, WebhookID: 1, EventID: "e", Event: "cake.created", Payload: []byte("{}"), Status: "pending", Attempts: 1,
+				NextAttemptAt: sql.NullTime{Time: now, Valid: true},
+				ResponseCode:  sql.NullInt64{Int64: 500, Valid: true},
+				ResponseBody:  sql.NullString{String: "oops", Valid: true},
+				LastError:     sql.NullString{String: "webhook answered 500", Valid: true},
+				CreatedAt:     now,
+				UpdatedAt:     sql.NullTime{Time: now, Valid: true},
+			}},
+		},
+		{
+			name: "error",
+			beforeFunc: func() {
+				mock.ExpectQuery(query).WillReturnError(errors.New("foo"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.beforeFunc()
+			got, err := repo.GetDueDeliveries(ctx, now, 10)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("webhook.GetDueDeliveries() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("webhook.GetDueDeliveries() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_webhook_ClaimDelivery(t *testing.T) {
+
+	ctx := context.Background()
+	now := time.Now()
+	lease := now.Add(time.Minute)
+	query := "UPDATE webhook_delivery SET next_attempt_at = \\? WHERE id = \\? AND status = 'pending' AND next_attempt_at <= \\?"
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	repo := NewWebhook(mysqlDatabase(db), zerolog.Logger{})
+
+	tests := []struct {
+		name       string
+		beforeFunc func()
+		want       bool
+		wantErr    bool
+	}{
+		{
+			name: "claimed",
+			beforeFunc: func() {
+				mock.ExpectExec(query).WithArgs(lease, 3, now).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: true,
+		},
+		{
+			name: "claimed by another instance",
+			beforeFunc: func() {
+				mock.ExpectExec(query).WithArgs(lease, 3, now).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			want: false,
+		},
+		{
+			name: "error",
+			beforeFunc: func() {
+				mock.ExpectExec(query).WillReturnError(errors.New("foo"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.beforeFunc()
+			got, err := repo.ClaimDelivery(ctx, 3, now, lease)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("webhook.ClaimDelivery() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("webhook.ClaimDelivery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_webhook_GetByEvent_postgres(t *testing.T) {
+
+	ctx := context.Background()
+	now := time.Now()
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	repo := NewWebhook(&infra.Database{DB: db, Dialect: infra.DialectPostgres}, zerolog.Logger{})
+
+	mock.ExpectQuery("SELECT id, url, secret, events, active, created_at, updated_at FROM webhook WHERE active = TRUE AND \\$1 = ANY\\(string_to_array\\(events, ','\\)\\) ORDER BY id").
+		WithArgs("cake.created").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "secret", "events", "active", "created_at", "updated_at"}).
+			AddRow(1, "https://a.example", "s1", "cake.created", true, now, nil))
+
+	got, err := repo.GetByEvent(ctx, "cake.created")
+	if err != nil {
+		t.Fatalf("webhook.GetByEvent() error = %v", err)
+	}
+	want := []WebhookModel{{ID: 1, URL: "https://a.example", Secret: "s1", Events: "cake.created", Active: true, CreatedAt: now}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("webhook.GetByEvent() = %v, want %v", got, want)
+	}
+}
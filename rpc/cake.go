@@ -0,0 +1,277 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/url"
+	"strconv"
+
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/pb"
+	"gitlab.com/cake-store-RESTFul/service"
+	cakeApi "gitlab.com/cake-store-RESTFul/service/cake"
+	commonApi "gitlab.com/cake-store-RESTFul/service/common"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const defaultMaxImageSize = 10 << 20
+
+// Cake serves pb.CakeServiceServer on top of the same service.Cake used by
+// the REST handlers.
+type Cake struct {
+	pb.UnimplementedCakeServiceServer
+	cakeService service.Cake
+	Log         zerolog.Logger
+	// MaxImageSize is the largest image accepted by UploadCakeImage.
+	MaxImageSize int
+}
+
+func NewCake(cakeService service.Cake, log zerolog.Logger) *Cake {
+	return &Cake{
+		cakeService:  cakeService,
+		Log:          log,
+		MaxImageSize: defaultMaxImageSize,
+	}
+}
+
+func newCake(cake cakeApi.CakeResponse) *pb.Cake {
+
+	res := &pb.Cake{
+		Id:          int64(cake.ID),
+		Title:       cake.Title,
+		Description: cake.Description,
+		Image:       cake.Image,
+		Rating:      cake.Rating,
+		CreatedAt:   timestamppb.New(cake.CreatedAt),
+	}
+
+	if cake.UpdatedAt != nil {
+		res.UpdatedAt = timestamppb.New(*cake.UpdatedAt)
+	}
+
+	return res
+}
+
+func (c *Cake) CreateCake(ctx context.Context, in *pb.CreateCakeRequest) (*pb.Cake, error) {
+
+	if err := validateNewCake(in.GetTitle(), in.GetDescription(), in.GetRating()); err != nil {
+		return nil, err
+	}
+
+	if len(in.GetImage()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "image cannot be empty")
+	}
+
+	return c.create(ctx, in.GetTitle(), in.GetDescription(), in.GetRating(), in.GetImage())
+}
+
+func (c *Cake) create(ctx context.Context, title, description string, rating float32, image []byte) (*pb.Cake, error) {
+
+	res, err := c.cakeService.Create(ctx, cakeApi.CreateRequest{
+		Title:       title,
+		Rating:      rating,
+		Description: description,
+		Image:       bytes.NewReader(image),
+	})
+	if err != nil {
+		return nil, c.serviceError(err)
+	}
+
+	return newCake(res), nil
+}
+
+func (c *Cake) GetCake(ctx context.Context, in *pb.GetCakeRequest) (*pb.Cake, error) {
+
+	if in.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid cake id")
+	}
+
+	res, err := c.cakeService.GetDetail(ctx, int(in.GetId()))
+	if err != nil {
+		return nil, c.serviceError(err)
+	}
+
+	return newCake(res), nil
+}
+
+// ListCakes applies the same defaults as GET /api/v1/cake, an unknown sort
+// column or direction is InvalidArgument.
+func (c *Cake) ListCakes(ctx context.Context, in *pb.ListCakesRequest) (*pb.ListCakesResponse, error) {
+
+	query := url.Values{}
+	query.Set("search", in.GetSearch())
+	query.Set("sort", in.GetSort())
+	query.Set("sort_by", in.GetSortBy())
+	if in.GetLimit() != 0 {
+		query.Set("limit", strconv.Itoa(int(in.GetLimit())))
+	}
+	if in.GetPage() != 0 {
+		query.Set("page", strconv.Itoa(int(in.GetPage())))
+	}
+
+	req := cakeApi.GetListRequest{}
+	if err := req.ParseQuery(query); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	paginateReq := commonApi.PaginationRequest{}
+	paginateReq.ParseQuery(query)
+
+	res, pagination, err := c.cakeService.GetList(ctx, req, paginateReq)
+	if err != nil {
+		return nil, c.serviceError(err)
+	}
+
+	cakes := make([]*pb.Cake, 0, len(res))
+	for _, cake := range res {
+		cakes = append(cakes, newCake(cake))
+	}
+
+	return &pb.ListCakesResponse{
+		Cakes: cakes,
+		Pagination: &pb.Pagination{
+			Page:  int32(pagination.Page),
+			Limit: int32(pagination.Limit),
+			Total: int32(pagination.Total),
+		},
+	}, nil
+}
+
+func (c *Cake) UpdateCake(ctx context.Context, in *pb.UpdateCakeRequest) (*pb.Cake, error) {
+
+	if in.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid cake id")
+	}
+
+	if in.Title != nil && in.GetTitle() == "" {
+		return nil, status.Error(codes.InvalidArgument, "title cannot by empty")
+	}
+
+	if in.Rating != nil && in.GetRating() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "rating cannot less than 0")
+	}
+
+	res, err := c.cakeService.Update(ctx, cakeApi.UpdateRequest{
+		ID:          int(in.GetId()),
+		Title:       in.Title,
+		Description: in.Description,
+		Rating:      in.Rating,
+		ImageURL:    in.ImageUrl,
+	})
+	if err != nil {
+		return nil, c.serviceError(err)
+	}
+
+	return newCake(res), nil
+}
+
+func (c *Cake) DeleteCake(ctx context.Context, in *pb.DeleteCakeRequest) (*emptypb.Empty, error) {
+
+	if in.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid cake id")
+	}
+
+	if err := c.cakeService.Delete(ctx, int(in.GetId())); err != nil {
+		return nil, c.serviceError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// UploadCakeImage reads the target from the first message and the image from
+// the following chunks, then creates the cake or replaces its image.
+func (c *Cake) UploadCakeImage(stream pb.CakeService_UploadCakeImageServer) error {
+
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+
+	target := first.GetTarget()
+	if target == nil {
+		return status.Error(codes.InvalidArgument, "first message must name the target")
+	}
+
+	if cake := target.GetCake(); cake != nil {
+		if err = validateNewCake(cake.GetTitle(), cake.GetDescription(), cake.GetRating()); err != nil {
+			return err
+		}
+	} else if target.GetId() <= 0 {
+		return status.Error(codes.InvalidArgument, "invalid cake id")
+	}
+
+	image := bytes.Buffer{}
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if msg.GetTarget() != nil {
+			return status.Error(codes.InvalidArgument, "target can only be sent in the first message")
+		}
+
+		if image.Len()+len(msg.GetChunk()) > c.MaxImageSize {
+			return status.Errorf(codes.ResourceExhausted, "image is larger than %d bytes", c.MaxImageSize)
+		}
+		image.Write(msg.GetChunk())
+	}
+
+	if image.Len() == 0 {
+		return status.Error(codes.InvalidArgument, "image cannot be empty")
+	}
+
+	if cake := target.GetCake(); cake != nil {
+		res, err := c.create(stream.Context(), cake.GetTitle(), cake.GetDescription(), cake.GetRating(), image.Bytes())
+		if err != nil {
+			return err
+		}
+		return stream.SendAndClose(res)
+	}
+
+	res, err := c.cakeService.Update(stream.Context(), cakeApi.UpdateRequest{
+		ID:    int(target.GetId()),
+		Image: bytes.NewReader(image.Bytes()),
+	})
+	if err != nil {
+		return c.serviceError(err)
+	}
+
+	return stream.SendAndClose(newCake(res))
+}
+
+// validateNewCake applies the rules of the REST create endpoints.
+func validateNewCake(title, description string, rating float32) error {
+
+	if title == "" {
+		return status.Error(codes.InvalidArgument, "title cannot by empty")
+	}
+
+	if description == "" {
+		return status.Error(codes.InvalidArgument, "description cannot be empty")
+	}
+
+	if rating <= 0 {
+		return status.Error(codes.InvalidArgument, "rating cannot less than 1")
+	}
+
+	return nil
+}
+
+func (c *Cake) serviceError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return status.Error(codes.NotFound, "cake not found")
+	}
+
+	c.Log.Error().Msg(err.Error())
+	return status.Error(codes.Internal, err.Error())
+}
@@ -0,0 +1,373 @@
+package rpc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/pb"
+	cakeApi "gitlab.com/cake-store-RESTFul/service/cake"
+	commonApi "gitlab.com/cake-store-RESTFul/service/common"
+	mockService "gitlab.com/cake-store-RESTFul/service/mocks"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestCake_CreateCake(t *testing.T) {
+	tests := []struct {
+		name       string
+		in         *pb.CreateCakeRequest
+		beforeFunc func(s *mockService.MockCake)
+		wantCode   codes.Code
+	}{
+		{
+			name: "success",
+			in:   &pb.CreateCakeRequest{Title: "test", Description: "test", Rating: 4, Image: []byte("png")},
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().Create(gomock.Any(), gomock.Any()).Return(cakeApi.CakeResponse{ID: 3, Title: "test"}, nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:       "error invalid argument (title)",
+			in:         &pb.CreateCakeRequest{Description: "test", Rating: 4, Image: []byte("png")},
+			beforeFunc: func(s *mockService.MockCake) {},
+			wantCode:   codes.InvalidArgument,
+		},
+		{
+			name:       "error invalid argument (image)",
+			in:         &pb.CreateCakeRequest{Title: "test", Description: "test", Rating: 4},
+			beforeFunc: func(s *mockService.MockCake) {},
+			wantCode:   codes.InvalidArgument,
+		},
+		{
+			name: "error internal",
+			in:   &pb.CreateCakeRequest{Title: "test", Description: "test", Rating: 4, Image: []byte("png")},
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().Create(gomock.Any(), gomock.Any()).Return(cakeApi.CakeResponse{}, errors.New("foo"))
+			},
+			wantCode: codes.Internal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			service := mockService.NewMockCake(ct)
+			tt.beforeFunc(service)
+			c := NewCake(service, zerolog.Logger{})
+
+			got, err := c.CreateCake(context.Background(), tt.in)
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("Cake.CreateCake() error = %v, wantCode %v", err, tt.wantCode)
+			}
+			if tt.wantCode == codes.OK && (got.GetId() != 3 || got.GetTitle() != "test") {
+				t.Errorf("Cake.CreateCake() = %v", got)
+			}
+		})
+	}
+}
+
+func TestCake_GetCake(t *testing.T) {
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name       string
+		id         int64
+		beforeFunc func(s *mockService.MockCake)
+		want       *pb.Cake
+		wantCode   codes.Code
+	}{
+		{
+			name: "success",
+			id:   1,
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().GetDetail(gomock.Any(), 1).Return(cakeApi.CakeResponse{ID: 1, Title: "test", CreatedAt: now, UpdatedAt: &now}, nil)
+			},
+			want:     &pb.Cake{Id: 1, Title: "test", CreatedAt: timestamppb.New(now), UpdatedAt: timestamppb.New(now)},
+			wantCode: codes.OK,
+		},
+		{
+			name: "error not found",
+			id:   1,
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().GetDetail(gomock.Any(), 1).Return(cakeApi.CakeResponse{}, sql.ErrNoRows)
+			},
+			wantCode: codes.NotFound,
+		},
+		{
+			name:       "error invalid argument",
+			id:         0,
+			beforeFunc: func(s *mockService.MockCake) {},
+			wantCode:   codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			service := mockService.NewMockCake(ct)
+			tt.beforeFunc(service)
+			c := NewCake(service, zerolog.Logger{})
+
+			got, err := c.GetCake(context.Background(), &pb.GetCakeRequest{Id: tt.id})
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("Cake.GetCake() error = %v, wantCode %v", err, tt.wantCode)
+			}
+			if tt.want != nil && !proto.Equal(got, tt.want) {
+				t.Errorf("Cake.GetCake() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCake_ListCakes(t *testing.T) {
+	tests := []struct {
+		name       string
+		in         *pb.ListCakesRequest
+		beforeFunc func(s *mockService.MockCake)
+		wantCode   codes.Code
+	}{
+		{
+			name: "success (defaults)",
+			in:   &pb.ListCakesRequest{},
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().GetList(gomock.Any(), cakeApi.GetListRequest{Sort: "id,title", SortBy: "ASC"}, commonApi.PaginationRequest{Limit: 10, Page: 1}).
+					Return(cakeApi.CakesResponse{{ID: 1}}, commonApi.PaginationResponse{Page: 1, Limit: 10, Total: 1}, nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name: "success (filters)",
+			in:   &pb.ListCakesRequest{Search: "chess", Sort: "rating", SortBy: "DESC", Limit: 5, Page: 2},
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().GetList(gomock.Any(), cakeApi.GetListRequest{Search: "chess", Sort: "rating", SortBy: "DESC"}, commonApi.PaginationRequest{Limit: 5, Page: 2}).
+					Return(cakeApi.CakesResponse{{ID: 1}}, commonApi.PaginationResponse{Page: 2, Limit: 5, Total: 6}, nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:       "error invalid argument (sort)",
+			in:         &pb.ListCakesRequest{Sort: "id; DROP TABLE cake"},
+			beforeFunc: func(s *mockService.MockCake) {},
+			wantCode:   codes.InvalidArgument,
+		},
+		{
+			name:       "error invalid argument (sort_by)",
+			in:         &pb.ListCakesRequest{SortBy: "sideways"},
+			beforeFunc: func(s *mockService.MockCake) {},
+			wantCode:   codes.InvalidArgument,
+		},
+		{
+			name: "error internal",
+			in:   &pb.ListCakesRequest{},
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().GetList(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, commonApi.PaginationResponse{}, errors.New("foo"))
+			},
+			wantCode: codes.Internal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			service := mockService.NewMockCake(ct)
+			tt.beforeFunc(service)
+			c := NewCake(service, zerolog.Logger{})
+
+			got, err := c.ListCakes(context.Background(), tt.in)
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("Cake.ListCakes() error = %v, wantCode %v", err, tt.wantCode)
+			}
+			if tt.wantCode == codes.OK && (len(got.GetCakes()) != 1 || got.GetPagination().GetTotal() == 0) {
+				t.Errorf("Cake.ListCakes() = %v", got)
+			}
+		})
+	}
+}
+
+func TestCake_UpdateCake(t *testing.T) {
+	title, rating, empty := "test", float32(4), ""
+	negative := float32(-1)
+
+	tests := []struct {
+		name       string
+		in         *pb.UpdateCakeRequest
+		beforeFunc func(s *mockService.MockCake)
+		wantCode   codes.Code
+	}{
+		{
+			name: "success",
+			in:   &pb.UpdateCakeRequest{Id: 1, Title: &title, Rating: &rating, ImageUrl: &empty},
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().Update(gomock.Any(), cakeApi.UpdateRequest{ID: 1, Title: &title, Rating: &rating, ImageURL: &empty}).
+					Return(cakeApi.CakeResponse{ID: 1}, nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:       "error invalid argument (title)",
+			in:         &pb.UpdateCakeRequest{Id: 1, Title: &empty},
+			beforeFunc: func(s *mockService.MockCake) {},
+			wantCode:   codes.InvalidArgument,
+		},
+		{
+			name:       "error invalid argument (rating)",
+			in:         &pb.UpdateCakeRequest{Id: 1, Rating: &negative},
+			beforeFunc: func(s *mockService.MockCake) {},
+			wantCode:   codes.InvalidArgument,
+		},
+		{
+			name: "error not found",
+			in:   &pb.UpdateCakeRequest{Id: 1},
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().Update(gomock.Any(), gomock.Any()).Return(cakeApi.CakeResponse{}, sql.ErrNoRows)
+			},
+			wantCode: codes.NotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			service := mockService.NewMockCake(ct)
+			tt.beforeFunc(service)
+			c := NewCake(service, zerolog.Logger{})
+
+			_, err := c.UpdateCake(context.Background(), tt.in)
+			if status.Code(err) != tt.wantCode {
+				t.Errorf("Cake.UpdateCake() error = %v, wantCode %v", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestCake_DeleteCake(t *testing.T) {
+	ct := gomock.NewController(t)
+	service := mockService.NewMockCake(ct)
+	service.EXPECT().Delete(gomock.Any(), 1).Return(nil)
+	c := NewCake(service, zerolog.Logger{})
+
+	if _, err := c.DeleteCake(context.Background(), &pb.DeleteCakeRequest{Id: 1}); err != nil {
+		t.Errorf("Cake.DeleteCake() error = %v", err)
+	}
+}
+
+func TestCake_UploadCakeImage(t *testing.T) {
+	target := func(target *pb.ImageTarget) *pb.UploadCakeImageRequest {
+		return &pb.UploadCakeImageRequest{Data: &pb.UploadCakeImageRequest_Target{Target: target}}
+	}
+	chunk := func(b string) *pb.UploadCakeImageRequest {
+		return &pb.UploadCakeImageRequest{Data: &pb.UploadCakeImageRequest_Chunk{Chunk: []byte(b)}}
+	}
+
+	tests := []struct {
+		name       string
+		messages   []*pb.UploadCakeImageRequest
+		beforeFunc func(s *mockService.MockCake)
+		wantCode   codes.Code
+	}{
+		{
+			name: "success (create)",
+			messages: []*pb.UploadCakeImageRequest{
+				target(&pb.ImageTarget{Target: &pb.ImageTarget_Cake{Cake: &pb.NewCake{Title: "test", Description: "test", Rating: 4}}}),
+				chunk("pn"), chunk("g"),
+			},
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req cakeApi.CreateRequest) (cakeApi.CakeResponse, error) {
+					if b, _ := io.ReadAll(req.Image); string(b) != "png" {
+						t.Errorf("image = %s, want png", b)
+					}
+					return cakeApi.CakeResponse{ID: 1}, nil
+				})
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name: "success (replace image)",
+			messages: []*pb.UploadCakeImageRequest{
+				target(&pb.ImageTarget{Target: &pb.ImageTarget_Id{Id: 1}}),
+				chunk("png"),
+			},
+			beforeFunc: func(s *mockService.MockCake) {
+				s.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req cakeApi.UpdateRequest) (cakeApi.CakeResponse, error) {
+					if b, _ := io.ReadAll(req.Image); req.ID != 1 || string(b) != "png" {
+						t.Errorf("update = %v %s", req.ID, b)
+					}
+					return cakeApi.CakeResponse{ID: 1}, nil
+				})
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:       "error invalid argument (no target)",
+			messages:   []*pb.UploadCakeImageRequest{chunk("png")},
+			beforeFunc: func(s *mockService.MockCake) {},
+			wantCode:   codes.InvalidArgument,
+		},
+		{
+			name: "error invalid argument (empty image)",
+			messages: []*pb.UploadCakeImageRequest{
+				target(&pb.ImageTarget{Target: &pb.ImageTarget_Id{Id: 1}}),
+			},
+			beforeFunc: func(s *mockService.MockCake) {},
+			wantCode:   codes.InvalidArgument,
+		},
+		{
+			name: "error resource exhausted",
+			messages: []*pb.UploadCakeImageRequest{
+				target(&pb.ImageTarget{Target: &pb.ImageTarget_Id{Id: 1}}),
+				chunk("0123456789"), chunk("0"),
+			},
+			beforeFunc: func(s *mockService.MockCake) {},
+			wantCode:   codes.ResourceExhausted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			service := mockService.NewMockCake(ct)
+			tt.beforeFunc(service)
+			c := NewCake(service, zerolog.Logger{})
+			c.MaxImageSize = 10
+
+			stream := &uploadStream{messages: tt.messages}
+			err := c.UploadCakeImage(stream)
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("Cake.UploadCakeImage() error = %v, wantCode %v", err, tt.wantCode)
+			}
+			if tt.wantCode == codes.OK && stream.res.GetId() != 1 {
+				t.Errorf("Cake.UploadCakeImage() = %v", stream.res)
+			}
+		})
+	}
+}
+
+// uploadStream replays messages as a client stream and keeps the response.
+type uploadStream struct {
+	grpc.ServerStream
+	messages []*pb.UploadCakeImageRequest
+	res      *pb.Cake
+}
+
+func (s *uploadStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *uploadStream) Recv() (*pb.UploadCakeImageRequest, error) {
+	if len(s.messages) == 0 {
+		return nil, io.EOF
+	}
+	msg := s.messages[0]
+	s.messages = s.messages[1:]
+	return msg, nil
+}
+
+func (s *uploadStream) SendAndClose(res *pb.Cake) error {
+	s.res = res
+	return nil
+}
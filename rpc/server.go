@@ -0,0 +1,93 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/config"
+	"gitlab.com/cake-store-RESTFul/pb"
+	service_manager "gitlab.com/cake-store-RESTFul/service-manager"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// Run serves the gRPC API on its own port until ctx is done, then stops
+// taking calls and waits for the ones in flight, like api.Run.
+func Run(ctx context.Context, config config.GRPC, serviceManager service_manager.ServiceManager, log zerolog.Logger) error {
+
+	address := fmt.Sprintf(":%d", config.Port)
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("grpc listen: %w", err)
+	}
+
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(recoverUnary(log)),
+		grpc.StreamInterceptor(recoverStream(log)),
+	)
+
+	cakeServer := NewCake(serviceManager.CakeService(), log)
+	if size := config.MaxImageSize; size > 0 {
+		cakeServer.MaxImageSize = size
+	}
+	pb.RegisterCakeServiceServer(server, cakeServer)
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(pb.CakeService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	if config.Reflection {
+		reflection.Register(server)
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+	log.Info().Str("address", address).Msg("grpc server started")
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Info().Msg("grpc server shutting down")
+
+	// Serve returns nil once GracefulStop is called
+	server.GracefulStop()
+	return <-serveErr
+}
+
+// recoverUnary answers a panicking call with codes.Internal, the gRPC
+// counterpart of the router PanicHandler.
+func recoverUnary(log zerolog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error().Str("method", info.FullMethod).Msg(fmt.Sprint(r))
+				err = status.Error(codes.Internal, "internal server error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+func recoverStream(log zerolog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error().Str("method", info.FullMethod).Msg(fmt.Sprint(r))
+				err = status.Error(codes.Internal, "internal server error")
+			}
+		}()
+		return handler(srv, stream)
+	}
+}
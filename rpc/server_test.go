@@ -0,0 +1,72 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/config"
+	"gitlab.com/cake-store-RESTFul/infra"
+	service_manager "gitlab.com/cake-store-RESTFul/service-manager"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestRun(t *testing.T) {
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	serviceManager := service_manager.NewServiceManager(&infra.Infra{
+		Database: &infra.Database{DB: db, Dialect: infra.DialectMySQL},
+		Log:      zerolog.Nop(),
+	})
+
+	// a free port for the server, Run listens on its own
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Run(ctx, config.GRPC{Port: port}, serviceManager, zerolog.Nop())
+	}()
+
+	dialCtx, dialCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer dialCancel()
+	conn, err := grpc.DialContext(dialCtx, listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+	if err != nil {
+		t.Fatalf("Run() is not serving: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := healthpb.NewHealthClient(conn).Check(dialCtx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Errorf("Health.Check() error = %v", err)
+	}
+
+	// a second server can not listen on the same port
+	if err := Run(context.Background(), config.GRPC{Port: port}, serviceManager, zerolog.Nop()); err == nil {
+		t.Error("Run() on a used port error = nil")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return once its context was done")
+	}
+}
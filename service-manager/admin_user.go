@@ -0,0 +1,18 @@
+package service_manager
+
+import (
+	"gitlab.com/cake-store-RESTFul/repo"
+	"gitlab.com/cake-store-RESTFul/service"
+)
+
+func (s *serviceManager) AdminUserRepo() repo.AdminUser {
+	return s.get(AdminUserRepoComponent, func() interface{} {
+		return repo.NewAdminUser(s.infra.Database, s.infra.Log)
+	}).(repo.AdminUser)
+}
+
+func (s *serviceManager) AdminUserService() service.AdminUser {
+	return s.get(AdminUserServiceComponent, func() interface{} {
+		return service.NewAdminUser(s.AdminUserRepo(), s.infra.Log)
+	}).(service.AdminUser)
+}
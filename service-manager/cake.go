@@ -1,29 +1,25 @@
 package service_manager
 
 import (
-	"sync"
-
 	"gitlab.com/cake-store-RESTFul/repo"
 	"gitlab.com/cake-store-RESTFul/service"
 )
 
-var (
-	cakeRepo        repo.Cake
-	cakeRepoOnce    sync.Once
-	cakeService     service.Cake
-	cakeServiceOnce sync.Once
-)
-
 func (s *serviceManager) CakeRepo() repo.Cake {
-	cakeRepoOnce.Do(func() {
-		cakeRepo = repo.NewCake(s.infra.MySQL, s.infra.Log)
-	})
-	return cakeRepo
+	return s.get(CakeRepoComponent, func() interface{} {
+		return repo.NewCake(s.infra.Database, s.infra.Log)
+	}).(repo.Cake)
 }
 
 func (s *serviceManager) CakeService() service.Cake {
-	cakeServiceOnce.Do(func() {
-		cakeService = service.NewCake(s.CakeRepo(), s.infra.Log, s.infra.Cloudinary)
-	})
-	return cakeService
+	return s.get(CakeServiceComponent, func() interface{} {
+		cakeService := service.NewCake(s.CakeRepo(), s.TxManager(), s.infra.Log, s.infra.Cloudinary)
+		if s.infra.Cache != nil {
+			cakeService = service.NewCakeCache(cakeService, s.infra.Cache, s.infra.Log)
+		}
+		if s.infra.Webhook.Enabled {
+			cakeService = service.NewCakeWebhook(cakeService, s.WebhookService(), s.infra.Log)
+		}
+		return cakeService
+	}).(service.Cake)
 }
@@ -16,12 +16,11 @@ import (
 
 func Test_serviceManager_CakeRepo(t *testing.T) {
 	db, _, _ := sqlmock.New()
-	cakeRepo := repo.NewCake(db, *zerolog.Ctx(context.Background()))
+	database := &infra.Database{DB: db, Dialect: infra.DialectMySQL}
+	cakeRepo := repo.NewCake(database, *zerolog.Ctx(context.Background()))
 
 	infra := infra.Infra{
-		Mysql: &infra.Mysql{
-			MySQL: db,
-		},
+		Database: database,
 	}
 
 	sm := NewServiceManager(&infra)
@@ -34,16 +33,15 @@ func Test_serviceManager_CakeRepo(t *testing.T) {
 func Test_serviceManager_CakeService(t *testing.T) {
 
 	db, _, _ := sqlmock.New()
+	database := &infra.Database{DB: db, Dialect: infra.DialectMySQL}
 	log := *zerolog.Ctx(context.Background())
-	cakeRepo := repo.NewCake(db, log)
+	cakeRepo := repo.NewCake(database, log)
 	ctrl := gomock.NewController(t)
 	mc := mockSvc.NewMockCloudinary(ctrl)
-	cakeService := service.NewCake(cakeRepo, log, mc)
+	cakeService := service.NewCake(cakeRepo, repo.NewTxManager(database, log), log, mc)
 
 	infra := infra.Infra{
-		Mysql: &infra.Mysql{
-			MySQL: db,
-		},
+		Database: database,
 	}
 
 	sm := NewServiceManager(&infra)
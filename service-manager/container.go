@@ -0,0 +1,279 @@
+package service_manager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Component names a dependency built by a ServiceManager, it is the key of
+// its overrides, decorators and hooks.
+type Component string
+
+const (
+	TxManagerComponent          Component = "tx_manager"
+	CakeRepoComponent           Component = "cake_repo"
+	CakeServiceComponent        Component = "cake_service"
+	IdempotencyRepoComponent    Component = "idempotency_repo"
+	IdempotencyServiceComponent Component = "idempotency_service"
+	WebhookRepoComponent        Component = "webhook_repo"
+	WebhookServiceComponent     Component = "webhook_service"
+	OutboxRepoComponent         Component = "outbox_repo"
+	OutboxServiceComponent      Component = "outbox_service"
+	AdminUserRepoComponent      Component = "admin_user_repo"
+	AdminUserServiceComponent   Component = "admin_user_service"
+	DemoSeedRepoComponent       Component = "demo_seed_repo"
+	SeederServiceComponent      Component = "seeder_service"
+)
+
+// Hook is work done when the ServiceManager starts or stops, either func may
+// be nil.
+type Hook struct {
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+// Starter and Stopper are implemented by components with work to start or
+// stop, they are hooked like the hooks of WithHook. The database is shared
+// by every ServiceManager of an infra.Infra, it is closed by infra.
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
+
+// Option customizes the components of a single ServiceManager.
+type Option func(*container)
+
+// WithOverride builds component with build instead of the default, e.g. a
+// mock in tests. build must return the interface of the component.
+func WithOverride(component Component, build func(ServiceManager) interface{}) Option {
+	return func(c *container) {
+		c.overrides[component] = build
+	}
+}
+
+// WithDecorator wraps component once it is built, e.g. with metrics.
+// Decorators are applied in the order they are given and must return the
+// interface of the component.
+func WithDecorator(component Component, decorate func(ServiceManager, interface{}) interface{}) Option {
+	return func(c *container) {
+		c.decorators[component] = append(c.decorators[component], decorate)
+	}
+}
+
+// WithHook runs hook when the ServiceManager starts and stops, provided
+// component was built.
+func WithHook(component Component, hook Hook) Option {
+	return func(c *container) {
+		c.hooks[component] = append(c.hooks[component], hook)
+	}
+}
+
+// container holds the components of one ServiceManager. Every component is
+// built once on first use, and its hooks are started in the order the
+// components were built, so a component starts after its dependencies and
+// stops before them.
+type container struct {
+	overrides  map[Component]func(ServiceManager) interface{}
+	decorators map[Component][]func(ServiceManager, interface{}) interface{}
+	hooks      map[Component][]Hook
+
+	// lifecycle serializes Start and Stop, mu guards the fields below. The
+	// hooks run without mu held as they may build other components.
+	lifecycle sync.Mutex
+	mu        sync.Mutex
+	instances map[Component]*instance
+	states    []*hookState
+	running   bool
+}
+
+type instance struct {
+	once  sync.Once
+	value interface{}
+}
+
+type hookState struct {
+	Hook
+	started bool
+}
+
+func newContainer(options ...Option) *container {
+
+	c := &container{
+		overrides:  map[Component]func(ServiceManager) interface{}{},
+		decorators: map[Component][]func(ServiceManager, interface{}) interface{}{},
+		hooks:      map[Component][]Hook{},
+		instances:  map[Component]*instance{},
+	}
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+// get returns component, building it with build, its override or decorators
+// on first use. A component built while the ServiceManager runs is started
+// right away.
+func (s *serviceManager) get(component Component, build func() interface{}) interface{} {
+
+	c := s.container
+
+	c.mu.Lock()
+	entry, ok := c.instances[component]
+	if !ok {
+		entry = &instance{}
+		c.instances[component] = entry
+	}
+	c.mu.Unlock()
+
+	entry.once.Do(func() {
+
+		var value interface{}
+		if override, ok := c.overrides[component]; ok {
+			value = override(s)
+		} else {
+			value = build()
+		}
+		for _, decorate := range c.decorators[component] {
+			value = decorate(s, value)
+		}
+		entry.value = value
+
+		if err := c.hook(component, value); err != nil {
+			s.infra.Log.Error().Msg(err.Error())
+		}
+	})
+
+	return entry.value
+}
+
+// hook adds the hooks of a built component to the lifecycle, they are started
+// right away when the ServiceManager runs.
+func (c *container) hook(component Component, value interface{}) error {
+
+	// the component starts before and stops after the hooks registered on it
+	var hooks []Hook
+	if starter, ok := value.(Starter); ok {
+		hooks = append(hooks, Hook{OnStart: starter.Start})
+	}
+	if stopper, ok := value.(Stopper); ok {
+		hooks = append(hooks, Hook{OnStop: stopper.Stop})
+	}
+	hooks = append(hooks, c.hooks[component]...)
+
+	states := make([]*hookState, 0, len(hooks))
+	for _, hook := range hooks {
+		states = append(states, &hookState{Hook: hook})
+	}
+
+	c.mu.Lock()
+	c.states = append(c.states, states...)
+	running := c.running
+	c.mu.Unlock()
+
+	if !running {
+		return nil
+	}
+
+	for _, state := range states {
+		if err := c.start(context.Background(), state); err != nil {
+			return fmt.Errorf("start %s: %w", component, err)
+		}
+	}
+	return nil
+}
+
+// start runs the start hook of state unless it was started, a hook that fails
+// to start is not stopped.
+func (c *container) start(ctx context.Context, state *hookState) error {
+
+	c.mu.Lock()
+	started := state.started
+	state.started = true
+	c.mu.Unlock()
+
+	if started || state.OnStart == nil {
+		return nil
+	}
+
+	if err := state.OnStart(ctx); err != nil {
+		c.mu.Lock()
+		state.started = false
+		c.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Start runs the start hooks of the components built so far in build order,
+// it stops at the first error. Components built later are started when they
+// are built.
+func (s *serviceManager) Start(ctx context.Context) error {
+
+	c := s.container
+
+	c.lifecycle.Lock()
+	defer c.lifecycle.Unlock()
+
+	c.mu.Lock()
+	c.running = true
+	c.mu.Unlock()
+
+	for i := 0; ; i++ {
+
+		c.mu.Lock()
+		if i >= len(c.states) {
+			c.mu.Unlock()
+			return nil
+		}
+		state := c.states[i]
+		c.mu.Unlock()
+
+		if err := c.start(ctx, state); err != nil {
+			return err
+		}
+	}
+}
+
+// Stop runs the stop hooks of the started components in reverse build order,
+// every hook runs even when another one failed.
+func (s *serviceManager) Stop(ctx context.Context) error {
+
+	c := s.container
+
+	c.lifecycle.Lock()
+	defer c.lifecycle.Unlock()
+
+	c.mu.Lock()
+	c.running = false
+	states := append([]*hookState{}, c.states...)
+	c.mu.Unlock()
+
+	var errs []string
+	for i := len(states) - 1; i >= 0; i-- {
+
+		state := states[i]
+
+		c.mu.Lock()
+		started := state.started
+		state.started = false
+		c.mu.Unlock()
+
+		if !started || state.OnStop == nil {
+			continue
+		}
+		if err := state.OnStop(ctx); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("stop: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package service_manager
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/infra"
+	"gitlab.com/cake-store-RESTFul/repo"
+	mockRepo "gitlab.com/cake-store-RESTFul/repo/mocks"
+	"gitlab.com/cake-store-RESTFul/service"
+	mockSvc "gitlab.com/cake-store-RESTFul/service/mocks"
+)
+
+func newTestInfra(t *testing.T) *infra.Infra {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return &infra.Infra{
+		Database: &infra.Database{DB: db, Dialect: infra.DialectMySQL},
+		Log:      zerolog.Nop(),
+	}
+}
+
+func Test_serviceManager_instances(t *testing.T) {
+	infra := newTestInfra(t)
+
+	first := NewServiceManager(infra)
+	second := NewServiceManager(infra)
+
+	if first.CakeRepo() != first.CakeRepo() {
+		t.Errorf("CakeRepo() built twice by the same manager")
+	}
+	if first.CakeRepo() == second.CakeRepo() {
+		t.Errorf("CakeRepo() shared by two managers")
+	}
+	second.CakeRepo()
+
+	// the database belongs to infra, stopping a manager leaves it open
+	ctx := context.Background()
+	if err := first.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := infra.DB.PingContext(ctx); err != nil {
+		t.Errorf("DB.PingContext() after Stop() error = %v", err)
+	}
+}
+
+func Test_serviceManager_WithOverride(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cakeRepo := mockRepo.NewMockCake(ctrl)
+	cakeService := mockSvc.NewMockCake(ctrl)
+
+	sm := NewServiceManager(newTestInfra(t),
+		WithOverride(CakeRepoComponent, func(ServiceManager) interface{} { return cakeRepo }),
+		WithOverride(CakeServiceComponent, func(sm ServiceManager) interface{} {
+			// an override may depend on other components
+			if sm.CakeRepo() != repo.Cake(cakeRepo) {
+				t.Errorf("CakeRepo() is not the override")
+			}
+			return cakeService
+		}),
+	)
+
+	if got := sm.CakeService(); !reflect.DeepEqual(got, service.Cake(cakeService)) {
+		t.Errorf("CakeService() = %v, want %v", got, cakeService)
+	}
+}
+
+// tracedCake records the decorators it went through.
+type tracedCake struct {
+	repo.Cake
+	trace []string
+}
+
+func Test_serviceManager_WithDecorator(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	decorate := func(name string) func(ServiceManager, interface{}) interface{} {
+		return func(_ ServiceManager, value interface{}) interface{} {
+			traced, ok := value.(*tracedCake)
+			if !ok {
+				traced = &tracedCake{Cake: value.(repo.Cake)}
+			}
+			traced.trace = append(traced.trace, name)
+			return traced
+		}
+	}
+
+	sm := NewServiceManager(newTestInfra(t),
+		WithOverride(CakeRepoComponent, func(ServiceManager) interface{} { return mockRepo.NewMockCake(ctrl) }),
+		WithDecorator(CakeRepoComponent, decorate("metrics")),
+		WithDecorator(CakeRepoComponent, decorate("tracing")),
+	)
+
+	got := sm.CakeRepo().(*tracedCake).trace
+	if want := []string{"metrics", "tracing"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("decorators = %v, want %v", got, want)
+	}
+}
+
+func Test_serviceManager_lifecycle(t *testing.T) {
+	fail := errors.New("fail")
+
+	tests := []struct {
+		name string
+		// startErr and stopErr are returned by the hooks of the cake service
+		startErr  error
+		stopErr   error
+		wantStart error
+		wantStop  string
+		wantCalls []string
+	}{
+		{
+			name: "dependencies start first and stop last",
+			wantCalls: []string{
+				"start cake_repo", "start cake_service",
+				"start admin_user_repo",
+				"stop admin_user_repo",
+				"stop cake_service", "stop cake_repo",
+			},
+		},
+		{
+			name:      "failed start stops the started ones",
+			startErr:  fail,
+			wantStart: fail,
+			wantCalls: []string{
+				"start cake_repo", "start cake_service",
+				"stop cake_repo",
+			},
+		},
+		{
+			name:     "failed stop runs the other hooks",
+			stopErr:  fail,
+			wantStop: "stop: fail",
+			wantCalls: []string{
+				"start cake_repo", "start cake_service",
+				"start admin_user_repo",
+				"stop admin_user_repo",
+				"stop cake_service", "stop cake_repo",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var calls []string
+			hook := func(component Component, startErr, stopErr error) Hook {
+				return Hook{
+					OnStart: func(context.Context) error {
+						calls = append(calls, "start "+string(component))
+						return startErr
+					},
+					OnStop: func(context.Context) error {
+						calls = append(calls, "stop "+string(component))
+						return stopErr
+					},
+				}
+			}
+
+			sm := NewServiceManager(newTestInfra(t),
+				WithOverride(CakeRepoComponent, func(ServiceManager) interface{} { return mockRepo.NewMockCake(ctrl) }),
+				WithOverride(CakeServiceComponent, func(sm ServiceManager) interface{} {
+					sm.CakeRepo()
+					return mockSvc.NewMockCake(ctrl)
+				}),
+				WithOverride(AdminUserRepoComponent, func(ServiceManager) interface{} { return mockRepo.NewMockAdminUser(ctrl) }),
+				WithHook(CakeRepoComponent, hook(CakeRepoComponent, nil, nil)),
+				WithHook(CakeServiceComponent, hook(CakeServiceComponent, tt.startErr, tt.stopErr)),
+				WithHook(AdminUserRepoComponent, hook(AdminUserRepoComponent, nil, nil)),
+				// never built, so never started
+				WithHook(WebhookRepoComponent, hook(WebhookRepoComponent, nil, nil)),
+			)
+
+			ctx := context.Background()
+			sm.CakeService()
+
+			if err := sm.Start(ctx); err != tt.wantStart {
+				t.Fatalf("Start() error = %v, want %v", err, tt.wantStart)
+			}
+			if tt.wantStart == nil {
+				// built after Start, so started right away
+				sm.AdminUserRepo()
+			}
+
+			err := sm.Stop(ctx)
+			if (err != nil || tt.wantStop != "") && (err == nil || err.Error() != tt.wantStop) {
+				t.Errorf("Stop() error = %v, want %q", err, tt.wantStop)
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
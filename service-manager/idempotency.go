@@ -0,0 +1,18 @@
+package service_manager
+
+import (
+	"gitlab.com/cake-store-RESTFul/repo"
+	"gitlab.com/cake-store-RESTFul/service"
+)
+
+func (s *serviceManager) IdempotencyRepo() repo.Idempotency {
+	return s.get(IdempotencyRepoComponent, func() interface{} {
+		return repo.NewIdempotency(s.infra.Database, s.infra.Log)
+	}).(repo.Idempotency)
+}
+
+func (s *serviceManager) IdempotencyService() service.Idempotency {
+	return s.get(IdempotencyServiceComponent, func() interface{} {
+		return service.NewIdempotency(s.IdempotencyRepo(), s.infra.Log, s.infra.Idempotency.TTL)
+	}).(service.Idempotency)
+}
@@ -0,0 +1,18 @@
+package service_manager
+
+import (
+	"gitlab.com/cake-store-RESTFul/repo"
+	"gitlab.com/cake-store-RESTFul/service"
+)
+
+func (s *serviceManager) OutboxRepo() repo.Outbox {
+	return s.get(OutboxRepoComponent, func() interface{} {
+		return repo.NewOutbox(s.infra.Database, s.infra.Log)
+	}).(repo.Outbox)
+}
+
+func (s *serviceManager) OutboxService() service.Outbox {
+	return s.get(OutboxServiceComponent, func() interface{} {
+		return service.NewOutbox(s.OutboxRepo(), s.infra.Log, s.infra.Outbox)
+	}).(service.Outbox)
+}
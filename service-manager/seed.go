@@ -0,0 +1,18 @@
+package service_manager
+
+import (
+	"gitlab.com/cake-store-RESTFul/repo"
+	"gitlab.com/cake-store-RESTFul/service"
+)
+
+func (s *serviceManager) DemoSeedRepo() repo.DemoSeed {
+	return s.get(DemoSeedRepoComponent, func() interface{} {
+		return repo.NewDemoSeed(s.infra.Database, s.infra.Log)
+	}).(repo.DemoSeed)
+}
+
+func (s *serviceManager) SeederService() service.Seeder {
+	return s.get(SeederServiceComponent, func() interface{} {
+		return service.NewSeeder(s.CakeRepo(), s.DemoSeedRepo(), s.TxManager(), s.infra.Log, s.infra.Cloudinary)
+	}).(service.Seeder)
+}
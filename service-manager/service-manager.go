@@ -1,23 +1,49 @@
 package service_manager
 
 import (
+	"context"
+
 	"gitlab.com/cake-store-RESTFul/infra"
 	"gitlab.com/cake-store-RESTFul/repo"
 	"gitlab.com/cake-store-RESTFul/service"
 )
 
 type ServiceManager interface {
+	// transaction
+	TxManager() repo.TxManager
 	// cake
 	CakeRepo() repo.Cake
 	CakeService() service.Cake
+	// idempotency
+	IdempotencyRepo() repo.Idempotency
+	IdempotencyService() service.Idempotency
+	// webhook
+	WebhookRepo() repo.Webhook
+	WebhookService() service.Webhook
+	// outbox
+	OutboxRepo() repo.Outbox
+	OutboxService() service.Outbox
+	// admin user
+	AdminUserRepo() repo.AdminUser
+	AdminUserService() service.AdminUser
+	// demo data
+	DemoSeedRepo() repo.DemoSeed
+	SeederService() service.Seeder
+	// lifecycle of the components built so far
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
 }
 
+// serviceManager builds every component once per instance, two managers
+// never share a component.
 type serviceManager struct {
-	infra *infra.Infra
+	infra     *infra.Infra
+	container *container
 }
 
-func NewServiceManager(infra *infra.Infra) ServiceManager {
+func NewServiceManager(infra *infra.Infra, options ...Option) ServiceManager {
 	return &serviceManager{
-		infra: infra,
+		infra:     infra,
+		container: newContainer(options...),
 	}
 }
@@ -0,0 +1,11 @@
+package service_manager
+
+import (
+	"gitlab.com/cake-store-RESTFul/repo"
+)
+
+func (s *serviceManager) TxManager() repo.TxManager {
+	return s.get(TxManagerComponent, func() interface{} {
+		return repo.NewTxManager(s.infra.Database, s.infra.Log)
+	}).(repo.TxManager)
+}
@@ -0,0 +1,18 @@
+package service_manager
+
+import (
+	"gitlab.com/cake-store-RESTFul/repo"
+	"gitlab.com/cake-store-RESTFul/service"
+)
+
+func (s *serviceManager) WebhookRepo() repo.Webhook {
+	return s.get(WebhookRepoComponent, func() interface{} {
+		return repo.NewWebhook(s.infra.Database, s.infra.Log)
+	}).(repo.Webhook)
+}
+
+func (s *serviceManager) WebhookService() service.Webhook {
+	return s.get(WebhookServiceComponent, func() interface{} {
+		return service.NewWebhook(s.WebhookRepo(), s.infra.Log, s.infra.Webhook)
+	}).(service.Webhook)
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/repo"
+)
+
+// adminTokenBytes is the amount of randomness in an admin user token.
+const adminTokenBytes = 32
+
+// ErrAdminUserExists is returned when an admin user with the same name
+// already exists.
+var ErrAdminUserExists = errors.New("an admin user with this name already exists")
+
+type AdminUser interface {
+	// Create stores a new admin user and returns its API token. Only a hash
+	// of the token is kept, it cannot be shown again.
+	Create(ctx context.Context, name string) (string, error)
+	// Authenticate returns the name of the admin user owning token,
+	// sql.ErrNoRows when there is none.
+	Authenticate(ctx context.Context, token string) (string, error)
+}
+
+type adminUser struct {
+	adminUserRepo repo.AdminUser
+	Log           zerolog.Logger
+}
+
+func NewAdminUser(adminUserRepo repo.AdminUser, log zerolog.Logger) AdminUser {
+	return &adminUser{
+		adminUserRepo: adminUserRepo,
+		Log:           log,
+	}
+}
+
+func (a *adminUser) Create(ctx context.Context, name string) (string, error) {
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("name cannot be empty")
+	}
+
+	secret := make([]byte, adminTokenBytes)
+	if _, err := rand.Read(secret); err != nil {
+		a.Log.Error().Msg(err.Error())
+		return "", err
+	}
+	token := hex.EncodeToString(secret)
+
+	created, err := a.adminUserRepo.Create(ctx, repo.AdminUserModel{
+		Name:      name,
+		TokenHash: hashAdminToken(token),
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		a.Log.Error().Msg(err.Error())
+		return "", err
+	}
+	if !created {
+		return "", ErrAdminUserExists
+	}
+
+	return token, nil
+}
+
+func (a *adminUser) Authenticate(ctx context.Context, token string) (string, error) {
+
+	user, err := a.adminUserRepo.GetByTokenHash(ctx, hashAdminToken(token))
+	if err != nil {
+		return "", err
+	}
+
+	return user.Name, nil
+}
+
+// hashAdminToken is how tokens are stored, a plain SHA-256 is enough for
+// random tokens of this length.
+func hashAdminToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/repo"
+	mockRepo "gitlab.com/cake-store-RESTFul/repo/mocks"
+)
+
+func Test_adminUser_Create(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		userName   string
+		beforeFunc func(m *mockRepo.MockAdminUser)
+		wantErr    error
+	}{
+		{
+			name:     "success",
+			userName: " alice ",
+			beforeFunc: func(m *mockRepo.MockAdminUser) {
+				m.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, input repo.AdminUserModel) (bool, error) {
+					if input.Name != "alice" || len(input.TokenHash) != 64 {
+						t.Errorf("adminUser.Create() stored %+v", input)
+					}
+					return true, nil
+				})
+			},
+		},
+		{
+			name:     "error name taken",
+			userName: "alice",
+			beforeFunc: func(m *mockRepo.MockAdminUser) {
+				m.EXPECT().Create(ctx, gomock.Any()).Return(false, nil)
+			},
+			wantErr: ErrAdminUserExists,
+		},
+		{
+			name:       "error empty name",
+			userName:   " ",
+			beforeFunc: func(m *mockRepo.MockAdminUser) {},
+			wantErr:    errors.New("name cannot be empty"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := mockRepo.NewMockAdminUser(ctrl)
+			tt.beforeFunc(m)
+
+			token, err := NewAdminUser(m, zerolog.Logger{}).Create(ctx, tt.userName)
+			if tt.wantErr != nil {
+				if err == nil || err.Error() != tt.wantErr.Error() {
+					t.Errorf("adminUser.Create() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || len(token) != adminTokenBytes*2 {
+				t.Errorf("adminUser.Create() = %q, %v", token, err)
+			}
+		})
+	}
+}
+
+func Test_adminUser_Authenticate(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		beforeFunc func(m *mockRepo.MockAdminUser)
+		want       string
+		wantErr    error
+	}{
+		{
+			name: "success",
+			beforeFunc: func(m *mockRepo.MockAdminUser) {
+				m.EXPECT().GetByTokenHash(ctx, hashAdminToken("token")).Return(repo.AdminUserModel{Name: "alice"}, nil)
+			},
+			want: "alice",
+		},
+		{
+			name: "unknown token",
+			beforeFunc: func(m *mockRepo.MockAdminUser) {
+				m.EXPECT().GetByTokenHash(ctx, hashAdminToken("token")).Return(repo.AdminUserModel{}, sql.ErrNoRows)
+			},
+			wantErr: sql.ErrNoRows,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := mockRepo.NewMockAdminUser(ctrl)
+			tt.beforeFunc(m)
+
+			got, err := NewAdminUser(m, zerolog.Logger{}).Authenticate(ctx, "token")
+			if err != tt.wantErr {
+				t.Errorf("adminUser.Authenticate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("adminUser.Authenticate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
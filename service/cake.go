@@ -1,8 +1,13 @@
 package service
 
 import (
+	"archive/zip"
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
@@ -14,28 +19,36 @@ import (
 )
 
 type Cake interface {
-	Create(ctx context.Context, req cakeApi.CreateRequest) error
+	Create(ctx context.Context, req cakeApi.CreateRequest) (cakeApi.CakeResponse, error)
 	GetList(ctx context.Context, req cakeApi.GetListRequest, paginateReq commonApi.PaginationRequest) (cakeApi.CakesResponse, commonApi.PaginationResponse, error)
 	GetDetail(ctx context.Context, id int) (cakeApi.CakeResponse, error)
-	Update(ctx context.Context, req cakeApi.UpdateRequest) error
+	GetByIDs(ctx context.Context, ids []int) (cakeApi.CakesResponse, error)
+	Export(ctx context.Context, req cakeApi.GetListRequest, fn func(cakeApi.CakeResponse) error) error
+	Update(ctx context.Context, req cakeApi.UpdateRequest) (cakeApi.CakeResponse, error)
 	Delete(ctx context.Context, id int) error
+	Import(ctx context.Context, req cakeApi.ImportRequest) (cakeApi.ImportResponse, error)
+	BulkUpdate(ctx context.Context, req cakeApi.BulkUpdateRequest) (cakeApi.BulkResponse, error)
+	BulkDelete(ctx context.Context, req cakeApi.BulkRequest) (cakeApi.BulkResponse, error)
 }
 
 type cake struct {
 	cakeRepo   repo.Cake
+	txManager  repo.TxManager
 	Log        zerolog.Logger
 	Cloudinary infra.Cloudinary
 }
 
-func NewCake(cakeRepo repo.Cake, log zerolog.Logger, cl infra.Cloudinary) Cake {
+func NewCake(cakeRepo repo.Cake, txManager repo.TxManager, log zerolog.Logger, cl infra.Cloudinary) Cake {
 	return &cake{
 		cakeRepo:   cakeRepo,
+		txManager:  txManager,
 		Log:        log,
 		Cloudinary: cl,
 	}
 }
 
-func (c *cake) Create(ctx context.Context, req cakeApi.CreateRequest) (err error) {
+// Create stores the cake and returns it with its generated ID.
+func (c *cake) Create(ctx context.Context, req cakeApi.CreateRequest) (res cakeApi.CakeResponse, err error) {
 
 	r, err := c.Cloudinary.Upload(ctx, req.Image, uploader.UploadParams{})
 	if err != nil {
@@ -51,13 +64,13 @@ func (c *cake) Create(ctx context.Context, req cakeApi.CreateRequest) (err error
 		CreatedAt:   time.Now().UTC(),
 	}
 
-	err = c.cakeRepo.Create(ctx, cake)
+	cake.ID, err = c.cakeRepo.Create(ctx, cake)
 	if err != nil {
 		c.Log.Error().Msg(err.Error())
-		return err
+		return res, err
 	}
 
-	return nil
+	return newCakeResponse(cake), nil
 }
 
 func (c *cake) GetList(ctx context.Context, req cakeApi.GetListRequest, paginateReq commonApi.PaginationRequest) (res cakeApi.CakesResponse, pagination commonApi.PaginationResponse, err error) {
@@ -130,37 +143,308 @@ func (c *cake) GetDetail(ctx context.Context, id int) (res cakeApi.CakeResponse,
 	return res, nil
 }
 
-func (c *cake) Update(ctx context.Context, req cakeApi.UpdateRequest) (err error) {
+// Export calls fn for every cake matching the list filter, without pagination.
+func (c *cake) Export(ctx context.Context, req cakeApi.GetListRequest, fn func(cakeApi.CakeResponse) error) (err error) {
+
+	return c.cakeRepo.Stream(ctx, req.Search, req.Sort, req.SortBy, func(cake repo.CakeBaseModel) error {
+		return fn(newCakeResponse(cake))
+	})
+}
+
+func newCakeResponse(cake repo.CakeBaseModel) cakeApi.CakeResponse {
+
+	res := cakeApi.CakeResponse{
+		ID:          cake.ID,
+		Title:       cake.Title,
+		Description: cake.Description,
+		Image:       cake.Image,
+		Rating:      cake.Rating,
+		CreatedAt:   cake.CreatedAt,
+	}
+
+	if cake.UpdatedAt.Valid {
+		res.UpdatedAt = &cake.UpdatedAt.Time
+	}
+
+	return res
+}
+
+// GetByIDs returns the cakes of ids found in a single read, the order of the
+// result does not follow ids.
+func (c *cake) GetByIDs(ctx context.Context, ids []int) (res cakeApi.CakesResponse, err error) {
+
+	cakes, err := c.cakeRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		c.Log.Error().Msg(err.Error())
+		return
+	}
+
+	res = make(cakeApi.CakesResponse, 0, len(cakes))
+	for _, cake := range cakes {
+		res = append(res, newCakeResponse(cake))
+	}
+
+	return res, nil
+}
+
+// Update applies the changes and returns the cake as stored afterwards, a
+// missing cake is reported as sql.ErrNoRows.
+func (c *cake) Update(ctx context.Context, req cakeApi.UpdateRequest) (res cakeApi.CakeResponse, err error) {
 
 	now := time.Now().UTC()
-	iUrl := ""
+
+	cake := repo.CakeUpdateModel{
+		ID:          req.ID,
+		Title:       req.Title,
+		Description: req.Description,
+		Rating:      req.Rating,
+		Image:       req.ImageURL,
+		UpdatedAt:   sql.NullTime{Time: now, Valid: true},
+	}
 
 	if req.Image != nil {
 		r, err := c.Cloudinary.Upload(ctx, req.Image, uploader.UploadParams{})
 		if err != nil {
 			c.Log.Error().Msg(err.Error())
-			return err
+			return res, err
 		}
-		iUrl = r.URL
+		cake.Image = &r.URL
 	}
 
-	cake := repo.CakeBaseModel{
-		ID:          req.ID,
-		Title:       req.Title,
-		Description: req.Description,
-		UpdatedAt:   sql.NullTime{Time: now, Valid: true},
-		Image:       iUrl,
-	}
+	// the cake is read back in the same transaction so the response is the
+	// state this update left, not a later one
+	updated := repo.CakeBaseModel{}
+	err = c.txManager.WithinTx(ctx, func(ctx context.Context) (err error) {
+		err = c.cakeRepo.Update(ctx, cake)
+		if err != nil {
+			return
+		}
 
-	err = c.cakeRepo.Update(ctx, cake)
+		updated, err = c.cakeRepo.GetDetail(ctx, req.ID)
+		return
+	})
 	if err != nil {
 		c.Log.Error().Msg(err.Error())
 		return
 	}
 
-	return nil
+	return newCakeResponse(updated), nil
 }
 
 func (c *cake) Delete(ctx context.Context, id int) (err error) {
 	return c.cakeRepo.Delete(ctx, id)
 }
+
+func (c *cake) BulkUpdate(ctx context.Context, req cakeApi.BulkUpdateRequest) (res cakeApi.BulkResponse, err error) {
+
+	cake := repo.CakeUpdateModel{
+		Title:       req.Changes.Title,
+		Description: req.Changes.Description,
+		Rating:      req.Changes.Rating,
+		UpdatedAt:   sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	}
+
+	results, err := c.cakeRepo.BulkUpdate(ctx, bulkFilter(req.BulkRequest), cake, req.Atomic)
+	if err != nil && err != repo.ErrBulkRolledBack {
+		c.Log.Error().Msg(err.Error())
+		return
+	}
+
+	return newBulkResponse(results, req.Atomic, err == nil, cakeApi.BulkStatusUpdated), nil
+}
+
+func (c *cake) BulkDelete(ctx context.Context, req cakeApi.BulkRequest) (res cakeApi.BulkResponse, err error) {
+
+	results, err := c.cakeRepo.BulkDelete(ctx, bulkFilter(req), req.Atomic)
+	if err != nil && err != repo.ErrBulkRolledBack {
+		c.Log.Error().Msg(err.Error())
+		return
+	}
+
+	return newBulkResponse(results, req.Atomic, err == nil, cakeApi.BulkStatusDeleted), nil
+}
+
+func bulkFilter(req cakeApi.BulkRequest) repo.BulkFilter {
+
+	filter := repo.BulkFilter{IDs: req.IDs}
+	if req.Filter != nil {
+		filter.Search = req.Filter.Search
+	}
+
+	return filter
+}
+
+func newBulkResponse(results []repo.BulkResult, atomic, committed bool, success string) cakeApi.BulkResponse {
+
+	res := cakeApi.BulkResponse{
+		Atomic:    atomic,
+		Committed: committed,
+		Total:     len(results),
+		Results:   make([]cakeApi.BulkResult, 0, len(results)),
+	}
+
+	for _, r := range results {
+		result := cakeApi.BulkResult{ID: r.ID, Status: success}
+
+		switch {
+		case r.Err != nil:
+			result.Status, result.Error = cakeApi.BulkStatusFailed, r.Err.Error()
+		case !r.Found:
+			result.Status = cakeApi.BulkStatusNotFound
+		case !committed:
+			result.Status = cakeApi.BulkStatusRolledBack
+		}
+
+		if result.Status == success {
+			res.Succeeded++
+		} else {
+			res.Failed++
+		}
+
+		res.Results = append(res.Results, result)
+	}
+
+	return res
+}
+
+// Import validates every row and, unless it is a dry run, uploads the images
+// of the valid rows and stores them in a single transaction. Invalid rows are
+// reported and skipped. When the transaction fails the uploaded images are
+// destroyed and the report is returned together with the error.
+func (c *cake) Import(ctx context.Context, req cakeApi.ImportRequest) (res cakeApi.ImportResponse, err error) {
+
+	res = cakeApi.ImportResponse{
+		DryRun: req.DryRun,
+		Total:  len(req.Rows),
+		Rows:   make([]cakeApi.ImportRowResult, 0, len(req.Rows)),
+	}
+
+	now := time.Now().UTC()
+	cakes := []repo.CakeBaseModel{}
+	inserted := []int{}
+	uploaded := []string{}
+
+	for _, row := range req.Rows {
+		result := cakeApi.ImportRowResult{Line: row.Line, Title: row.Title, Status: cakeApi.ImportStatusValid}
+
+		createReq := cakeApi.CreateRequest{}
+		if err := createReq.ParseForm(row.Values()); err != nil {
+			result.Status, result.Error = cakeApi.ImportStatusInvalid, err.Error()
+			res.Rows = append(res.Rows, result)
+			continue
+		}
+
+		image, err := importImage(row.Image, req.Images)
+		if err != nil {
+			result.Status, result.Error = cakeApi.ImportStatusInvalid, err.Error()
+			res.Rows = append(res.Rows, result)
+			continue
+		}
+
+		if req.DryRun {
+			res.Rows = append(res.Rows, result)
+			continue
+		}
+
+		upload, err := c.uploadImportImage(ctx, image)
+		if err != nil {
+			c.Log.Error().Msg(err.Error())
+			result.Status, result.Error = cakeApi.ImportStatusFailed, err.Error()
+			res.Rows = append(res.Rows, result)
+			continue
+		}
+
+		cakes = append(cakes, repo.CakeBaseModel{
+			Title:       createReq.Title,
+			Description: createReq.Description,
+			Rating:      createReq.Rating,
+			Image:       upload.URL,
+			CreatedAt:   now,
+		})
+		inserted = append(inserted, len(res.Rows))
+		uploaded = append(uploaded, upload.PublicID)
+		res.Rows = append(res.Rows, result)
+	}
+
+	if len(cakes) > 0 {
+		ids, err := c.cakeRepo.CreateBatch(ctx, cakes)
+		if err != nil {
+			c.Log.Error().Msg(err.Error())
+			c.destroyImportImages(ctx, uploaded)
+			for _, i := range inserted {
+				res.Rows[i].Status, res.Rows[i].Error = cakeApi.ImportStatusFailed, err.Error()
+			}
+			countImport(&res)
+			return res, err
+		}
+
+		for n, i := range inserted {
+			res.Rows[i].Status, res.Rows[i].ID = cakeApi.ImportStatusCreated, ids[n]
+		}
+	}
+
+	countImport(&res)
+	return res, nil
+}
+
+// countImport counts the succeeded and failed rows of an import report.
+func countImport(res *cakeApi.ImportResponse) {
+
+	for _, row := range res.Rows {
+		if row.Status == cakeApi.ImportStatusValid || row.Status == cakeApi.ImportStatusCreated {
+			res.Succeeded++
+		} else {
+			res.Failed++
+		}
+	}
+}
+
+// importImage resolves the image column of an import row to either a remote
+// URL or a file inside the images archive.
+func importImage(name string, images *zip.Reader) (interface{}, error) {
+
+	if name == "" {
+		return nil, errors.New("image cannot be empty")
+	}
+
+	if strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://") {
+		return name, nil
+	}
+
+	if images == nil {
+		return nil, fmt.Errorf("image %s is not a URL and no images archive was uploaded", name)
+	}
+
+	for _, f := range images.File {
+		if f.Name == name || path.Base(f.Name) == name {
+			return f, nil
+		}
+	}
+
+	return nil, fmt.Errorf("image %s not found in images archive", name)
+}
+
+func (c *cake) uploadImportImage(ctx context.Context, image interface{}) (*uploader.UploadResult, error) {
+
+	if f, ok := image.(*zip.File); ok {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		image = rc
+	}
+
+	return c.Cloudinary.Upload(ctx, image, uploader.UploadParams{})
+}
+
+// destroyImportImages removes the images uploaded for rows that were not
+// stored, so a failed import does not leave them behind in Cloudinary.
+func (c *cake) destroyImportImages(ctx context.Context, publicIDs []string) {
+
+	for _, publicID := range publicIDs {
+		if _, err := c.Cloudinary.Destroy(ctx, uploader.DestroyParams{PublicID: publicID}); err != nil {
+			c.Log.Error().Msg(err.Error())
+		}
+	}
+}
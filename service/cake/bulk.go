@@ -0,0 +1,103 @@
+package cake
+
+import (
+	"errors"
+)
+
+const (
+	BulkStatusUpdated    = "updated"
+	BulkStatusDeleted    = "deleted"
+	BulkStatusNotFound   = "not_found"
+	BulkStatusFailed     = "failed"
+	BulkStatusRolledBack = "rolled_back"
+)
+
+// BulkFilter selects cakes with the same search as GetListRequest. Every
+// cake is only selected with All, an empty filter selects nothing.
+type BulkFilter struct {
+	Search string `json:"search"`
+	All    bool   `json:"all"`
+}
+
+type BulkRequest struct {
+	IDs    []int       `json:"ids"`
+	Filter *BulkFilter `json:"filter"`
+	// Atomic rolls back the whole operation when any cake fails.
+	Atomic bool `json:"atomic"`
+}
+
+func (b *BulkRequest) Validate() error {
+
+	if len(b.IDs) == 0 && b.Filter == nil {
+		return errors.New("ids or filter is required")
+	}
+
+	if len(b.IDs) > 0 && b.Filter != nil {
+		return errors.New("ids and filter cannot be combined")
+	}
+
+	if b.Filter != nil && b.Filter.Search == "" && !b.Filter.All {
+		return errors.New("filter requires a search or all")
+	}
+
+	if b.Filter != nil && b.Filter.Search != "" && b.Filter.All {
+		return errors.New("search and all cannot be combined")
+	}
+
+	for _, id := range b.IDs {
+		if id <= 0 {
+			return errors.New("ids must be positive")
+		}
+	}
+
+	return nil
+}
+
+// BulkChanges holds the fields to set on every selected cake, nil fields are
+// left untouched.
+type BulkChanges struct {
+	Title       *string  `json:"title"`
+	Description *string  `json:"description"`
+	Rating      *float32 `json:"rating"`
+}
+
+type BulkUpdateRequest struct {
+	BulkRequest
+	Changes BulkChanges `json:"changes"`
+}
+
+func (b *BulkUpdateRequest) Validate() error {
+
+	if err := b.BulkRequest.Validate(); err != nil {
+		return err
+	}
+
+	if b.Changes == (BulkChanges{}) {
+		return errors.New("changes cannot be empty")
+	}
+
+	if b.Changes.Title != nil && *b.Changes.Title == "" {
+		return errors.New("title cannot by empty")
+	}
+
+	if b.Changes.Rating != nil && *b.Changes.Rating < 0 {
+		return errors.New("rating cannot less than 0")
+	}
+
+	return nil
+}
+
+type BulkResult struct {
+	ID     int    `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type BulkResponse struct {
+	Atomic    bool         `json:"atomic"`
+	Committed bool         `json:"committed"`
+	Total     int          `json:"total"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Results   []BulkResult `json:"results"`
+}
@@ -0,0 +1,76 @@
+package cake
+
+import "testing"
+
+func TestBulkUpdateRequest_Validate(t *testing.T) {
+	title, empty, rating, negative := "test", "", float32(4), float32(-1)
+
+	tests := []struct {
+		name    string
+		req     BulkUpdateRequest
+		wantErr bool
+	}{
+		{
+			name:    "success by ids",
+			req:     BulkUpdateRequest{BulkRequest: BulkRequest{IDs: []int{1, 2}}, Changes: BulkChanges{Rating: &rating}},
+			wantErr: false,
+		},
+		{
+			name:    "success by filter",
+			req:     BulkUpdateRequest{BulkRequest: BulkRequest{Filter: &BulkFilter{Search: "chess"}}, Changes: BulkChanges{Title: &title}},
+			wantErr: false,
+		},
+		{
+			name:    "success every cake",
+			req:     BulkUpdateRequest{BulkRequest: BulkRequest{Filter: &BulkFilter{All: true}}, Changes: BulkChanges{Title: &title}},
+			wantErr: false,
+		},
+		{
+			name:    "error: empty filter",
+			req:     BulkUpdateRequest{BulkRequest: BulkRequest{Filter: &BulkFilter{}}, Changes: BulkChanges{Title: &title}},
+			wantErr: true,
+		},
+		{
+			name:    "error: search and all",
+			req:     BulkUpdateRequest{BulkRequest: BulkRequest{Filter: &BulkFilter{Search: "chess", All: true}}, Changes: BulkChanges{Title: &title}},
+			wantErr: true,
+		},
+		{
+			name:    "error: no ids or filter",
+			req:     BulkUpdateRequest{Changes: BulkChanges{Title: &title}},
+			wantErr: true,
+		},
+		{
+			name:    "error: ids and filter",
+			req:     BulkUpdateRequest{BulkRequest: BulkRequest{IDs: []int{1}, Filter: &BulkFilter{}}, Changes: BulkChanges{Title: &title}},
+			wantErr: true,
+		},
+		{
+			name:    "error: invalid id",
+			req:     BulkUpdateRequest{BulkRequest: BulkRequest{IDs: []int{0}}, Changes: BulkChanges{Title: &title}},
+			wantErr: true,
+		},
+		{
+			name:    "error: empty changes",
+			req:     BulkUpdateRequest{BulkRequest: BulkRequest{IDs: []int{1}}},
+			wantErr: true,
+		},
+		{
+			name:    "error: empty title",
+			req:     BulkUpdateRequest{BulkRequest: BulkRequest{IDs: []int{1}}, Changes: BulkChanges{Title: &empty}},
+			wantErr: true,
+		},
+		{
+			name:    "error: negative rating",
+			req:     BulkUpdateRequest{BulkRequest: BulkRequest{IDs: []int{1}}, Changes: BulkChanges{Rating: &negative}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.req.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("BulkUpdateRequest.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
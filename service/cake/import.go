@@ -0,0 +1,166 @@
+package cake
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+
+	ImportStatusValid   = "valid"
+	ImportStatusInvalid = "invalid"
+	ImportStatusCreated = "created"
+	ImportStatusFailed  = "failed"
+)
+
+// ImportRow is a single cake read from an import file, Image holds either an
+// http(s) URL or the name of a file inside the images zip.
+type ImportRow struct {
+	Line        int
+	Title       string
+	Description string
+	Rating      string
+	Image       string
+}
+
+// Values returns the row as form values so it can be validated exactly like
+// a multipart create request.
+func (i ImportRow) Values() url.Values {
+	return url.Values{
+		"title":       []string{i.Title},
+		"description": []string{i.Description},
+		"rating":      []string{i.Rating},
+	}
+}
+
+type ImportRequest struct {
+	Rows   []ImportRow
+	Images *zip.Reader
+	DryRun bool
+}
+
+// ImportRowResult is the outcome of a row, ID is set once it is created.
+type ImportRowResult struct {
+	Line   int    `json:"line"`
+	ID     int    `json:"id,omitempty"`
+	Title  string `json:"title"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type ImportResponse struct {
+	DryRun    bool              `json:"dry_run"`
+	Total     int               `json:"total"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Rows      []ImportRowResult `json:"rows"`
+}
+
+// ParseImport reads every row of a CSV or NDJSON import file. CSV files must
+// start with a header naming the title, description, rating and image columns.
+func ParseImport(format string, r io.Reader) ([]ImportRow, error) {
+	switch format {
+	case ImportFormatCSV:
+		return parseImportCSV(r)
+	case ImportFormatNDJSON:
+		return parseImportNDJSON(r)
+	}
+
+	return nil, fmt.Errorf("unsupported import format %q", format)
+}
+
+func parseImportCSV(r io.Reader) (rows []ImportRow, err error) {
+
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("import file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range []string{"title", "description", "rating", "image"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("column %s is missing from the header", name)
+		}
+	}
+
+	column := func(record []string, name string) string {
+		if i := columns[name]; i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	reader.FieldsPerRecord = -1
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rows = append(rows, ImportRow{
+			Line:        line,
+			Title:       column(record, "title"),
+			Description: column(record, "description"),
+			Rating:      column(record, "rating"),
+			Image:       column(record, "image"),
+		})
+	}
+
+	return rows, nil
+}
+
+func parseImportNDJSON(r io.Reader) (rows []ImportRow, err error) {
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		v := struct {
+			Title       string      `json:"title"`
+			Description string      `json:"description"`
+			Rating      json.Number `json:"rating"`
+			Image       string      `json:"image"`
+		}{}
+
+		if err := json.Unmarshal([]byte(text), &v); err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err.Error())
+		}
+
+		rows = append(rows, ImportRow{
+			Line:        line,
+			Title:       v.Title,
+			Description: v.Description,
+			Rating:      v.Rating.String(),
+			Image:       v.Image,
+		})
+	}
+
+	return rows, scanner.Err()
+}
@@ -0,0 +1,97 @@
+package cake
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseImport(t *testing.T) {
+	type args struct {
+		format string
+		body   string
+	}
+	tests := []struct {
+		name     string
+		args     args
+		wantRows []ImportRow
+		wantErr  bool
+	}{
+		{
+			name: "success csv",
+			args: args{
+				format: ImportFormatCSV,
+				body:   "title,description,rating,image\nChess Cake,Yummy,4.5,chess.png\nLemon Cake, Sour ,3,https://example.com/lemon.png\n",
+			},
+			wantRows: []ImportRow{
+				{Line: 2, Title: "Chess Cake", Description: "Yummy", Rating: "4.5", Image: "chess.png"},
+				{Line: 3, Title: "Lemon Cake", Description: "Sour", Rating: "3", Image: "https://example.com/lemon.png"},
+			},
+		},
+		{
+			name: "success csv with reordered columns and short rows",
+			args: args{
+				format: ImportFormatCSV,
+				body:   "image,rating,description,title\nchess.png,4.5\n",
+			},
+			wantRows: []ImportRow{
+				{Line: 2, Rating: "4.5", Image: "chess.png"},
+			},
+		},
+		{
+			name: "error csv missing column",
+			args: args{
+				format: ImportFormatCSV,
+				body:   "title,description,rating\nChess Cake,Yummy,4.5\n",
+			},
+			wantErr: true,
+		},
+		{
+			name: "error csv empty",
+			args: args{
+				format: ImportFormatCSV,
+				body:   "",
+			},
+			wantErr: true,
+		},
+		{
+			name: "success ndjson",
+			args: args{
+				format: ImportFormatNDJSON,
+				body:   "{\"title\":\"Chess Cake\",\"description\":\"Yummy\",\"rating\":4.5,\"image\":\"chess.png\"}\n\n{\"title\":\"Lemon Cake\"}\n",
+			},
+			wantRows: []ImportRow{
+				{Line: 1, Title: "Chess Cake", Description: "Yummy", Rating: "4.5", Image: "chess.png"},
+				{Line: 3, Title: "Lemon Cake"},
+			},
+		},
+		{
+			name: "error ndjson invalid line",
+			args: args{
+				format: ImportFormatNDJSON,
+				body:   "{\"title\":\"Chess Cake\"}\n{invalid\n",
+			},
+			wantErr: true,
+		},
+		{
+			name: "error unsupported format",
+			args: args{
+				format: "xml",
+				body:   "<cake/>",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotRows, err := ParseImport(tt.args.format, strings.NewReader(tt.args.body))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseImport() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotRows, tt.wantRows) {
+				t.Errorf("ParseImport() = %v, want %v", gotRows, tt.wantRows)
+			}
+		})
+	}
+}
@@ -1,11 +1,15 @@
 package cake
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
-	"mime/multipart"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -77,43 +81,190 @@ func (c *CreateRequest) ParseForm(v url.Values) error {
 	return nil
 }
 
+// UpdateRequest carries the changes of a partial or full update, nil fields
+// are left untouched.
 type UpdateRequest struct {
-	ID          int            `json:"id"`
-	Title       string         `json:"title"`
-	Rating      float32        `json:"rating"`
-	Description string         `json:"description"`
-	Image       multipart.File `json:"image"`
-	UpdatedAt   time.Time      `json:"updated_at"`
+	ID          int      `json:"id"`
+	Title       *string  `json:"title"`
+	Rating      *float32 `json:"rating"`
+	Description *string  `json:"description"`
+	// Image is a new image to upload, it takes precedence over ImageURL.
+	Image io.Reader `json:"-"`
+	// ImageURL sets the stored image directly, an empty string removes it.
+	ImageURL  *string   `json:"-"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// ParseForm reads the fields present in the form, a field sent with an empty
+// value clears it while a missing field is left untouched.
 func (c *UpdateRequest) ParseForm(v url.Values) (err error) {
 
-	x := float64(0)
-	if v.Get("rating") != "" {
-		x, err = strconv.ParseFloat(v.Get("rating"), 32)
-		if err != nil {
+	if _, ok := v["title"]; ok {
+		if err = c.setTitle(v.Get("title")); err != nil {
+			return err
+		}
+	}
+
+	if _, ok := v["description"]; ok {
+		description := v.Get("description")
+		c.Description = &description
+	}
+
+	if _, ok := v["rating"]; ok {
+		x := float64(0)
+		if v.Get("rating") != "" {
+			x, err = strconv.ParseFloat(v.Get("rating"), 32)
+			if err != nil {
+				return errors.New("rating is invalid")
+			}
+		}
+
+		if err = c.setRating(float32(x)); err != nil {
+			return err
+		}
+	}
+
+	// an uploaded file is not part of the form values, only a text field
+	// holding a URL or an empty value can be seen here
+	if _, ok := v["image"]; ok && c.Image == nil {
+		if err = c.setImage(v.Get("image")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ParseMergePatch applies a JSON Merge Patch (RFC 7386) document. Members
+// that are absent are left untouched and null clears the value. The image is
+// an image URL or base64 encoded image data.
+func (c *UpdateRequest) ParseMergePatch(body []byte) error {
+
+	doc := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return errors.New("body must be a JSON object")
+	}
+
+	return c.parseJSON(doc)
+}
+
+// ParseReplace reads the full representation sent with PUT. Title,
+// description and rating are required, a missing image removes the current one.
+func (c *UpdateRequest) ParseReplace(body []byte) error {
+
+	doc := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return errors.New("body must be a JSON object")
+	}
+
+	for _, key := range []string{"title", "description", "rating"} {
+		if raw, ok := doc[key]; !ok || isNull(raw) {
+			return fmt.Errorf("%s is required", key)
+		}
+	}
+
+	if _, ok := doc["image"]; !ok {
+		doc["image"] = json.RawMessage("null")
+	}
+
+	return c.parseJSON(doc)
+}
+
+func (c *UpdateRequest) parseJSON(doc map[string]json.RawMessage) error {
+
+	if raw, ok := doc["title"]; ok {
+		title := ""
+		if !isNull(raw) && json.Unmarshal(raw, &title) != nil {
+			return errors.New("title is invalid")
+		}
+
+		if err := c.setTitle(title); err != nil {
+			return err
+		}
+	}
+
+	if raw, ok := doc["description"]; ok {
+		description := ""
+		if !isNull(raw) && json.Unmarshal(raw, &description) != nil {
+			return errors.New("description is invalid")
+		}
+		c.Description = &description
+	}
+
+	if raw, ok := doc["rating"]; ok {
+		rating := float32(0)
+		if !isNull(raw) && json.Unmarshal(raw, &rating) != nil {
 			return errors.New("rating is invalid")
 		}
+
+		if err := c.setRating(rating); err != nil {
+			return err
+		}
 	}
 
-	if x < 0 {
+	if raw, ok := doc["image"]; ok {
+		image := ""
+		if !isNull(raw) && json.Unmarshal(raw, &image) != nil {
+			return errors.New("image is invalid")
+		}
+
+		if err := c.setImage(image); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *UpdateRequest) setTitle(title string) error {
+	if title == "" {
+		return errors.New("title cannot by empty")
+	}
+
+	c.Title = &title
+	return nil
+}
+
+func (c *UpdateRequest) setRating(rating float32) error {
+	if rating < 0 {
 		return errors.New("rating cannot less than 0")
 	}
 
-	c.Title = v.Get("title")
-	c.Description = v.Get("description")
-	c.Rating = float32(x)
+	c.Rating = &rating
+	return nil
+}
+
+// setImage keeps empty values and URLs as ImageURL, anything else must be
+// base64 encoded image data to upload.
+func (c *UpdateRequest) setImage(image string) error {
 
+	if image == "" || strings.HasPrefix(image, "http://") || strings.HasPrefix(image, "https://") {
+		c.ImageURL = &image
+		return nil
+	}
+
+	b, err := base64.StdEncoding.DecodeString(image)
+	if err != nil {
+		return errors.New("image is invalid")
+	}
+
+	c.Image = bytes.NewReader(b)
 	return nil
 }
 
+func isNull(raw json.RawMessage) bool {
+	return string(bytes.TrimSpace(raw)) == "null"
+}
+
 type GetListRequest struct {
 	Search string
 	Sort   string
 	SortBy string
 }
 
-func (c *GetListRequest) ParseQuery(v url.Values) {
+// ParseQuery reads sort as a comma separated list of fields and sort_by as
+// the direction of the last one, ASC or DESC.
+func (c *GetListRequest) ParseQuery(v url.Values) error {
 
 	c.Search = v.Get("search")
 	c.SortBy = v.Get("sort_by")
@@ -126,4 +277,57 @@ func (c *GetListRequest) ParseQuery(v url.Values) {
 	if c.SortBy == "" {
 		c.SortBy = "ASC"
 	}
+
+	for _, field := range strings.Split(c.Sort, ",") {
+		if !sortColumns[strings.TrimSpace(field)] {
+			return fmt.Errorf("cannot sort by %q", field)
+		}
+	}
+
+	switch strings.ToUpper(c.SortBy) {
+	case "ASC", "DESC":
+	default:
+		return fmt.Errorf("sort_by must be ASC or DESC")
+	}
+
+	return nil
+}
+
+// sortColumns are the fields a list can be ordered by.
+var sortColumns = map[string]bool{
+	"id":         true,
+	"title":      true,
+	"rating":     true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+// ParseSort reads a comma separated list of fields where a leading "-" sorts
+// that field descending, e.g. "-rating,title". Every field gets its own
+// direction so SortBy is left empty.
+func (c *GetListRequest) ParseSort(sort string) error {
+
+	if sort == "" {
+		sort = "id"
+	}
+
+	orders := []string{}
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+
+		direction := "ASC"
+		if strings.HasPrefix(field, "-") {
+			field, direction = field[1:], "DESC"
+		}
+
+		if !sortColumns[field] {
+			return fmt.Errorf("cannot sort by %q", field)
+		}
+
+		orders = append(orders, field+" "+direction)
+	}
+
+	c.Sort = strings.Join(orders, ", ")
+	c.SortBy = ""
+	return nil
 }
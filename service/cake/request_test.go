@@ -1,8 +1,10 @@
 package cake
 
 import (
+	"io"
 	"mime/multipart"
 	"net/url"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -28,6 +30,24 @@ func TestGetListRequest_ParseQuery(t *testing.T) {
 			args:    args{},
 			wantErr: false,
 		},
+		{
+			name:    "success sort fields",
+			fields:  fields{},
+			args:    args{v: url.Values{"sort": {"rating, title"}, "sort_by": {"desc"}}},
+			wantErr: false,
+		},
+		{
+			name:    "error unknown sort field",
+			fields:  fields{},
+			args:    args{v: url.Values{"sort": {"id,(SELECT 1)"}}},
+			wantErr: true,
+		},
+		{
+			name:    "error invalid sort_by",
+			fields:  fields{},
+			args:    args{v: url.Values{"sort_by": {"ASC; DROP TABLE cake"}}},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -36,7 +56,9 @@ func TestGetListRequest_ParseQuery(t *testing.T) {
 				Sort:   tt.fields.Sort,
 				SortBy: tt.fields.SortBy,
 			}
-			c.ParseQuery(tt.args.v)
+			if err := c.ParseQuery(tt.args.v); (err != nil) != tt.wantErr {
+				t.Errorf("GetListRequest.ParseQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
 		})
 	}
 }
@@ -150,10 +172,10 @@ func TestCreateRequest_ParseForm(t *testing.T) {
 func TestUpdateRequest_ParseForm(t *testing.T) {
 	type fields struct {
 		ID          int
-		Title       string
-		Rating      float32
-		Description string
-		Image       multipart.File
+		Title       *string
+		Rating      *float32
+		Description *string
+		Image       io.Reader
 		UpdatedAt   time.Time
 	}
 	type args struct {
@@ -201,6 +223,16 @@ func TestUpdateRequest_ParseForm(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name:   "error: empty title",
+			fields: fields{},
+			args: args{
+				url.Values{
+					"title": []string{""},
+				},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -218,3 +250,162 @@ func TestUpdateRequest_ParseForm(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateRequest_ParseMergePatch(t *testing.T) {
+	title, empty, rating, url := "test", "", float32(4.5), "https://example.com/cake.png"
+
+	tests := []struct {
+		name    string
+		body    string
+		want    UpdateRequest
+		wantErr bool
+	}{
+		{
+			name: "success partial",
+			body: `{"title":"test","rating":4.5}`,
+			want: UpdateRequest{Title: &title, Rating: &rating},
+		},
+		{
+			name: "success null clears description and image",
+			body: `{"description":null,"image":null}`,
+			want: UpdateRequest{Description: &empty, ImageURL: &empty},
+		},
+		{
+			name: "success image url",
+			body: `{"image":"https://example.com/cake.png"}`,
+			want: UpdateRequest{ImageURL: &url},
+		},
+		{
+			name: "success empty patch",
+			body: `{}`,
+			want: UpdateRequest{},
+		},
+		{
+			name:    "error: not an object",
+			body:    `[1]`,
+			wantErr: true,
+		},
+		{
+			name:    "error: null title",
+			body:    `{"title":null}`,
+			wantErr: true,
+		},
+		{
+			name:    "error: invalid rating",
+			body:    `{"rating":"high"}`,
+			wantErr: true,
+		},
+		{
+			name:    "error: invalid image",
+			body:    `{"image":"not base64!"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := UpdateRequest{}
+			err := c.ParseMergePatch([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UpdateRequest.ParseMergePatch() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(c, tt.want) {
+				t.Errorf("UpdateRequest.ParseMergePatch() = %+v, want %+v", c, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateRequest_ParseReplace(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantImage bool
+		wantErr   bool
+	}{
+		{
+			name: "success without image removes it",
+			body: `{"title":"test","description":"test","rating":4}`,
+		},
+		{
+			name:      "success with base64 image",
+			body:      `{"title":"test","description":"test","rating":4,"image":"cG5n"}`,
+			wantImage: true,
+		},
+		{
+			name:    "error: missing rating",
+			body:    `{"title":"test","description":"test"}`,
+			wantErr: true,
+		},
+		{
+			name:    "error: null description",
+			body:    `{"title":"test","description":null,"rating":4}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := UpdateRequest{}
+			err := c.ParseReplace([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UpdateRequest.ParseReplace() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if c.Title == nil || c.Description == nil || c.Rating == nil {
+				t.Errorf("UpdateRequest.ParseReplace() = %+v, want every field set", c)
+			}
+			if tt.wantImage != (c.Image != nil) {
+				t.Errorf("UpdateRequest.ParseReplace() Image = %v, wantImage %v", c.Image, tt.wantImage)
+			}
+			if !tt.wantImage && (c.ImageURL == nil || *c.ImageURL != "") {
+				t.Errorf("UpdateRequest.ParseReplace() ImageURL = %v, want empty", c.ImageURL)
+			}
+		})
+	}
+}
+
+func TestGetListRequest_ParseSort(t *testing.T) {
+	tests := []struct {
+		name     string
+		sort     string
+		wantSort string
+		wantErr  bool
+	}{
+		{
+			name:     "default",
+			sort:     "",
+			wantSort: "id ASC",
+		},
+		{
+			name:     "mixed directions",
+			sort:     "-rating,title",
+			wantSort: "rating DESC, title ASC",
+		},
+		{
+			name:    "error: unknown field",
+			sort:    "rating,description",
+			wantErr: true,
+		},
+		{
+			name:    "error: injection",
+			sort:    "id;DROP TABLE cake",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &GetListRequest{}
+			err := c.ParseSort(tt.sort)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetListRequest.ParseSort() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if c.Sort != tt.wantSort || c.SortBy != "" {
+				t.Errorf("GetListRequest.ParseSort() = %q %q, want %q", c.Sort, c.SortBy, tt.wantSort)
+			}
+		})
+	}
+}
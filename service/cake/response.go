@@ -15,3 +15,12 @@ type CakeResponse struct {
 }
 
 type CakesResponse []CakeResponse
+
+// LastModified returns the time the cake was last changed, falling back to
+// its creation time when it has never been updated.
+func (c CakeResponse) LastModified() time.Time {
+	if c.UpdatedAt != nil && c.UpdatedAt.After(c.CreatedAt) {
+		return *c.UpdatedAt
+	}
+	return c.CreatedAt
+}
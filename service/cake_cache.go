@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/infra"
+	"gitlab.com/cake-store-RESTFul/repo"
+	cakeApi "gitlab.com/cake-store-RESTFul/service/cake"
+	commonApi "gitlab.com/cake-store-RESTFul/service/common"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	cakeListVersionKey = "cake:list:version"
+	// cacheLoadTimeout bounds a cache miss load shared by several callers, it
+	// no longer follows the deadline of any of them.
+	cacheLoadTimeout = 30 * time.Second
+)
+
+// cakeCache is a read-through cache in front of another Cake service. Detail
+// pages are cached per id, list pages are cached under a version that is
+// bumped on every write so all pages are invalidated at once.
+type cakeCache struct {
+	next  Cake
+	cache *infra.Cache
+	group singleflight.Group
+	Log   zerolog.Logger
+}
+
+type cakeListCache struct {
+	Cakes      cakeApi.CakesResponse        `json:"cakes"`
+	Pagination commonApi.PaginationResponse `json:"pagination"`
+}
+
+func NewCakeCache(next Cake, cache *infra.Cache, log zerolog.Logger) Cake {
+	return &cakeCache{
+		next:  next,
+		cache: cache,
+		Log:   log,
+	}
+}
+
+func (c *cakeCache) Create(ctx context.Context, req cakeApi.CreateRequest) (res cakeApi.CakeResponse, err error) {
+
+	res, err = c.next.Create(ctx, req)
+	if err != nil {
+		return
+	}
+
+	c.invalidate(ctx)
+	return res, nil
+}
+
+func (c *cakeCache) GetList(ctx context.Context, req cakeApi.GetListRequest, paginateReq commonApi.PaginationRequest) (res cakeApi.CakesResponse, pagination commonApi.PaginationResponse, err error) {
+
+	version := c.listVersion(ctx)
+	key := fmt.Sprintf("cake:list:%s:%q:%q:%q:%d:%d", version, req.Search, req.Sort, req.SortBy, paginateReq.Limit, paginateReq.Page)
+
+	cached := cakeListCache{}
+	if c.get(ctx, key, &cached) {
+		return cached.Cakes, cached.Pagination, nil
+	}
+
+	v, err := c.load(ctx, key, func(ctx context.Context) (interface{}, error) {
+		res, pagination, err := c.next.GetList(c.fillContext(ctx, version), req, paginateReq)
+		if err != nil {
+			return nil, err
+		}
+
+		cached := cakeListCache{Cakes: res, Pagination: pagination}
+		_, ttl := c.cache.TTL()
+		c.set(ctx, key, cached, ttl)
+		return cached, nil
+	})
+	if err != nil {
+		return
+	}
+
+	cached = v.(cakeListCache)
+	return cached.Cakes, cached.Pagination, nil
+}
+
+func (c *cakeCache) GetDetail(ctx context.Context, id int) (res cakeApi.CakeResponse, err error) {
+
+	key := detailKey(id)
+	if c.get(ctx, key, &res) {
+		return res, nil
+	}
+
+	v, err := c.load(ctx, key, func(ctx context.Context) (interface{}, error) {
+		res, err := c.next.GetDetail(c.fillContext(ctx, c.listVersion(ctx)), id)
+		if err != nil {
+			return nil, err
+		}
+
+		ttl, _ := c.cache.TTL()
+		c.set(ctx, key, res, ttl)
+		return res, nil
+	})
+	if err != nil {
+		return
+	}
+
+	return v.(cakeApi.CakeResponse), nil
+}
+
+// GetByIDs answers cached cakes from their detail keys and reads only the
+// missing ones from the next service, caching them on the way.
+func (c *cakeCache) GetByIDs(ctx context.Context, ids []int) (res cakeApi.CakesResponse, err error) {
+
+	missing := []int{}
+	for _, id := range ids {
+		cached := cakeApi.CakeResponse{}
+		if c.get(ctx, detailKey(id), &cached) {
+			res = append(res, cached)
+			continue
+		}
+		missing = append(missing, id)
+	}
+
+	if len(missing) == 0 {
+		return res, nil
+	}
+
+	loaded, err := c.next.GetByIDs(c.fillContext(ctx, c.listVersion(ctx)), missing)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl, _ := c.cache.TTL()
+	for _, cake := range loaded {
+		c.set(ctx, detailKey(cake.ID), cake, ttl)
+	}
+
+	return append(res, loaded...), nil
+}
+
+// Export always reads from the next service, exports are not cached.
+func (c *cakeCache) Export(ctx context.Context, req cakeApi.GetListRequest, fn func(cakeApi.CakeResponse) error) error {
+	return c.next.Export(ctx, req, fn)
+}
+
+func (c *cakeCache) Update(ctx context.Context, req cakeApi.UpdateRequest) (res cakeApi.CakeResponse, err error) {
+
+	res, err = c.next.Update(ctx, req)
+	if err != nil {
+		return
+	}
+
+	c.invalidate(ctx, detailKey(req.ID))
+	return res, nil
+}
+
+func (c *cakeCache) Delete(ctx context.Context, id int) (err error) {
+
+	err = c.next.Delete(ctx, id)
+	if err != nil {
+		return
+	}
+
+	c.invalidate(ctx, detailKey(id))
+	return nil
+}
+
+func (c *cakeCache) Import(ctx context.Context, req cakeApi.ImportRequest) (res cakeApi.ImportResponse, err error) {
+
+	res, err = c.next.Import(ctx, req)
+	if err != nil {
+		return
+	}
+
+	if !req.DryRun {
+		c.invalidate(ctx)
+	}
+	return res, nil
+}
+
+func (c *cakeCache) BulkUpdate(ctx context.Context, req cakeApi.BulkUpdateRequest) (res cakeApi.BulkResponse, err error) {
+
+	res, err = c.next.BulkUpdate(ctx, req)
+	if err != nil {
+		return
+	}
+
+	c.invalidateBulk(ctx, res)
+	return res, nil
+}
+
+func (c *cakeCache) BulkDelete(ctx context.Context, req cakeApi.BulkRequest) (res cakeApi.BulkResponse, err error) {
+
+	res, err = c.next.BulkDelete(ctx, req)
+	if err != nil {
+		return
+	}
+
+	c.invalidateBulk(ctx, res)
+	return res, nil
+}
+
+func (c *cakeCache) invalidateBulk(ctx context.Context, res cakeApi.BulkResponse) {
+
+	if !res.Committed || res.Succeeded == 0 {
+		return
+	}
+
+	keys := []string{}
+	for _, result := range res.Results {
+		keys = append(keys, detailKey(result.ID))
+	}
+
+	c.invalidate(ctx, keys...)
+}
+
+func detailKey(id int) string {
+	return fmt.Sprintf("cake:detail:%d", id)
+}
+
+// load runs fn once for all the concurrent callers of key. fn gets the values
+// of ctx but not its cancellation, so the first caller giving up does not fail
+// the others, and every caller still returns as soon as its own ctx is done.
+func (c *cakeCache) load(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+
+	ch := c.group.DoChan(key, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(detachedContext{ctx}, cacheLoadTimeout)
+		defer cancel()
+		return fn(ctx)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-ch:
+		return r.Val, r.Err
+	}
+}
+
+// detachedContext keeps the values of parent without its deadline and
+// cancellation.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (d detachedContext) Value(key interface{}) interface{} {
+	return d.parent.Value(key)
+}
+
+// listVersion returns the version of the list pages. A missing version, never
+// set or evicted from the cache, is replaced by a new one instead of a fixed
+// default, so the pages cached under an earlier version are never served
+// again.
+func (c *cakeCache) listVersion(ctx context.Context) string {
+	v, ok, err := c.cache.Store.Get(ctx, cakeListVersionKey)
+	if err != nil {
+		c.Log.Error().Msg(err.Error())
+	}
+
+	if ok {
+		return string(v)
+	}
+
+	// not a write time, fillContext keeps reading the replicas
+	version := fmt.Sprintf("seed-%d", time.Now().UnixNano())
+	if err := c.cache.Store.Set(ctx, cakeListVersionKey, []byte(version), 0); err != nil {
+		c.Log.Error().Msg(err.Error())
+	}
+
+	return version
+}
+
+// fillContext returns the ctx reading a cache miss, it asks for the primary
+// when the list version shows a write less than Cache.WriteSettleTime ago. A
+// replica lagging behind would otherwise put the old cake back in the cache
+// for a whole TTL.
+func (c *cakeCache) fillContext(ctx context.Context, version string) context.Context {
+
+	written, err := strconv.ParseInt(version, 10, 64)
+	if err != nil || time.Since(time.Unix(0, written)) >= c.cache.WriteSettleTime {
+		return ctx
+	}
+
+	return repo.WithPrimary(ctx)
+}
+
+// invalidate drops the given detail keys and moves every list page to a new
+// version. Failures are only logged, stale entries still expire by TTL.
+func (c *cakeCache) invalidate(ctx context.Context, keys ...string) {
+
+	version := strconv.FormatInt(time.Now().UnixNano(), 10)
+	if err := c.cache.Store.Set(ctx, cakeListVersionKey, []byte(version), 0); err != nil {
+		c.Log.Error().Msg(err.Error())
+	}
+
+	if err := c.cache.Store.Delete(ctx, keys...); err != nil {
+		c.Log.Error().Msg(err.Error())
+	}
+}
+
+func (c *cakeCache) get(ctx context.Context, key string, v interface{}) bool {
+
+	b, ok, err := c.cache.Store.Get(ctx, key)
+	if err != nil {
+		c.Log.Error().Msg(err.Error())
+		return false
+	}
+
+	if !ok {
+		return false
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		c.Log.Error().Msg(err.Error())
+		return false
+	}
+
+	return true
+}
+
+func (c *cakeCache) set(ctx context.Context, key string, v interface{}, ttl time.Duration) {
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		c.Log.Error().Msg(err.Error())
+		return
+	}
+
+	if err := c.cache.Store.Set(ctx, key, b, ttl); err != nil {
+		c.Log.Error().Msg(err.Error())
+	}
+}
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/infra"
+	"gitlab.com/cake-store-RESTFul/repo"
+	cakeApi "gitlab.com/cake-store-RESTFul/service/cake"
+	commonApi "gitlab.com/cake-store-RESTFul/service/common"
+	mockSvc "gitlab.com/cake-store-RESTFul/service/mocks"
+)
+
+func newTestCache() *infra.Cache {
+	return &infra.Cache{
+		Store:           infra.NewMemoryCache(10),
+		DetailTTL:       time.Minute,
+		ListTTL:         time.Minute,
+		WriteSettleTime: 10 * time.Second,
+	}
+}
+
+// primaryCtx matches the ctx of a read asked to go to the primary.
+type primaryCtx struct{}
+
+func (primaryCtx) Matches(x interface{}) bool {
+	ctx, ok := x.(context.Context)
+	return ok && repo.ReadsPrimary(ctx)
+}
+
+func (primaryCtx) String() string {
+	return "reads the primary"
+}
+
+// replicaCtx matches the ctx of a read left to the replicas, cache misses
+// are loaded on a ctx detached from the caller.
+var replicaCtx = gomock.Not(primaryCtx{})
+
+func Test_cakeCache_GetDetail(t *testing.T) {
+	ctx := context.Background()
+	res := cakeApi.CakeResponse{ID: 1, Title: "test", Description: "test", Rating: 1, CreatedAt: time.Now().UTC()}
+
+	tests := []struct {
+		name       string
+		beforeFunc func(m *mockSvc.MockCake)
+		afterFunc  func(c Cake)
+		wantErr    bool
+	}{
+		{
+			name: "second read served from cache",
+			beforeFunc: func(m *mockSvc.MockCake) {
+				m.EXPECT().GetDetail(replicaCtx, 1).Return(res, nil).Times(1)
+			},
+		},
+		{
+			name: "update invalidates detail",
+			beforeFunc: func(m *mockSvc.MockCake) {
+				// the read right after the write skips the replicas
+				m.EXPECT().GetDetail(replicaCtx, 1).Return(res, nil)
+				m.EXPECT().Update(ctx, gomock.Any()).Return(res, nil)
+				m.EXPECT().GetDetail(primaryCtx{}, 1).Return(res, nil)
+			},
+			afterFunc: func(c Cake) {
+				_, _ = c.Update(ctx, cakeApi.UpdateRequest{ID: 1})
+			},
+		},
+		{
+			name: "delete invalidates detail",
+			beforeFunc: func(m *mockSvc.MockCake) {
+				m.EXPECT().GetDetail(replicaCtx, 1).Return(res, nil)
+				m.EXPECT().Delete(ctx, 1).Return(nil)
+				m.EXPECT().GetDetail(primaryCtx{}, 1).Return(res, nil)
+			},
+			afterFunc: func(c Cake) {
+				_ = c.Delete(ctx, 1)
+			},
+		},
+		{
+			name: "errors are not cached",
+			beforeFunc: func(m *mockSvc.MockCake) {
+				m.EXPECT().GetDetail(replicaCtx, 1).Return(cakeApi.CakeResponse{}, errors.New("foo")).Times(2)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			m := mockSvc.NewMockCake(ctrl)
+			tt.beforeFunc(m)
+			c := NewCakeCache(m, newTestCache(), zerolog.Logger{})
+
+			for i := 0; i < 2; i++ {
+				got, err := c.GetDetail(ctx, 1)
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("cakeCache.GetDetail() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				if !tt.wantErr && !reflect.DeepEqual(got.Title, res.Title) {
+					t.Errorf("cakeCache.GetDetail() = %v, want %v", got, res)
+				}
+				if i == 0 && tt.afterFunc != nil {
+					tt.afterFunc(c)
+				}
+			}
+		})
+	}
+}
+
+func Test_cakeCache_GetDetail_withoutReplicas(t *testing.T) {
+	ctx := context.Background()
+	res := cakeApi.CakeResponse{ID: 1, Title: "test", CreatedAt: time.Now().UTC()}
+
+	// with no replica to lag behind, reads after a write stay on ctx
+	ctrl := gomock.NewController(t)
+	m := mockSvc.NewMockCake(ctrl)
+	m.EXPECT().Delete(ctx, 1).Return(nil)
+	m.EXPECT().GetDetail(replicaCtx, 1).Return(res, nil)
+
+	cache := newTestCache()
+	cache.WriteSettleTime = 0
+	c := NewCakeCache(m, cache, zerolog.Logger{})
+
+	if err := c.Delete(ctx, 1); err != nil {
+		t.Fatalf("cakeCache.Delete() error = %v", err)
+	}
+	if _, err := c.GetDetail(ctx, 1); err != nil {
+		t.Errorf("cakeCache.GetDetail() error = %v", err)
+	}
+}
+
+func Test_cakeCache_GetByIDs(t *testing.T) {
+	ctx := context.Background()
+	one := cakeApi.CakeResponse{ID: 1, Title: "one", CreatedAt: time.Now().UTC()}
+	two := cakeApi.CakeResponse{ID: 2, Title: "two", CreatedAt: time.Now().UTC()}
+
+	ctrl := gomock.NewController(t)
+	m := mockSvc.NewMockCake(ctrl)
+	m.EXPECT().GetDetail(replicaCtx, 1).Return(one, nil).Times(1)
+	m.EXPECT().GetByIDs(ctx, []int{2}).Return(cakeApi.CakesResponse{two}, nil).Times(1)
+	c := NewCakeCache(m, newTestCache(), zerolog.Logger{})
+
+	if _, err := c.GetDetail(ctx, 1); err != nil {
+		t.Fatalf("cakeCache.GetDetail() error = %v", err)
+	}
+
+	// the first read only loads the missing cake, the second one is served
+	// from the cache entirely
+	for i := 0; i < 2; i++ {
+		got, err := c.GetByIDs(ctx, []int{1, 2})
+		if err != nil {
+			t.Fatalf("cakeCache.GetByIDs() error = %v", err)
+		}
+		if len(got) != 2 || got[0].Title != "one" || got[1].Title != "two" {
+			t.Errorf("cakeCache.GetByIDs() = %v", got)
+		}
+	}
+}
+
+func Test_cakeCache_GetList(t *testing.T) {
+	ctx := context.Background()
+	req := cakeApi.GetListRequest{Sort: "id", SortBy: "asc"}
+	paginateReq := commonApi.PaginationRequest{Limit: 10, Page: 1}
+	res := cakeApi.CakesResponse{{ID: 1, Title: "test"}}
+	page := commonApi.PaginationResponse{Page: 1, Limit: 10, Total: 1}
+
+	tests := []struct {
+		name       string
+		beforeFunc func(m *mockSvc.MockCake)
+		afterFunc  func(c Cake)
+	}{
+		{
+			name: "second read served from cache",
+			beforeFunc: func(m *mockSvc.MockCake) {
+				m.EXPECT().GetList(replicaCtx, req, paginateReq).Return(res, page, nil).Times(1)
+			},
+		},
+		{
+			name: "create invalidates every page",
+			beforeFunc: func(m *mockSvc.MockCake) {
+				m.EXPECT().GetList(replicaCtx, req, paginateReq).Return(res, page, nil)
+				m.EXPECT().Create(ctx, gomock.Any()).Return(cakeApi.CakeResponse{ID: 2}, nil)
+				m.EXPECT().GetList(primaryCtx{}, req, paginateReq).Return(res, page, nil)
+			},
+			afterFunc: func(c Cake) {
+				_, _ = c.Create(ctx, cakeApi.CreateRequest{})
+			},
+		},
+		{
+			name: "failed create keeps cache",
+			beforeFunc: func(m *mockSvc.MockCake) {
+				m.EXPECT().GetList(replicaCtx, req, paginateReq).Return(res, page, nil).Times(1)
+				m.EXPECT().Create(ctx, gomock.Any()).Return(cakeApi.CakeResponse{}, errors.New("foo"))
+			},
+			afterFunc: func(c Cake) {
+				_, _ = c.Create(ctx, cakeApi.CreateRequest{})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			m := mockSvc.NewMockCake(ctrl)
+			tt.beforeFunc(m)
+			c := NewCakeCache(m, newTestCache(), zerolog.Logger{})
+
+			for i := 0; i < 2; i++ {
+				gotRes, gotPagination, err := c.GetList(ctx, req, paginateReq)
+				if err != nil {
+					t.Fatalf("cakeCache.GetList() error = %v", err)
+				}
+				if !reflect.DeepEqual(gotRes, res) {
+					t.Errorf("cakeCache.GetList() gotRes = %v, want %v", gotRes, res)
+				}
+				if !reflect.DeepEqual(gotPagination, page) {
+					t.Errorf("cakeCache.GetList() gotPagination = %v, want %v", gotPagination, page)
+				}
+				if i == 0 && tt.afterFunc != nil {
+					tt.afterFunc(c)
+				}
+			}
+		})
+	}
+}
+
+func Test_cakeCache_SingleFlight(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	m := mockSvc.NewMockCake(ctrl)
+	m.EXPECT().GetDetail(replicaCtx, 1).DoAndReturn(func(ctx context.Context, id int) (cakeApi.CakeResponse, error) {
+		time.Sleep(50 * time.Millisecond)
+		return cakeApi.CakeResponse{ID: id}, nil
+	}).Times(1)
+
+	c := NewCakeCache(m, newTestCache(), zerolog.Logger{})
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.GetDetail(ctx, 1); err != nil {
+				t.Errorf("cakeCache.GetDetail() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func Test_cakeCache_SingleFlight_canceled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	m := mockSvc.NewMockCake(ctrl)
+	loading, release := make(chan struct{}), make(chan struct{})
+	m.EXPECT().GetDetail(replicaCtx, 1).DoAndReturn(func(ctx context.Context, id int) (cakeApi.CakeResponse, error) {
+		close(loading)
+		<-release
+		return cakeApi.CakeResponse{ID: id}, ctx.Err()
+	}).Times(1)
+
+	c := NewCakeCache(m, newTestCache(), zerolog.Logger{})
+
+	// the first caller gives up while the load it started is running
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := c.GetDetail(ctx, 1)
+		first <- err
+	}()
+	<-loading
+
+	second := make(chan error, 1)
+	go func() {
+		_, err := c.GetDetail(context.Background(), 1)
+		second <- err
+	}()
+
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("cakeCache.GetDetail() canceled error = %v, want %v", err, context.Canceled)
+	}
+
+	close(release)
+	if err := <-second; err != nil {
+		t.Errorf("cakeCache.GetDetail() error = %v", err)
+	}
+}
+
+func Test_cakeCache_GetList_versionEvicted(t *testing.T) {
+	ctx := context.Background()
+	req := cakeApi.GetListRequest{Sort: "id", SortBy: "asc"}
+	paginateReq := commonApi.PaginationRequest{Limit: 10, Page: 1}
+	before := cakeApi.CakesResponse{{ID: 1, Title: "before"}}
+	after := cakeApi.CakesResponse{{ID: 1, Title: "after"}}
+
+	ctrl := gomock.NewController(t)
+	m := mockSvc.NewMockCake(ctrl)
+	m.EXPECT().GetList(replicaCtx, req, paginateReq).Return(before, commonApi.PaginationResponse{}, nil)
+	m.EXPECT().Update(ctx, gomock.Any()).Return(cakeApi.CakeResponse{ID: 1}, nil)
+	m.EXPECT().GetList(primaryCtx{}, req, paginateReq).Return(after, commonApi.PaginationResponse{}, nil)
+	m.EXPECT().GetList(replicaCtx, req, paginateReq).Return(after, commonApi.PaginationResponse{}, nil)
+
+	cache := newTestCache()
+	c := NewCakeCache(m, cache, zerolog.Logger{})
+
+	_, _, _ = c.GetList(ctx, req, paginateReq)
+	_, _ = c.Update(ctx, cakeApi.UpdateRequest{ID: 1})
+	_, _, _ = c.GetList(ctx, req, paginateReq)
+
+	// the version is dropped like the cache would evict it, the page cached
+	// before the update must not come back
+	if err := cache.Store.Delete(ctx, cakeListVersionKey); err != nil {
+		t.Fatal(err)
+	}
+
+	got, _, err := c.GetList(ctx, req, paginateReq)
+	if err != nil {
+		t.Fatalf("cakeCache.GetList() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, after) {
+		t.Errorf("cakeCache.GetList() = %v, want %v", got, after)
+	}
+}
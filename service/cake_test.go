@@ -1,11 +1,14 @@
 package service
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
 	"database/sql"
 	"errors"
 	"mime/multipart"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -41,6 +44,7 @@ func Test_cake_Create(t *testing.T) {
 		name       string
 		args       args
 		beforeFunc func(m *mockRepo.MockCake, cl *mockSvc.MockCloudinary)
+		wantID     int
 		wantErr    bool
 	}{
 		{
@@ -50,9 +54,10 @@ func Test_cake_Create(t *testing.T) {
 				req: req,
 			},
 			beforeFunc: func(m *mockRepo.MockCake, cl *mockSvc.MockCloudinary) {
-				cl.EXPECT().Upload(ctx, gomock.Any(), gomock.Any()).Return(&uploader.UploadResult{}, nil)
-				m.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+				cl.EXPECT().Upload(ctx, gomock.Any(), gomock.Any()).Return(&uploader.UploadResult{URL: "http://img"}, nil)
+				m.EXPECT().Create(ctx, gomock.Any()).Return(5, nil)
 			},
+			wantID:  5,
 			wantErr: false,
 		},
 		{
@@ -63,7 +68,7 @@ func Test_cake_Create(t *testing.T) {
 			},
 			beforeFunc: func(m *mockRepo.MockCake, cl *mockSvc.MockCloudinary) {
 				cl.EXPECT().Upload(ctx, gomock.Any(), gomock.Any()).Return(&uploader.UploadResult{}, nil)
-				m.EXPECT().Create(ctx, gomock.Any()).Return(errors.New("foo"))
+				m.EXPECT().Create(ctx, gomock.Any()).Return(0, errors.New("foo"))
 			},
 			wantErr: true,
 		},
@@ -86,10 +91,15 @@ func Test_cake_Create(t *testing.T) {
 			mc := mockSvc.NewMockCloudinary(m)
 			cakeRepo := mockRepo.NewMockCake(m)
 			tt.beforeFunc(cakeRepo, mc)
-			c := NewCake(cakeRepo, *log, mc)
+			c := NewCake(cakeRepo, nil, *log, mc)
 
-			if err := c.Create(tt.args.ctx, tt.args.req); (err != nil) != tt.wantErr {
+			gotRes, err := c.Create(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
 				t.Errorf("cake.Create() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotRes.ID != tt.wantID {
+				t.Errorf("cake.Create() ID = %v, want %v", gotRes.ID, tt.wantID)
 			}
 		})
 	}
@@ -207,7 +217,7 @@ func Test_cake_GetList(t *testing.T) {
 			m := mockRepo.NewMockCake(ctrl)
 			mc := mockSvc.NewMockCloudinary(ctrl)
 			tt.beforeFunc(m, mc)
-			c := NewCake(m, zerolog.Logger{}, mc)
+			c := NewCake(m, nil, zerolog.Logger{}, mc)
 
 			gotRes, gotPagination, err := c.GetList(tt.args.ctx, tt.args.req, tt.args.paginateReq)
 			if (err != nil) != tt.wantErr {
@@ -287,7 +297,7 @@ func Test_cake_GetDetail(t *testing.T) {
 			mc := mockSvc.NewMockCloudinary(m)
 			cakeRepo := mockRepo.NewMockCake(m)
 			tt.beforeFunc(cakeRepo, mc)
-			c := NewCake(cakeRepo, zerolog.Logger{}, mc)
+			c := NewCake(cakeRepo, nil, zerolog.Logger{}, mc)
 
 			gotRes, err := c.GetDetail(tt.args.ctx, tt.args.id)
 			if (err != nil) != tt.wantErr {
@@ -301,16 +311,63 @@ func Test_cake_GetDetail(t *testing.T) {
 	}
 }
 
+func Test_cake_GetByIDs(t *testing.T) {
+
+	ctx := context.Background()
+	output := []repo.CakeBaseModel{{ID: 1, Title: "test"}, {ID: 2, Title: "test"}}
+
+	tests := []struct {
+		name       string
+		wantRes    cakeApi.CakesResponse
+		beforeFunc func(m *mockRepo.MockCake)
+		wantErr    bool
+	}{
+		{
+			name:    "success",
+			wantRes: cakeApi.CakesResponse{{ID: 1, Title: "test"}, {ID: 2, Title: "test"}},
+			beforeFunc: func(m *mockRepo.MockCake) {
+				m.EXPECT().GetByIDs(ctx, []int{1, 2, 3}).Return(output, nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:    "error",
+			wantRes: nil,
+			beforeFunc: func(m *mockRepo.MockCake) {
+				m.EXPECT().GetByIDs(ctx, []int{1, 2, 3}).Return(nil, errors.New("foo"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			m := gomock.NewController(t)
+			cakeRepo := mockRepo.NewMockCake(m)
+			tt.beforeFunc(cakeRepo)
+			c := NewCake(cakeRepo, nil, zerolog.Logger{}, mockSvc.NewMockCloudinary(m))
+
+			gotRes, err := c.GetByIDs(ctx, []int{1, 2, 3})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("cake.GetByIDs() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotRes, tt.wantRes) {
+				t.Errorf("cake.GetByIDs() = %v, want %v", gotRes, tt.wantRes)
+			}
+		})
+	}
+}
+
 func Test_cake_Update(t *testing.T) {
 	ctx := context.Background()
-	x := &multipart.FileHeader{}
-	f, _ := x.Open()
+	title, description, rating := "test", "test", float32(1)
 	req := cakeApi.UpdateRequest{
 		ID:          1,
-		Title:       "test",
-		Description: "test",
-		Rating:      1,
-		Image:       f,
+		Title:       &title,
+		Description: &description,
+		Rating:      &rating,
+		Image:       strings.NewReader("png"),
 	}
 
 	type args struct {
@@ -331,10 +388,40 @@ func Test_cake_Update(t *testing.T) {
 			},
 			beforeFunc: func(m *mockRepo.MockCake, cl *mockSvc.MockCloudinary) {
 				m.EXPECT().Update(context.Background(), gomock.Any()).Return(nil)
+				m.EXPECT().GetDetail(context.Background(), 1).Return(repo.CakeBaseModel{ID: 1, Title: "test"}, nil)
 				cl.EXPECT().Upload(ctx, gomock.Any(), gomock.Any()).Return(&uploader.UploadResult{}, nil)
 			},
 			wantErr: false,
 		},
+		{
+			name: "success forwards the changed fields",
+			args: args{
+				ctx: context.Background(),
+				req: cakeApi.UpdateRequest{ID: 1, Rating: &rating},
+			},
+			beforeFunc: func(m *mockRepo.MockCake, cl *mockSvc.MockCloudinary) {
+				m.EXPECT().Update(context.Background(), gomock.Any()).DoAndReturn(func(_ context.Context, input repo.CakeUpdateModel) error {
+					if input.ID != 1 || input.Rating == nil || *input.Rating != rating || input.Title != nil || input.Image != nil {
+						t.Errorf("cake.Update() repo input = %+v", input)
+					}
+					return nil
+				})
+				m.EXPECT().GetDetail(context.Background(), 1).Return(repo.CakeBaseModel{ID: 1, Rating: rating}, nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "error not found",
+			args: args{
+				ctx: context.Background(),
+				req: cakeApi.UpdateRequest{ID: 1, Rating: &rating},
+			},
+			beforeFunc: func(m *mockRepo.MockCake, cl *mockSvc.MockCloudinary) {
+				m.EXPECT().Update(context.Background(), gomock.Any()).Return(nil)
+				m.EXPECT().GetDetail(context.Background(), 1).Return(repo.CakeBaseModel{}, sql.ErrNoRows)
+			},
+			wantErr: true,
+		},
 		{
 			name: "error when call repo",
 			args: args{
@@ -366,10 +453,19 @@ func Test_cake_Update(t *testing.T) {
 			m := mockRepo.NewMockCake(ct)
 			mc := mockSvc.NewMockCloudinary(ct)
 			tt.beforeFunc(m, mc)
-			c := NewCake(m, zerolog.Logger{}, mc)
+			tx := mockRepo.NewMockTxManager(ct)
+			tx.EXPECT().WithinTx(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+				return fn(ctx)
+			})
+			c := NewCake(m, tx, zerolog.Logger{}, mc)
 
-			if err := c.Update(tt.args.ctx, tt.args.req); (err != nil) != tt.wantErr {
+			gotRes, err := c.Update(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
 				t.Errorf("cake.Update() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && gotRes.ID != tt.args.req.ID {
+				t.Errorf("cake.Update() ID = %v, want %v", gotRes.ID, tt.args.req.ID)
 			}
 		})
 	}
@@ -381,9 +477,261 @@ func Test_cake_Delete(t *testing.T) {
 	mc := mockRepo.NewMockCake(m)
 	mcd := mockSvc.NewMockCloudinary(m)
 	mc.EXPECT().Delete(context.Background(), 1).Return(nil)
-	c := NewCake(mc, zerolog.Logger{}, mcd)
+	c := NewCake(mc, nil, zerolog.Logger{}, mcd)
 
 	if err := c.Delete(context.Background(), 1); err != nil {
 		t.Errorf("cake.Delete() error = %v", err)
 	}
 }
+
+func Test_cake_Import(t *testing.T) {
+	ctx := context.Background()
+
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	fw, _ := zw.Create("images/chess.png")
+	_, _ = fw.Write([]byte("png"))
+	_ = zw.Close()
+	images, _ := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+
+	rows := []cakeApi.ImportRow{
+		{Line: 2, Title: "Chess Cake", Description: "test", Rating: "4.5", Image: "chess.png"},
+		{Line: 3, Title: "Lemon Cake", Description: "test", Rating: "3", Image: "https://example.com/lemon.png"},
+		{Line: 4, Title: "", Description: "test", Rating: "3", Image: "chess.png"},
+		{Line: 5, Title: "Cheese Cake", Description: "test", Rating: "3", Image: "missing.png"},
+	}
+
+	type args struct {
+		ctx context.Context
+		req cakeApi.ImportRequest
+	}
+	tests := []struct {
+		name       string
+		args       args
+		beforeFunc func(m *mockRepo.MockCake, cl *mockSvc.MockCloudinary)
+		wantStatus []string
+		wantIDs    []int
+		wantErr    bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx: ctx,
+				req: cakeApi.ImportRequest{Rows: rows, Images: images},
+			},
+			beforeFunc: func(m *mockRepo.MockCake, cl *mockSvc.MockCloudinary) {
+				cl.EXPECT().Upload(ctx, gomock.Any(), gomock.Any()).Return(&uploader.UploadResult{URL: "url"}, nil).Times(2)
+				m.EXPECT().CreateBatch(ctx, gomock.Len(2)).Return([]int{7, 3}, nil)
+			},
+			wantStatus: []string{cakeApi.ImportStatusCreated, cakeApi.ImportStatusCreated, cakeApi.ImportStatusInvalid, cakeApi.ImportStatusInvalid},
+			wantIDs:    []int{7, 3, 0, 0},
+		},
+		{
+			name: "dry run",
+			args: args{
+				ctx: ctx,
+				req: cakeApi.ImportRequest{Rows: rows, Images: images, DryRun: true},
+			},
+			beforeFunc: func(m *mockRepo.MockCake, cl *mockSvc.MockCloudinary) {},
+			wantStatus: []string{cakeApi.ImportStatusValid, cakeApi.ImportStatusValid, cakeApi.ImportStatusInvalid, cakeApi.ImportStatusInvalid},
+			wantIDs:    []int{0, 0, 0, 0},
+		},
+		{
+			name: "upload failure is reported per row",
+			args: args{
+				ctx: ctx,
+				req: cakeApi.ImportRequest{Rows: rows[:2], Images: images},
+			},
+			beforeFunc: func(m *mockRepo.MockCake, cl *mockSvc.MockCloudinary) {
+				cl.EXPECT().Upload(ctx, gomock.Any(), gomock.Any()).Return(&uploader.UploadResult{URL: "url"}, nil)
+				cl.EXPECT().Upload(ctx, gomock.Any(), gomock.Any()).Return(nil, errors.New("foo"))
+				m.EXPECT().CreateBatch(ctx, gomock.Len(1)).Return([]int{5}, nil)
+			},
+			wantStatus: []string{cakeApi.ImportStatusCreated, cakeApi.ImportStatusFailed},
+			wantIDs:    []int{5, 0},
+		},
+		{
+			name: "error when call repository",
+			args: args{
+				ctx: ctx,
+				req: cakeApi.ImportRequest{Rows: rows, Images: images},
+			},
+			beforeFunc: func(m *mockRepo.MockCake, cl *mockSvc.MockCloudinary) {
+				cl.EXPECT().Upload(ctx, gomock.Any(), gomock.Any()).Return(&uploader.UploadResult{URL: "url", PublicID: "a"}, nil)
+				cl.EXPECT().Upload(ctx, gomock.Any(), gomock.Any()).Return(&uploader.UploadResult{URL: "url", PublicID: "b"}, nil)
+				m.EXPECT().CreateBatch(ctx, gomock.Any()).Return(nil, errors.New("foo"))
+				cl.EXPECT().Destroy(ctx, uploader.DestroyParams{PublicID: "a"}).Return(&uploader.DestroyResult{}, nil)
+				cl.EXPECT().Destroy(ctx, uploader.DestroyParams{PublicID: "b"}).Return(nil, errors.New("bar"))
+			},
+			wantStatus: []string{cakeApi.ImportStatusFailed, cakeApi.ImportStatusFailed, cakeApi.ImportStatusInvalid, cakeApi.ImportStatusInvalid},
+			wantIDs:    []int{0, 0, 0, 0},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			m := mockRepo.NewMockCake(ctrl)
+			mc := mockSvc.NewMockCloudinary(ctrl)
+			tt.beforeFunc(m, mc)
+			c := NewCake(m, nil, zerolog.Logger{}, mc)
+
+			gotRes, err := c.Import(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("cake.Import() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			gotStatus, gotIDs := []string{}, []int{}
+			for _, row := range gotRes.Rows {
+				gotStatus = append(gotStatus, row.Status)
+				gotIDs = append(gotIDs, row.ID)
+			}
+			if !reflect.DeepEqual(gotStatus, tt.wantStatus) {
+				t.Errorf("cake.Import() status = %v, want %v", gotStatus, tt.wantStatus)
+			}
+			if !reflect.DeepEqual(gotIDs, tt.wantIDs) {
+				t.Errorf("cake.Import() ids = %v, want %v", gotIDs, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func Test_cake_Export(t *testing.T) {
+	ctx := context.Background()
+	updateAt := sql.NullTime{Time: time.Now(), Valid: true}
+	req := cakeApi.GetListRequest{Search: "cake", Sort: "id", SortBy: "asc"}
+	output := repo.CakeBaseModel{ID: 1, Title: "test", Rating: 1, UpdatedAt: updateAt}
+
+	tests := []struct {
+		name       string
+		beforeFunc func(m *mockRepo.MockCake)
+		wantRes    []cakeApi.CakeResponse
+		wantErr    bool
+	}{
+		{
+			name: "success",
+			beforeFunc: func(m *mockRepo.MockCake) {
+				m.EXPECT().Stream(ctx, "cake", "id", "asc", gomock.Any()).DoAndReturn(func(_ context.Context, _, _, _ string, fn func(repo.CakeBaseModel) error) error {
+					return fn(output)
+				})
+			},
+			wantRes: []cakeApi.CakeResponse{{ID: 1, Title: "test", Rating: 1, UpdatedAt: &updateAt.Time}},
+		},
+		{
+			name: "error when call repo",
+			beforeFunc: func(m *mockRepo.MockCake) {
+				m.EXPECT().Stream(ctx, "cake", "id", "asc", gomock.Any()).Return(errors.New("foo"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			m := mockRepo.NewMockCake(ctrl)
+			tt.beforeFunc(m)
+			c := NewCake(m, nil, zerolog.Logger{}, mockSvc.NewMockCloudinary(ctrl))
+
+			var gotRes []cakeApi.CakeResponse
+			err := c.Export(ctx, req, func(res cakeApi.CakeResponse) error {
+				gotRes = append(gotRes, res)
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("cake.Export() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotRes, tt.wantRes) {
+				t.Errorf("cake.Export() = %v, want %v", gotRes, tt.wantRes)
+			}
+		})
+	}
+}
+
+func Test_cake_BulkUpdate(t *testing.T) {
+	ctx := context.Background()
+	rating := float32(4)
+	req := cakeApi.BulkUpdateRequest{
+		BulkRequest: cakeApi.BulkRequest{IDs: []int{1, 2, 3}, Atomic: true},
+		Changes:     cakeApi.BulkChanges{Rating: &rating},
+	}
+
+	tests := []struct {
+		name       string
+		beforeFunc func(m *mockRepo.MockCake)
+		wantRes    cakeApi.BulkResponse
+		wantErr    bool
+	}{
+		{
+			name: "success",
+			beforeFunc: func(m *mockRepo.MockCake) {
+				m.EXPECT().BulkUpdate(ctx, repo.BulkFilter{IDs: []int{1, 2, 3}}, gomock.Any(), true).
+					Return([]repo.BulkResult{{ID: 1, Found: true}, {ID: 2, Found: true}, {ID: 3, Found: true}}, nil)
+			},
+			wantRes: cakeApi.BulkResponse{
+				Atomic: true, Committed: true, Total: 3, Succeeded: 3,
+				Results: []cakeApi.BulkResult{{ID: 1, Status: "updated"}, {ID: 2, Status: "updated"}, {ID: 3, Status: "updated"}},
+			},
+		},
+		{
+			name: "rolled back",
+			beforeFunc: func(m *mockRepo.MockCake) {
+				m.EXPECT().BulkUpdate(ctx, gomock.Any(), gomock.Any(), true).
+					Return([]repo.BulkResult{{ID: 1, Found: true}, {ID: 2}, {ID: 3, Err: errors.New("foo")}}, repo.ErrBulkRolledBack)
+			},
+			wantRes: cakeApi.BulkResponse{
+				Atomic: true, Committed: false, Total: 3, Succeeded: 0, Failed: 3,
+				Results: []cakeApi.BulkResult{{ID: 1, Status: "rolled_back"}, {ID: 2, Status: "not_found"}, {ID: 3, Status: "failed", Error: "foo"}},
+			},
+		},
+		{
+			name: "error when call repo",
+			beforeFunc: func(m *mockRepo.MockCake) {
+				m.EXPECT().BulkUpdate(ctx, gomock.Any(), gomock.Any(), true).Return(nil, errors.New("foo"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			m := mockRepo.NewMockCake(ctrl)
+			tt.beforeFunc(m)
+			c := NewCake(m, nil, zerolog.Logger{}, mockSvc.NewMockCloudinary(ctrl))
+
+			gotRes, err := c.BulkUpdate(ctx, req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("cake.BulkUpdate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(gotRes, tt.wantRes) {
+				t.Errorf("cake.BulkUpdate() = %+v, want %+v", gotRes, tt.wantRes)
+			}
+		})
+	}
+}
+
+func Test_cake_BulkDelete(t *testing.T) {
+	ctx := context.Background()
+	req := cakeApi.BulkRequest{Filter: &cakeApi.BulkFilter{Search: "chess"}}
+
+	ctrl := gomock.NewController(t)
+	m := mockRepo.NewMockCake(ctrl)
+	m.EXPECT().BulkDelete(ctx, repo.BulkFilter{Search: "chess"}, false).
+		Return([]repo.BulkResult{{ID: 1, Found: true}, {ID: 2, Err: errors.New("foo")}}, nil)
+	c := NewCake(m, nil, zerolog.Logger{}, mockSvc.NewMockCloudinary(ctrl))
+
+	want := cakeApi.BulkResponse{
+		Committed: true, Total: 2, Succeeded: 1, Failed: 1,
+		Results: []cakeApi.BulkResult{{ID: 1, Status: "deleted"}, {ID: 2, Status: "failed", Error: "foo"}},
+	}
+
+	got, err := c.BulkDelete(ctx, req)
+	if err != nil {
+		t.Fatalf("cake.BulkDelete() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cake.BulkDelete() = %+v, want %+v", got, want)
+	}
+}
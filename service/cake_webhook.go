@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	cakeApi "gitlab.com/cake-store-RESTFul/service/cake"
+	commonApi "gitlab.com/cake-store-RESTFul/service/common"
+	webhookApi "gitlab.com/cake-store-RESTFul/service/webhook"
+)
+
+// cakeWebhook publishes a webhook event for every successful write of the
+// next Cake service. A failure to queue an event is logged, it never fails the
+// write that already happened.
+type cakeWebhook struct {
+	next           Cake
+	webhookService Webhook
+	Log            zerolog.Logger
+}
+
+func NewCakeWebhook(next Cake, webhookService Webhook, log zerolog.Logger) Cake {
+	return &cakeWebhook{
+		next:           next,
+		webhookService: webhookService,
+		Log:            log,
+	}
+}
+
+func (c *cakeWebhook) publish(ctx context.Context, event string, data interface{}) {
+	if err := c.webhookService.Publish(ctx, event, data); err != nil {
+		c.Log.Error().Str("event", event).Msg(err.Error())
+	}
+}
+
+func (c *cakeWebhook) Create(ctx context.Context, req cakeApi.CreateRequest) (res cakeApi.CakeResponse, err error) {
+
+	res, err = c.next.Create(ctx, req)
+	if err != nil {
+		return
+	}
+
+	c.publish(ctx, webhookApi.EventCakeCreated, res)
+	return res, nil
+}
+
+func (c *cakeWebhook) GetList(ctx context.Context, req cakeApi.GetListRequest, paginateReq commonApi.PaginationRequest) (cakeApi.CakesResponse, commonApi.PaginationResponse, error) {
+	return c.next.GetList(ctx, req, paginateReq)
+}
+
+func (c *cakeWebhook) GetDetail(ctx context.Context, id int) (cakeApi.CakeResponse, error) {
+	return c.next.GetDetail(ctx, id)
+}
+
+func (c *cakeWebhook) GetByIDs(ctx context.Context, ids []int) (cakeApi.CakesResponse, error) {
+	return c.next.GetByIDs(ctx, ids)
+}
+
+func (c *cakeWebhook) Export(ctx context.Context, req cakeApi.GetListRequest, fn func(cakeApi.CakeResponse) error) error {
+	return c.next.Export(ctx, req, fn)
+}
+
+func (c *cakeWebhook) Update(ctx context.Context, req cakeApi.UpdateRequest) (res cakeApi.CakeResponse, err error) {
+
+	res, err = c.next.Update(ctx, req)
+	if err != nil {
+		return
+	}
+
+	c.publish(ctx, webhookApi.EventCakeUpdated, res)
+	return res, nil
+}
+
+func (c *cakeWebhook) Delete(ctx context.Context, id int) (err error) {
+
+	err = c.next.Delete(ctx, id)
+	if err != nil {
+		return
+	}
+
+	c.publish(ctx, webhookApi.EventCakeDeleted, webhookApi.DeletedCake{ID: id})
+	return nil
+}
+
+// Import publishes a cake.created event with the stored cake for every
+// created row, like Create, then a cake.imported event with the report.
+func (c *cakeWebhook) Import(ctx context.Context, req cakeApi.ImportRequest) (res cakeApi.ImportResponse, err error) {
+
+	res, err = c.next.Import(ctx, req)
+	if err != nil {
+		return
+	}
+
+	ids := []int{}
+	for _, row := range res.Rows {
+		if row.Status == cakeApi.ImportStatusCreated {
+			ids = append(ids, row.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return res, nil
+	}
+
+	cakes, err := c.next.GetByIDs(ctx, ids)
+	if err != nil {
+		c.Log.Error().Msg(err.Error())
+	}
+	for _, cake := range cakes {
+		c.publish(ctx, webhookApi.EventCakeCreated, cake)
+	}
+
+	c.publish(ctx, webhookApi.EventCakeImported, res)
+	return res, nil
+}
+
+// BulkUpdate publishes a cake.updated event with the stored cake for every
+// updated id.
+func (c *cakeWebhook) BulkUpdate(ctx context.Context, req cakeApi.BulkUpdateRequest) (res cakeApi.BulkResponse, err error) {
+
+	res, err = c.next.BulkUpdate(ctx, req)
+	if err != nil || !res.Committed {
+		return
+	}
+
+	ids := bulkIDs(res, cakeApi.BulkStatusUpdated)
+	if len(ids) == 0 {
+		return res, nil
+	}
+
+	cakes, err := c.next.GetByIDs(ctx, ids)
+	if err != nil {
+		c.Log.Error().Msg(err.Error())
+		return res, nil
+	}
+
+	for _, cake := range cakes {
+		c.publish(ctx, webhookApi.EventCakeUpdated, cake)
+	}
+	return res, nil
+}
+
+func (c *cakeWebhook) BulkDelete(ctx context.Context, req cakeApi.BulkRequest) (res cakeApi.BulkResponse, err error) {
+
+	res, err = c.next.BulkDelete(ctx, req)
+	if err != nil || !res.Committed {
+		return
+	}
+
+	for _, id := range bulkIDs(res, cakeApi.BulkStatusDeleted) {
+		c.publish(ctx, webhookApi.EventCakeDeleted, webhookApi.DeletedCake{ID: id})
+	}
+	return res, nil
+}
+
+func bulkIDs(res cakeApi.BulkResponse, status string) (ids []int) {
+	for _, result := range res.Results {
+		if result.Status == status {
+			ids = append(ids, result.ID)
+		}
+	}
+	return ids
+}
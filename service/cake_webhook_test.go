@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	cakeApi "gitlab.com/cake-store-RESTFul/service/cake"
+	mockService "gitlab.com/cake-store-RESTFul/service/mocks"
+	webhookApi "gitlab.com/cake-store-RESTFul/service/webhook"
+)
+
+func Test_cakeWebhook_Create(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		beforeFunc func(next *mockService.MockCake, hooks *mockService.MockWebhook)
+		wantErr    bool
+	}{
+		{
+			name: "publishes cake.created",
+			beforeFunc: func(next *mockService.MockCake, hooks *mockService.MockWebhook) {
+				next.EXPECT().Create(ctx, gomock.Any()).Return(cakeApi.CakeResponse{ID: 1}, nil)
+				hooks.EXPECT().Publish(ctx, webhookApi.EventCakeCreated, cakeApi.CakeResponse{ID: 1}).Return(nil)
+			},
+		},
+		{
+			name: "publish error does not fail the write",
+			beforeFunc: func(next *mockService.MockCake, hooks *mockService.MockWebhook) {
+				next.EXPECT().Create(ctx, gomock.Any()).Return(cakeApi.CakeResponse{ID: 1}, nil)
+				hooks.EXPECT().Publish(ctx, webhookApi.EventCakeCreated, gomock.Any()).Return(errors.New("foo"))
+			},
+		},
+		{
+			name: "nothing is published for a failed write",
+			beforeFunc: func(next *mockService.MockCake, hooks *mockService.MockWebhook) {
+				next.EXPECT().Create(ctx, gomock.Any()).Return(cakeApi.CakeResponse{}, errors.New("foo"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			next := mockService.NewMockCake(ctrl)
+			hooks := mockService.NewMockWebhook(ctrl)
+			tt.beforeFunc(next, hooks)
+
+			c := NewCakeWebhook(next, hooks, zerolog.Logger{})
+			if _, err := c.Create(ctx, cakeApi.CreateRequest{}); (err != nil) != tt.wantErr {
+				t.Errorf("cakeWebhook.Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_cakeWebhook_Delete(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	next := mockService.NewMockCake(ctrl)
+	hooks := mockService.NewMockWebhook(ctrl)
+
+	next.EXPECT().Delete(ctx, 3).Return(nil)
+	hooks.EXPECT().Publish(ctx, webhookApi.EventCakeDeleted, webhookApi.DeletedCake{ID: 3}).Return(nil)
+
+	c := NewCakeWebhook(next, hooks, zerolog.Logger{})
+	if err := c.Delete(ctx, 3); err != nil {
+		t.Errorf("cakeWebhook.Delete() error = %v", err)
+	}
+}
+
+func Test_cakeWebhook_Delete_notFound(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	next := mockService.NewMockCake(ctrl)
+	hooks := mockService.NewMockWebhook(ctrl)
+
+	// no event is published for a missing cake
+	next.EXPECT().Delete(ctx, 9).Return(sql.ErrNoRows)
+
+	c := NewCakeWebhook(next, hooks, zerolog.Logger{})
+	if err := c.Delete(ctx, 9); err != sql.ErrNoRows {
+		t.Errorf("cakeWebhook.Delete() error = %v, want %v", err, sql.ErrNoRows)
+	}
+}
+
+func Test_cakeWebhook_Import(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		req        cakeApi.ImportRequest
+		beforeFunc func(next *mockService.MockCake, hooks *mockService.MockWebhook)
+	}{
+		{
+			name: "publishes every created cake and the report",
+			beforeFunc: func(next *mockService.MockCake, hooks *mockService.MockWebhook) {
+				res := cakeApi.ImportResponse{Rows: []cakeApi.ImportRowResult{
+					{Line: 2, ID: 7, Status: cakeApi.ImportStatusCreated},
+					{Line: 3, Status: cakeApi.ImportStatusInvalid},
+					{Line: 4, ID: 3, Status: cakeApi.ImportStatusCreated},
+				}}
+				next.EXPECT().Import(ctx, gomock.Any()).Return(res, nil)
+				next.EXPECT().GetByIDs(ctx, []int{7, 3}).Return(cakeApi.CakesResponse{{ID: 3}, {ID: 7}}, nil)
+				gomock.InOrder(
+					hooks.EXPECT().Publish(ctx, webhookApi.EventCakeCreated, cakeApi.CakeResponse{ID: 3}).Return(nil),
+					hooks.EXPECT().Publish(ctx, webhookApi.EventCakeCreated, cakeApi.CakeResponse{ID: 7}).Return(nil),
+					hooks.EXPECT().Publish(ctx, webhookApi.EventCakeImported, res).Return(nil),
+				)
+			},
+		},
+		{
+			name: "dry run",
+			req:  cakeApi.ImportRequest{DryRun: true},
+			beforeFunc: func(next *mockService.MockCake, hooks *mockService.MockWebhook) {
+				next.EXPECT().Import(ctx, gomock.Any()).Return(cakeApi.ImportResponse{DryRun: true, Rows: []cakeApi.ImportRowResult{
+					{Line: 2, Status: cakeApi.ImportStatusValid},
+				}}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			next := mockService.NewMockCake(ctrl)
+			hooks := mockService.NewMockWebhook(ctrl)
+			tt.beforeFunc(next, hooks)
+
+			c := NewCakeWebhook(next, hooks, zerolog.Logger{})
+			if _, err := c.Import(ctx, tt.req); err != nil {
+				t.Errorf("cakeWebhook.Import() error = %v", err)
+			}
+		})
+	}
+}
+
+func Test_cakeWebhook_BulkUpdate(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		beforeFunc func(next *mockService.MockCake, hooks *mockService.MockWebhook)
+	}{
+		{
+			name: "publishes the updated cakes",
+			beforeFunc: func(next *mockService.MockCake, hooks *mockService.MockWebhook) {
+				next.EXPECT().BulkUpdate(ctx, gomock.Any()).Return(cakeApi.BulkResponse{Committed: true, Results: []cakeApi.BulkResult{
+					{ID: 1, Status: cakeApi.BulkStatusUpdated},
+					{ID: 2, Status: cakeApi.BulkStatusNotFound},
+					{ID: 3, Status: cakeApi.BulkStatusUpdated},
+				}}, nil)
+				next.EXPECT().GetByIDs(ctx, []int{1, 3}).Return(cakeApi.CakesResponse{{ID: 1}, {ID: 3}}, nil)
+				hooks.EXPECT().Publish(ctx, webhookApi.EventCakeUpdated, cakeApi.CakeResponse{ID: 1}).Return(nil)
+				hooks.EXPECT().Publish(ctx, webhookApi.EventCakeUpdated, cakeApi.CakeResponse{ID: 3}).Return(nil)
+			},
+		},
+		{
+			name: "rolled back",
+			beforeFunc: func(next *mockService.MockCake, hooks *mockService.MockWebhook) {
+				next.EXPECT().BulkUpdate(ctx, gomock.Any()).Return(cakeApi.BulkResponse{Atomic: true, Committed: false, Results: []cakeApi.BulkResult{
+					{ID: 1, Status: cakeApi.BulkStatusRolledBack},
+				}}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			next := mockService.NewMockCake(ctrl)
+			hooks := mockService.NewMockWebhook(ctrl)
+			tt.beforeFunc(next, hooks)
+
+			c := NewCakeWebhook(next, hooks, zerolog.Logger{})
+			if _, err := c.BulkUpdate(ctx, cakeApi.BulkUpdateRequest{}); err != nil {
+				t.Errorf("cakeWebhook.BulkUpdate() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/repo"
+	idempotencyApi "gitlab.com/cake-store-RESTFul/service/idempotency"
+)
+
+var (
+	// ErrIdempotencyInProgress is returned while the original request of a key
+	// is still being processed.
+	ErrIdempotencyInProgress = errors.New("a request with this idempotency key is still in progress")
+	// ErrIdempotencyMismatch is returned when a key is reused with a different
+	// request.
+	ErrIdempotencyMismatch = errors.New("idempotency key was used with a different request")
+)
+
+// Idempotency records the responses of requests sent with an
+// Idempotency-Key. The keys are expected in their scoped form, see
+// idempotencyApi.Key.
+type Idempotency interface {
+	Begin(ctx context.Context, key, fingerprint string) (*idempotencyApi.Response, error)
+	Complete(ctx context.Context, key string, res idempotencyApi.Response) error
+	Release(ctx context.Context, key string) error
+	PurgeExpired(ctx context.Context) (int64, error)
+}
+
+type idempotency struct {
+	idempotencyRepo repo.Idempotency
+	Log             zerolog.Logger
+	TTL             time.Duration
+}
+
+func NewIdempotency(idempotencyRepo repo.Idempotency, log zerolog.Logger, ttl time.Duration) Idempotency {
+	return &idempotency{
+		idempotencyRepo: idempotencyRepo,
+		Log:             log,
+		TTL:             ttl,
+	}
+}
+
+// Begin reserves the key for a new request. It returns the recorded response
+// when the key was already used for the same request, the caller must then
+// replay it instead of processing the request again.
+func (i *idempotency) Begin(ctx context.Context, key, fingerprint string) (res *idempotencyApi.Response, err error) {
+
+	// the second attempt only happens after an expired key was removed
+	for attempt := 0; attempt < 2; attempt++ {
+		now := time.Now().UTC()
+
+		reserved, err := i.idempotencyRepo.Reserve(ctx, repo.IdempotencyModel{
+			Key:         key,
+			Fingerprint: fingerprint,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(i.TTL),
+		})
+		if err != nil {
+			i.Log.Error().Msg(err.Error())
+			return nil, err
+		}
+
+		if reserved {
+			return nil, nil
+		}
+
+		stored, err := i.idempotencyRepo.Get(ctx, key)
+		if err == sql.ErrNoRows {
+			// removed between both queries, reserve it again
+			continue
+		}
+		if err != nil {
+			i.Log.Error().Msg(err.Error())
+			return nil, err
+		}
+
+		if stored.ExpiresAt.Before(now) {
+			if err = i.idempotencyRepo.Delete(ctx, key); err != nil {
+				i.Log.Error().Msg(err.Error())
+				return nil, err
+			}
+			continue
+		}
+
+		if stored.Fingerprint != fingerprint {
+			return nil, ErrIdempotencyMismatch
+		}
+
+		if stored.StatusCode == 0 {
+			return nil, ErrIdempotencyInProgress
+		}
+
+		res = &idempotencyApi.Response{StatusCode: stored.StatusCode, Body: stored.Body}
+		if stored.Header.Valid {
+			if err = json.Unmarshal([]byte(stored.Header.String), &res.Header); err != nil {
+				i.Log.Error().Msg(err.Error())
+				return nil, err
+			}
+		}
+
+		return res, nil
+	}
+
+	return nil, ErrIdempotencyInProgress
+}
+
+// Complete records the response so identical retries can replay it.
+func (i *idempotency) Complete(ctx context.Context, key string, res idempotencyApi.Response) (err error) {
+
+	header, err := json.Marshal(res.Header)
+	if err != nil {
+		i.Log.Error().Msg(err.Error())
+		return
+	}
+
+	return i.idempotencyRepo.Complete(ctx, key, res.StatusCode, string(header), res.Body)
+}
+
+// Release forgets the key so the request can be retried, it is used when the
+// request failed without a response worth replaying.
+func (i *idempotency) Release(ctx context.Context, key string) error {
+	return i.idempotencyRepo.Delete(ctx, key)
+}
+
+func (i *idempotency) PurgeExpired(ctx context.Context) (int64, error) {
+	return i.idempotencyRepo.DeleteExpired(ctx, time.Now().UTC())
+}
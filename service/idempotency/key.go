@@ -0,0 +1,24 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+)
+
+// Key is the stored form of an Idempotency-Key. It hashes the key with who
+// sent it and the endpoint it was sent to, so two clients picking the same
+// key, or one client reusing a key on another endpoint, never get each other's
+// response replayed.
+func Key(principal, method, path, key string) string {
+
+	h := sha256.New()
+	for _, v := range []string{principal, method, path, key} {
+		// length prefixed so adjacent fields cannot be shifted into each other
+		_ = binary.Write(h, binary.BigEndian, uint64(len(v)))
+		_, _ = io.WriteString(h, v)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,13 @@
+package idempotency
+
+import (
+	"net/http"
+)
+
+// Response is the recorded response of a request made with an
+// Idempotency-Key, it is replayed as is for identical retries.
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
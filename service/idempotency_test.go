@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/repo"
+	mockRepo "gitlab.com/cake-store-RESTFul/repo/mocks"
+	idempotencyApi "gitlab.com/cake-store-RESTFul/service/idempotency"
+)
+
+func Test_idempotency_Begin(t *testing.T) {
+	ctx := context.Background()
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name       string
+		beforeFunc func(m *mockRepo.MockIdempotency)
+		wantRes    *idempotencyApi.Response
+		wantErr    error
+	}{
+		{
+			name: "new key",
+			beforeFunc: func(m *mockRepo.MockIdempotency) {
+				m.EXPECT().Reserve(ctx, gomock.Any()).Return(true, nil)
+			},
+		},
+		{
+			name: "replay completed request",
+			beforeFunc: func(m *mockRepo.MockIdempotency) {
+				m.EXPECT().Reserve(ctx, gomock.Any()).Return(false, nil)
+				m.EXPECT().Get(ctx, "abc").Return(repo.IdempotencyModel{
+					Key: "abc", Fingerprint: "f", StatusCode: 201, ExpiresAt: future,
+					Header: sql.NullString{String: `{"Location":["/api/v1/cake/1"]}`, Valid: true},
+					Body:   []byte(`{"data":{"id":1}}`),
+				}, nil)
+			},
+			wantRes: &idempotencyApi.Response{
+				StatusCode: 201,
+				Header:     http.Header{"Location": []string{"/api/v1/cake/1"}},
+				Body:       []byte(`{"data":{"id":1}}`),
+			},
+		},
+		{
+			name: "error different request",
+			beforeFunc: func(m *mockRepo.MockIdempotency) {
+				m.EXPECT().Reserve(ctx, gomock.Any()).Return(false, nil)
+				m.EXPECT().Get(ctx, "abc").Return(repo.IdempotencyModel{Key: "abc", Fingerprint: "other", StatusCode: 201, ExpiresAt: future}, nil)
+			},
+			wantErr: ErrIdempotencyMismatch,
+		},
+		{
+			name: "error still in progress",
+			beforeFunc: func(m *mockRepo.MockIdempotency) {
+				m.EXPECT().Reserve(ctx, gomock.Any()).Return(false, nil)
+				m.EXPECT().Get(ctx, "abc").Return(repo.IdempotencyModel{Key: "abc", Fingerprint: "f", ExpiresAt: future}, nil)
+			},
+			wantErr: ErrIdempotencyInProgress,
+		},
+		{
+			name: "expired key is reserved again",
+			beforeFunc: func(m *mockRepo.MockIdempotency) {
+				gomock.InOrder(
+					m.EXPECT().Reserve(ctx, gomock.Any()).Return(false, nil),
+					m.EXPECT().Get(ctx, "abc").Return(repo.IdempotencyModel{Key: "abc", Fingerprint: "other", StatusCode: 201, ExpiresAt: time.Now().Add(-time.Hour)}, nil),
+					m.EXPECT().Delete(ctx, "abc").Return(nil),
+					m.EXPECT().Reserve(ctx, gomock.Any()).Return(true, nil),
+				)
+			},
+		},
+		{
+			name: "error when call repo",
+			beforeFunc: func(m *mockRepo.MockIdempotency) {
+				m.EXPECT().Reserve(ctx, gomock.Any()).Return(false, errors.New("foo"))
+			},
+			wantErr: errors.New("foo"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			m := mockRepo.NewMockIdempotency(ctrl)
+			tt.beforeFunc(m)
+			i := NewIdempotency(m, zerolog.Logger{}, time.Hour)
+
+			gotRes, err := i.Begin(ctx, "abc", "f")
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("idempotency.Begin() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotRes, tt.wantRes) {
+				t.Errorf("idempotency.Begin() = %+v, want %+v", gotRes, tt.wantRes)
+			}
+		})
+	}
+}
+
+func Test_idempotency_Complete(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	m := mockRepo.NewMockIdempotency(ctrl)
+	m.EXPECT().Complete(ctx, "abc", 201, `{"Location":["/api/v1/cake/1"]}`, []byte("{}")).Return(nil)
+	i := NewIdempotency(m, zerolog.Logger{}, time.Hour)
+
+	err := i.Complete(ctx, "abc", idempotencyApi.Response{
+		StatusCode: 201,
+		Header:     http.Header{"Location": []string{"/api/v1/cake/1"}},
+		Body:       []byte("{}"),
+	})
+	if err != nil {
+		t.Errorf("idempotency.Complete() error = %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./service/admin_user.go
+
+// Package mock_service is a generated GoMock package.
+package mock_service
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockAdminUser is a mock of AdminUser interface.
+type MockAdminUser struct {
+	ctrl     *gomock.Controller
+	recorder *MockAdminUserMockRecorder
+}
+
+// MockAdminUserMockRecorder is the mock recorder for MockAdminUser.
+type MockAdminUserMockRecorder struct {
+	mock *MockAdminUser
+}
+
+// NewMockAdminUser creates a new mock instance.
+func NewMockAdminUser(ctrl *gomock.Controller) *MockAdminUser {
+	mock := &MockAdminUser{ctrl: ctrl}
+	mock.recorder = &MockAdminUserMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAdminUser) EXPECT() *MockAdminUserMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockAdminUser) Authenticate(ctx context.Context, token string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", ctx, token)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockAdminUserMockRecorder) Authenticate(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockAdminUser)(nil).Authenticate), ctx, token)
+}
+
+// Create mocks base method.
+func (m *MockAdminUser) Create(ctx context.Context, name string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, name)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockAdminUserMockRecorder) Create(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockAdminUser)(nil).Create), ctx, name)
+}
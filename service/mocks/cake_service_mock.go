@@ -36,12 +36,43 @@ func (m *MockCake) EXPECT() *MockCakeMockRecorder {
 	return m.recorder
 }
 
+// BulkDelete mocks base method.
+func (m *MockCake) BulkDelete(ctx context.Context, req cake.BulkRequest) (cake.BulkResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BulkDelete", ctx, req)
+	ret0, _ := ret[0].(cake.BulkResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BulkDelete indicates an expected call of BulkDelete.
+func (mr *MockCakeMockRecorder) BulkDelete(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkDelete", reflect.TypeOf((*MockCake)(nil).BulkDelete), ctx, req)
+}
+
+// BulkUpdate mocks base method.
+func (m *MockCake) BulkUpdate(ctx context.Context, req cake.BulkUpdateRequest) (cake.BulkResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BulkUpdate", ctx, req)
+	ret0, _ := ret[0].(cake.BulkResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BulkUpdate indicates an expected call of BulkUpdate.
+func (mr *MockCakeMockRecorder) BulkUpdate(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkUpdate", reflect.TypeOf((*MockCake)(nil).BulkUpdate), ctx, req)
+}
+
 // Create mocks base method.
-func (m *MockCake) Create(ctx context.Context, req cake.CreateRequest) error {
+func (m *MockCake) Create(ctx context.Context, req cake.CreateRequest) (cake.CakeResponse, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Create", ctx, req)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(cake.CakeResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
@@ -64,6 +95,35 @@ func (mr *MockCakeMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCake)(nil).Delete), ctx, id)
 }
 
+// Export mocks base method.
+func (m *MockCake) Export(ctx context.Context, req cake.GetListRequest, fn func(cake.CakeResponse) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Export", ctx, req, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Export indicates an expected call of Export.
+func (mr *MockCakeMockRecorder) Export(ctx, req, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockCake)(nil).Export), ctx, req, fn)
+}
+
+// GetByIDs mocks base method.
+func (m *MockCake) GetByIDs(ctx context.Context, ids []int) (cake.CakesResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDs", ctx, ids)
+	ret0, _ := ret[0].(cake.CakesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDs indicates an expected call of GetByIDs.
+func (mr *MockCakeMockRecorder) GetByIDs(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDs", reflect.TypeOf((*MockCake)(nil).GetByIDs), ctx, ids)
+}
+
 // GetDetail mocks base method.
 func (m *MockCake) GetDetail(ctx context.Context, id int) (cake.CakeResponse, error) {
 	m.ctrl.T.Helper()
@@ -95,12 +155,28 @@ func (mr *MockCakeMockRecorder) GetList(ctx, req, paginateReq interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetList", reflect.TypeOf((*MockCake)(nil).GetList), ctx, req, paginateReq)
 }
 
+// Import mocks base method.
+func (m *MockCake) Import(ctx context.Context, req cake.ImportRequest) (cake.ImportResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Import", ctx, req)
+	ret0, _ := ret[0].(cake.ImportResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Import indicates an expected call of Import.
+func (mr *MockCakeMockRecorder) Import(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Import", reflect.TypeOf((*MockCake)(nil).Import), ctx, req)
+}
+
 // Update mocks base method.
-func (m *MockCake) Update(ctx context.Context, req cake.UpdateRequest) error {
+func (m *MockCake) Update(ctx context.Context, req cake.UpdateRequest) (cake.CakeResponse, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Update", ctx, req)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(cake.CakeResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Update indicates an expected call of Update.
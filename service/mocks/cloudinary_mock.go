@@ -35,6 +35,21 @@ func (m *MockCloudinary) EXPECT() *MockCloudinaryMockRecorder {
 	return m.recorder
 }
 
+// Destroy mocks base method.
+func (m *MockCloudinary) Destroy(ctx context.Context, params uploader.DestroyParams) (*uploader.DestroyResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Destroy", ctx, params)
+	ret0, _ := ret[0].(*uploader.DestroyResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Destroy indicates an expected call of Destroy.
+func (mr *MockCloudinaryMockRecorder) Destroy(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Destroy", reflect.TypeOf((*MockCloudinary)(nil).Destroy), ctx, params)
+}
+
 // Upload mocks base method.
 func (m *MockCloudinary) Upload(ctx context.Context, file interface{}, uploadParams uploader.UploadParams) (*uploader.UploadResult, error) {
 	m.ctrl.T.Helper()
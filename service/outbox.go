@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/infra"
+	"gitlab.com/cake-store-RESTFul/repo"
+	outboxApi "gitlab.com/cake-store-RESTFul/service/outbox"
+)
+
+type Outbox interface {
+	// Relay publishes the pending outbox events and returns how many were
+	// sent, it does nothing while another instance is relaying.
+	Relay(ctx context.Context) (int, error)
+	// Cleanup removes the events sent longer than the retention ago.
+	Cleanup(ctx context.Context) (int64, error)
+}
+
+type outbox struct {
+	outboxRepo repo.Outbox
+	Log        zerolog.Logger
+	Config     infra.Outbox
+}
+
+func NewOutbox(outboxRepo repo.Outbox, log zerolog.Logger, config infra.Outbox) Outbox {
+	return &outbox{
+		outboxRepo: outboxRepo,
+		Log:        log,
+		Config:     config,
+	}
+}
+
+// Relay publishes every pending event before marking it sent, an event whose
+// mark is lost is published again so delivery is at least once. When an
+// event fails the later events of the same cake wait for the next relay,
+// which keeps the events of a cake in order.
+func (o *outbox) Relay(ctx context.Context) (sent int, err error) {
+
+	release, ok, err := o.outboxRepo.Lock(ctx)
+	if err != nil || !ok {
+		return 0, err
+	}
+	defer release()
+
+	events, err := o.outboxRepo.GetPending(ctx, o.Config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	blocked := map[int]bool{}
+	for _, event := range events {
+		if blocked[event.AggregateID] {
+			continue
+		}
+
+		publishErr := o.publish(ctx, event)
+		if publishErr != nil {
+			o.Log.Error().Int64("outbox_id", event.ID).Msg(publishErr.Error())
+			blocked[event.AggregateID] = true
+
+			err = o.outboxRepo.MarkFailed(ctx, event.ID, publishErr.Error())
+			if err != nil {
+				return sent, err
+			}
+			continue
+		}
+
+		err = o.outboxRepo.MarkSent(ctx, event.ID, time.Now())
+		if err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+func (o *outbox) publish(ctx context.Context, event repo.OutboxModel) error {
+
+	value, err := json.Marshal(outboxApi.Event{
+		ID:        event.EventID,
+		Type:      event.Event,
+		CakeID:    event.AggregateID,
+		CreatedAt: event.CreatedAt,
+		Data:      event.Payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	return o.Config.Publisher.Publish(ctx, infra.Message{
+		Topic: o.Config.Topic,
+		Key:   strconv.Itoa(event.AggregateID),
+		Value: value,
+		Header: map[string]string{
+			outboxApi.HeaderEvent:   event.Event,
+			outboxApi.HeaderEventID: event.EventID,
+		},
+	})
+}
+
+func (o *outbox) Cleanup(ctx context.Context) (int64, error) {
+	return o.outboxRepo.DeleteSent(ctx, time.Now().Add(-o.Config.Retention))
+}
@@ -0,0 +1,23 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Headers sent with every outbox message, brokers without headers only get
+// the Event body.
+const (
+	HeaderEvent   = "Cake-Event"
+	HeaderEventID = "Cake-Event-Id"
+)
+
+// Event is the body of every message relayed from the outbox. Messages are
+// delivered at least once, consumers deduplicate on ID.
+type Event struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CakeID    int             `json:"cake_id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
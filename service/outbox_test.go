@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/infra"
+	"gitlab.com/cake-store-RESTFul/repo"
+	mockRepo "gitlab.com/cake-store-RESTFul/repo/mocks"
+	outboxApi "gitlab.com/cake-store-RESTFul/service/outbox"
+)
+
+func Test_outbox_Relay(t *testing.T) {
+	ctx := context.Background()
+	pending := []repo.OutboxModel{
+		{ID: 1, EventID: "e1", AggregateID: 3, Event: "cake.created", Payload: []byte(`{"id":3}`)},
+		{ID: 2, EventID: "e2", AggregateID: 4, Event: "cake.created", Payload: []byte(`{"id":4}`)},
+		{ID: 3, EventID: "e3", AggregateID: 3, Event: "cake.updated", Payload: []byte(`{"id":3}`)},
+		{ID: 4, EventID: "e4", AggregateID: 4, Event: "cake.deleted", Payload: []byte(`{"id":4}`)},
+	}
+
+	tests := []struct {
+		name       string
+		fail       func(message infra.Message) error
+		beforeFunc func(m *mockRepo.MockOutbox)
+		wantSent   int
+		wantIDs    []string
+		wantErr    bool
+	}{
+		{
+			name: "publishes in order",
+			beforeFunc: func(m *mockRepo.MockOutbox) {
+				m.EXPECT().Lock(ctx).Return(func() {}, true, nil)
+				m.EXPECT().GetPending(ctx, 10).Return(pending, nil)
+				for _, id := range []int64{1, 2, 3, 4} {
+					m.EXPECT().MarkSent(ctx, id, gomock.Any()).Return(nil)
+				}
+			},
+			wantSent: 4,
+			wantIDs:  []string{"e1", "e2", "e3", "e4"},
+		},
+		{
+			name: "failed event holds back the later events of its cake",
+			fail: func(message infra.Message) error {
+				if message.Header[outboxApi.HeaderEventID] == "e1" {
+					return errors.New("broker down")
+				}
+				return nil
+			},
+			beforeFunc: func(m *mockRepo.MockOutbox) {
+				m.EXPECT().Lock(ctx).Return(func() {}, true, nil)
+				m.EXPECT().GetPending(ctx, 10).Return(pending, nil)
+				m.EXPECT().MarkFailed(ctx, int64(1), "broker down").Return(nil)
+				m.EXPECT().MarkSent(ctx, int64(2), gomock.Any()).Return(nil)
+				m.EXPECT().MarkSent(ctx, int64(4), gomock.Any()).Return(nil)
+			},
+			wantSent: 2,
+			wantIDs:  []string{"e2", "e4"},
+		},
+		{
+			name: "another instance is relaying",
+			beforeFunc: func(m *mockRepo.MockOutbox) {
+				m.EXPECT().Lock(ctx).Return(nil, false, nil)
+			},
+		},
+		{
+			name: "error",
+			beforeFunc: func(m *mockRepo.MockOutbox) {
+				m.EXPECT().Lock(ctx).Return(func() {}, true, nil)
+				m.EXPECT().GetPending(ctx, 10).Return(nil, errors.New("foo"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			m := mockRepo.NewMockOutbox(ctrl)
+			tt.beforeFunc(m)
+
+			publisher := infra.NewMemoryPublisher()
+			publisher.Fail = tt.fail
+
+			o := NewOutbox(m, zerolog.Logger{}, infra.Outbox{Publisher: publisher, Topic: "cake.events", BatchSize: 10, Retention: time.Hour})
+			sent, err := o.Relay(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("outbox.Relay() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if sent != tt.wantSent {
+				t.Errorf("outbox.Relay() = %v, want %v", sent, tt.wantSent)
+			}
+
+			messages := publisher.Messages()
+			if len(messages) != len(tt.wantIDs) {
+				t.Fatalf("outbox.Relay() published %d messages, want %d", len(messages), len(tt.wantIDs))
+			}
+			for i, message := range messages {
+				event := outboxApi.Event{}
+				if err := json.Unmarshal(message.Value, &event); err != nil {
+					t.Fatal(err)
+				}
+				if event.ID != tt.wantIDs[i] || message.Topic != "cake.events" || message.Key != strconv.Itoa(event.CakeID) {
+					t.Errorf("message %d = %+v, event %+v", i, message, event)
+				}
+				if message.Header[outboxApi.HeaderEventID] != event.ID || message.Header[outboxApi.HeaderEvent] != event.Type {
+					t.Errorf("message %d header = %v", i, message.Header)
+				}
+			}
+		})
+	}
+}
+
+func Test_outbox_Cleanup(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	m := mockRepo.NewMockOutbox(ctrl)
+
+	m.EXPECT().DeleteSent(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, before time.Time) (int64, error) {
+		if age := time.Since(before); age < time.Hour || age > time.Hour+time.Second {
+			t.Errorf("outbox.Cleanup() deletes events sent before %v", before)
+		}
+		return 2, nil
+	})
+
+	o := NewOutbox(m, zerolog.Logger{}, infra.Outbox{Publisher: infra.NewMemoryPublisher(), Retention: time.Hour})
+	if deleted, err := o.Cleanup(ctx); err != nil || deleted != 2 {
+		t.Errorf("outbox.Cleanup() = %v, %v", deleted, err)
+	}
+}
@@ -0,0 +1,161 @@
+package seed
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxCount bounds a single seed so a typo does not fill the database.
+	MaxCount = 10000
+	// SpreadDays is how far back the created_at of demo cakes goes.
+	SpreadDays = 365
+)
+
+// Flavour is the main flavour of a demo cake, the cakes of a flavour share
+// its placeholder image.
+type Flavour struct {
+	Name    string
+	Color   color.RGBA
+	Accents []string
+}
+
+// Slug names the placeholder image of the flavour.
+func (f Flavour) Slug() string {
+	return strings.ReplaceAll(strings.ToLower(f.Name), " ", "-")
+}
+
+var Flavours = []Flavour{
+	{Name: "Chocolate", Color: color.RGBA{R: 92, G: 51, B: 23, A: 255}, Accents: []string{"Hazelnut", "Raspberry", "Salted Caramel", "Espresso", "Orange"}},
+	{Name: "Vanilla", Color: color.RGBA{R: 243, G: 229, B: 171, A: 255}, Accents: []string{"Bean", "Berry", "Almond", "Honey", "Peach"}},
+	{Name: "Red Velvet", Color: color.RGBA{R: 165, G: 28, B: 48, A: 255}, Accents: []string{"Cream Cheese", "White Chocolate", "Cherry"}},
+	{Name: "Lemon", Color: color.RGBA{R: 250, G: 224, B: 66, A: 255}, Accents: []string{"Poppy Seed", "Blueberry", "Lavender", "Elderflower"}},
+	{Name: "Carrot", Color: color.RGBA{R: 237, G: 145, B: 33, A: 255}, Accents: []string{"Walnut", "Pineapple", "Ginger", "Cinnamon"}},
+	{Name: "Strawberry", Color: color.RGBA{R: 252, G: 90, B: 141, A: 255}, Accents: []string{"Shortcake", "Rhubarb", "Basil", "Champagne"}},
+	{Name: "Matcha", Color: color.RGBA{R: 116, G: 156, B: 68, A: 255}, Accents: []string{"White Chocolate", "Yuzu", "Red Bean", "Black Sesame"}},
+	{Name: "Coconut", Color: color.RGBA{R: 245, G: 240, B: 230, A: 255}, Accents: []string{"Lime", "Mango", "Passion Fruit", "Pandan"}},
+	{Name: "Coffee", Color: color.RGBA{R: 111, G: 78, B: 55, A: 255}, Accents: []string{"Walnut", "Mocha", "Cardamom", "Tiramisu"}},
+	{Name: "Pistachio", Color: color.RGBA{R: 147, G: 197, B: 114, A: 255}, Accents: []string{"Rose", "Raspberry", "Cherry", "Honey"}},
+	{Name: "Pumpkin", Color: color.RGBA{R: 255, G: 117, B: 24, A: 255}, Accents: []string{"Spice", "Maple", "Pecan"}},
+	{Name: "Black Forest", Color: color.RGBA{R: 59, G: 30, B: 30, A: 255}, Accents: []string{"Kirsch", "Sour Cherry"}},
+}
+
+var (
+	styles   = []string{"Classic", "Double", "Rustic", "Triple Layer", "Naked", "Mini", "Grandma's", "Midnight", "Summer", "Brown Butter"}
+	forms    = []string{"Layer Cake", "Cheesecake", "Bundt Cake", "Sponge Cake", "Torte", "Chiffon Cake", "Roll Cake", "Pound Cake", "Mousse Cake", "Cupcakes"}
+	textures = []string{"moist", "light", "fluffy", "dense", "tender", "buttery", "delicate", "rich"}
+	fillings = []string{"whipped cream", "buttercream", "ganache", "custard", "mascarpone cream", "fruit compote", "caramel"}
+	toppings = []string{"fresh berries", "toasted nuts", "a mirror glaze", "chocolate shavings", "candied zest", "edible flowers", "a dusting of powdered sugar", "a drizzle of syrup"}
+	serves   = []int{6, 8, 10, 12, 16}
+)
+
+// Cake is a generated demo cake, Flavour is the index in Flavours.
+type Cake struct {
+	Index       int
+	Flavour     int
+	Title       string
+	Description string
+	Rating      float32
+	CreatedAt   time.Time
+	UpdatedAt   *time.Time
+}
+
+type Request struct {
+	Seed  int64
+	Count int
+}
+
+func (r Request) Validate() error {
+
+	if r.Count <= 0 {
+		return errors.New("count must be positive")
+	}
+
+	if r.Count > MaxCount {
+		return fmt.Errorf("count cannot be more than %d", MaxCount)
+	}
+
+	return nil
+}
+
+type Response struct {
+	Seed     int64
+	Count    int
+	Existing int
+	Created  int
+}
+
+// Generate returns the demo cakes with an index from from up to to. A cake
+// only depends on the seed and its index, so the first N cakes of a seed are
+// the same whatever the count. The timestamps lie in the SpreadDays before
+// anchor.
+func Generate(seed int64, from, to int, anchor time.Time) []Cake {
+
+	cakes := make([]Cake, 0, to-from)
+	for i := from; i < to; i++ {
+		cakes = append(cakes, generate(seed, i, anchor))
+	}
+
+	return cakes
+}
+
+func generate(seed int64, index int, anchor time.Time) Cake {
+
+	rng := rand.New(rand.NewSource(mix(seed, index)))
+	pick := func(values []string) string {
+		return values[rng.Intn(len(values))]
+	}
+
+	flavourIndex := rng.Intn(len(Flavours))
+	flavour := Flavours[flavourIndex]
+
+	title := []string{}
+	if rng.Intn(2) == 0 {
+		title = append(title, pick(styles))
+	}
+	title = append(title, flavour.Name)
+	accent := pick(flavour.Accents)
+	if rng.Intn(3) > 0 {
+		title = append(title, accent)
+	}
+	form := pick(forms)
+	title = append(title, form)
+
+	description := fmt.Sprintf("A %s %s %s with %s notes, filled with %s and finished with %s. Serves %d.",
+		pick(textures), strings.ToLower(flavour.Name), strings.ToLower(form), strings.ToLower(accent),
+		pick(fillings), pick(toppings), serves[rng.Intn(len(serves))])
+
+	spread := int64(SpreadDays * 24 * time.Hour / time.Second)
+	createdAt := anchor.Add(-time.Duration(1+rng.Int63n(spread)) * time.Second)
+
+	cake := Cake{
+		Index:       index,
+		Flavour:     flavourIndex,
+		Title:       strings.Join(title, " "),
+		Description: description,
+		// between 5.0 and 10.0 with a single decimal
+		Rating:    float32(50+rng.Intn(51)) / 10,
+		CreatedAt: createdAt,
+	}
+
+	// about half of the cakes were edited since
+	if since := int64(anchor.Sub(createdAt) / time.Second); rng.Intn(2) == 0 && since > 1 {
+		updatedAt := createdAt.Add(time.Duration(1+rng.Int63n(since-1)) * time.Second)
+		cake.UpdatedAt = &updatedAt
+	}
+
+	return cake
+}
+
+// mix derives the random source of a cake from the seed and its index, the
+// splitmix64 finalizer keeps neighbouring indexes unrelated.
+func mix(seed int64, index int) int64 {
+	z := uint64(seed) + uint64(index+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64(z ^ (z >> 31))
+}
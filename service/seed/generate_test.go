@@ -0,0 +1,92 @@
+package seed
+
+import (
+	"bytes"
+	"image/png"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGenerate(t *testing.T) {
+
+	anchor := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	cakes := Generate(42, 0, 200, anchor)
+	if len(cakes) != 200 {
+		t.Fatalf("Generate() returned %d cakes, want 200", len(cakes))
+	}
+
+	if again := Generate(42, 0, 200, anchor); !reflect.DeepEqual(cakes, again) {
+		t.Error("Generate() is not deterministic")
+	}
+
+	if tail := Generate(42, 150, 200, anchor); !reflect.DeepEqual(cakes[150:], tail) {
+		t.Error("Generate() from an offset differs from the same cakes of a full run")
+	}
+
+	if other := Generate(43, 0, 200, anchor); reflect.DeepEqual(cakes, other) {
+		t.Error("Generate() returned the same cakes for another seed")
+	}
+
+	titles := map[string]bool{}
+	for i, cake := range cakes {
+		titles[cake.Title] = true
+
+		if cake.Index != i || cake.Title == "" || cake.Description == "" {
+			t.Errorf("Generate() cake %d = %+v", i, cake)
+		}
+		if cake.Rating < 5 || cake.Rating > 10 {
+			t.Errorf("Generate() cake %d rating = %v", i, cake.Rating)
+		}
+		if !cake.CreatedAt.Before(anchor) || cake.CreatedAt.Before(anchor.AddDate(0, 0, -SpreadDays)) {
+			t.Errorf("Generate() cake %d created_at = %v", i, cake.CreatedAt)
+		}
+		if cake.UpdatedAt != nil && (!cake.UpdatedAt.After(cake.CreatedAt) || cake.UpdatedAt.After(anchor)) {
+			t.Errorf("Generate() cake %d updated_at = %v, created_at %v", i, cake.UpdatedAt, cake.CreatedAt)
+		}
+	}
+
+	if len(titles) < 100 {
+		t.Errorf("Generate() returned only %d distinct titles of 200", len(titles))
+	}
+}
+
+func TestRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     Request
+		wantErr bool
+	}{
+		{name: "valid", req: Request{Seed: 1, Count: 100}},
+		{name: "error no count", req: Request{Seed: 1}, wantErr: true},
+		{name: "error too many", req: Request{Seed: 1, Count: MaxCount + 1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.req.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Request.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPlaceholder(t *testing.T) {
+
+	image, err := Placeholder(Flavours[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := png.Decode(bytes.NewReader(image))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Bounds().Dx() != placeholderWidth || decoded.Bounds().Dy() != placeholderHeight {
+		t.Errorf("Placeholder() size = %v", decoded.Bounds())
+	}
+
+	if again, _ := Placeholder(Flavours[0]); !bytes.Equal(image, again) {
+		t.Error("Placeholder() is not deterministic")
+	}
+}
@@ -0,0 +1,49 @@
+package seed
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+const (
+	placeholderWidth  = 600
+	placeholderHeight = 400
+)
+
+// Placeholder draws the image of a flavour, a cake of three layers in the
+// flavour colour with cream between them on a plain background.
+func Placeholder(flavour Flavour) ([]byte, error) {
+
+	img := image.NewRGBA(image.Rect(0, 0, placeholderWidth, placeholderHeight))
+	background := color.RGBA{R: 250, G: 246, B: 240, A: 255}
+	cream := color.RGBA{R: 255, G: 253, B: 245, A: 255}
+	plate := color.RGBA{R: 210, G: 210, B: 215, A: 255}
+
+	fill(img, image.Rect(0, 0, placeholderWidth, placeholderHeight), background)
+	fill(img, image.Rect(80, 330, 520, 345), plate)
+
+	// layers from the bottom up, each layer topped with cream
+	top := 330
+	for i := 0; i < 3; i++ {
+		fill(img, image.Rect(120, top-60, 480, top), flavour.Color)
+		fill(img, image.Rect(120, top-72, 480, top-60), cream)
+		top -= 72
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func fill(img *image.RGBA, rect image.Rectangle, c color.RGBA) {
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
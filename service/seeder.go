@@ -0,0 +1,141 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/infra"
+	"gitlab.com/cake-store-RESTFul/repo"
+	seedApi "gitlab.com/cake-store-RESTFul/service/seed"
+)
+
+// placeholderFolder holds the placeholder images, one per flavour.
+const placeholderFolder = "cake-store/demo/"
+
+type Seeder interface {
+	// Seed makes sure the first req.Count demo cakes of req.Seed exist. Only
+	// the cakes missing since an earlier run are created, seeding again with
+	// the same request does nothing.
+	Seed(ctx context.Context, req seedApi.Request) (seedApi.Response, error)
+}
+
+type seeder struct {
+	cakeRepo     repo.Cake
+	demoSeedRepo repo.DemoSeed
+	txManager    repo.TxManager
+	Log          zerolog.Logger
+	Cloudinary   infra.Cloudinary
+}
+
+func NewSeeder(cakeRepo repo.Cake, demoSeedRepo repo.DemoSeed, txManager repo.TxManager, log zerolog.Logger, cl infra.Cloudinary) Seeder {
+	return &seeder{
+		cakeRepo:     cakeRepo,
+		demoSeedRepo: demoSeedRepo,
+		txManager:    txManager,
+		Log:          log,
+		Cloudinary:   cl,
+	}
+}
+
+func (s *seeder) Seed(ctx context.Context, req seedApi.Request) (res seedApi.Response, err error) {
+
+	if err = req.Validate(); err != nil {
+		return
+	}
+
+	res = seedApi.Response{Seed: req.Seed, Count: req.Count}
+
+	existing, err := s.demoSeedRepo.GetCount(ctx, req.Seed)
+	if err != nil {
+		s.Log.Error().Msg(err.Error())
+		return
+	}
+	if existing >= req.Count {
+		res.Existing = existing
+		return res, nil
+	}
+
+	// uploads happen before the transaction, which may be retried
+	images, err := s.uploadPlaceholders(ctx)
+	if err != nil {
+		s.Log.Error().Msg(err.Error())
+		return
+	}
+
+	now := time.Now().UTC()
+	anchor := now.Truncate(24 * time.Hour)
+
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+
+		// counted again under the row lock, another seeder may have run
+		existing, err := s.demoSeedRepo.GetCount(ctx, req.Seed)
+		if err != nil {
+			return err
+		}
+
+		res.Existing, res.Created = existing, 0
+		if existing >= req.Count {
+			return nil
+		}
+
+		cakes := []repo.CakeBaseModel{}
+		for _, cake := range seedApi.Generate(req.Seed, existing, req.Count, anchor) {
+			model := repo.CakeBaseModel{
+				Title:       cake.Title,
+				Description: cake.Description,
+				Image:       images[cake.Flavour],
+				Rating:      cake.Rating,
+				CreatedAt:   cake.CreatedAt,
+			}
+			if cake.UpdatedAt != nil {
+				model.UpdatedAt = sql.NullTime{Time: *cake.UpdatedAt, Valid: true}
+			}
+			cakes = append(cakes, model)
+		}
+
+		if _, err := s.cakeRepo.CreateBatch(ctx, cakes); err != nil {
+			return err
+		}
+
+		res.Created = len(cakes)
+		return s.demoSeedRepo.SetCount(ctx, req.Seed, req.Count, now)
+	})
+	if err != nil {
+		s.Log.Error().Msg(err.Error())
+		return
+	}
+
+	return res, nil
+}
+
+// uploadPlaceholders stores the image of every flavour under a fixed public
+// ID and returns their URLs by flavour. An image already uploaded by an
+// earlier run is kept.
+func (s *seeder) uploadPlaceholders(ctx context.Context) ([]string, error) {
+
+	overwrite := false
+	images := make([]string, len(seedApi.Flavours))
+
+	for i, flavour := range seedApi.Flavours {
+		image, err := seedApi.Placeholder(flavour)
+		if err != nil {
+			return nil, err
+		}
+
+		r, err := s.Cloudinary.Upload(ctx, bytes.NewReader(image), uploader.UploadParams{
+			PublicID:  placeholderFolder + flavour.Slug(),
+			Overwrite: &overwrite,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		images[i] = r.URL
+	}
+
+	return images, nil
+}
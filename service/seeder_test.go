@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/repo"
+	mockRepo "gitlab.com/cake-store-RESTFul/repo/mocks"
+	mockSvc "gitlab.com/cake-store-RESTFul/service/mocks"
+	seedApi "gitlab.com/cake-store-RESTFul/service/seed"
+)
+
+func Test_seeder_Seed(t *testing.T) {
+	ctx := context.Background()
+	req := seedApi.Request{Seed: 7, Count: 30}
+
+	type mocks struct {
+		cakes *mockRepo.MockCake
+		seeds *mockRepo.MockDemoSeed
+		tm    *mockRepo.MockTxManager
+		cl    *mockSvc.MockCloudinary
+	}
+
+	uploads := func(m mocks) {
+		m.cl.EXPECT().Upload(ctx, gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ interface{}, params uploader.UploadParams) (*uploader.UploadResult, error) {
+				if !strings.HasPrefix(params.PublicID, placeholderFolder) || params.Overwrite == nil || *params.Overwrite {
+					t.Errorf("seeder.Seed() upload params = %+v", params)
+				}
+				return &uploader.UploadResult{URL: "http://image/" + params.PublicID}, nil
+			}).Times(len(seedApi.Flavours))
+	}
+	withinTx := func(m mocks) {
+		m.tm.EXPECT().WithinTx(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		})
+	}
+
+	tests := []struct {
+		name       string
+		req        seedApi.Request
+		beforeFunc func(m mocks)
+		want       seedApi.Response
+		wantErr    bool
+	}{
+		{
+			name: "creates the missing cakes",
+			req:  req,
+			beforeFunc: func(m mocks) {
+				m.seeds.EXPECT().GetCount(ctx, int64(7)).Return(10, nil).Times(2)
+				uploads(m)
+				withinTx(m)
+				m.cakes.EXPECT().CreateBatch(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, input []repo.CakeBaseModel) ([]int, error) {
+					if len(input) != 20 {
+						t.Errorf("seeder.Seed() stored %d cakes, want 20", len(input))
+					}
+					for _, cake := range input {
+						if !strings.HasPrefix(cake.Image, "http://image/"+placeholderFolder) {
+							t.Errorf("seeder.Seed() stored image %q", cake.Image)
+						}
+					}
+					return nil, nil
+				})
+				m.seeds.EXPECT().SetCount(ctx, int64(7), 30, gomock.Any()).Return(nil)
+			},
+			want: seedApi.Response{Seed: 7, Count: 30, Existing: 10, Created: 20},
+		},
+		{
+			name: "already seeded",
+			req:  req,
+			beforeFunc: func(m mocks) {
+				m.seeds.EXPECT().GetCount(ctx, int64(7)).Return(30, nil)
+			},
+			want: seedApi.Response{Seed: 7, Count: 30, Existing: 30},
+		},
+		{
+			name: "seeded by another run meanwhile",
+			req:  req,
+			beforeFunc: func(m mocks) {
+				m.seeds.EXPECT().GetCount(ctx, int64(7)).Return(0, nil)
+				uploads(m)
+				withinTx(m)
+				m.seeds.EXPECT().GetCount(ctx, int64(7)).Return(30, nil)
+			},
+			want: seedApi.Response{Seed: 7, Count: 30, Existing: 30},
+		},
+		{
+			name: "error upload",
+			req:  req,
+			beforeFunc: func(m mocks) {
+				m.seeds.EXPECT().GetCount(ctx, int64(7)).Return(0, nil)
+				m.cl.EXPECT().Upload(ctx, gomock.Any(), gomock.Any()).Return(nil, errors.New("foo"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "error insert",
+			req:  req,
+			beforeFunc: func(m mocks) {
+				m.seeds.EXPECT().GetCount(ctx, int64(7)).Return(0, nil).Times(2)
+				uploads(m)
+				withinTx(m)
+				m.cakes.EXPECT().CreateBatch(ctx, gomock.Any()).Return(nil, errors.New("foo"))
+			},
+			wantErr: true,
+		},
+		{
+			name:       "error invalid request",
+			req:        seedApi.Request{Seed: 7},
+			beforeFunc: func(m mocks) {},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := mocks{
+				cakes: mockRepo.NewMockCake(ctrl),
+				seeds: mockRepo.NewMockDemoSeed(ctrl),
+				tm:    mockRepo.NewMockTxManager(ctrl),
+				cl:    mockSvc.NewMockCloudinary(ctrl),
+			}
+			tt.beforeFunc(m)
+
+			got, err := NewSeeder(m.cakes, m.seeds, m.tm, zerolog.Logger{}, m.cl).Seed(ctx, tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("seeder.Seed() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("seeder.Seed() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
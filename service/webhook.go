@@ -0,0 +1,466 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/infra"
+	"gitlab.com/cake-store-RESTFul/repo"
+	commonApi "gitlab.com/cake-store-RESTFul/service/common"
+	webhookApi "gitlab.com/cake-store-RESTFul/service/webhook"
+)
+
+// maxResponseBody is how much of a webhook response is kept in the delivery
+// log.
+const maxResponseBody = 1024
+
+type Webhook interface {
+	Create(ctx context.Context, req webhookApi.CreateRequest) (webhookApi.WebhookResponse, error)
+	GetList(ctx context.Context) (webhookApi.WebhooksResponse, error)
+	GetDetail(ctx context.Context, id int) (webhookApi.WebhookResponse, error)
+	Update(ctx context.Context, req webhookApi.UpdateRequest) (webhookApi.WebhookResponse, error)
+	Delete(ctx context.Context, id int) error
+	GetDeliveries(ctx context.Context, webhookID int, paginateReq commonApi.PaginationRequest) (webhookApi.DeliveriesResponse, commonApi.PaginationResponse, error)
+	Redeliver(ctx context.Context, webhookID, deliveryID int) (webhookApi.DeliveryResponse, error)
+	Publish(ctx context.Context, event string, data interface{}) error
+	Dispatch(ctx context.Context) (int, error)
+}
+
+type webhook struct {
+	webhookRepo repo.Webhook
+	Log         zerolog.Logger
+	Config      infra.Webhook
+	Client      *http.Client
+}
+
+func NewWebhook(webhookRepo repo.Webhook, log zerolog.Logger, config infra.Webhook) Webhook {
+	return &webhook{
+		webhookRepo: webhookRepo,
+		Log:         log,
+		Config:      config,
+		Client:      &http.Client{Timeout: config.Timeout},
+	}
+}
+
+func newWebhookResponse(hook repo.WebhookModel) webhookApi.WebhookResponse {
+
+	res := webhookApi.WebhookResponse{
+		ID:        hook.ID,
+		URL:       hook.URL,
+		Events:    strings.Split(hook.Events, ","),
+		Active:    hook.Active,
+		CreatedAt: hook.CreatedAt,
+	}
+
+	if hook.UpdatedAt.Valid {
+		res.UpdatedAt = &hook.UpdatedAt.Time
+	}
+
+	return res
+}
+
+func newDeliveryResponse(delivery repo.WebhookDeliveryModel) webhookApi.DeliveryResponse {
+
+	res := webhookApi.DeliveryResponse{
+		ID:           delivery.ID,
+		WebhookID:    delivery.WebhookID,
+		EventID:      delivery.EventID,
+		Event:        delivery.Event,
+		Payload:      json.RawMessage(delivery.Payload),
+		Status:       delivery.Status,
+		Attempts:     delivery.Attempts,
+		ResponseBody: delivery.ResponseBody.String,
+		LastError:    delivery.LastError.String,
+		CreatedAt:    delivery.CreatedAt,
+	}
+
+	// a delivery that is no longer pending will not be attempted again
+	if delivery.NextAttemptAt.Valid && delivery.Status == webhookApi.DeliveryStatusPending {
+		res.NextAttemptAt = &delivery.NextAttemptAt.Time
+	}
+
+	if delivery.ResponseCode.Valid {
+		code := int(delivery.ResponseCode.Int64)
+		res.ResponseCode = &code
+	}
+
+	if delivery.UpdatedAt.Valid {
+		res.UpdatedAt = &delivery.UpdatedAt.Time
+	}
+
+	return res
+}
+
+// Create stores the subscription, when no secret is given one is generated
+// and sent back once in the response.
+func (w *webhook) Create(ctx context.Context, req webhookApi.CreateRequest) (res webhookApi.WebhookResponse, err error) {
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = newWebhookSecret(); err != nil {
+			w.Log.Error().Msg(err.Error())
+			return
+		}
+	}
+
+	hook := repo.WebhookModel{
+		URL:       req.URL,
+		Secret:    secret,
+		Events:    strings.Join(req.Events, ","),
+		Active:    req.Active == nil || *req.Active,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	hook.ID, err = w.webhookRepo.Create(ctx, hook)
+	if err != nil {
+		w.Log.Error().Msg(err.Error())
+		return
+	}
+
+	res = newWebhookResponse(hook)
+	if req.Secret == "" {
+		res.Secret = secret
+	}
+
+	return res, nil
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (w *webhook) GetList(ctx context.Context) (res webhookApi.WebhooksResponse, err error) {
+
+	hooks, err := w.webhookRepo.GetList(ctx)
+	if err != nil {
+		w.Log.Error().Msg(err.Error())
+		return
+	}
+
+	res = make(webhookApi.WebhooksResponse, 0, len(hooks))
+	for _, hook := range hooks {
+		res = append(res, newWebhookResponse(hook))
+	}
+
+	return res, nil
+}
+
+func (w *webhook) GetDetail(ctx context.Context, id int) (res webhookApi.WebhookResponse, err error) {
+
+	hook, err := w.webhookRepo.GetDetail(ctx, id)
+	if err != nil {
+		w.Log.Error().Msg(err.Error())
+		return
+	}
+
+	return newWebhookResponse(hook), nil
+}
+
+// Update applies the changes, a missing webhook is reported as sql.ErrNoRows.
+func (w *webhook) Update(ctx context.Context, req webhookApi.UpdateRequest) (res webhookApi.WebhookResponse, err error) {
+
+	hook, err := w.webhookRepo.GetDetail(ctx, req.ID)
+	if err != nil {
+		w.Log.Error().Msg(err.Error())
+		return
+	}
+
+	if req.URL != nil {
+		hook.URL = *req.URL
+	}
+	if req.Secret != nil {
+		hook.Secret = *req.Secret
+	}
+	if req.Events != nil {
+		hook.Events = strings.Join(*req.Events, ",")
+	}
+	if req.Active != nil {
+		hook.Active = *req.Active
+	}
+	hook.UpdatedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+
+	if err = w.webhookRepo.Update(ctx, hook); err != nil {
+		w.Log.Error().Msg(err.Error())
+		return
+	}
+
+	return newWebhookResponse(hook), nil
+}
+
+func (w *webhook) Delete(ctx context.Context, id int) error {
+	return w.webhookRepo.Delete(ctx, id)
+}
+
+// GetDeliveries returns a page of the delivery log of a webhook, newest first.
+func (w *webhook) GetDeliveries(ctx context.Context, webhookID int, paginateReq commonApi.PaginationRequest) (res webhookApi.DeliveriesResponse, pagination commonApi.PaginationResponse, err error) {
+
+	if _, err = w.webhookRepo.GetDetail(ctx, webhookID); err != nil {
+		w.Log.Error().Msg(err.Error())
+		return
+	}
+
+	offset := paginateReq.Limit * (paginateReq.Page - 1)
+	deliveries, err := w.webhookRepo.GetDeliveries(ctx, webhookID, paginateReq.Limit, offset)
+	if err != nil {
+		w.Log.Error().Msg(err.Error())
+		return
+	}
+
+	res = make(webhookApi.DeliveriesResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		res = append(res, newDeliveryResponse(delivery))
+	}
+
+	count, err := w.webhookRepo.CountDeliveries(ctx, webhookID)
+	if err != nil {
+		w.Log.Error().Msg(err.Error())
+		return
+	}
+
+	pagination = commonApi.PaginationResponse{
+		Page:  paginateReq.Page,
+		Limit: paginateReq.Limit,
+		Total: count,
+	}
+
+	return res, pagination, nil
+}
+
+// Redeliver queues a new delivery of the same event, the original delivery is
+// kept in the log as it was.
+func (w *webhook) Redeliver(ctx context.Context, webhookID, deliveryID int) (res webhookApi.DeliveryResponse, err error) {
+
+	original, err := w.webhookRepo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		w.Log.Error().Msg(err.Error())
+		return
+	}
+
+	if original.WebhookID != webhookID {
+		return res, sql.ErrNoRows
+	}
+
+	now := time.Now().UTC()
+	delivery := repo.WebhookDeliveryModel{
+		WebhookID:     original.WebhookID,
+		EventID:       original.EventID,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        webhookApi.DeliveryStatusPending,
+		NextAttemptAt: sql.NullTime{Time: now, Valid: true},
+		CreatedAt:     now,
+	}
+
+	delivery.ID, err = w.webhookRepo.CreateDelivery(ctx, delivery)
+	if err != nil {
+		w.Log.Error().Msg(err.Error())
+		return
+	}
+
+	return newDeliveryResponse(delivery), nil
+}
+
+// Publish queues a delivery of the event for every active webhook subscribed
+// to it, they are sent by Dispatch.
+func (w *webhook) Publish(ctx context.Context, event string, data interface{}) (err error) {
+
+	hooks, err := w.webhookRepo.GetByEvent(ctx, event)
+	if err != nil {
+		w.Log.Error().Msg(err.Error())
+		return
+	}
+
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	eventID := uuid.NewString()
+	payload, err := json.Marshal(webhookApi.Event{
+		ID:        eventID,
+		Type:      event,
+		CreatedAt: now,
+		Data:      data,
+	})
+	if err != nil {
+		w.Log.Error().Msg(err.Error())
+		return
+	}
+
+	deliveries := make([]repo.WebhookDeliveryModel, 0, len(hooks))
+	for _, hook := range hooks {
+		deliveries = append(deliveries, repo.WebhookDeliveryModel{
+			WebhookID:     hook.ID,
+			EventID:       eventID,
+			Event:         event,
+			Payload:       payload,
+			Status:        webhookApi.DeliveryStatusPending,
+			NextAttemptAt: sql.NullTime{Time: now, Valid: true},
+			CreatedAt:     now,
+		})
+	}
+
+	return w.webhookRepo.CreateDeliveries(ctx, deliveries)
+}
+
+// Dispatch sends every due delivery once and returns how many were sent
+// successfully. Deliveries are claimed first so instances sharing the
+// database never send the same attempt twice.
+func (w *webhook) Dispatch(ctx context.Context) (int, error) {
+
+	now := time.Now().UTC()
+	due, err := w.webhookRepo.GetDueDeliveries(ctx, now, w.Config.BatchSize)
+	if err != nil {
+		w.Log.Error().Msg(err.Error())
+		return 0, err
+	}
+
+	// a claimed delivery is picked up again after the lease when this
+	// instance dies before recording the attempt
+	lease := now.Add(2 * w.Config.Timeout)
+
+	hooks := map[int]repo.WebhookModel{}
+	wg := sync.WaitGroup{}
+	mu := sync.Mutex{}
+	sent := 0
+
+	for _, delivery := range due {
+		claimed, err := w.webhookRepo.ClaimDelivery(ctx, delivery.ID, now, lease)
+		if err != nil {
+			w.Log.Error().Msg(err.Error())
+			return sent, err
+		}
+		if !claimed {
+			continue
+		}
+
+		hook, ok := hooks[delivery.WebhookID]
+		if !ok {
+			hook, err = w.webhookRepo.GetDetail(ctx, delivery.WebhookID)
+			if err == sql.ErrNoRows {
+				// deleted meanwhile, its deliveries are gone too
+				continue
+			}
+			if err != nil {
+				w.Log.Error().Msg(err.Error())
+				return sent, err
+			}
+			hooks[hook.ID] = hook
+		}
+
+		wg.Add(1)
+		go func(delivery repo.WebhookDeliveryModel, hook repo.WebhookModel) {
+			defer wg.Done()
+			if w.deliver(ctx, hook, delivery) {
+				mu.Lock()
+				sent++
+				mu.Unlock()
+			}
+		}(delivery, hook)
+	}
+
+	wg.Wait()
+	return sent, nil
+}
+
+// deliver makes one attempt and records its outcome, a failed attempt is
+// retried with exponential backoff until MaxAttempts is reached.
+func (w *webhook) deliver(ctx context.Context, hook repo.WebhookModel, delivery repo.WebhookDeliveryModel) bool {
+
+	now := time.Now().UTC()
+	delivery.Attempts++
+	delivery.UpdatedAt = sql.NullTime{Time: now, Valid: true}
+	delivery.ResponseCode = sql.NullInt64{}
+	delivery.ResponseBody = sql.NullString{}
+	delivery.LastError = sql.NullString{}
+
+	err := w.send(ctx, hook, &delivery, now)
+	switch {
+	case err == nil:
+		delivery.Status = webhookApi.DeliveryStatusSucceeded
+	case !hook.Active:
+		delivery.Status = webhookApi.DeliveryStatusFailed
+	case delivery.Attempts >= w.Config.MaxAttempts:
+		delivery.Status = webhookApi.DeliveryStatusFailed
+	default:
+		delivery.NextAttemptAt = sql.NullTime{Time: now.Add(w.backoff(delivery.Attempts)), Valid: true}
+	}
+
+	if err != nil {
+		delivery.LastError = sql.NullString{String: err.Error(), Valid: true}
+		w.Log.Warn().Int("webhook_id", hook.ID).Int("delivery_id", delivery.ID).Int("attempts", delivery.Attempts).Msg(err.Error())
+	}
+
+	// the outcome is recorded even when ctx is cancelled meanwhile
+	if err := w.webhookRepo.UpdateDelivery(context.Background(), delivery); err != nil {
+		w.Log.Error().Msg(err.Error())
+	}
+
+	return err == nil
+}
+
+func (w *webhook) send(ctx context.Context, hook repo.WebhookModel, delivery *repo.WebhookDeliveryModel, now time.Time) error {
+
+	if !hook.Active {
+		return fmt.Errorf("webhook %d is inactive", hook.ID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "cake-store-webhook")
+	req.Header.Set(webhookApi.HeaderEvent, delivery.Event)
+	req.Header.Set(webhookApi.HeaderDelivery, strconv.Itoa(delivery.ID))
+	req.Header.Set(webhookApi.HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(webhookApi.HeaderSignature, webhookApi.Sign(hook.Secret, now, delivery.Payload))
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	delivery.ResponseCode = sql.NullInt64{Int64: int64(resp.StatusCode), Valid: true}
+	delivery.ResponseBody = sql.NullString{String: string(body), Valid: true}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// backoff is the delay after the given number of failed attempts.
+func (w *webhook) backoff(attempts int) time.Duration {
+
+	delay := w.Config.BackoffBase
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= w.Config.BackoffMax {
+			return w.Config.BackoffMax
+		}
+	}
+
+	return delay
+}
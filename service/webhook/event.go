@@ -0,0 +1,41 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// Event is the body posted to a webhook.
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// DeletedCake is the data of a cake.deleted event.
+type DeletedCake struct {
+	ID int `json:"id"`
+}
+
+// Headers sent with every delivery.
+const (
+	HeaderEvent     = "X-Cake-Event"
+	HeaderDelivery  = "X-Cake-Delivery"
+	HeaderTimestamp = "X-Cake-Timestamp"
+	HeaderSignature = "X-Cake-Signature"
+)
+
+// Sign returns the X-Cake-Signature value of a delivery, the hex encoded
+// HMAC-SHA256 of the timestamp, a dot and the body keyed with the webhook
+// secret. Receivers should recompute it and reject old timestamps.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
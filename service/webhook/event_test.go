@@ -0,0 +1,15 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{"id":"1"}' | openssl dgst -sha256 -hmac secret
+	want := "sha256=" + "086f6aff7bd084c98679825129c5a64dbad88c760016d6d2c0fb123f27951d54"
+	got := Sign("secret", time.Unix(1700000000, 0), []byte(`{"id":"1"}`))
+	if got != want {
+		t.Errorf("Sign() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,114 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	EventCakeCreated  = "cake.created"
+	EventCakeUpdated  = "cake.updated"
+	EventCakeDeleted  = "cake.deleted"
+	EventCakeImported = "cake.imported"
+)
+
+// Events are the event types a webhook can subscribe to.
+var Events = []string{EventCakeCreated, EventCakeUpdated, EventCakeDeleted, EventCakeImported}
+
+const minSecretLength = 16
+
+type CreateRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
+
+// Validate checks the subscription, an empty secret is generated by the
+// service.
+func (c *CreateRequest) Validate() error {
+
+	if err := validateURL(c.URL); err != nil {
+		return err
+	}
+
+	if c.Secret != "" && len(c.Secret) < minSecretLength {
+		return fmt.Errorf("secret must be at least %d characters", minSecretLength)
+	}
+
+	return validateEvents(c.Events)
+}
+
+// UpdateRequest carries the changes of a webhook, nil fields are left
+// untouched.
+type UpdateRequest struct {
+	ID     int       `json:"-"`
+	URL    *string   `json:"url"`
+	Secret *string   `json:"secret"`
+	Events *[]string `json:"events"`
+	Active *bool     `json:"active"`
+}
+
+func (c *UpdateRequest) ParseJSON(body []byte) error {
+
+	if err := json.Unmarshal(body, c); err != nil {
+		return err
+	}
+
+	if c.URL != nil {
+		if err := validateURL(*c.URL); err != nil {
+			return err
+		}
+	}
+
+	if c.Secret != nil && len(*c.Secret) < minSecretLength {
+		return fmt.Errorf("secret must be at least %d characters", minSecretLength)
+	}
+
+	if c.Events != nil {
+		return validateEvents(*c.Events)
+	}
+
+	return nil
+}
+
+func validateURL(rawURL string) error {
+
+	if rawURL == "" {
+		return errors.New("url cannot be empty")
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+
+	return nil
+}
+
+func validateEvents(events []string) error {
+
+	if len(events) == 0 {
+		return errors.New("events cannot be empty")
+	}
+
+	for _, event := range events {
+		if !isEvent(event) {
+			return fmt.Errorf("unknown event %q, must be one of %s", event, strings.Join(Events, ", "))
+		}
+	}
+
+	return nil
+}
+
+func isEvent(event string) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package webhook
+
+import (
+	"testing"
+)
+
+func TestCreateRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     CreateRequest
+		wantErr bool
+	}{
+		{
+			name: "success",
+			req:  CreateRequest{URL: "https://example.com/hook", Events: []string{EventCakeCreated, EventCakeDeleted}},
+		},
+		{
+			name:    "relative url",
+			req:     CreateRequest{URL: "/hook", Events: []string{EventCakeCreated}},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported scheme",
+			req:     CreateRequest{URL: "ftp://example.com", Events: []string{EventCakeCreated}},
+			wantErr: true,
+		},
+		{
+			name:    "short secret",
+			req:     CreateRequest{URL: "https://example.com", Secret: "abc", Events: []string{EventCakeCreated}},
+			wantErr: true,
+		},
+		{
+			name:    "no events",
+			req:     CreateRequest{URL: "https://example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown event",
+			req:     CreateRequest{URL: "https://example.com", Events: []string{"cake.eaten"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.req.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("CreateRequest.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpdateRequest_ParseJSON(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantActive *bool
+		wantErr    bool
+	}{
+		{
+			name: "absent members are left nil",
+			body: `{"events":["cake.updated"]}`,
+		},
+		{
+			name:       "active",
+			body:       `{"active":false}`,
+			wantActive: new(bool),
+		},
+		{
+			name:    "empty events",
+			body:    `{"events":[]}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid url",
+			body:    `{"url":"example.com"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := UpdateRequest{}
+			err := req.ParseJSON([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UpdateRequest.ParseJSON() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if (req.Active == nil) != (tt.wantActive == nil) || (req.Active != nil && *req.Active != *tt.wantActive) {
+				t.Errorf("UpdateRequest.ParseJSON() active = %v, want %v", req.Active, tt.wantActive)
+			}
+			if req.URL != nil || req.Secret != nil {
+				t.Errorf("UpdateRequest.ParseJSON() = %+v", req)
+			}
+		})
+	}
+}
@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	"gitlab.com/cake-store-RESTFul/infra"
+	"gitlab.com/cake-store-RESTFul/repo"
+	mockRepo "gitlab.com/cake-store-RESTFul/repo/mocks"
+	webhookApi "gitlab.com/cake-store-RESTFul/service/webhook"
+)
+
+var testWebhookConfig = infra.Webhook{
+	Enabled:     true,
+	Timeout:     time.Second,
+	MaxAttempts: 3,
+	BackoffBase: time.Minute,
+	BackoffMax:  3 * time.Minute,
+	BatchSize:   10,
+}
+
+func Test_webhook_Create(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		req        webhookApi.CreateRequest
+		wantSecret bool
+	}{
+		{
+			name:       "secret is generated",
+			req:        webhookApi.CreateRequest{URL: "https://example.com", Events: []string{"cake.created"}},
+			wantSecret: true,
+		},
+		{
+			name: "secret is kept",
+			req:  webhookApi.CreateRequest{URL: "https://example.com", Secret: "0123456789abcdef", Events: []string{"cake.created"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			m := mockRepo.NewMockWebhook(ctrl)
+
+			stored := repo.WebhookModel{}
+			m.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, hook repo.WebhookModel) (int, error) {
+				stored = hook
+				return 1, nil
+			})
+
+			w := NewWebhook(m, zerolog.Logger{}, testWebhookConfig)
+			got, err := w.Create(ctx, tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !stored.Active || stored.Events != "cake.created" {
+				t.Errorf("webhook.Create() stored %+v", stored)
+			}
+			if tt.wantSecret && (len(stored.Secret) != 64 || got.Secret != stored.Secret) {
+				t.Errorf("webhook.Create() secret = %q, stored %q", got.Secret, stored.Secret)
+			}
+			if !tt.wantSecret && (got.Secret != "" || stored.Secret != tt.req.Secret) {
+				t.Errorf("webhook.Create() secret = %q, stored %q", got.Secret, stored.Secret)
+			}
+		})
+	}
+}
+
+func Test_webhook_Publish(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		beforeFunc func(m *mockRepo.MockWebhook)
+		wantErr    bool
+	}{
+		{
+			name: "one delivery per webhook",
+			beforeFunc: func(m *mockRepo.MockWebhook) {
+				m.EXPECT().GetByEvent(ctx, "cake.deleted").Return([]repo.WebhookModel{{ID: 1}, {ID: 2}}, nil)
+				m.EXPECT().CreateDeliveries(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, deliveries []repo.WebhookDeliveryModel) error {
+					if len(deliveries) != 2 || deliveries[0].WebhookID != 1 || deliveries[1].WebhookID != 2 {
+						t.Errorf("deliveries = %+v", deliveries)
+					}
+
+					event := webhookApi.Event{}
+					if err := json.Unmarshal(deliveries[0].Payload, &event); err != nil {
+						t.Fatal(err)
+					}
+					if event.ID != deliveries[0].EventID || event.ID != deliveries[1].EventID || event.Type != "cake.deleted" {
+						t.Errorf("event = %+v", event)
+					}
+					if deliveries[0].Status != webhookApi.DeliveryStatusPending || !deliveries[0].NextAttemptAt.Valid {
+						t.Errorf("delivery = %+v", deliveries[0])
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name: "no subscriber",
+			beforeFunc: func(m *mockRepo.MockWebhook) {
+				m.EXPECT().GetByEvent(ctx, "cake.deleted").Return(nil, nil)
+			},
+		},
+		{
+			name: "error",
+			beforeFunc: func(m *mockRepo.MockWebhook) {
+				m.EXPECT().GetByEvent(ctx, "cake.deleted").Return(nil, errors.New("foo"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			m := mockRepo.NewMockWebhook(ctrl)
+			tt.beforeFunc(m)
+
+			w := NewWebhook(m, zerolog.Logger{}, testWebhookConfig)
+			if err := w.Publish(ctx, "cake.deleted", webhookApi.DeletedCake{ID: 3}); (err != nil) != tt.wantErr {
+				t.Errorf("webhook.Publish() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_webhook_Dispatch(t *testing.T) {
+	ctx := context.Background()
+	payload := []byte(`{"type":"cake.created"}`)
+
+	tests := []struct {
+		name       string
+		status     int
+		active     bool
+		attempts   int
+		claimed    bool
+		wantSent   int
+		wantStatus string
+		wantCode   int64
+		wantNext   time.Duration
+	}{
+		{
+			name:       "delivered",
+			status:     http.StatusNoContent,
+			active:     true,
+			claimed:    true,
+			wantSent:   1,
+			wantStatus: webhookApi.DeliveryStatusSucceeded,
+			wantCode:   http.StatusNoContent,
+		},
+		{
+			name:       "failed attempt is retried with backoff",
+			status:     http.StatusInternalServerError,
+			active:     true,
+			attempts:   1,
+			claimed:    true,
+			wantStatus: webhookApi.DeliveryStatusPending,
+			wantCode:   http.StatusInternalServerError,
+			wantNext:   2 * time.Minute,
+		},
+		{
+			name:       "last attempt fails the delivery",
+			status:     http.StatusInternalServerError,
+			active:     true,
+			attempts:   2,
+			claimed:    true,
+			wantStatus: webhookApi.DeliveryStatusFailed,
+			wantCode:   http.StatusInternalServerError,
+		},
+		{
+			name:       "inactive webhook fails the delivery",
+			active:     false,
+			claimed:    true,
+			wantStatus: webhookApi.DeliveryStatusFailed,
+		},
+		{
+			name:    "claimed by another instance",
+			claimed: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			m := mockRepo.NewMockWebhook(ctrl)
+
+			received := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received++
+				body, _ := io.ReadAll(r.Body)
+				timestamp, _ := strconv.ParseInt(r.Header.Get(webhookApi.HeaderTimestamp), 10, 64)
+
+				if got := r.Header.Get(webhookApi.HeaderSignature); got != webhookApi.Sign("secret", time.Unix(timestamp, 0), body) {
+					t.Errorf("signature = %q", got)
+				}
+				if r.Header.Get(webhookApi.HeaderEvent) != "cake.created" || r.Header.Get(webhookApi.HeaderDelivery) != "7" {
+					t.Errorf("headers = %v", r.Header)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			delivery := repo.WebhookDeliveryModel{ID: 7, WebhookID: 1, Event: "cake.created", Payload: payload, Status: webhookApi.DeliveryStatusPending, Attempts: tt.attempts}
+			m.EXPECT().GetDueDeliveries(ctx, gomock.Any(), 10).Return([]repo.WebhookDeliveryModel{delivery}, nil)
+			m.EXPECT().ClaimDelivery(ctx, 7, gomock.Any(), gomock.Any()).Return(tt.claimed, nil)
+
+			var updated repo.WebhookDeliveryModel
+			if tt.claimed {
+				m.EXPECT().GetDetail(ctx, 1).Return(repo.WebhookModel{ID: 1, URL: server.URL, Secret: "secret", Active: tt.active}, nil)
+				m.EXPECT().UpdateDelivery(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, d repo.WebhookDeliveryModel) error {
+					updated = d
+					return nil
+				})
+			}
+
+			w := NewWebhook(m, zerolog.Logger{}, testWebhookConfig)
+			sent, err := w.Dispatch(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sent != tt.wantSent {
+				t.Errorf("webhook.Dispatch() = %v, want %v", sent, tt.wantSent)
+			}
+
+			if !tt.claimed {
+				if received != 0 {
+					t.Errorf("webhook.Dispatch() sent a delivery claimed by another instance")
+				}
+				return
+			}
+
+			if updated.Status != tt.wantStatus || updated.Attempts != tt.attempts+1 || updated.ResponseCode.Int64 != tt.wantCode {
+				t.Errorf("webhook.Dispatch() recorded %+v", updated)
+			}
+			if tt.wantNext > 0 {
+				if next := time.Until(updated.NextAttemptAt.Time); next < tt.wantNext-time.Second || next > tt.wantNext {
+					t.Errorf("webhook.Dispatch() next attempt in %v, want %v", next, tt.wantNext)
+				}
+			}
+		})
+	}
+}
+
+func Test_webhook_backoff(t *testing.T) {
+	w := &webhook{Config: testWebhookConfig}
+
+	for attempts, want := range map[int]time.Duration{1: time.Minute, 2: 2 * time.Minute, 3: 3 * time.Minute, 10: 3 * time.Minute} {
+		if got := w.backoff(attempts); got != want {
+			t.Errorf("webhook.backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func Test_webhook_Redeliver(t *testing.T) {
+	ctx := context.Background()
+	original := repo.WebhookDeliveryModel{ID: 7, WebhookID: 1, EventID: "e", Event: "cake.created", Payload: []byte("{}"), Status: webhookApi.DeliveryStatusFailed, Attempts: 3}
+
+	tests := []struct {
+		name       string
+		webhookID  int
+		beforeFunc func(m *mockRepo.MockWebhook)
+		wantID     int
+		wantErr    error
+	}{
+		{
+			name:      "success",
+			webhookID: 1,
+			beforeFunc: func(m *mockRepo.MockWebhook) {
+				m.EXPECT().GetDelivery(ctx, 7).Return(original, nil)
+				m.EXPECT().CreateDelivery(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, d repo.WebhookDeliveryModel) (int, error) {
+					if d.EventID != "e" || d.Attempts != 0 || d.Status != webhookApi.DeliveryStatusPending {
+						t.Errorf("delivery = %+v", d)
+					}
+					return 8, nil
+				})
+			},
+			wantID: 8,
+		},
+		{
+			name:      "delivery of another webhook",
+			webhookID: 2,
+			beforeFunc: func(m *mockRepo.MockWebhook) {
+				m.EXPECT().GetDelivery(ctx, 7).Return(original, nil)
+			},
+			wantErr: sql.ErrNoRows,
+		},
+		{
+			name:      "not found",
+			webhookID: 1,
+			beforeFunc: func(m *mockRepo.MockWebhook) {
+				m.EXPECT().GetDelivery(ctx, 7).Return(repo.WebhookDeliveryModel{}, sql.ErrNoRows)
+			},
+			wantErr: sql.ErrNoRows,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			m := mockRepo.NewMockWebhook(ctrl)
+			tt.beforeFunc(m)
+
+			w := NewWebhook(m, zerolog.Logger{}, testWebhookConfig)
+			got, err := w.Redeliver(ctx, tt.webhookID, 7)
+			if err != tt.wantErr {
+				t.Errorf("webhook.Redeliver() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got.ID != tt.wantID {
+				t.Errorf("webhook.Redeliver() = %v, want %v", got.ID, tt.wantID)
+			}
+		})
+	}
+}